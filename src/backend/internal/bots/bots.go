@@ -0,0 +1,179 @@
+// Package bots recognizes chat bots and service accounts so overlays can hide
+// timer spam and command replies.
+package bots
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Reasons reported by Detect.
+const (
+	// ReasonRegistry is a well-known bot from DefaultAccounts.
+	ReasonRegistry = "registry"
+	// ReasonCustom is an account listed in the runtime config.
+	ReasonCustom = "custom"
+	// ReasonPattern is a username matching a configured pattern.
+	ReasonPattern = "pattern"
+	// ReasonBadge is a platform badge marking the account as a bot.
+	ReasonBadge = "badge"
+	// ReasonHeuristic is a moderator whose name ends in "bot".
+	ReasonHeuristic = "heuristic"
+)
+
+// DefaultAccounts are bots and service accounts common on Twitch and YouTube.
+// They match Twitch logins and YouTube display names, ignoring case.
+var DefaultAccounts = []string{
+	"nightbot",
+	"streamelements",
+	"moobot",
+	"fossabot",
+	"streamlabs",
+	"wizebot",
+	"botisimo",
+	"deepbot",
+	"coebot",
+	"phantombot",
+	"ankhbot",
+	"sery_bot",
+	"kofistreambot",
+	"soundalerts",
+	"pokemoncommunitygame",
+	"buttsbot",
+	"own3d",
+	"creatisbot",
+	"frostytoolsdotcom",
+	"blerp",
+}
+
+// botBadges are Twitch badge set IDs that only bots carry.
+var botBadges = map[string]struct{}{
+	"bot-badge": {},
+	"bot":       {},
+}
+
+const moderatorBadge = "moderator"
+
+// Author is what Detect looks at for one message.
+type Author struct {
+	// Username is the display name or login.
+	Username string
+	// AccountID is the Twitch login or YouTube channel ID, when known.
+	AccountID string
+	// Badges are badge set IDs, such as "moderator".
+	Badges []string
+}
+
+// Config adds to and trims the default registry.
+type Config struct {
+	// Accounts are extra logins, display names or YouTube channel IDs.
+	Accounts []string
+	// Patterns are Go regular expressions matched against usernames.
+	Patterns []string
+	// Allow lists accounts that are never treated as bots, even when they
+	// are in DefaultAccounts or match a heuristic.
+	Allow []string
+}
+
+// Registry decides whether a message author is a bot. The zero value applies
+// only the badge heuristics; New also loads DefaultAccounts. A Registry is
+// immutable and safe for concurrent use.
+type Registry struct {
+	defaults map[string]struct{}
+	custom   map[string]struct{}
+	allow    map[string]struct{}
+	patterns []*regexp.Regexp
+}
+
+// New builds a registry from DefaultAccounts plus cfg.
+func New(cfg Config) (*Registry, error) {
+	r := &Registry{
+		defaults: accountSet(DefaultAccounts),
+		custom:   accountSet(cfg.Accounts),
+		allow:    accountSet(cfg.Allow),
+	}
+	for _, pattern := range cfg.Patterns {
+		if strings.TrimSpace(pattern) == "" {
+			continue
+		}
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("bots: pattern %q: %w", pattern, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// NormalizeAccount folds an account for comparison: trimmed, lower case and
+// without a leading "@".
+func NormalizeAccount(account string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(account), "@"))
+}
+
+func accountSet(accounts []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(accounts))
+	for _, account := range accounts {
+		if account = NormalizeAccount(account); account != "" {
+			set[account] = struct{}{}
+		}
+	}
+	return set
+}
+
+func inSet(set map[string]struct{}, keys ...string) bool {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if _, ok := set[key]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Detect reports whether a is a bot and why. Explicit entries win over
+// heuristics: an allowed account is never a bot.
+func (r *Registry) Detect(a Author) (string, bool) {
+	if r == nil {
+		return "", false
+	}
+	username := NormalizeAccount(a.Username)
+	account := NormalizeAccount(a.AccountID)
+	if username == "" && account == "" {
+		return "", false
+	}
+	if inSet(r.allow, username, account) {
+		return "", false
+	}
+	if inSet(r.custom, username, account) {
+		return ReasonCustom, true
+	}
+	if inSet(r.defaults, username, account) {
+		return ReasonRegistry, true
+	}
+	for _, re := range r.patterns {
+		if re.MatchString(a.Username) || (a.AccountID != "" && re.MatchString(a.AccountID)) {
+			return ReasonPattern, true
+		}
+	}
+
+	moderator := false
+	for _, badge := range a.Badges {
+		badge = strings.ToLower(strings.TrimSpace(badge))
+		if _, ok := botBadges[badge]; ok {
+			return ReasonBadge, true
+		}
+		if badge == moderatorBadge {
+			moderator = true
+		}
+	}
+	// Channel bots are usually modded to get past rate limits, and almost
+	// all of them are named "...bot".
+	if moderator && (strings.HasSuffix(username, "bot") || strings.HasSuffix(account, "bot")) {
+		return ReasonHeuristic, true
+	}
+	return "", false
+}
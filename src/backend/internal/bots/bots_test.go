@@ -0,0 +1,46 @@
+package bots
+
+import "testing"
+
+func TestDetect(t *testing.T) {
+	r, err := New(Config{
+		Accounts: []string{"@TimerPal"},
+		Patterns: []string{`(?i)^relay_`},
+		Allow:    []string{"streamlabs", "jacobot"},
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		author Author
+		reason string
+	}{
+		{name: "default by login", author: Author{Username: "Nightbot", AccountID: "nightbot"}, reason: ReasonRegistry},
+		{name: "default on youtube", author: Author{Username: "StreamElements", AccountID: "UCabc"}, reason: ReasonRegistry},
+		{name: "custom", author: Author{Username: "timerpal"}, reason: ReasonCustom},
+		{name: "pattern", author: Author{Username: "relay_discord"}, reason: ReasonPattern},
+		{name: "bot badge", author: Author{Username: "someone", Badges: []string{"bot-badge"}}, reason: ReasonBadge},
+		{name: "modded bot name", author: Author{Username: "ChannelBot", Badges: []string{"moderator"}}, reason: ReasonHeuristic},
+		{name: "unmodded bot name", author: Author{Username: "ChannelBot"}},
+		{name: "allowed default", author: Author{Username: "Streamlabs"}},
+		{name: "allowed heuristic", author: Author{Username: "jacobot", Badges: []string{"moderator"}}},
+		{name: "viewer", author: Author{Username: "erin", Badges: []string{"subscriber"}}},
+		{name: "empty", author: Author{}},
+	}
+	for _, tt := range tests {
+		reason, ok := r.Detect(tt.author)
+		if ok != (tt.reason != "") || reason != tt.reason {
+			t.Fatalf("%s: Detect = (%q, %v), want %q", tt.name, reason, ok, tt.reason)
+		}
+	}
+
+	var none *Registry
+	if _, ok := none.Detect(Author{Username: "nightbot"}); ok {
+		t.Fatalf("nil registry should detect nothing")
+	}
+	if _, err := New(Config{Patterns: []string{"("}}); err == nil {
+		t.Fatalf("expected error for an invalid pattern")
+	}
+}
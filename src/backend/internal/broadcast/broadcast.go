@@ -0,0 +1,159 @@
+// Package broadcast fans chat payloads out to stream subscribers, either within
+// one process or across a set of peer instances.
+package broadcast
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+)
+
+// Broadcaster delivers sanitized chat payloads to every subscribed stream.
+type Broadcaster interface {
+	Publish(msg []byte)
+	Subscribe() chan []byte
+	Unsubscribe(ch chan []byte)
+	Subscribers() int
+}
+
+const (
+	subscriberBuffer    = 64
+	defaultStallTimeout = 2 * time.Second
+)
+
+// Local is the in-process Broadcaster. A subscriber that stays full for
+// StallTimeout is dropped so one slow client cannot hold up the rest.
+type Local struct {
+	// StallTimeout bounds how long Publish waits on a full subscriber.
+	StallTimeout time.Duration
+	// OnStall, if set, is called before a stalled subscriber is removed.
+	OnStall func(ch chan []byte)
+
+	mu   sync.Mutex
+	subs map[chan []byte]struct{}
+}
+
+// NewLocal returns an empty in-process broadcaster.
+func NewLocal() *Local {
+	return &Local{StallTimeout: defaultStallTimeout, subs: make(map[chan []byte]struct{})}
+}
+
+// Publish copies msg to every subscriber.
+func (l *Local) Publish(msg []byte) {
+	l.mu.Lock()
+	if len(l.subs) == 0 {
+		l.mu.Unlock()
+		return
+	}
+	targets := make([]chan []byte, 0, len(l.subs))
+	for ch := range l.subs {
+		targets = append(targets, ch)
+	}
+	l.mu.Unlock()
+
+	timeout := l.StallTimeout
+	if timeout <= 0 {
+		timeout = defaultStallTimeout
+	}
+	for _, ch := range targets {
+		payload := make([]byte, len(msg))
+		copy(payload, msg)
+
+		select {
+		case ch <- payload:
+		case <-time.After(timeout):
+			log.Printf("ws: subscriber stalled; dropping connection")
+			if l.OnStall != nil {
+				l.OnStall(ch)
+			}
+			l.Unsubscribe(ch)
+		}
+	}
+}
+
+// Subscribe registers a new buffered subscriber channel.
+func (l *Local) Subscribe() chan []byte {
+	ch := make(chan []byte, subscriberBuffer)
+	l.mu.Lock()
+	l.subs[ch] = struct{}{}
+	l.mu.Unlock()
+	return ch
+}
+
+// Unsubscribe removes and closes ch. It is safe to call more than once.
+func (l *Local) Unsubscribe(ch chan []byte) {
+	l.mu.Lock()
+	if _, ok := l.subs[ch]; ok {
+		delete(l.subs, ch)
+		close(ch)
+	}
+	l.mu.Unlock()
+}
+
+// Subscribers returns the number of live subscribers.
+func (l *Local) Subscribers() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return len(l.subs)
+}
+
+// MessageID returns a stable dedupe key for a chat payload: the source plus
+// upstream message id when present, otherwise a hash of the payload. Non-chat
+// frames sharing a message id (a cheer's event and its chat line) are kept
+// apart by their frame name.
+func MessageID(payload []byte) string {
+	if id, ok := upstreamMessageID(payload); ok {
+		return id
+	}
+	sum := sha256.Sum256(payload)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// upstreamMessageID returns the MessageID key of a payload that carries an
+// upstream message id. Frames without one (mode toggles, stats, totals) can
+// legitimately repeat byte for byte, so they have no stable key.
+func upstreamMessageID(payload []byte) (string, bool) {
+	var meta struct {
+		Frame  string `json:"frame"`
+		ID     string `json:"id"`
+		Source string `json:"source"`
+	}
+	if err := json.Unmarshal(payload, &meta); err != nil || meta.ID == "" {
+		return "", false
+	}
+	if meta.Frame != "" {
+		return meta.Frame + ":" + meta.Source + ":" + meta.ID, true
+	}
+	return meta.Source + ":" + meta.ID, true
+}
+
+// recentIDs remembers the last n message IDs in insertion order.
+type recentIDs struct {
+	mu   sync.Mutex
+	ring []string
+	next int
+	set  map[string]struct{}
+}
+
+func newRecentIDs(n int) *recentIDs {
+	return &recentIDs{ring: make([]string, n), set: make(map[string]struct{}, n)}
+}
+
+// add records id and reports whether it was new.
+func (r *recentIDs) add(id string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.set[id]; ok {
+		return false
+	}
+	if old := r.ring[r.next]; old != "" {
+		delete(r.set, old)
+	}
+	r.ring[r.next] = id
+	r.next = (r.next + 1) % len(r.ring)
+	r.set[id] = struct{}{}
+	return true
+}
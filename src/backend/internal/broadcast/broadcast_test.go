@@ -0,0 +1,78 @@
+package broadcast
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLocalPublishAndUnsubscribe(t *testing.T) {
+	l := NewLocal()
+	a := l.Subscribe()
+	b := l.Subscribe()
+	if l.Subscribers() != 2 {
+		t.Fatalf("expected 2 subscribers, got %d", l.Subscribers())
+	}
+
+	l.Publish([]byte("hello"))
+	for _, ch := range []chan []byte{a, b} {
+		if got := string(<-ch); got != "hello" {
+			t.Fatalf("expected hello, got %q", got)
+		}
+	}
+
+	l.Unsubscribe(a)
+	l.Unsubscribe(a)
+	if _, ok := <-a; ok {
+		t.Fatalf("expected unsubscribed channel to be closed")
+	}
+	if l.Subscribers() != 1 {
+		t.Fatalf("expected 1 subscriber, got %d", l.Subscribers())
+	}
+}
+
+func TestLocalDropsStalledSubscriber(t *testing.T) {
+	l := NewLocal()
+	l.StallTimeout = 10 * time.Millisecond
+	var stalled chan []byte
+	l.OnStall = func(ch chan []byte) { stalled = ch }
+
+	ch := l.Subscribe()
+	for i := 0; i < subscriberBuffer; i++ {
+		l.Publish([]byte("x"))
+	}
+	l.Publish([]byte("overflow"))
+
+	if stalled != ch {
+		t.Fatalf("expected OnStall to be called with the full subscriber")
+	}
+	if l.Subscribers() != 0 {
+		t.Fatalf("expected stalled subscriber to be removed")
+	}
+}
+
+func TestMessageID(t *testing.T) {
+	withID := MessageID([]byte(`{"id":"abc","source":"Twitch","cursor":5}`))
+	if withID != "Twitch:abc" {
+		t.Fatalf("expected source-scoped id, got %q", withID)
+	}
+	if event := MessageID([]byte(`{"frame":"event","id":"abc","source":"Twitch"}`)); event == withID {
+		t.Fatalf("expected event frame id to differ from chat id, got %q", event)
+	}
+	a := MessageID([]byte(`{"message":"hi"}`))
+	b := MessageID([]byte(`{"message":"hi"}`))
+	if a != b || !strings.HasPrefix(a, "sha256:") {
+		t.Fatalf("expected stable hash id, got %q and %q", a, b)
+	}
+}
+
+func TestRecentIDsEvictsOldest(t *testing.T) {
+	r := newRecentIDs(2)
+	if !r.add("a") || !r.add("b") || r.add("a") {
+		t.Fatalf("expected a and b to be new and a repeat to be rejected")
+	}
+	r.add("c")
+	if !r.add("a") {
+		t.Fatalf("expected a to be forgotten after eviction")
+	}
+}
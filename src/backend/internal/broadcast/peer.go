@@ -0,0 +1,253 @@
+package broadcast
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// PeerPath is where instances accept forwarded payloads from each other.
+const PeerPath = "/api/broadcast/peer"
+
+// PeerSecretHeader carries the shared secret on peer requests.
+const PeerSecretHeader = "X-Elora-Broadcast-Secret"
+
+const (
+	peerQueueSize    = 1024
+	peerBatchMax     = 100
+	peerMaxBodyBytes = 8 << 20
+	dedupeWindow     = 8192
+)
+
+// PeerConfig configures a Peer broadcaster.
+type PeerConfig struct {
+	// InstanceID identifies this process; requests it sent itself are ignored.
+	InstanceID string
+	// Peers are the base URLs of the other instances, e.g. http://elora-2:8080.
+	Peers []string
+	// Secret must match on every instance.
+	Secret string
+	Client *http.Client
+	Logger *log.Logger
+}
+
+type peerEnvelope struct {
+	Origin   string        `json:"origin"`
+	Messages []peerMessage `json:"messages"`
+}
+
+type peerMessage struct {
+	ID      string          `json:"id"`
+	Payload json.RawMessage `json:"payload"`
+}
+
+// PeerStatus reports forwarding counters for one peer.
+type PeerStatus struct {
+	URL       string `json:"url"`
+	Sent      int64  `json:"sent"`
+	Dropped   int64  `json:"dropped"`
+	Failures  int64  `json:"failures"`
+	LastError string `json:"last_error,omitempty"`
+}
+
+type peerLink struct {
+	url      string
+	queue    chan peerMessage
+	sent     atomic.Int64
+	dropped  atomic.Int64
+	failures atomic.Int64
+	lastErr  atomic.Value
+}
+
+// Peer delivers payloads to local subscribers and forwards them to the
+// configured peers. Payloads received from a peer are delivered locally but
+// never re-forwarded, so any topology where every instance lists the others
+// converges in one hop. Upstream message IDs seen recently are dropped, which
+// covers both retries and several instances publishing the same message.
+// Payloads without one are stamped with the instance ID and a sequence number,
+// so repeated frames are delivered every time but still forwarded once.
+type Peer struct {
+	local  *Local
+	cfg    PeerConfig
+	seen   *recentIDs
+	seq    atomic.Uint64
+	links  []*peerLink
+	logger *log.Logger
+}
+
+var _ Broadcaster = (*Peer)(nil)
+
+// NewPeer wraps local with peer forwarding. Call Start to begin forwarding.
+func NewPeer(local *Local, cfg PeerConfig) (*Peer, error) {
+	if local == nil {
+		return nil, errors.New("broadcast: local broadcaster is nil")
+	}
+	if strings.TrimSpace(cfg.Secret) == "" {
+		return nil, errors.New("broadcast: peer secret is required")
+	}
+	if strings.TrimSpace(cfg.InstanceID) == "" {
+		return nil, errors.New("broadcast: instance id is required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = &http.Client{Timeout: 5 * time.Second}
+	}
+	logger := cfg.Logger
+	if logger == nil {
+		logger = log.Default()
+	}
+	p := &Peer{local: local, cfg: cfg, seen: newRecentIDs(dedupeWindow), logger: logger}
+	for _, raw := range cfg.Peers {
+		base := strings.TrimRight(strings.TrimSpace(raw), "/")
+		if base == "" {
+			continue
+		}
+		p.links = append(p.links, &peerLink{url: base + PeerPath, queue: make(chan peerMessage, peerQueueSize)})
+	}
+	return p, nil
+}
+
+// Start runs one forwarder per peer until ctx is cancelled.
+func (p *Peer) Start(ctx context.Context) {
+	for _, link := range p.links {
+		go p.forward(ctx, link)
+	}
+}
+
+// Publish delivers msg locally and queues it for every peer. Queues are
+// bounded; when a peer falls behind, new messages for it are dropped rather
+// than blocking local delivery.
+func (p *Peer) Publish(msg []byte) {
+	id, upstream := upstreamMessageID(msg)
+	if !upstream {
+		id = fmt.Sprintf("%s#%d", p.cfg.InstanceID, p.seq.Add(1))
+	}
+	if !p.seen.add(id) {
+		return
+	}
+	p.local.Publish(msg)
+	pm := peerMessage{ID: id, Payload: append(json.RawMessage(nil), msg...)}
+	for _, link := range p.links {
+		select {
+		case link.queue <- pm:
+		default:
+			link.dropped.Add(1)
+		}
+	}
+}
+
+// Subscribe registers a local subscriber.
+func (p *Peer) Subscribe() chan []byte { return p.local.Subscribe() }
+
+// Unsubscribe removes a local subscriber.
+func (p *Peer) Unsubscribe(ch chan []byte) { p.local.Unsubscribe(ch) }
+
+// Subscribers returns the number of local subscribers.
+func (p *Peer) Subscribers() int { return p.local.Subscribers() }
+
+// Status returns per-peer forwarding counters.
+func (p *Peer) Status() []PeerStatus {
+	out := make([]PeerStatus, 0, len(p.links))
+	for _, link := range p.links {
+		st := PeerStatus{URL: link.url, Sent: link.sent.Load(), Dropped: link.dropped.Load(), Failures: link.failures.Load()}
+		if v, ok := link.lastErr.Load().(string); ok {
+			st.LastError = v
+		}
+		out = append(out, st)
+	}
+	return out
+}
+
+// ServeHTTP accepts payloads forwarded by another instance.
+func (p *Peer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(r.Header.Get(PeerSecretHeader)), []byte(p.cfg.Secret)) != 1 {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var env peerEnvelope
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, peerMaxBodyBytes)).Decode(&env); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+
+	accepted, duplicates := 0, 0
+	if env.Origin != p.cfg.InstanceID {
+		for _, m := range env.Messages {
+			if len(m.Payload) == 0 {
+				continue
+			}
+			if m.ID != "" && !p.seen.add(m.ID) {
+				duplicates++
+				continue
+			}
+			p.local.Publish(m.Payload)
+			accepted++
+		}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]int{"accepted": accepted, "duplicates": duplicates})
+}
+
+func (p *Peer) forward(ctx context.Context, link *peerLink) {
+	batch := make([]peerMessage, 0, peerBatchMax)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case m := <-link.queue:
+			batch = append(batch[:0], m)
+		}
+	drain:
+		for len(batch) < peerBatchMax {
+			select {
+			case m := <-link.queue:
+				batch = append(batch, m)
+			default:
+				break drain
+			}
+		}
+		if err := p.send(ctx, link.url, batch); err != nil {
+			link.failures.Add(1)
+			link.lastErr.Store(err.Error())
+			p.logger.Printf("broadcast: forward %d messages to %s failed: %v", len(batch), link.url, err)
+			continue
+		}
+		link.sent.Add(int64(len(batch)))
+	}
+}
+
+func (p *Peer) send(ctx context.Context, url string, batch []peerMessage) error {
+	body, err := json.Marshal(peerEnvelope{Origin: p.cfg.InstanceID, Messages: batch})
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(PeerSecretHeader, p.cfg.Secret)
+	resp, err := p.cfg.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package broadcast
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestPeer(t *testing.T, id string, peers []string) (*Peer, *httptest.Server) {
+	t.Helper()
+	p, err := NewPeer(NewLocal(), PeerConfig{InstanceID: id, Peers: peers, Secret: "shared", Logger: log.New(io.Discard, "", 0)})
+	if err != nil {
+		t.Fatalf("NewPeer: %v", err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle(PeerPath, p)
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return p, srv
+}
+
+func receive(t *testing.T, ch chan []byte) string {
+	t.Helper()
+	select {
+	case msg := <-ch:
+		return string(msg)
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for message")
+		return ""
+	}
+}
+
+func TestNewPeerRequiresSecretAndInstance(t *testing.T) {
+	if _, err := NewPeer(NewLocal(), PeerConfig{InstanceID: "a"}); err == nil {
+		t.Fatalf("expected error without secret")
+	}
+	if _, err := NewPeer(NewLocal(), PeerConfig{Secret: "s"}); err == nil {
+		t.Fatalf("expected error without instance id")
+	}
+}
+
+func TestPeerForwardsAndDedupes(t *testing.T) {
+	receiver, srv := newTestPeer(t, "b", nil)
+	sender, _ := newTestPeer(t, "a", []string{srv.URL + "/"})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sender.Start(ctx)
+
+	local := sender.Subscribe()
+	remote := receiver.Subscribe()
+
+	msg := `{"id":"m1","source":"Twitch","message":"hi"}`
+	sender.Publish([]byte(msg))
+	sender.Publish([]byte(msg))
+	// The receiver publishing the same message itself must not double-deliver.
+	receiver.Publish([]byte(msg))
+	sender.Publish([]byte(`{"id":"m2","source":"Twitch","message":"next"}`))
+
+	if got := receive(t, local); got != msg {
+		t.Fatalf("expected local delivery, got %s", got)
+	}
+	if got := receive(t, remote); got != msg {
+		t.Fatalf("expected remote delivery, got %s", got)
+	}
+	if got := receive(t, remote); !strings.Contains(got, `"m2"`) {
+		t.Fatalf("expected the next message without a duplicate, got %s", got)
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for sender.Status()[0].Sent != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected 2 forwarded messages, got %+v", sender.Status())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestPeerRejectsBadSecret(t *testing.T) {
+	_, srv := newTestPeer(t, "b", nil)
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+PeerPath, strings.NewReader(`{"origin":"x","messages":[]}`))
+	req.Header.Set(PeerSecretHeader, "wrong")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", resp.StatusCode)
+	}
+}
+
+func TestPeerIgnoresOwnOrigin(t *testing.T) {
+	p, srv := newTestPeer(t, "a", nil)
+	ch := p.Subscribe()
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+PeerPath, strings.NewReader(`{"origin":"a","messages":[{"id":"x","payload":{"message":"loop"}}]}`))
+	req.Header.Set(PeerSecretHeader, "shared")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	select {
+	case msg := <-ch:
+		t.Fatalf("expected own-origin message to be ignored, got %s", msg)
+	case <-time.After(20 * time.Millisecond):
+	}
+}
+
+func TestPeerDeliversRepeatedFramesWithoutID(t *testing.T) {
+	receiver, srv := newTestPeer(t, "b", nil)
+	sender, _ := newTestPeer(t, "a", []string{srv.URL})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sender.Start(ctx)
+
+	local := sender.Subscribe()
+	remote := receiver.Subscribe()
+
+	// Toggling approval mode on, off and on again repeats the first frame exactly.
+	frames := []string{
+		`{"frame":"approval_mode","enabled":true}`,
+		`{"frame":"approval_mode","enabled":false}`,
+		`{"frame":"approval_mode","enabled":true}`,
+	}
+	for _, frame := range frames {
+		sender.Publish([]byte(frame))
+	}
+	for i, want := range frames {
+		if got := receive(t, local); got != want {
+			t.Fatalf("local frame %d: expected %s, got %s", i, want, got)
+		}
+		if got := receive(t, remote); got != want {
+			t.Fatalf("remote frame %d: expected %s, got %s", i, want, got)
+		}
+	}
+}
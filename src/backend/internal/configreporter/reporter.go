@@ -71,7 +71,10 @@ type DBSnapshot struct {
 type TailerSnapshot struct {
 	Enabled        bool   `json:"enabled"`
 	IntervalMS     int    `json:"interval_ms"`
+	MinIntervalMS  int    `json:"min_interval_ms"`
+	MaxIntervalMS  int    `json:"max_interval_ms"`
 	Batch          int    `json:"batch"`
+	BatchCeiling   int    `json:"batch_ceiling"`
 	MaxLagMS       int    `json:"max_lag_ms"`
 	PersistOffsets bool   `json:"persist_offsets"`
 	OffsetPath     string `json:"offset_path,omitempty"`
@@ -79,7 +82,10 @@ type TailerSnapshot struct {
 
 // IngestSnapshot surfaces the selected ingest driver without revealing secrets.
 type IngestSnapshot struct {
-	Driver string `json:"driver"`
+	Driver      string                `json:"driver"`
+	PushEnabled bool                  `json:"push_enabled"`
+	PushSocket  string                `json:"push_socket,omitempty"`
+	Sources     []ingest.SourceStatus `json:"sources,omitempty"`
 }
 
 // GnastySyncSnapshot reports best-effort gnasty admin sync health.
@@ -189,12 +195,19 @@ func (r Reporter) Snapshot() Snapshot {
 		Tailer: TailerSnapshot{
 			Enabled:        r.tailer.Enabled,
 			IntervalMS:     int(r.tailer.Interval / time.Millisecond),
+			MinIntervalMS:  int(r.tailer.MinInterval / time.Millisecond),
+			MaxIntervalMS:  int(r.tailer.MaxInterval / time.Millisecond),
 			Batch:          r.tailer.Batch,
+			BatchCeiling:   r.tailer.BatchCeiling,
 			MaxLagMS:       int(r.tailer.MaxLag / time.Millisecond),
 			PersistOffsets: r.tailer.PersistOffsets,
 			OffsetPath:     offsetPath,
 		},
-		Ingest: IngestSnapshot{Driver: r.ingest.Driver},
+		Ingest: IngestSnapshot{
+			Driver:      r.ingest.Driver,
+			PushEnabled: strings.TrimSpace(r.ingest.PushToken) != "",
+			PushSocket:  r.ingest.PushSocketPath,
+		},
 		GnastySync: GnastySyncSnapshot{
 			TargetBase: "",
 		},
@@ -237,7 +250,11 @@ func (r Reporter) Summary() Summary {
 			Batch:      r.tailer.Batch,
 			MaxLagMS:   int(r.tailer.MaxLag / time.Millisecond),
 		},
-		Ingest: IngestSnapshot{Driver: r.ingest.Driver},
+		Ingest: IngestSnapshot{
+			Driver:      r.ingest.Driver,
+			PushEnabled: strings.TrimSpace(r.ingest.PushToken) != "",
+			PushSocket:  r.ingest.PushSocketPath,
+		},
 		Websocket: WebsocketSummary{
 			PingIntervalMS:  durationToMS(r.websocket.PingInterval),
 			PongWaitMS:      durationToMS(r.websocket.PongWait),
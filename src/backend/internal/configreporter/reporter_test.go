@@ -15,7 +15,7 @@ func TestSnapshotRedactsIngestSecrets(t *testing.T) {
 	reporter := NewReporter(
 		sqlite.Config{Mode: "persistent", Path: "/data/gnasty.db", JournalMode: "WAL", MaxConns: 8, BusyTimeoutMS: 1000, PragmasExtraCSV: "test=1"},
 		tailer.Config{Enabled: true, Interval: 25 * time.Millisecond, Batch: 200, MaxLag: 50 * time.Millisecond, PersistOffsets: true, OffsetPath: "/tmp/off"},
-		ingest.Env{Driver: ingest.DriverGnasty, GnastyArgs: []string{"--token=secret"}},
+		ingest.Env{Driver: ingest.DriverGnasty, GnastyArgs: []string{"--token=secret"}, PushToken: "secret"},
 		Origins{AllowAny: false, Values: []string{"http://localhost:8080"}},
 		WebsocketLimits{PingInterval: 25 * time.Second, PongWait: 30 * time.Second, WriteDeadline: 5 * time.Second, MaxMessage: 131072},
 		AuthConfig{},
@@ -25,6 +25,9 @@ func TestSnapshotRedactsIngestSecrets(t *testing.T) {
 	if snapshot.Ingest.Driver != ingest.DriverGnasty {
 		t.Fatalf("expected driver %q, got %q", ingest.DriverGnasty, snapshot.Ingest.Driver)
 	}
+	if !snapshot.Ingest.PushEnabled {
+		t.Fatalf("expected push ingest to be reported as enabled")
+	}
 	if snapshot.GnastySync.TargetBase != "" {
 		t.Fatalf("expected empty gnasty sync target by default, got %q", snapshot.GnastySync.TargetBase)
 	}
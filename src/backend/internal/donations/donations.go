@@ -0,0 +1,234 @@
+// Package donations normalizes Twitch bits and YouTube paid messages into a
+// single base currency and summarizes them for stream goals.
+package donations
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/events"
+)
+
+// BitsCurrency is the pseudo-currency cheers are recorded in; one unit is one
+// bit. It converts through the rate table like any other currency.
+const BitsCurrency = "BITS"
+
+// DefaultBase is the base currency used when none is configured.
+const DefaultBase = "USD"
+
+// bitValueUSD is what Twitch pays out per bit, used as the default BITS rate
+// when the base currency is USD.
+const bitValueUSD = 0.01
+
+var currencyCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// Rates is a locally maintained conversion table. Rates[code] is the value of
+// one unit of code in the base currency.
+type Rates struct {
+	Base  string             `json:"base"`
+	Rates map[string]float64 `json:"rates"`
+}
+
+// DefaultRates returns the table used before any rates are saved.
+func DefaultRates(base string) Rates {
+	base = strings.ToUpper(strings.TrimSpace(base))
+	if base == "" {
+		base = DefaultBase
+	}
+	r := Rates{Base: base, Rates: map[string]float64{}}
+	if base == "USD" {
+		r.Rates[BitsCurrency] = bitValueUSD
+	}
+	return r
+}
+
+// Normalize upper-cases codes and validates the table.
+func (r Rates) Normalize() (Rates, error) {
+	out := Rates{Base: strings.ToUpper(strings.TrimSpace(r.Base)), Rates: make(map[string]float64, len(r.Rates))}
+	if !currencyCode.MatchString(out.Base) {
+		return Rates{}, fmt.Errorf("invalid base currency %q", r.Base)
+	}
+	for code, rate := range r.Rates {
+		code = strings.ToUpper(strings.TrimSpace(code))
+		if !currencyCode.MatchString(code) && code != BitsCurrency {
+			return Rates{}, fmt.Errorf("invalid currency code %q", code)
+		}
+		if rate <= 0 || math.IsInf(rate, 0) || math.IsNaN(rate) {
+			return Rates{}, fmt.Errorf("rate for %s must be positive", code)
+		}
+		if code == out.Base {
+			continue
+		}
+		out.Rates[code] = rate
+	}
+	return out, nil
+}
+
+// Convert returns micros of currency expressed in base-currency micros. It
+// reports false when the table has no rate for currency.
+func (r Rates) Convert(micros int64, currency string) (int64, bool) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == r.Base {
+		return micros, true
+	}
+	rate, ok := r.Rates[currency]
+	if !ok {
+		return 0, false
+	}
+	return int64(math.Round(float64(micros) * rate)), true
+}
+
+// Amount returns the money an event carries: bits for cheers, the paid amount
+// for Super Chats and Super Stickers. Other events carry none.
+func Amount(ev events.Event) (int64, string) {
+	switch ev.Type {
+	case events.TypeCheer:
+		return int64(ev.Bits) * 1e6, BitsCurrency
+	case events.TypeSuperChat, events.TypeSuperSticker:
+		// An unrecognized currency symbol leaves Currency empty; the amount is
+		// still recorded and reported as unconverted.
+		return ev.AmountMicros, ev.Currency
+	}
+	return 0, ""
+}
+
+// Entry is one contribution in its original currency.
+type Entry struct {
+	ID           string
+	Platform     string
+	Type         string
+	User         string
+	AmountMicros int64
+	Currency     string
+	Timestamp    time.Time
+}
+
+// Supporter aggregates a viewer's contributions within a summary.
+type Supporter struct {
+	User        string  `json:"user"`
+	Platform    string  `json:"platform"`
+	Total       float64 `json:"total"`
+	TotalMicros int64   `json:"total_micros"`
+	Count       int     `json:"count"`
+}
+
+// Unconverted is money in a currency the rate table does not cover.
+type Unconverted struct {
+	Currency     string  `json:"currency"`
+	Amount       float64 `json:"amount"`
+	AmountMicros int64   `json:"amount_micros"`
+	Count        int     `json:"count"`
+}
+
+// Goal reports progress toward a target in the base currency.
+type Goal struct {
+	Target       float64 `json:"target"`
+	TargetMicros int64   `json:"target_micros"`
+	Progress     float64 `json:"progress"`
+	Remaining    float64 `json:"remaining"`
+	Reached      bool    `json:"reached"`
+}
+
+// Summary is the ledger view of a set of entries.
+type Summary struct {
+	BaseCurrency  string             `json:"base_currency"`
+	Total         float64            `json:"total"`
+	TotalMicros   int64              `json:"total_micros"`
+	Count         int                `json:"count"`
+	ByPlatform    map[string]float64 `json:"by_platform"`
+	TopSupporters []Supporter        `json:"top_supporters"`
+	Unconverted   []Unconverted      `json:"unconverted"`
+	Goal          *Goal              `json:"goal,omitempty"`
+}
+
+// Summarize converts entries with rates and aggregates them. Supporters are
+// grouped by platform and case-insensitive name; topN <= 0 keeps all of them.
+// A goal is reported when goalMicros is positive.
+func Summarize(entries []Entry, rates Rates, goalMicros int64, topN int) Summary {
+	sum := Summary{
+		BaseCurrency:  rates.Base,
+		ByPlatform:    map[string]float64{},
+		TopSupporters: []Supporter{},
+		Unconverted:   []Unconverted{},
+	}
+	byPlatform := map[string]int64{}
+	supporters := map[string]*Supporter{}
+	unconverted := map[string]*Unconverted{}
+
+	for _, e := range entries {
+		if e.AmountMicros <= 0 {
+			continue
+		}
+		base, ok := rates.Convert(e.AmountMicros, e.Currency)
+		if !ok {
+			code := strings.ToUpper(strings.TrimSpace(e.Currency))
+			u := unconverted[code]
+			if u == nil {
+				u = &Unconverted{Currency: code}
+				unconverted[code] = u
+			}
+			u.AmountMicros += e.AmountMicros
+			u.Count++
+			continue
+		}
+		sum.Count++
+		sum.TotalMicros += base
+		byPlatform[e.Platform] += base
+
+		key := strings.ToLower(e.Platform) + "\x00" + strings.ToLower(strings.TrimSpace(e.User))
+		s := supporters[key]
+		if s == nil {
+			s = &Supporter{User: e.User, Platform: e.Platform}
+			supporters[key] = s
+		}
+		s.TotalMicros += base
+		s.Count++
+	}
+
+	sum.Total = fromMicros(sum.TotalMicros)
+	for platform, micros := range byPlatform {
+		sum.ByPlatform[platform] = fromMicros(micros)
+	}
+	for _, s := range supporters {
+		s.Total = fromMicros(s.TotalMicros)
+		sum.TopSupporters = append(sum.TopSupporters, *s)
+	}
+	sort.Slice(sum.TopSupporters, func(i, j int) bool {
+		a, b := sum.TopSupporters[i], sum.TopSupporters[j]
+		if a.TotalMicros != b.TotalMicros {
+			return a.TotalMicros > b.TotalMicros
+		}
+		return strings.ToLower(a.User) < strings.ToLower(b.User)
+	})
+	if topN > 0 && len(sum.TopSupporters) > topN {
+		sum.TopSupporters = sum.TopSupporters[:topN]
+	}
+	for _, u := range unconverted {
+		u.Amount = fromMicros(u.AmountMicros)
+		sum.Unconverted = append(sum.Unconverted, *u)
+	}
+	sort.Slice(sum.Unconverted, func(i, j int) bool { return sum.Unconverted[i].Currency < sum.Unconverted[j].Currency })
+
+	if goalMicros > 0 {
+		g := &Goal{Target: fromMicros(goalMicros), TargetMicros: goalMicros}
+		g.Progress = math.Min(float64(sum.TotalMicros)/float64(goalMicros), 1)
+		g.Remaining = fromMicros(max(goalMicros-sum.TotalMicros, 0))
+		g.Reached = sum.TotalMicros >= goalMicros
+		sum.Goal = g
+	}
+	return sum
+}
+
+// ToMicros converts a major-unit amount to micros.
+func ToMicros(amount float64) int64 {
+	return int64(math.Round(amount * 1e6))
+}
+
+func fromMicros(micros int64) float64 {
+	// Round to cents so totals render cleanly; micros stay exact.
+	return math.Round(float64(micros)/1e4) / 100
+}
@@ -0,0 +1,83 @@
+package donations
+
+import (
+	"testing"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/events"
+)
+
+func TestRatesNormalize(t *testing.T) {
+	r, err := Rates{Base: "eur", Rates: map[string]float64{"usd": 0.9, "EUR": 2, "bits": 0.009}}.Normalize()
+	if err != nil {
+		t.Fatalf("Normalize returned error: %v", err)
+	}
+	if r.Base != "EUR" || r.Rates["USD"] != 0.9 || r.Rates["BITS"] != 0.009 {
+		t.Fatalf("unexpected normalized rates: %+v", r)
+	}
+	if _, ok := r.Rates["EUR"]; ok {
+		t.Fatalf("expected the base currency to be dropped from the table")
+	}
+
+	for _, bad := range []Rates{
+		{Base: "euro"},
+		{Base: "BITS"},
+		{Base: "USD", Rates: map[string]float64{"GBP": 0}},
+		{Base: "USD", Rates: map[string]float64{"x1": 1}},
+	} {
+		if _, err := bad.Normalize(); err == nil {
+			t.Fatalf("expected error for %+v", bad)
+		}
+	}
+}
+
+func TestSummarize(t *testing.T) {
+	rates := DefaultRates("")
+	rates.Rates["EUR"] = 1.1
+
+	entries := []Entry{
+		{Platform: "Twitch", User: "Alice", AmountMicros: 500 * 1e6, Currency: BitsCurrency},
+		{Platform: "YouTube", User: "Bob", AmountMicros: 10 * 1e6, Currency: "EUR"},
+		{Platform: "Twitch", User: "alice", AmountMicros: 100 * 1e6, Currency: BitsCurrency},
+		{Platform: "YouTube", User: "Carol", AmountMicros: 2 * 1e6, Currency: "USD"},
+		{Platform: "YouTube", User: "Dan", AmountMicros: 1000 * 1e6, Currency: "JPY"},
+	}
+	sum := Summarize(entries, rates, 50*1e6, 2)
+
+	if sum.BaseCurrency != "USD" || sum.Count != 4 || sum.TotalMicros != 19_000_000 || sum.Total != 19 {
+		t.Fatalf("unexpected totals: %+v", sum)
+	}
+	if sum.ByPlatform["Twitch"] != 6 || sum.ByPlatform["YouTube"] != 13 {
+		t.Fatalf("unexpected platform split: %+v", sum.ByPlatform)
+	}
+	if len(sum.TopSupporters) != 2 || sum.TopSupporters[0].User != "Bob" || sum.TopSupporters[1].Count != 2 {
+		t.Fatalf("unexpected supporters: %+v", sum.TopSupporters)
+	}
+	if len(sum.Unconverted) != 1 || sum.Unconverted[0].Currency != "JPY" || sum.Unconverted[0].Amount != 1000 {
+		t.Fatalf("unexpected unconverted: %+v", sum.Unconverted)
+	}
+	if sum.Goal == nil || sum.Goal.Progress != 0.38 || sum.Goal.Remaining != 31 || sum.Goal.Reached {
+		t.Fatalf("unexpected goal: %+v", sum.Goal)
+	}
+
+	if reached := Summarize(entries, rates, 5*1e6, 0); !reached.Goal.Reached || reached.Goal.Progress != 1 {
+		t.Fatalf("expected reached goal capped at 1, got %+v", reached.Goal)
+	}
+	if none := Summarize(nil, rates, 0, 0); none.Goal != nil || none.TopSupporters == nil {
+		t.Fatalf("expected empty summary without goal, got %+v", none)
+	}
+}
+
+func TestAmount(t *testing.T) {
+	if micros, code := Amount(events.Event{Type: events.TypeCheer, Bits: 250}); micros != 250_000_000 || code != BitsCurrency {
+		t.Fatalf("unexpected cheer amount: %d %s", micros, code)
+	}
+	if micros, code := Amount(events.Event{Type: events.TypeSuperChat, AmountMicros: 5_000_000, Currency: "EUR"}); micros != 5_000_000 || code != "EUR" {
+		t.Fatalf("unexpected super chat amount: %d %s", micros, code)
+	}
+	if micros, code := Amount(events.Event{Type: events.TypeSuperChat, AmountMicros: 5_000_000}); micros != 5_000_000 || code != "" {
+		t.Fatalf("expected a super chat with an unknown currency to keep its amount, got %d %q", micros, code)
+	}
+	if micros, _ := Amount(events.Event{Type: events.TypeSub, Tier: "1"}); micros != 0 {
+		t.Fatalf("expected subs to carry no amount")
+	}
+}
@@ -0,0 +1,192 @@
+// Package events extracts structured platform events (subs, gifts, raids,
+// cheers, Super Chats, memberships) from the raw provider payloads stored
+// alongside chat rows.
+package events
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+// Event types.
+const (
+	TypeSub                 = "sub"
+	TypeResub               = "resub"
+	TypeGift                = "gift"
+	TypeMysteryGift         = "mystery_gift"
+	TypeRaid                = "raid"
+	TypeAnnouncement        = "announcement"
+	TypeCheer               = "cheer"
+	TypeSuperChat           = "superchat"
+	TypeSuperSticker        = "supersticker"
+	TypeMembership          = "membership"
+	TypeMembershipMilestone = "membership_milestone"
+	TypeMembershipGift      = "membership_gift"
+)
+
+// Event is a typed platform event. Amounts are in the currency's major unit,
+// with AmountMicros kept for exact arithmetic.
+type Event struct {
+	Type          string    `json:"type"`
+	ID            string    `json:"id"`
+	Source        string    `json:"source"`
+	Channel       string    `json:"channel,omitempty"`
+	User          string    `json:"user,omitempty"`
+	UserLogin     string    `json:"user_login,omitempty"`
+	Message       string    `json:"message,omitempty"`
+	SystemMessage string    `json:"system_message,omitempty"`
+	Tier          string    `json:"tier,omitempty"`
+	Months        int       `json:"months,omitempty"`
+	StreakMonths  int       `json:"streak_months,omitempty"`
+	GiftCount     int       `json:"gift_count,omitempty"`
+	Recipient     string    `json:"recipient,omitempty"`
+	Viewers       int       `json:"viewers,omitempty"`
+	Bits          int       `json:"bits,omitempty"`
+	Amount        float64   `json:"amount,omitempty"`
+	AmountMicros  int64     `json:"amount_micros,omitempty"`
+	Currency      string    `json:"currency,omitempty"`
+	AmountDisplay string    `json:"amount_display,omitempty"`
+	Color         string    `json:"color,omitempty"`
+	Timestamp     time.Time `json:"ts"`
+}
+
+// ReplacesChat reports whether the event stands in for the row's chat line.
+// Cheers are ordinary chat messages that also carry bits, so they keep their
+// chat line and add an event; everything else is delivered only as an event.
+func (e Event) ReplacesChat() bool {
+	return e.Type != TypeCheer
+}
+
+// FromMessage extracts an event from a stored row, if it carries one.
+func FromMessage(m storage.Message) (Event, bool) {
+	raw := strings.TrimSpace(m.RawJSON)
+	if raw == "" || raw[0] != '{' {
+		return Event{}, false
+	}
+	var ev Event
+	var ok bool
+	switch strings.ToLower(strings.TrimSpace(m.Platform)) {
+	case "twitch":
+		ev, ok = fromTwitch(raw)
+	case "youtube":
+		ev, ok = fromYouTube(raw)
+	}
+	if !ok {
+		return Event{}, false
+	}
+	ev.ID = m.ID
+	ev.Timestamp = m.Timestamp.UTC()
+	if ev.User == "" {
+		ev.User = m.Username
+	}
+	return ev, true
+}
+
+type twitchRaw struct {
+	Command string            `json:"command"`
+	Channel string            `json:"channel"`
+	Login   string            `json:"login"`
+	Body    string            `json:"body"`
+	Tags    map[string]string `json:"tags"`
+}
+
+func fromTwitch(raw string) (Event, bool) {
+	var r twitchRaw
+	if err := json.Unmarshal([]byte(raw), &r); err != nil || r.Tags == nil {
+		return Event{}, false
+	}
+	tags := r.Tags
+	ev := Event{
+		Source:        "Twitch",
+		Channel:       r.Channel,
+		User:          strings.TrimSpace(tags["display-name"]),
+		UserLogin:     firstNonEmpty(tags["login"], r.Login),
+		Message:       r.Body,
+		SystemMessage: tags["system-msg"],
+		Color:         tags["color"],
+	}
+
+	if strings.EqualFold(r.Command, "PRIVMSG") || r.Command == "" {
+		bits := atoi(tags["bits"])
+		if bits <= 0 {
+			return Event{}, false
+		}
+		ev.Type = TypeCheer
+		ev.Bits = bits
+		return ev, true
+	}
+	if !strings.EqualFold(r.Command, "USERNOTICE") {
+		return Event{}, false
+	}
+
+	ev.Tier = twitchTier(tags["msg-param-sub-plan"])
+	switch tags["msg-id"] {
+	case "sub":
+		ev.Type = TypeSub
+		ev.Months = max(atoi(tags["msg-param-cumulative-months"]), 1)
+	case "resub":
+		ev.Type = TypeResub
+		ev.Months = atoi(tags["msg-param-cumulative-months"])
+		ev.StreakMonths = atoi(tags["msg-param-streak-months"])
+	case "subgift", "anonsubgift":
+		ev.Type = TypeGift
+		ev.GiftCount = 1
+		ev.Months = atoi(tags["msg-param-gift-months"])
+		ev.Recipient = firstNonEmpty(tags["msg-param-recipient-display-name"], tags["msg-param-recipient-user-name"])
+	case "submysterygift", "anonsubmysterygift":
+		ev.Type = TypeMysteryGift
+		ev.GiftCount = atoi(tags["msg-param-mass-gift-count"])
+	case "raid":
+		ev.Type = TypeRaid
+		ev.User = firstNonEmpty(tags["msg-param-displayName"], ev.User)
+		ev.UserLogin = firstNonEmpty(tags["msg-param-login"], ev.UserLogin)
+		ev.Viewers = atoi(tags["msg-param-viewerCount"])
+		ev.Tier = ""
+	case "announcement":
+		ev.Type = TypeAnnouncement
+		ev.Color = strings.ToLower(tags["msg-param-color"])
+		ev.Tier = ""
+	default:
+		return Event{}, false
+	}
+	return ev, true
+}
+
+// twitchTier maps msg-param-sub-plan to "prime", "1", "2" or "3".
+func twitchTier(plan string) string {
+	switch strings.ToLower(strings.TrimSpace(plan)) {
+	case "":
+		return ""
+	case "prime":
+		return "prime"
+	case "1000":
+		return "1"
+	case "2000":
+		return "2"
+	case "3000":
+		return "3"
+	default:
+		return plan
+	}
+}
+
+func atoi(s string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
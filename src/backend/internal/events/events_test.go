@@ -0,0 +1,168 @@
+package events
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func twitchRow(raw string) storage.Message {
+	return storage.Message{ID: "m1", Platform: "Twitch", Username: "fallback", Timestamp: time.Unix(1700000000, 0), RawJSON: raw}
+}
+
+func TestFromMessageTwitch(t *testing.T) {
+	cases := []struct {
+		name  string
+		raw   string
+		check func(t *testing.T, ev Event)
+	}{
+		{
+			name: "resub",
+			raw:  `{"command":"USERNOTICE","channel":"chan","tags":{"msg-id":"resub","display-name":"Alice","login":"alice","msg-param-sub-plan":"2000","msg-param-cumulative-months":"14","msg-param-streak-months":"3","system-msg":"Alice subscribed at Tier 2."},"body":"hype"}`,
+			check: func(t *testing.T, ev Event) {
+				if ev.Type != TypeResub || ev.Tier != "2" || ev.Months != 14 || ev.StreakMonths != 3 || ev.Message != "hype" || ev.User != "Alice" {
+					t.Fatalf("unexpected resub: %+v", ev)
+				}
+			},
+		},
+		{
+			name: "gift",
+			raw:  `{"command":"USERNOTICE","channel":"chan","tags":{"msg-id":"subgift","display-name":"Bob","msg-param-sub-plan":"Prime","msg-param-recipient-display-name":"Carol"}}`,
+			check: func(t *testing.T, ev Event) {
+				if ev.Type != TypeGift || ev.Recipient != "Carol" || ev.Tier != "prime" || ev.GiftCount != 1 {
+					t.Fatalf("unexpected gift: %+v", ev)
+				}
+			},
+		},
+		{
+			name: "mystery gift",
+			raw:  `{"command":"USERNOTICE","tags":{"msg-id":"submysterygift","msg-param-mass-gift-count":"5","msg-param-sub-plan":"1000"}}`,
+			check: func(t *testing.T, ev Event) {
+				if ev.Type != TypeMysteryGift || ev.GiftCount != 5 || ev.Tier != "1" || ev.User != "fallback" {
+					t.Fatalf("unexpected mystery gift: %+v", ev)
+				}
+			},
+		},
+		{
+			name: "raid",
+			raw:  `{"command":"USERNOTICE","tags":{"msg-id":"raid","msg-param-displayName":"Raider","msg-param-login":"raider","msg-param-viewerCount":"42"}}`,
+			check: func(t *testing.T, ev Event) {
+				if ev.Type != TypeRaid || ev.User != "Raider" || ev.UserLogin != "raider" || ev.Viewers != 42 {
+					t.Fatalf("unexpected raid: %+v", ev)
+				}
+			},
+		},
+		{
+			name: "announcement",
+			raw:  `{"command":"USERNOTICE","tags":{"msg-id":"announcement","msg-param-color":"BLUE"},"body":"hello"}`,
+			check: func(t *testing.T, ev Event) {
+				if ev.Type != TypeAnnouncement || ev.Color != "blue" || ev.Message != "hello" || !ev.ReplacesChat() {
+					t.Fatalf("unexpected announcement: %+v", ev)
+				}
+			},
+		},
+		{
+			name: "cheer",
+			raw:  `{"command":"PRIVMSG","tags":{"bits":"100","display-name":"Dana"},"body":"Cheer100 gg"}`,
+			check: func(t *testing.T, ev Event) {
+				if ev.Type != TypeCheer || ev.Bits != 100 || ev.ReplacesChat() {
+					t.Fatalf("unexpected cheer: %+v", ev)
+				}
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			ev, ok := FromMessage(twitchRow(tc.raw))
+			if !ok {
+				t.Fatalf("expected an event")
+			}
+			if ev.ID != "m1" || ev.Source != "Twitch" || ev.Timestamp.IsZero() {
+				t.Fatalf("expected row metadata to be copied, got %+v", ev)
+			}
+			tc.check(t, ev)
+		})
+	}
+}
+
+func TestFromMessageIgnoresPlainChat(t *testing.T) {
+	for _, m := range []storage.Message{
+		twitchRow(`{"command":"PRIVMSG","tags":{"display-name":"x"},"body":"hi"}`),
+		twitchRow(`{"command":"USERNOTICE","tags":{"msg-id":"ritual"}}`),
+		twitchRow(`not json`),
+		{Platform: "YouTube", RawJSON: `{"snippet":{"type":"textMessageEvent"}}`},
+	} {
+		if ev, ok := FromMessage(m); ok {
+			t.Fatalf("expected no event for %q, got %+v", m.RawJSON, ev)
+		}
+	}
+}
+
+func TestFromMessageYouTubeRenderers(t *testing.T) {
+	row := func(raw string) storage.Message {
+		return storage.Message{ID: "yt1", Platform: "YouTube", Username: "viewer", RawJSON: raw}
+	}
+
+	ev, ok := FromMessage(row(`{"addChatItemAction":{"item":{"liveChatPaidMessageRenderer":{"authorName":{"simpleText":"Erin"},"purchaseAmountText":{"simpleText":"€10,50"},"message":{"runs":[{"text":"great "},{"emoji":{"shortcuts":[":wave:"]}}]}}}}}`))
+	if !ok || ev.Type != TypeSuperChat || ev.Currency != "EUR" || ev.AmountMicros != 10_500_000 || ev.Message != "great :wave:" || ev.User != "Erin" {
+		t.Fatalf("unexpected super chat: ok=%v %+v", ok, ev)
+	}
+
+	ev, ok = FromMessage(row(`{"liveChatPaidStickerRenderer":{"purchaseAmountText":{"simpleText":"CA$2.00"}}}`))
+	if !ok || ev.Type != TypeSuperSticker || ev.Currency != "CAD" || ev.Amount != 2 || ev.User != "viewer" {
+		t.Fatalf("unexpected super sticker: ok=%v %+v", ok, ev)
+	}
+
+	ev, ok = FromMessage(row(`{"liveChatMembershipItemRenderer":{"headerPrimaryText":{"runs":[{"text":"Member for "},{"text":"6"},{"text":" months"}]},"headerSubtext":{"simpleText":"Gold"},"message":{"runs":[{"text":"half a year!"}]}}}`))
+	if !ok || ev.Type != TypeMembershipMilestone || ev.Months != 6 || ev.Message != "half a year!" {
+		t.Fatalf("unexpected milestone: ok=%v %+v", ok, ev)
+	}
+
+	ev, ok = FromMessage(row(`{"liveChatMembershipItemRenderer":{"headerSubtext":{"simpleText":"Welcome to Gold!"}}}`))
+	if !ok || ev.Type != TypeMembership || ev.Tier != "Gold" {
+		t.Fatalf("unexpected new member: ok=%v %+v", ok, ev)
+	}
+
+	ev, ok = FromMessage(row(`{"liveChatSponsorshipsGiftPurchaseAnnouncementRenderer":{"header":{"liveChatSponsorshipsHeaderRenderer":{"authorName":{"simpleText":"Frank"},"primaryText":{"runs":[{"text":"Gifted "},{"text":"5"},{"text":" memberships"}]}}}}}`))
+	if !ok || ev.Type != TypeMembershipGift || ev.GiftCount != 5 || ev.User != "Frank" {
+		t.Fatalf("unexpected membership gift: ok=%v %+v", ok, ev)
+	}
+}
+
+func TestFromMessageYouTubeDataAPI(t *testing.T) {
+	ev, ok := FromMessage(storage.Message{Platform: "YouTube", RawJSON: `{"snippet":{"type":"superChatEvent","displayMessage":"$5.00 from Gus","superChatDetails":{"amountMicros":"5000000","currency":"usd","amountDisplayString":"$5.00","userComment":"hi","tier":2}}}`})
+	if !ok || ev.Type != TypeSuperChat || ev.AmountMicros != 5_000_000 || ev.Currency != "USD" || ev.Message != "hi" {
+		t.Fatalf("unexpected data api super chat: ok=%v %+v", ok, ev)
+	}
+
+	ev, ok = FromMessage(storage.Message{Platform: "YouTube", RawJSON: `{"snippet":{"type":"memberMilestoneChatEvent","memberMilestoneChatDetails":{"memberMonth":12,"memberLevelName":"Gold","userComment":"a year"}}}`})
+	if !ok || ev.Type != TypeMembershipMilestone || ev.Months != 12 || ev.Tier != "Gold" {
+		t.Fatalf("unexpected data api milestone: ok=%v %+v", ok, ev)
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	cases := []struct {
+		in       string
+		currency string
+		amount   float64
+	}{
+		{"$5.00", "USD", 5},
+		{"€10,00", "EUR", 10},
+		{"£1,234.56", "GBP", 1234.56},
+		{"R$ 1.234,56", "BRL", 1234.56},
+		{"¥1,000", "JPY", 1000},
+		{"PHP 100.00", "PHP", 100},
+		{"100.00 zł", "PLN", 100},
+		{"??5", "", 5},
+		{"", "", 0},
+	}
+	for _, tc := range cases {
+		currency, amount := ParseAmount(tc.in)
+		if currency != tc.currency || math.Abs(amount-tc.amount) > 1e-9 {
+			t.Errorf("ParseAmount(%q) = %q, %v; want %q, %v", tc.in, currency, amount, tc.currency, tc.amount)
+		}
+	}
+}
@@ -0,0 +1,289 @@
+package events
+
+import (
+	"encoding/json"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// fromYouTube understands both shapes harvesters store for YouTube rows: an
+// innertube live chat renderer (possibly nested inside an action/item
+// wrapper) and a Data API liveChatMessage with a typed snippet.
+func fromYouTube(raw string) (Event, bool) {
+	var doc map[string]any
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		return Event{}, false
+	}
+	if snippet, ok := findKey(doc, "snippet", 4).(map[string]any); ok {
+		if ev, ok := fromYouTubeSnippet(snippet); ok {
+			return ev, true
+		}
+	}
+	for _, name := range []string{
+		"liveChatPaidMessageRenderer",
+		"liveChatPaidStickerRenderer",
+		"liveChatMembershipItemRenderer",
+		"liveChatSponsorshipsGiftPurchaseAnnouncementRenderer",
+	} {
+		if renderer, ok := findKey(doc, name, 6).(map[string]any); ok {
+			return fromYouTubeRenderer(name, renderer)
+		}
+	}
+	return Event{}, false
+}
+
+func fromYouTubeRenderer(name string, r map[string]any) (Event, bool) {
+	ev := Event{
+		Source: "YouTube",
+		User:   ytText(r["authorName"]),
+	}
+	switch name {
+	case "liveChatPaidMessageRenderer":
+		ev.Type = TypeSuperChat
+		ev.Message = ytText(r["message"])
+		ev.setDisplayAmount(ytText(r["purchaseAmountText"]))
+	case "liveChatPaidStickerRenderer":
+		ev.Type = TypeSuperSticker
+		ev.setDisplayAmount(ytText(r["purchaseAmountText"]))
+	case "liveChatMembershipItemRenderer":
+		// Milestones put "Member for N months" in the primary text and the
+		// viewer's comment in message; new members only have a subtext.
+		primary := ytText(r["headerPrimaryText"])
+		ev.Message = ytText(r["message"])
+		ev.SystemMessage = firstNonEmpty(primary, ytText(r["headerSubtext"]))
+		if months := leadingMonths(primary); months > 0 {
+			ev.Type = TypeMembershipMilestone
+			ev.Months = months
+		} else {
+			ev.Type = TypeMembership
+		}
+		ev.Tier = membershipLevel(ytText(r["headerSubtext"]))
+	case "liveChatSponsorshipsGiftPurchaseAnnouncementRenderer":
+		ev.Type = TypeMembershipGift
+		header, _ := findKey(r, "liveChatSponsorshipsHeaderRenderer", 3).(map[string]any)
+		if header != nil {
+			ev.User = firstNonEmpty(ytText(header["authorName"]), ev.User)
+			ev.SystemMessage = ytText(header["primaryText"])
+			ev.GiftCount = firstNumber(ev.SystemMessage)
+		}
+	default:
+		return Event{}, false
+	}
+	return ev, true
+}
+
+func fromYouTubeSnippet(s map[string]any) (Event, bool) {
+	ev := Event{Source: "YouTube", SystemMessage: str(s["displayMessage"])}
+	switch str(s["type"]) {
+	case "superChatEvent":
+		d, _ := s["superChatDetails"].(map[string]any)
+		ev.Type = TypeSuperChat
+		ev.Message = str(d["userComment"])
+		ev.setMicros(d)
+		ev.Tier = str(d["tier"])
+	case "superStickerEvent":
+		d, _ := s["superStickerDetails"].(map[string]any)
+		ev.Type = TypeSuperSticker
+		ev.setMicros(d)
+		ev.Tier = str(d["tier"])
+	case "newSponsorEvent":
+		d, _ := s["newSponsorDetails"].(map[string]any)
+		ev.Type = TypeMembership
+		ev.Tier = str(d["memberLevelName"])
+	case "memberMilestoneChatEvent":
+		d, _ := s["memberMilestoneChatDetails"].(map[string]any)
+		ev.Type = TypeMembershipMilestone
+		ev.Message = str(d["userComment"])
+		ev.Months = int(num(d["memberMonth"]))
+		ev.Tier = str(d["memberLevelName"])
+	case "membershipGiftingEvent":
+		d, _ := s["membershipGiftingDetails"].(map[string]any)
+		ev.Type = TypeMembershipGift
+		ev.GiftCount = int(num(d["giftMembershipsCount"]))
+		ev.Tier = str(d["giftMembershipsLevelName"])
+	default:
+		return Event{}, false
+	}
+	return ev, true
+}
+
+func (e *Event) setMicros(d map[string]any) {
+	e.AmountMicros = int64(num(d["amountMicros"]))
+	e.Amount = float64(e.AmountMicros) / 1e6
+	e.Currency = strings.ToUpper(str(d["currency"]))
+	e.AmountDisplay = str(d["amountDisplayString"])
+}
+
+func (e *Event) setDisplayAmount(display string) {
+	e.AmountDisplay = display
+	e.Currency, e.Amount = ParseAmount(display)
+	e.AmountMicros = int64(math.Round(e.Amount * 1e6))
+}
+
+// currencySymbols maps the prefixes YouTube uses in purchaseAmountText to ISO
+// codes. Longer prefixes are matched first.
+var currencySymbols = []struct{ prefix, code string }{
+	{"CA$", "CAD"}, {"A$", "AUD"}, {"NZ$", "NZD"}, {"MX$", "MXN"}, {"HK$", "HKD"},
+	{"NT$", "TWD"}, {"R$", "BRL"}, {"$", "USD"}, {"€", "EUR"}, {"£", "GBP"},
+	{"¥", "JPY"}, {"₩", "KRW"}, {"₹", "INR"}, {"₱", "PHP"}, {"₫", "VND"},
+	{"₪", "ILS"}, {"₺", "TRY"}, {"zł", "PLN"},
+}
+
+var isoCode = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// ParseAmount splits a display amount such as "$5.00", "€10,00" or
+// "PHP 100.00" into an ISO currency code and a value. Unknown symbols yield
+// an empty code.
+func ParseAmount(display string) (string, float64) {
+	s := strings.TrimSpace(strings.ReplaceAll(display, " ", " "))
+	if s == "" {
+		return "", 0
+	}
+	start := strings.IndexAny(s, "0123456789")
+	if start < 0 {
+		return "", 0
+	}
+	end := strings.LastIndexAny(s, "0123456789") + 1
+	prefix := strings.TrimSpace(s[:start])
+	suffix := strings.TrimSpace(s[end:])
+	value := parseLocaleNumber(s[start:end])
+
+	symbol := prefix
+	if symbol == "" {
+		symbol = suffix
+	}
+	if isoCode.MatchString(symbol) {
+		return symbol, value
+	}
+	for _, cs := range currencySymbols {
+		if symbol == cs.prefix {
+			return cs.code, value
+		}
+	}
+	return "", value
+}
+
+// parseLocaleNumber accepts "1,234.56", "1.234,56", "10,00" and "1 000".
+func parseLocaleNumber(s string) float64 {
+	s = strings.NewReplacer(" ", "", " ", "").Replace(s)
+	lastComma := strings.LastIndex(s, ",")
+	lastDot := strings.LastIndex(s, ".")
+	switch {
+	case lastComma >= 0 && lastDot >= 0:
+		if lastComma > lastDot {
+			s = strings.ReplaceAll(s, ".", "")
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	case lastComma >= 0:
+		if len(s)-lastComma-1 == 2 && strings.Count(s, ",") == 1 {
+			s = strings.Replace(s, ",", ".", 1)
+		} else {
+			s = strings.ReplaceAll(s, ",", "")
+		}
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return v
+}
+
+var (
+	monthsPattern = regexp.MustCompile(`(\d+)\s+months?`)
+	numberPattern = regexp.MustCompile(`\d+`)
+)
+
+func leadingMonths(text string) int {
+	m := monthsPattern.FindStringSubmatch(strings.ToLower(text))
+	if m == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(m[1])
+	return n
+}
+
+func firstNumber(text string) int {
+	m := numberPattern.FindString(text)
+	n, _ := strconv.Atoi(m)
+	return n
+}
+
+// membershipLevel pulls the level name out of "Welcome to <level>!".
+func membershipLevel(subtext string) string {
+	s := strings.TrimSpace(subtext)
+	if rest, ok := strings.CutPrefix(s, "Welcome to "); ok {
+		return strings.TrimSuffix(strings.TrimSpace(rest), "!")
+	}
+	return ""
+}
+
+// ytText flattens innertube text ({"simpleText":...} or {"runs":[...]}).
+func ytText(v any) string {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return str(v)
+	}
+	if s := str(m["simpleText"]); s != "" {
+		return s
+	}
+	runs, _ := m["runs"].([]any)
+	var b strings.Builder
+	for _, run := range runs {
+		r, _ := run.(map[string]any)
+		if t, _ := r["text"].(string); t != "" {
+			b.WriteString(t)
+			continue
+		}
+		if emoji, ok := r["emoji"].(map[string]any); ok {
+			if shortcuts, _ := emoji["shortcuts"].([]any); len(shortcuts) > 0 {
+				b.WriteString(str(shortcuts[0]))
+			}
+		}
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// findKey returns the first value stored under key within depth levels of v.
+func findKey(v any, key string, depth int) any {
+	if depth < 0 {
+		return nil
+	}
+	switch val := v.(type) {
+	case map[string]any:
+		if found, ok := val[key]; ok {
+			return found
+		}
+		for _, child := range val {
+			if found := findKey(child, key, depth-1); found != nil {
+				return found
+			}
+		}
+	case []any:
+		for _, child := range val {
+			if found := findKey(child, key, depth-1); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+func str(v any) string {
+	s, _ := v.(string)
+	return strings.TrimSpace(s)
+}
+
+func num(v any) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f
+	}
+	return 0
+}
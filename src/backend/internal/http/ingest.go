@@ -0,0 +1,99 @@
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/ingest"
+)
+
+const ingestMaxBodyBytes = 16 << 20
+
+// RegisterIngest installs POST /api/ingest, which accepts NDJSON batches from
+// third-party producers. Requests must carry the shared push token either as a
+// bearer token or in X-Elora-Ingest-Token. A nil push disables the endpoint.
+func RegisterIngest(mux *http.ServeMux, push *ingest.Push) {
+	if mux == nil {
+		return
+	}
+
+	mux.HandleFunc("/api/ingest", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		if push == nil {
+			writeIngestJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "ingest_push_disabled"})
+			return
+		}
+		if !push.Authorized(ingestToken(r)) {
+			writeIngestJSON(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+			return
+		}
+
+		body := http.MaxBytesReader(w, r.Body, ingestMaxBodyBytes)
+		result, err := push.IngestLines(r.Context(), "http:"+r.RemoteAddr, body)
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				writeIngestJSON(w, http.StatusRequestEntityTooLarge, map[string]any{"error": "body_too_large", "result": result})
+				return
+			}
+			writeIngestJSON(w, http.StatusBadRequest, map[string]any{"error": "read_failed", "result": result})
+			return
+		}
+
+		status := http.StatusOK
+		if result.Accepted == 0 && result.Rejected > 0 {
+			status = http.StatusBadRequest
+		}
+		writeIngestJSON(w, status, result)
+	})
+}
+
+// IngestStatus is the payload served by GET /api/ingest/status.
+type IngestStatus struct {
+	Driver  string                `json:"driver"`
+	Sources []ingest.SourceStatus `json:"sources"`
+}
+
+// RegisterIngestStatus installs GET /api/ingest/status, which reports the live
+// per-source state of every in-process ingest worker.
+func RegisterIngestStatus(mux *http.ServeMux, status func() IngestStatus) {
+	if mux == nil || status == nil {
+		return
+	}
+
+	mux.HandleFunc("/api/ingest/status", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		payload := status()
+		if payload.Sources == nil {
+			payload.Sources = []ingest.SourceStatus{}
+		}
+		writeIngestJSON(w, http.StatusOK, payload)
+	})
+}
+
+func ingestToken(r *http.Request) string {
+	if token := strings.TrimSpace(r.Header.Get("X-Elora-Ingest-Token")); token != "" {
+		return token
+	}
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+func writeIngestJSON(w http.ResponseWriter, status int, payload any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(payload)
+}
@@ -0,0 +1,100 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/ingest"
+)
+
+func TestRegisterIngestAcceptsNDJSON(t *testing.T) {
+	var inserted []string
+	push, err := ingest.NewPush(ingest.PushConfig{
+		Token: "secret",
+		Insert: func(ctx context.Context, raw json.RawMessage) error {
+			inserted = append(inserted, string(raw))
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new push: %v", err)
+	}
+	mux := http.NewServeMux()
+	RegisterIngest(mux, push)
+
+	body := "{\"id\":\"1\"}\n\nnot json\n{\"id\":\"2\"}\n"
+	req := httptest.NewRequest(http.MethodPost, "/api/ingest", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer secret")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var result ingest.PushResult
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if result.Accepted != 2 || result.Rejected != 1 {
+		t.Fatalf("expected 2 accepted and 1 rejected, got %+v", result)
+	}
+	if len(result.Errors) != 1 || result.Errors[0].Line != 3 {
+		t.Fatalf("expected line 3 to be rejected, got %+v", result.Errors)
+	}
+	if len(inserted) != 2 || inserted[1] != `{"id":"2"}` {
+		t.Fatalf("unexpected inserted lines: %v", inserted)
+	}
+}
+
+func TestRegisterIngestRejectsBadToken(t *testing.T) {
+	push, err := ingest.NewPush(ingest.PushConfig{
+		Token:  "secret",
+		Insert: func(ctx context.Context, raw json.RawMessage) error { return nil },
+	})
+	if err != nil {
+		t.Fatalf("new push: %v", err)
+	}
+	mux := http.NewServeMux()
+	RegisterIngest(mux, push)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/ingest", strings.NewReader("{}\n"))
+	req.Header.Set("X-Elora-Ingest-Token", "wrong")
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", rr.Code)
+	}
+}
+
+func TestRegisterIngestDisabledWithoutPush(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterIngest(mux, nil)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/ingest", strings.NewReader("{}\n"))
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("expected 503, got %d", rr.Code)
+	}
+}
+
+func TestRegisterIngestStatus(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterIngestStatus(mux, func() IngestStatus {
+		return IngestStatus{Driver: ingest.DriverGnasty}
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/api/ingest/status", nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", rr.Code)
+	}
+	if got := strings.TrimSpace(rr.Body.String()); got != `{"driver":"gnasty","sources":[]}` {
+		t.Fatalf("unexpected body: %s", got)
+	}
+}
@@ -0,0 +1,53 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+)
+
+// Rejection reasons reported through RejectFn.
+const (
+	RejectDecode = "decode"
+	RejectInsert = "insert"
+)
+
+// Rejection describes an ingest line that could not be stored.
+type Rejection struct {
+	Driver string
+	Source string
+	Reason string
+	Error  string
+	Line   []byte
+}
+
+// RejectFn is an optional hook that receives every rejected line so it can be
+// kept for later inspection and retry instead of being dropped.
+type RejectFn func(ctx context.Context, rej Rejection)
+
+// Redeliver feeds a previously rejected line back through decode and insert.
+func Redeliver(ctx context.Context, insert InsertFn, line []byte) error {
+	raw, err := decodeLine(line)
+	if err != nil {
+		return fmt.Errorf("decode: %w", err)
+	}
+	if insert == nil {
+		return nil
+	}
+	if err := insert(ctx, raw); err != nil {
+		return fmt.Errorf("insert: %w", err)
+	}
+	return nil
+}
+
+func reject(ctx context.Context, fn RejectFn, driver, source, reason string, err error, line []byte) {
+	if fn == nil {
+		return
+	}
+	fn(ctx, Rejection{
+		Driver: driver,
+		Source: source,
+		Reason: reason,
+		Error:  err.Error(),
+		Line:   append([]byte(nil), line...),
+	})
+}
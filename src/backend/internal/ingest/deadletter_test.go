@@ -0,0 +1,59 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"testing"
+)
+
+func TestGnastyHandleLineRejectsToHook(t *testing.T) {
+	var got []Rejection
+	proc, err := NewGnasty(GnastyConfig{
+		Bin:    "gnasty",
+		Logger: log.New(io.Discard, "", 0),
+		Insert: func(ctx context.Context, raw json.RawMessage) error {
+			return errors.New("unexpected shape")
+		},
+		Reject: func(ctx context.Context, rej Rejection) {
+			got = append(got, rej)
+		},
+	}, []string{"https://www.twitch.tv/dayoman"})
+	if err != nil {
+		t.Fatalf("new gnasty: %v", err)
+	}
+
+	url := "https://www.twitch.tv/dayoman"
+	tracker := proc.trackers[url]
+	proc.handleLine(context.Background(), tracker, url, []byte(`{"broken"`))
+	proc.handleLine(context.Background(), tracker, url, []byte(`{"id":"1"}`))
+
+	if len(got) != 2 {
+		t.Fatalf("expected 2 rejections, got %d", len(got))
+	}
+	if got[0].Reason != RejectDecode || string(got[0].Line) != `{"broken"` || got[0].Source != url || got[0].Driver != DriverGnasty {
+		t.Fatalf("unexpected decode rejection: %+v", got[0])
+	}
+	if got[1].Reason != RejectInsert || got[1].Error != "unexpected shape" || string(got[1].Line) != `{"id":"1"}` {
+		t.Fatalf("unexpected insert rejection: %+v", got[1])
+	}
+}
+
+func TestRedeliver(t *testing.T) {
+	var inserted []string
+	insert := func(ctx context.Context, raw json.RawMessage) error {
+		inserted = append(inserted, string(raw))
+		return nil
+	}
+	if err := Redeliver(context.Background(), insert, []byte(`{"id":"1"}`)); err != nil {
+		t.Fatalf("expected redeliver to succeed: %v", err)
+	}
+	if err := Redeliver(context.Background(), insert, []byte(`nope`)); err == nil {
+		t.Fatalf("expected decode error for invalid line")
+	}
+	if len(inserted) != 1 || inserted[0] != `{"id":"1"}` {
+		t.Fatalf("unexpected inserts: %v", inserted)
+	}
+}
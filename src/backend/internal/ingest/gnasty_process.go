@@ -26,11 +26,13 @@ type GnastyConfig struct {
 	BackoffMax  time.Duration
 	Logger      *log.Logger
 	Insert      InsertFn
+	Reject      RejectFn
 }
 
 type GnastyProcess struct {
-	cfg  GnastyConfig
-	urls []string
+	cfg      GnastyConfig
+	urls     []string
+	trackers map[string]*sourceTracker
 
 	wg sync.WaitGroup
 }
@@ -57,7 +59,21 @@ func NewGnasty(cfg GnastyConfig, urls []string) (*GnastyProcess, error) {
 			cleanURLs = append(cleanURLs, trimmed)
 		}
 	}
-	return &GnastyProcess{cfg: cfg, urls: cleanURLs}, nil
+	trackers := make(map[string]*sourceTracker, len(cleanURLs))
+	for _, u := range cleanURLs {
+		trackers[u] = newSourceTracker(DriverGnasty, u)
+	}
+	return &GnastyProcess{cfg: cfg, urls: cleanURLs, trackers: trackers}, nil
+}
+
+// Status reports the per-URL process state.
+func (g *GnastyProcess) Status() []SourceStatus {
+	out := make([]SourceStatus, 0, len(g.trackers))
+	for _, tr := range g.trackers {
+		out = append(out, tr.snapshot())
+	}
+	sortStatuses(out)
+	return out
 }
 
 func (g *GnastyProcess) Start(ctx context.Context) {
@@ -73,6 +89,8 @@ func (g *GnastyProcess) Wait() {
 
 func (g *GnastyProcess) run(ctx context.Context, url string) {
 	defer g.wg.Done()
+	tracker := g.trackers[url]
+	defer tracker.stopped()
 	logger := g.cfg.Logger
 	base := g.cfg.BackoffBase
 	max := g.cfg.BackoffMax
@@ -93,6 +111,8 @@ func (g *GnastyProcess) run(ctx context.Context, url string) {
 		stdout, err := cmd.StdoutPipe()
 		if err != nil {
 			logger.Printf("ingest[gnasty]: url=%s: stdout pipe error: %v", url, err)
+			tracker.exited(err)
+			tracker.backingOff(backoff)
 			if !sleepWithContext(ctx, backoff) {
 				return
 			}
@@ -102,6 +122,8 @@ func (g *GnastyProcess) run(ctx context.Context, url string) {
 		stderr, err := cmd.StderrPipe()
 		if err != nil {
 			logger.Printf("ingest[gnasty]: url=%s: stderr pipe error: %v", url, err)
+			tracker.exited(err)
+			tracker.backingOff(backoff)
 			if !sleepWithContext(ctx, backoff) {
 				return
 			}
@@ -112,6 +134,8 @@ func (g *GnastyProcess) run(ctx context.Context, url string) {
 		logger.Printf("ingest[gnasty]: starting: bin=%q args=%q url=%s", g.cfg.Bin, strings.Join(g.cfg.Args, " "), url)
 		if err := cmd.Start(); err != nil {
 			logger.Printf("ingest[gnasty]: url=%s: start error: %v", url, err)
+			tracker.exited(err)
+			tracker.backingOff(backoff)
 			if !sleepWithContext(ctx, backoff) {
 				return
 			}
@@ -119,6 +143,7 @@ func (g *GnastyProcess) run(ctx context.Context, url string) {
 			continue
 		}
 		backoff = base
+		tracker.running(cmd.Process.Pid)
 
 		errCh := make(chan struct{})
 		go func() {
@@ -136,7 +161,7 @@ func (g *GnastyProcess) run(ctx context.Context, url string) {
 		for {
 			line, err := reader.ReadBytes('\n')
 			if len(line) > 0 {
-				g.handleLine(ctx, url, bytes.TrimSpace(line))
+				g.handleLine(ctx, tracker, url, bytes.TrimSpace(line))
 			}
 			if err != nil {
 				if errors.Is(err, io.EOF) {
@@ -148,8 +173,10 @@ func (g *GnastyProcess) run(ctx context.Context, url string) {
 		}
 
 		<-errCh
-		if err := cmd.Wait(); err != nil {
-			logger.Printf("ingest[gnasty]: url=%s: exited err: %v", url, err)
+		waitErr := cmd.Wait()
+		tracker.exited(waitErr)
+		if waitErr != nil {
+			logger.Printf("ingest[gnasty]: url=%s: exited err: %v", url, waitErr)
 		} else {
 			logger.Printf("ingest[gnasty]: url=%s: exited ok", url)
 		}
@@ -162,6 +189,7 @@ func (g *GnastyProcess) run(ctx context.Context, url string) {
 		}
 
 		logger.Printf("ingest[gnasty]: url=%s: restarting in %s", url, backoff)
+		tracker.backingOff(backoff)
 		if !sleepWithContext(ctx, backoff) {
 			return
 		}
@@ -169,26 +197,40 @@ func (g *GnastyProcess) run(ctx context.Context, url string) {
 	}
 }
 
-func (g *GnastyProcess) handleLine(ctx context.Context, url string, line []byte) {
+func (g *GnastyProcess) handleLine(ctx context.Context, tracker *sourceTracker, url string, line []byte) {
 	logger := g.cfg.Logger
 	if len(line) == 0 {
 		return
 	}
-	var tmp json.RawMessage
-	if err := json.Unmarshal(line, &tmp); err != nil {
+	tracker.line()
+	tmp, err := decodeLine(line)
+	if err != nil {
+		tracker.decodeError()
 		logger.Printf("ingest[gnasty]: url=%s: decode error: %v; line=%s", url, err, ellipsis(string(line), 240))
+		reject(ctx, g.cfg.Reject, DriverGnasty, url, RejectDecode, err, line)
 		return
 	}
 
 	if g.cfg.Insert != nil {
-		if err := g.cfg.Insert(ctx, json.RawMessage(append([]byte(nil), tmp...))); err != nil {
+		if err := g.cfg.Insert(ctx, tmp); err != nil {
+			tracker.insertError()
 			logger.Printf("ingest[gnasty]: url=%s: insert error: %v", url, err)
+			reject(ctx, g.cfg.Reject, DriverGnasty, url, RejectInsert, err, tmp)
 		}
 		return
 	}
 	logger.Printf("ingest[gnasty]: url=%s: line ok (len=%d)", url, len(tmp))
 }
 
+// decodeLine validates a single NDJSON line and returns an owned copy of it.
+func decodeLine(line []byte) (json.RawMessage, error) {
+	var tmp json.RawMessage
+	if err := json.Unmarshal(line, &tmp); err != nil {
+		return nil, err
+	}
+	return json.RawMessage(append([]byte(nil), tmp...)), nil
+}
+
 func nextBackoff(cur, base, max time.Duration) time.Duration {
 	if cur < base {
 		return base
@@ -10,7 +10,13 @@ import (
 )
 
 const (
-	DriverGnasty = "gnasty"
+	DriverGnasty    = "gnasty"
+	DriverTwitchIRC = "twitch-irc"
+	DriverReplay    = "replay"
+
+	// DriverPush labels dead letters from the push endpoint. It is not a
+	// selectable ELORA_INGEST_DRIVER; push runs alongside the active driver.
+	DriverPush = "push"
 )
 
 type Env struct {
@@ -19,31 +25,65 @@ type Env struct {
 	GnastyArgs    []string
 	BackoffBaseMS int
 	BackoffMaxMS  int
+
+	TwitchIRCAddr      string
+	TwitchIRCTLS       bool
+	TwitchIRCNick      string
+	TwitchIRCToken     string
+	TwitchIRCTokenPath string
+
+	PushToken      string
+	PushSocketPath string
+
+	ReplayPath  string
+	ReplaySpeed float64
+	ReplayLoop  bool
 }
 
 func FromEnv() Env {
+	driver, err := New(getEnvTrim("ELORA_INGEST_DRIVER", DriverGnasty))
+	if err != nil {
+		log.Printf("ingest: %v; falling back to %q", err, DriverGnasty)
+	}
 	return Env{
-		Driver:        DriverGnasty,
+		Driver:        driver.Driver,
 		GnastyBin:     getEnvTrim("GNASTY_BIN", ""),
 		GnastyArgs:    splitCSV(getEnvTrim("GNASTY_ARGS", "")),
 		BackoffBaseMS: getEnvInt("GNASTY_BACKOFF_BASE_MS", 1000),
 		BackoffMaxMS:  getEnvInt("GNASTY_BACKOFF_MAX_MS", 30000),
+
+		TwitchIRCAddr:      getEnvTrim("ELORA_TWITCH_IRC_ADDR", defaultTwitchIRCAddr),
+		TwitchIRCTLS:       getEnvBool("ELORA_TWITCH_IRC_TLS", true),
+		TwitchIRCNick:      getEnvTrim("ELORA_TWITCH_IRC_NICK", getEnvTrim("TWITCH_NICK", "")),
+		TwitchIRCToken:     getEnvTrim("ELORA_TWITCH_IRC_TOKEN", ""),
+		TwitchIRCTokenPath: getEnvTrim("ELORA_TWITCH_IRC_TOKEN_FILE", ""),
+
+		PushToken:      getEnvTrim("ELORA_INGEST_TOKEN", ""),
+		PushSocketPath: getEnvTrim("ELORA_INGEST_SOCKET", ""),
+
+		ReplayPath:  getEnvTrim("ELORA_REPLAY_FILE", ""),
+		ReplaySpeed: parseReplaySpeed(getEnvTrim("ELORA_REPLAY_SPEED", "1")),
+		ReplayLoop:  getEnvBool("ELORA_REPLAY_LOOP", false),
 	}
 }
 
-// New returns an Env configured for the requested driver.
+// New returns an Env configured for the requested driver. Unsupported drivers
+// yield an error alongside an Env that falls back to gnasty.
 func New(driver string) (Env, error) {
-	trimmed := strings.TrimSpace(driver)
-	if trimmed == "" {
-		trimmed = DriverGnasty
+	trimmed := strings.ToLower(strings.TrimSpace(driver))
+	switch trimmed {
+	case "", DriverGnasty:
+		return Env{Driver: DriverGnasty}, nil
+	case DriverTwitchIRC:
+		return Env{Driver: DriverTwitchIRC}, nil
+	case DriverReplay:
+		return Env{Driver: DriverReplay}, nil
+	default:
+		return Env{Driver: DriverGnasty}, fmt.Errorf("ingest: unsupported driver %q", driver)
 	}
-	if trimmed != DriverGnasty {
-		return Env{}, fmt.Errorf("ingest: unsupported driver %q", driver)
-	}
-	return Env{Driver: DriverGnasty}, nil
 }
 
-func (e Env) BuildGnasty(insert InsertFn, urls []string, logger *log.Logger) (*GnastyProcess, error) {
+func (e Env) BuildGnasty(insert InsertFn, rejectFn RejectFn, urls []string, logger *log.Logger) (*GnastyProcess, error) {
 	cfg := GnastyConfig{
 		Bin:         e.GnastyBin,
 		Args:        e.GnastyArgs,
@@ -51,10 +91,52 @@ func (e Env) BuildGnasty(insert InsertFn, urls []string, logger *log.Logger) (*G
 		BackoffMax:  time.Duration(e.BackoffMaxMS) * time.Millisecond,
 		Logger:      logger,
 		Insert:      insert,
+		Reject:      rejectFn,
 	}
 	return NewGnasty(cfg, urls)
 }
 
+func (e Env) BuildTwitchIRC(insert InsertFn, rejectFn RejectFn, channels []string, logger *log.Logger) (*TwitchIRCClient, error) {
+	cfg := TwitchIRCConfig{
+		Addr:        e.TwitchIRCAddr,
+		TLS:         e.TwitchIRCTLS,
+		Nick:        e.TwitchIRCNick,
+		Token:       e.TwitchIRCToken,
+		TokenPath:   e.TwitchIRCTokenPath,
+		BackoffBase: time.Duration(e.BackoffBaseMS) * time.Millisecond,
+		BackoffMax:  time.Duration(e.BackoffMaxMS) * time.Millisecond,
+		Logger:      logger,
+		Insert:      insert,
+		Reject:      rejectFn,
+	}
+	return NewTwitchIRC(cfg, channels)
+}
+
+func (e Env) BuildReplay(insert InsertFn, rejectFn RejectFn, logger *log.Logger) (*ReplayProcess, error) {
+	return NewReplay(ReplayConfig{
+		Path:   e.ReplayPath,
+		Speed:  e.ReplaySpeed,
+		Loop:   e.ReplayLoop,
+		Logger: logger,
+		Insert: insert,
+		Reject: rejectFn,
+	})
+}
+
+// BuildPush returns the push endpoint, or nil when no shared token is configured.
+func (e Env) BuildPush(insert InsertFn, rejectFn RejectFn, logger *log.Logger) (*Push, error) {
+	if strings.TrimSpace(e.PushToken) == "" {
+		return nil, nil
+	}
+	return NewPush(PushConfig{
+		Token:      e.PushToken,
+		SocketPath: e.PushSocketPath,
+		Logger:     logger,
+		Insert:     insert,
+		Reject:     rejectFn,
+	})
+}
+
 func getEnvTrim(key, def string) string {
 	val := strings.TrimSpace(os.Getenv(key))
 	if val == "" {
@@ -75,6 +157,31 @@ func getEnvInt(key string, def int) int {
 	return n
 }
 
+func getEnvBool(key string, def bool) bool {
+	val := strings.TrimSpace(os.Getenv(key))
+	if val == "" {
+		return def
+	}
+	b, err := strconv.ParseBool(val)
+	if err != nil {
+		return def
+	}
+	return b
+}
+
+// parseReplaySpeed accepts a positive multiplier or "max" for no pacing.
+func parseReplaySpeed(val string) float64 {
+	val = strings.ToLower(strings.TrimSpace(val))
+	if val == "max" || val == "0" {
+		return 0
+	}
+	speed, err := strconv.ParseFloat(val, 64)
+	if err != nil || speed < 0 {
+		return 1
+	}
+	return speed
+}
+
 func splitCSV(s string) []string {
 	if s == "" {
 		return nil
@@ -13,3 +13,13 @@ func TestNewDriver(t *testing.T) {
 		t.Fatalf("expected chatdownloader to be rejected")
 	}
 }
+
+func TestNewDriverTwitchIRC(t *testing.T) {
+	env, err := New(" Twitch-IRC ")
+	if err != nil {
+		t.Fatalf("expected twitch-irc to be supported: %v", err)
+	}
+	if env.Driver != DriverTwitchIRC {
+		t.Fatalf("expected driver %q, got %q", DriverTwitchIRC, env.Driver)
+	}
+}
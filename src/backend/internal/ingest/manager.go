@@ -0,0 +1,260 @@
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"os/exec"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Worker is a single running ingest source (one gnasty URL, one IRC channel,
+// or one replay file).
+type Worker interface {
+	Start(ctx context.Context)
+	Wait()
+}
+
+type managedWorker struct {
+	worker Worker
+	cancel context.CancelFunc
+}
+
+// Manager owns the live ingest workers and supports hot-applying ingest config.
+// Workers are keyed by source so a channel or URL change restarts only the
+// affected worker; driver-level changes (binary, args, backoff) restart all.
+type Manager struct {
+	// applyMu serializes Apply and Stop, the only writers of env and workers,
+	// which also take mu for each change. Readers take mu alone; it is never
+	// held while a worker shuts down, so Status and SnapshotEnv stay responsive.
+	applyMu sync.Mutex
+	mu      sync.Mutex
+
+	ctx    context.Context
+	insert InsertFn
+	reject RejectFn
+	logger *log.Logger
+
+	env     Env
+	workers map[string]managedWorker
+
+	// build constructs a worker for one source; tests replace it.
+	build func(env Env, source string) (Worker, error)
+}
+
+// NewManager creates a manager bound to the provided base context, insert hook,
+// and optional reject hook for lines that fail to decode or insert.
+func NewManager(ctx context.Context, insert InsertFn, rejectFn RejectFn, logger *log.Logger) *Manager {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if logger == nil {
+		logger = log.Default()
+	}
+	m := &Manager{
+		ctx:     ctx,
+		insert:  insert,
+		reject:  rejectFn,
+		logger:  logger,
+		workers: make(map[string]managedWorker),
+	}
+	m.build = m.buildWorker
+	return m
+}
+
+// StartInitial starts ingest workers for the initial config.
+func (m *Manager) StartInitial(env Env, sources []string) error {
+	return m.Apply(env, sources)
+}
+
+// Apply hot-applies the given env and sources. Every replacement worker is
+// built before anything is stopped, so a failed apply leaves the previous
+// workers running untouched. Outgoing workers are removed from the set under
+// the lock and waited on after it is released.
+func (m *Manager) Apply(env Env, sources []string) error {
+	m.applyMu.Lock()
+	defer m.applyMu.Unlock()
+
+	keys := workerSources(env, sources)
+	if err := validateEnv(env, keys); err != nil {
+		return err
+	}
+
+	restartAll := !reflect.DeepEqual(m.env, env)
+	next := make(map[string]Worker, len(keys))
+	for _, key := range keys {
+		if _, running := m.workers[key]; running && !restartAll {
+			continue
+		}
+		w, err := m.build(env, key)
+		if err != nil {
+			return fmt.Errorf("ingest: build worker %s: %w", key, err)
+		}
+		next[key] = w
+	}
+
+	wanted := make(map[string]struct{}, len(keys))
+	for _, key := range keys {
+		wanted[key] = struct{}{}
+	}
+	m.mu.Lock()
+	stopping := make([]managedWorker, 0, len(m.workers))
+	for key, mw := range m.workers {
+		if _, keep := wanted[key]; keep && !restartAll {
+			continue
+		}
+		m.logger.Printf("ingest[%s]: stopping worker source=%s", m.env.Driver, key)
+		mw.cancel()
+		stopping = append(stopping, mw)
+		delete(m.workers, key)
+	}
+	m.mu.Unlock()
+	for _, mw := range stopping {
+		mw.worker.Wait()
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, w := range next {
+		m.logger.Printf("ingest[%s]: starting worker source=%s", env.Driver, key)
+		ctx, cancel := context.WithCancel(m.ctx)
+		w.Start(ctx)
+		m.workers[key] = managedWorker{worker: w, cancel: cancel}
+	}
+
+	m.env = env
+	return nil
+}
+
+// SnapshotEnv returns the currently active env and the sources with running workers.
+func (m *Manager) SnapshotEnv() (Env, []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	running := make([]string, 0, len(m.workers))
+	for key := range m.workers {
+		running = append(running, key)
+	}
+	sort.Strings(running)
+	return m.env, running
+}
+
+// Status returns per-source status for every managed worker that reports it.
+func (m *Manager) Status() []SourceStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	out := make([]SourceStatus, 0, len(m.workers))
+	for _, mw := range m.workers {
+		if reporter, ok := mw.worker.(StatusReporter); ok {
+			out = append(out, reporter.Status()...)
+		}
+	}
+	sortStatuses(out)
+	return out
+}
+
+// Stop stops every managed worker.
+func (m *Manager) Stop() {
+	m.applyMu.Lock()
+	defer m.applyMu.Unlock()
+
+	m.mu.Lock()
+	stopping := make([]managedWorker, 0, len(m.workers))
+	for key, mw := range m.workers {
+		mw.cancel()
+		stopping = append(stopping, mw)
+		delete(m.workers, key)
+	}
+	m.mu.Unlock()
+	for _, mw := range stopping {
+		mw.worker.Wait()
+	}
+}
+
+func (m *Manager) buildWorker(env Env, source string) (Worker, error) {
+	switch env.Driver {
+	case DriverTwitchIRC:
+		return env.BuildTwitchIRC(m.insert, m.reject, []string{source}, m.logger)
+	case DriverReplay:
+		return env.BuildReplay(m.insert, m.reject, m.logger)
+	default:
+		return env.BuildGnasty(m.insert, m.reject, []string{source}, m.logger)
+	}
+}
+
+// workerSources maps configured source URLs to per-worker keys for the driver.
+// Gnasty without a binary is external (it writes SQLite itself), so no workers run.
+func workerSources(env Env, sources []string) []string {
+	seen := make(map[string]struct{})
+	out := make([]string, 0, len(sources))
+	add := func(key string) {
+		if key == "" {
+			return
+		}
+		if _, ok := seen[key]; ok {
+			return
+		}
+		seen[key] = struct{}{}
+		out = append(out, key)
+	}
+	switch env.Driver {
+	case DriverTwitchIRC:
+		for _, src := range sources {
+			add(twitchChannelFromSource(src))
+		}
+	case DriverReplay:
+		add(strings.TrimSpace(env.ReplayPath))
+	default:
+		if strings.TrimSpace(env.GnastyBin) == "" {
+			return nil
+		}
+		for _, src := range sources {
+			add(strings.TrimSpace(src))
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+func validateEnv(env Env, keys []string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	switch env.Driver {
+	case DriverReplay:
+		if _, err := os.Stat(env.ReplayPath); err != nil {
+			return fmt.Errorf("ingest: replay file: %w", err)
+		}
+	case DriverTwitchIRC:
+	default:
+		if _, err := exec.LookPath(env.GnastyBin); err != nil {
+			return fmt.Errorf("ingest: gnasty binary: %w", err)
+		}
+	}
+	return nil
+}
+
+// twitchChannelFromSource accepts a channel login or a twitch.tv URL.
+func twitchChannelFromSource(src string) string {
+	src = strings.TrimSpace(src)
+	if src == "" {
+		return ""
+	}
+	if !strings.Contains(src, "/") {
+		return strings.ToLower(strings.TrimPrefix(src, "#"))
+	}
+	u, err := url.Parse(src)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(strings.TrimPrefix(u.Hostname(), "www."))
+	if host != "twitch.tv" && !strings.HasSuffix(host, ".twitch.tv") {
+		return ""
+	}
+	channel, _, _ := strings.Cut(strings.Trim(u.Path, "/"), "/")
+	return strings.ToLower(channel)
+}
@@ -0,0 +1,193 @@
+package ingest
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+)
+
+type fakeWorker struct {
+	source string
+	mu     *sync.Mutex
+	events *[]string
+	done   chan struct{}
+}
+
+func (w *fakeWorker) Start(ctx context.Context) {
+	w.mu.Lock()
+	*w.events = append(*w.events, "start "+w.source)
+	w.mu.Unlock()
+	go func() {
+		<-ctx.Done()
+		close(w.done)
+	}()
+}
+
+func (w *fakeWorker) Wait() {
+	<-w.done
+	w.mu.Lock()
+	*w.events = append(*w.events, "stop "+w.source)
+	w.mu.Unlock()
+}
+
+func newFakeManager(t *testing.T) (*Manager, func() []string) {
+	t.Helper()
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+	m := NewManager(context.Background(), nil, nil, log.New(io.Discard, "", 0))
+	m.build = func(env Env, source string) (Worker, error) {
+		return &fakeWorker{source: source, mu: &mu, events: &events, done: make(chan struct{})}, nil
+	}
+	t.Cleanup(m.Stop)
+	return m, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		out := append([]string(nil), events...)
+		events = nil
+		return out
+	}
+}
+
+func fakeGnastyBin(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "gnasty")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\n"), 0o755); err != nil {
+		t.Fatalf("write fake bin: %v", err)
+	}
+	return path
+}
+
+func TestManagerRestartsOnlyChangedSources(t *testing.T) {
+	m, drain := newFakeManager(t)
+	env := Env{Driver: DriverGnasty, GnastyBin: fakeGnastyBin(t)}
+
+	if err := m.StartInitial(env, []string{"https://www.twitch.tv/a", "https://youtu.be/x"}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	drain()
+
+	if err := m.Apply(env, []string{"https://www.twitch.tv/b", "https://youtu.be/x"}); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	got := drain()
+	want := []string{"stop https://www.twitch.tv/a", "start https://www.twitch.tv/b"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+
+	env.GnastyArgs = []string{"--verbose"}
+	if err := m.Apply(env, []string{"https://www.twitch.tv/b", "https://youtu.be/x"}); err != nil {
+		t.Fatalf("apply args: %v", err)
+	}
+	if got := drain(); len(got) != 4 {
+		t.Fatalf("expected args change to restart both workers, got %v", got)
+	}
+}
+
+func TestManagerKeepsWorkersWhenApplyFails(t *testing.T) {
+	m, drain := newFakeManager(t)
+	env := Env{Driver: DriverGnasty, GnastyBin: fakeGnastyBin(t)}
+	if err := m.StartInitial(env, []string{"https://www.twitch.tv/a"}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	drain()
+
+	bad := env
+	bad.GnastyBin = filepath.Join(t.TempDir(), "missing")
+	if err := m.Apply(bad, []string{"https://www.twitch.tv/a"}); err == nil {
+		t.Fatalf("expected missing binary to fail")
+	}
+	if got := drain(); len(got) != 0 {
+		t.Fatalf("expected running workers to be untouched, got %v", got)
+	}
+	active, running := m.SnapshotEnv()
+	if active.GnastyBin != env.GnastyBin || !reflect.DeepEqual(running, []string{"https://www.twitch.tv/a"}) {
+		t.Fatalf("expected previous config to stay active, got %+v %v", active, running)
+	}
+}
+
+func TestManagerTwitchIRCWorkersKeyedByChannel(t *testing.T) {
+	m, drain := newFakeManager(t)
+	env := Env{Driver: DriverTwitchIRC}
+	if err := m.StartInitial(env, []string{"https://www.twitch.tv/DayoMan", "https://www.youtube.com/@lofigirl/live"}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if got := drain(); !reflect.DeepEqual(got, []string{"start dayoman"}) {
+		t.Fatalf("expected one twitch worker, got %v", got)
+	}
+}
+
+func TestManagerExternalGnastyRunsNoWorkers(t *testing.T) {
+	m, drain := newFakeManager(t)
+	if err := m.StartInitial(Env{Driver: DriverGnasty}, []string{"https://www.twitch.tv/a"}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+	if got := drain(); len(got) != 0 {
+		t.Fatalf("expected no workers without a gnasty binary, got %v", got)
+	}
+}
+
+type slowStopWorker struct {
+	stopping chan struct{}
+	release  chan struct{}
+}
+
+func (w *slowStopWorker) Start(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		close(w.stopping)
+	}()
+}
+
+func (w *slowStopWorker) Wait() {
+	<-w.stopping
+	<-w.release
+}
+
+func TestManagerApplyDoesNotBlockReadersWhileWorkersStop(t *testing.T) {
+	slow := &slowStopWorker{stopping: make(chan struct{}), release: make(chan struct{})}
+	m := NewManager(context.Background(), nil, nil, log.New(io.Discard, "", 0))
+	m.build = func(env Env, source string) (Worker, error) {
+		if source == "https://www.twitch.tv/a" {
+			return slow, nil
+		}
+		return &fakeWorker{source: source, mu: &sync.Mutex{}, events: &[]string{}, done: make(chan struct{})}, nil
+	}
+	t.Cleanup(m.Stop)
+	env := Env{Driver: DriverGnasty, GnastyBin: fakeGnastyBin(t)}
+	if err := m.StartInitial(env, []string{"https://www.twitch.tv/a"}); err != nil {
+		t.Fatalf("start: %v", err)
+	}
+
+	applied := make(chan error, 1)
+	go func() { applied <- m.Apply(env, []string{"https://www.twitch.tv/b"}) }()
+	<-slow.stopping
+
+	readers := make(chan struct{})
+	go func() {
+		m.Status()
+		m.SnapshotEnv()
+		close(readers)
+	}()
+	select {
+	case <-readers:
+	case <-time.After(2 * time.Second):
+		t.Fatalf("expected Status and SnapshotEnv not to wait for a stopping worker")
+	}
+
+	close(slow.release)
+	if err := <-applied; err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, running := m.SnapshotEnv(); !reflect.DeepEqual(running, []string{"https://www.twitch.tv/b"}) {
+		t.Fatalf("expected only the new worker to run, got %v", running)
+	}
+}
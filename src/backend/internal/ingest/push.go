@@ -0,0 +1,240 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	pushMaxLineBytes = 1 << 20
+	pushMaxErrors    = 50
+)
+
+// PushConfig configures the push ingest endpoint used by third-party producers.
+type PushConfig struct {
+	Token      string
+	SocketPath string
+	Logger     *log.Logger
+	Insert     InsertFn
+	Reject     RejectFn
+}
+
+// Push accepts NDJSON lines from external producers over HTTP or a Unix socket
+// and forwards them to Insert after the same validation gnasty lines receive.
+type Push struct {
+	cfg PushConfig
+
+	wg sync.WaitGroup
+}
+
+// LineError describes a rejected line within a pushed batch.
+type LineError struct {
+	Line  int    `json:"line"`
+	Error string `json:"error"`
+}
+
+// PushResult summarizes a pushed batch.
+type PushResult struct {
+	Accepted int         `json:"accepted"`
+	Rejected int         `json:"rejected"`
+	Errors   []LineError `json:"errors,omitempty"`
+}
+
+func NewPush(cfg PushConfig) (*Push, error) {
+	cfg.Token = strings.TrimSpace(cfg.Token)
+	cfg.SocketPath = strings.TrimSpace(cfg.SocketPath)
+	if cfg.Token == "" {
+		return nil, errors.New("push: Token is required")
+	}
+	if cfg.Insert == nil {
+		return nil, errors.New("push: Insert is required")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+	return &Push{cfg: cfg}, nil
+}
+
+// Authorized reports whether token matches the shared push token.
+func (p *Push) Authorized(token string) bool {
+	token = strings.TrimSpace(token)
+	if p == nil || token == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(p.cfg.Token)) == 1
+}
+
+// IngestLines reads NDJSON from r and inserts every valid line. Blank lines are
+// skipped; the result reports per-line failures using 1-based line numbers.
+func (p *Push) IngestLines(ctx context.Context, source string, r io.Reader) (PushResult, error) {
+	var result PushResult
+	reader := bufio.NewReaderSize(r, 64<<10)
+	lineNo := 0
+	for {
+		line, err := readLimitedLine(reader, pushMaxLineBytes)
+		if len(line) == 0 && errors.Is(err, io.EOF) {
+			return result, nil
+		}
+		lineNo++
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			if lineErr := p.handleLine(ctx, source, trimmed); lineErr != nil {
+				result.Rejected++
+				if len(result.Errors) < pushMaxErrors {
+					result.Errors = append(result.Errors, LineError{Line: lineNo, Error: lineErr.Error()})
+				}
+			} else {
+				result.Accepted++
+			}
+		}
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return result, nil
+			}
+			if errors.Is(err, errLineTooLong) {
+				result.Rejected++
+				if len(result.Errors) < pushMaxErrors {
+					result.Errors = append(result.Errors, LineError{Line: lineNo, Error: err.Error()})
+				}
+				continue
+			}
+			return result, err
+		}
+	}
+}
+
+func (p *Push) handleLine(ctx context.Context, source string, line []byte) error {
+	logger := p.cfg.Logger
+	raw, err := decodeLine(line)
+	if err != nil {
+		logger.Printf("ingest[push]: source=%s: decode error: %v; line=%s", source, err, ellipsis(string(line), 240))
+		reject(ctx, p.cfg.Reject, DriverPush, source, RejectDecode, err, line)
+		return fmt.Errorf("decode: %w", err)
+	}
+	if err := p.cfg.Insert(ctx, raw); err != nil {
+		logger.Printf("ingest[push]: source=%s: insert error: %v", source, err)
+		reject(ctx, p.cfg.Reject, DriverPush, source, RejectInsert, err, raw)
+		return fmt.Errorf("insert: %w", err)
+	}
+	return nil
+}
+
+var errLineTooLong = fmt.Errorf("line exceeds %d bytes", pushMaxLineBytes)
+
+// readLimitedLine reads up to the next newline, discarding the remainder of any
+// line longer than limit and reporting errLineTooLong for it.
+func readLimitedLine(r *bufio.Reader, limit int) ([]byte, error) {
+	var buf []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if len(buf)+len(chunk) > limit {
+			for errors.Is(err, bufio.ErrBufferFull) {
+				_, err = r.ReadSlice('\n')
+			}
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			return nil, errLineTooLong
+		}
+		buf = append(buf, chunk...)
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		return buf, err
+	}
+}
+
+// ListenUnix serves the optional Unix socket until ctx is canceled. Each
+// connection must open with "AUTH <token>" followed by NDJSON lines. It returns
+// immediately when no socket path is configured.
+func (p *Push) ListenUnix(ctx context.Context) error {
+	if p.cfg.SocketPath == "" {
+		return nil
+	}
+	if err := os.Remove(p.cfg.SocketPath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("push: remove stale socket: %w", err)
+	}
+	ln, err := net.Listen("unix", p.cfg.SocketPath)
+	if err != nil {
+		return fmt.Errorf("push: listen: %w", err)
+	}
+	if err := os.Chmod(p.cfg.SocketPath, 0o660); err != nil {
+		p.cfg.Logger.Printf("ingest[push]: chmod socket: %v", err)
+	}
+	p.cfg.Logger.Printf("ingest[push]: listening on unix socket %s", p.cfg.SocketPath)
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		<-ctx.Done()
+		_ = ln.Close()
+	}()
+
+	p.wg.Add(1)
+	go func() {
+		defer p.wg.Done()
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				if ctx.Err() == nil {
+					p.cfg.Logger.Printf("ingest[push]: accept error: %v", err)
+				}
+				return
+			}
+			p.wg.Add(1)
+			go p.serveConn(ctx, conn)
+		}
+	}()
+	return nil
+}
+
+// Wait blocks until the Unix socket listener and its connections have exited.
+func (p *Push) Wait() {
+	p.wg.Wait()
+}
+
+func (p *Push) serveConn(ctx context.Context, conn net.Conn) {
+	defer p.wg.Done()
+	defer conn.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-stop:
+		}
+	}()
+
+	reader := bufio.NewReaderSize(conn, 64<<10)
+	first, err := readLimitedLine(reader, 4096)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return
+	}
+	token, ok := strings.CutPrefix(strings.TrimSpace(string(first)), "AUTH ")
+	if !ok || !p.Authorized(token) {
+		_, _ = io.WriteString(conn, "ERR unauthorized\n")
+		return
+	}
+	if _, err := io.WriteString(conn, "OK\n"); err != nil {
+		return
+	}
+
+	result, err := p.IngestLines(ctx, "unix", reader)
+	if err != nil && ctx.Err() == nil {
+		p.cfg.Logger.Printf("ingest[push]: unix read error: %v", err)
+	}
+	if result.Rejected > 0 {
+		p.cfg.Logger.Printf("ingest[push]: unix connection closed accepted=%d rejected=%d", result.Accepted, result.Rejected)
+	}
+}
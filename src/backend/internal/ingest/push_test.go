@@ -0,0 +1,139 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestPushIngestLinesRejectsOversizedLine(t *testing.T) {
+	var count int
+	push, err := NewPush(PushConfig{
+		Token:  "secret",
+		Logger: log.New(io.Discard, "", 0),
+		Insert: func(ctx context.Context, raw json.RawMessage) error {
+			count++
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new push: %v", err)
+	}
+	big := `{"text":"` + strings.Repeat("a", pushMaxLineBytes) + `"}`
+	result, err := push.IngestLines(context.Background(), "test", strings.NewReader(big+"\n{\"id\":\"ok\"}"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Accepted != 1 || result.Rejected != 1 || count != 1 {
+		t.Fatalf("expected one accepted and one rejected, got %+v (inserted %d)", result, count)
+	}
+	if result.Errors[0].Line != 1 {
+		t.Fatalf("expected line 1 rejected, got %+v", result.Errors)
+	}
+}
+
+func TestPushUnixSocketRequiresAuth(t *testing.T) {
+	inserted := make(chan string, 4)
+	push, err := NewPush(PushConfig{
+		Token:      "secret",
+		SocketPath: filepath.Join(t.TempDir(), "ingest.sock"),
+		Logger:     log.New(io.Discard, "", 0),
+		Insert: func(ctx context.Context, raw json.RawMessage) error {
+			inserted <- string(raw)
+			return nil
+		},
+	})
+	if err != nil {
+		t.Fatalf("new push: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if err := push.ListenUnix(ctx); err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer func() {
+		cancel()
+		push.Wait()
+	}()
+
+	dial := func(auth string) (net.Conn, string) {
+		conn, err := net.Dial("unix", push.cfg.SocketPath)
+		if err != nil {
+			t.Fatalf("dial: %v", err)
+		}
+		if _, err := io.WriteString(conn, auth+"\n"); err != nil {
+			t.Fatalf("write auth: %v", err)
+		}
+		reply, _ := bufio.NewReader(conn).ReadString('\n')
+		return conn, strings.TrimSpace(reply)
+	}
+
+	bad, reply := dial("AUTH nope")
+	bad.Close()
+	if reply != "ERR unauthorized" {
+		t.Fatalf("expected unauthorized reply, got %q", reply)
+	}
+
+	conn, reply := dial("AUTH secret")
+	defer conn.Close()
+	if reply != "OK" {
+		t.Fatalf("expected OK reply, got %q", reply)
+	}
+	if _, err := io.WriteString(conn, "{\"id\":\"1\"}\n"); err != nil {
+		t.Fatalf("write line: %v", err)
+	}
+	select {
+	case got := <-inserted:
+		if got != `{"id":"1"}` {
+			t.Fatalf("unexpected line %q", got)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timed out waiting for insert")
+	}
+}
+
+func TestPushIngestLinesReportsRejections(t *testing.T) {
+	var rejected []Rejection
+	push, err := NewPush(PushConfig{
+		Token:  "secret",
+		Logger: log.New(io.Discard, "", 0),
+		Insert: func(ctx context.Context, raw json.RawMessage) error {
+			if strings.Contains(string(raw), "boom") {
+				return errors.New("store down")
+			}
+			return nil
+		},
+		Reject: func(ctx context.Context, rej Rejection) {
+			rejected = append(rejected, rej)
+		},
+	})
+	if err != nil {
+		t.Fatalf("new push: %v", err)
+	}
+	body := "{not json\n{\"id\":\"boom\"}\n{\"id\":\"ok\"}\n"
+	result, err := push.IngestLines(context.Background(), "http:producer", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Accepted != 1 || result.Rejected != 2 {
+		t.Fatalf("expected one accepted and two rejected, got %+v", result)
+	}
+	if len(rejected) != 2 {
+		t.Fatalf("expected two rejections, got %+v", rejected)
+	}
+	for i, reason := range []string{RejectDecode, RejectInsert} {
+		if rejected[i].Driver != DriverPush || rejected[i].Source != "http:producer" || rejected[i].Reason != reason {
+			t.Fatalf("unexpected rejection %d: %+v", i, rejected[i])
+		}
+	}
+	if string(rejected[0].Line) != "{not json" {
+		t.Fatalf("expected the raw line to be kept, got %q", rejected[0].Line)
+	}
+}
@@ -0,0 +1,111 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+// Record is the NDJSON line shape produced by in-process drivers. It mirrors the
+// rows gnasty writes and the records emitted by /api/messages/export, so the same
+// payload can be inserted, exported, and replayed without translation.
+type Record struct {
+	ID         string `json:"id"`
+	Timestamp  string `json:"ts"`
+	Username   string `json:"username"`
+	Platform   string `json:"platform"`
+	Text       string `json:"text"`
+	EmotesJSON string `json:"emotes_json"`
+	BadgesJSON string `json:"badges_json,omitempty"`
+	RawJSON    string `json:"raw_json"`
+}
+
+// DecodeRecord parses a single NDJSON line into a Record. The ts field accepts
+// RFC3339 timestamps as well as unix milliseconds.
+func DecodeRecord(raw json.RawMessage) (Record, error) {
+	var rec struct {
+		Record
+		Timestamp json.RawMessage `json:"ts"`
+	}
+	if err := json.Unmarshal(raw, &rec); err != nil {
+		return Record{}, fmt.Errorf("ingest: decode record: %w", err)
+	}
+	out := rec.Record
+	ts, err := parseRecordTimestamp(rec.Timestamp)
+	if err != nil {
+		return Record{}, err
+	}
+	out.Timestamp = ts
+	return out, nil
+}
+
+func parseRecordTimestamp(raw json.RawMessage) (string, error) {
+	trimmed := strings.TrimSpace(string(raw))
+	if trimmed == "" || trimmed == "null" {
+		return "", nil
+	}
+	if trimmed[0] == '"' {
+		var s string
+		if err := json.Unmarshal(raw, &s); err != nil {
+			return "", fmt.Errorf("ingest: decode record ts: %w", err)
+		}
+		return strings.TrimSpace(s), nil
+	}
+	ms, err := strconv.ParseInt(trimmed, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("ingest: decode record ts: %w", err)
+	}
+	return time.UnixMilli(ms).UTC().Format(time.RFC3339Nano), nil
+}
+
+// Message converts the record into a storage.Message ready for insertion.
+func (r Record) Message() (storage.Message, error) {
+	if strings.TrimSpace(r.ID) == "" {
+		return storage.Message{}, errors.New("ingest: record id is required")
+	}
+	if strings.TrimSpace(r.Platform) == "" {
+		return storage.Message{}, errors.New("ingest: record platform is required")
+	}
+	ts := time.Now().UTC()
+	if s := strings.TrimSpace(r.Timestamp); s != "" {
+		parsed, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			return storage.Message{}, fmt.Errorf("ingest: record ts: %w", err)
+		}
+		ts = parsed.UTC()
+	}
+	return storage.Message{
+		ID:         r.ID,
+		Timestamp:  ts,
+		Username:   r.Username,
+		Platform:   r.Platform,
+		Text:       r.Text,
+		EmotesJSON: r.EmotesJSON,
+		BadgesJSON: r.BadgesJSON,
+		RawJSON:    r.RawJSON,
+	}, nil
+}
+
+// StoreInsert returns an InsertFn that decodes Record lines and writes them to store.
+func StoreInsert(store storage.Store) InsertFn {
+	return func(ctx context.Context, raw json.RawMessage) error {
+		if store == nil {
+			return errors.New("ingest: store is nil")
+		}
+		rec, err := DecodeRecord(raw)
+		if err != nil {
+			return err
+		}
+		msg, err := rec.Message()
+		if err != nil {
+			return err
+		}
+		return store.InsertMessage(ctx, &msg)
+	}
+}
@@ -0,0 +1,211 @@
+package ingest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ReplayIDPrefix starts the ID of every record the replay driver re-emits.
+const ReplayIDPrefix = "replay:"
+
+// IsReplayID reports whether id belongs to a record re-emitted by a replay, as
+// opposed to something that happened live.
+func IsReplayID(id string) bool {
+	return strings.HasPrefix(id, ReplayIDPrefix)
+}
+
+// ReplayConfig configures the recorded-session replay driver.
+//
+// Speed scales the original gaps between messages: 1 replays at the recorded
+// pace, 2 twice as fast, and 0 (or below) as fast as possible.
+type ReplayConfig struct {
+	Path   string
+	Speed  float64
+	Loop   bool
+	Logger *log.Logger
+	Insert InsertFn
+	Reject RejectFn
+}
+
+// ReplayProcess re-emits an NDJSON capture through Insert.
+type ReplayProcess struct {
+	cfg     ReplayConfig
+	runID   string
+	tracker *sourceTracker
+	now     func() time.Time
+	sleep   func(ctx context.Context, d time.Duration) bool
+
+	wg sync.WaitGroup
+}
+
+func NewReplay(cfg ReplayConfig) (*ReplayProcess, error) {
+	cfg.Path = strings.TrimSpace(cfg.Path)
+	if cfg.Path == "" {
+		return nil, errors.New("replay: Path is required")
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+	return &ReplayProcess{
+		cfg:     cfg,
+		runID:   strconv.FormatInt(time.Now().UnixMilli(), 36),
+		tracker: newSourceTracker(DriverReplay, cfg.Path),
+		now:     time.Now,
+		sleep:   sleepWithContext,
+	}, nil
+}
+
+// Status reports replay progress; each loop pass counts as a restart.
+func (p *ReplayProcess) Status() []SourceStatus {
+	return []SourceStatus{p.tracker.snapshot()}
+}
+
+func (p *ReplayProcess) Start(ctx context.Context) {
+	p.wg.Add(1)
+	go p.run(ctx)
+}
+
+func (p *ReplayProcess) Wait() {
+	p.wg.Wait()
+}
+
+func (p *ReplayProcess) run(ctx context.Context) {
+	defer p.wg.Done()
+	defer p.tracker.stopped()
+	logger := p.cfg.Logger
+
+	for pass := 0; ; pass++ {
+		logger.Printf("ingest[replay]: pass=%d starting: path=%s speed=%g loop=%t", pass, p.cfg.Path, p.cfg.Speed, p.cfg.Loop)
+		p.tracker.running(0)
+		n, err := p.replayOnce(ctx, pass)
+		p.tracker.exited(err)
+		if ctx.Err() != nil {
+			logger.Printf("ingest[replay]: context canceled")
+			return
+		}
+		if err != nil {
+			logger.Printf("ingest[replay]: pass=%d: error: %v", pass, err)
+			return
+		}
+		logger.Printf("ingest[replay]: pass=%d finished: emitted=%d", pass, n)
+		if !p.cfg.Loop || n == 0 {
+			return
+		}
+	}
+}
+
+// replayOnce streams the capture file once, returning the number of lines emitted.
+func (p *ReplayProcess) replayOnce(ctx context.Context, pass int) (int, error) {
+	f, err := os.Open(p.cfg.Path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	var (
+		emitted int
+		prevTS  time.Time
+	)
+	reader := bufio.NewReaderSize(f, 1<<20)
+	for {
+		line, readErr := reader.ReadBytes('\n')
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 {
+			ts, ok := replayLineTimestamp(trimmed)
+			if ok && !prevTS.IsZero() {
+				if !p.sleep(ctx, p.scaledGap(ts.Sub(prevTS))) {
+					return emitted, ctx.Err()
+				}
+			}
+			if ok {
+				prevTS = ts
+			}
+			if p.emit(ctx, pass, trimmed) {
+				emitted++
+			}
+		}
+		if readErr != nil {
+			if errors.Is(readErr, io.EOF) {
+				return emitted, nil
+			}
+			return emitted, readErr
+		}
+		if ctx.Err() != nil {
+			return emitted, ctx.Err()
+		}
+	}
+}
+
+func (p *ReplayProcess) scaledGap(gap time.Duration) time.Duration {
+	if gap <= 0 || p.cfg.Speed <= 0 {
+		return 0
+	}
+	return time.Duration(float64(gap) / p.cfg.Speed)
+}
+
+// emit rewrites record-shaped lines so repeated passes do not collide with the
+// original rows, then forwards the line to Insert.
+func (p *ReplayProcess) emit(ctx context.Context, pass int, line []byte) bool {
+	logger := p.cfg.Logger
+	p.tracker.line()
+	raw, err := decodeLine(line)
+	if err != nil {
+		p.tracker.decodeError()
+		logger.Printf("ingest[replay]: decode error: %v; line=%s", err, ellipsis(string(line), 240))
+		reject(ctx, p.cfg.Reject, DriverReplay, p.cfg.Path, RejectDecode, err, line)
+		return false
+	}
+	if rec, err := DecodeRecord(raw); err == nil && strings.TrimSpace(rec.ID) != "" {
+		rec.ID = fmt.Sprintf("%s%s:%d:%s", ReplayIDPrefix, p.runID, pass, rec.ID)
+		rec.Timestamp = p.now().UTC().Format(time.RFC3339Nano)
+		if data, err := json.Marshal(rec); err == nil {
+			raw = data
+		}
+	}
+	if p.cfg.Insert == nil {
+		logger.Printf("ingest[replay]: line ok (len=%d)", len(raw))
+		return true
+	}
+	if err := p.cfg.Insert(ctx, raw); err != nil {
+		p.tracker.insertError()
+		logger.Printf("ingest[replay]: insert error: %v", err)
+		reject(ctx, p.cfg.Reject, DriverReplay, p.cfg.Path, RejectInsert, err, raw)
+		return false
+	}
+	return true
+}
+
+// replayLineTimestamp extracts the original timestamp from export records
+// (RFC3339 "ts") or gnasty output (unix milliseconds in "ts"/"timestamp").
+func replayLineTimestamp(line []byte) (time.Time, bool) {
+	var obj map[string]json.RawMessage
+	if err := json.Unmarshal(line, &obj); err != nil {
+		return time.Time{}, false
+	}
+	for _, key := range []string{"ts", "timestamp", "time"} {
+		raw, ok := obj[key]
+		if !ok {
+			continue
+		}
+		s, err := parseRecordTimestamp(raw)
+		if err != nil || s == "" {
+			continue
+		}
+		ts, err := time.Parse(time.RFC3339Nano, s)
+		if err != nil {
+			continue
+		}
+		return ts, true
+	}
+	return time.Time{}, false
+}
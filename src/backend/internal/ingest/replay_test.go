@@ -0,0 +1,121 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeCapture(t *testing.T, lines ...string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "capture.ndjson")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0o644); err != nil {
+		t.Fatalf("write capture: %v", err)
+	}
+	return path
+}
+
+func newTestReplay(t *testing.T, cfg ReplayConfig) (*ReplayProcess, *[]Record, *[]time.Duration) {
+	t.Helper()
+	var (
+		records []Record
+		sleeps  []time.Duration
+	)
+	cfg.Logger = log.New(io.Discard, "", 0)
+	cfg.Insert = func(ctx context.Context, raw json.RawMessage) error {
+		rec, err := DecodeRecord(raw)
+		if err != nil {
+			return err
+		}
+		records = append(records, rec)
+		return nil
+	}
+	p, err := NewReplay(cfg)
+	if err != nil {
+		t.Fatalf("new replay: %v", err)
+	}
+	p.runID = "run"
+	p.now = func() time.Time { return time.Date(2024, 5, 1, 20, 0, 0, 0, time.UTC) }
+	p.sleep = func(ctx context.Context, d time.Duration) bool {
+		sleeps = append(sleeps, d)
+		return true
+	}
+	return p, &records, &sleeps
+}
+
+func TestReplayPacesExportCaptureWithSpeed(t *testing.T) {
+	path := writeCapture(t,
+		`{"id":"a","ts":"2024-01-01T00:00:00Z","username":"u","platform":"Twitch","text":"one","emotes_json":"[]","raw_json":"{}"}`,
+		`{"id":"b","ts":"2024-01-01T00:00:04Z","username":"u","platform":"Twitch","text":"two","emotes_json":"[]","raw_json":"{}"}`,
+		`{"id":"c","ts":1704067210000,"username":"u","platform":"Twitch","text":"three"}`,
+	)
+	p, records, sleeps := newTestReplay(t, ReplayConfig{Path: path, Speed: 2})
+
+	n, err := p.replayOnce(context.Background(), 0)
+	if err != nil {
+		t.Fatalf("replay: %v", err)
+	}
+	if n != 3 || len(*records) != 3 {
+		t.Fatalf("expected 3 emitted records, got %d/%d", n, len(*records))
+	}
+	want := []time.Duration{2 * time.Second, 3 * time.Second}
+	if len(*sleeps) != len(want) || (*sleeps)[0] != want[0] || (*sleeps)[1] != want[1] {
+		t.Fatalf("expected sleeps %v, got %v", want, *sleeps)
+	}
+	first := (*records)[0]
+	if first.ID != "replay:run:0:a" {
+		t.Fatalf("expected rewritten id, got %q", first.ID)
+	}
+	if first.Timestamp != "2024-05-01T20:00:00Z" {
+		t.Fatalf("expected rebased timestamp, got %q", first.Timestamp)
+	}
+}
+
+func TestReplayAsFastAsPossibleAndLoops(t *testing.T) {
+	path := writeCapture(t,
+		`{"id":"a","ts":"2024-01-01T00:00:00Z","username":"u","platform":"Twitch","text":"one"}`,
+		`{"id":"b","ts":"2024-01-01T00:01:00Z","username":"u","platform":"Twitch","text":"two"}`,
+	)
+	p, records, sleeps := newTestReplay(t, ReplayConfig{Path: path, Speed: 0, Loop: true})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cfg.Insert = func(next InsertFn) InsertFn {
+		return func(c context.Context, raw json.RawMessage) error {
+			err := next(c, raw)
+			if len(*records) == 4 {
+				cancel()
+			}
+			return err
+		}
+	}(p.cfg.Insert)
+
+	p.Start(ctx)
+	p.Wait()
+
+	if len(*records) != 4 {
+		t.Fatalf("expected two passes of two records, got %d", len(*records))
+	}
+	if (*records)[2].ID != "replay:run:1:a" {
+		t.Fatalf("expected second pass id, got %q", (*records)[2].ID)
+	}
+	for _, d := range *sleeps {
+		if d != 0 {
+			t.Fatalf("expected no pacing delay, got %v", *sleeps)
+		}
+	}
+}
+
+func TestParseReplaySpeed(t *testing.T) {
+	cases := map[string]float64{"": 1, "1": 1, "2.5": 2.5, "max": 0, "0": 0, "-3": 1, "fast": 1}
+	for in, want := range cases {
+		if got := parseReplaySpeed(in); got != want {
+			t.Fatalf("parseReplaySpeed(%q): expected %v, got %v", in, want, got)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package ingest
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Source states reported by SourceStatus.State.
+const (
+	StateStarting   = "starting"
+	StateRunning    = "running"
+	StateBackingOff = "backing_off"
+	StateStopped    = "stopped"
+)
+
+// SourceStatus describes the live state of one ingest source.
+type SourceStatus struct {
+	Source        string     `json:"source"`
+	Driver        string     `json:"driver"`
+	State         string     `json:"state"`
+	PID           int        `json:"pid,omitempty"`
+	StartedAt     *time.Time `json:"started_at,omitempty"`
+	Restarts      int        `json:"restarts"`
+	BackoffMS     int64      `json:"backoff_ms,omitempty"`
+	LastExitError string     `json:"last_exit_error,omitempty"`
+	LastExitAt    *time.Time `json:"last_exit_at,omitempty"`
+	LinesRead     uint64     `json:"lines_read"`
+	DecodeErrors  uint64     `json:"decode_errors"`
+	InsertErrors  uint64     `json:"insert_errors"`
+	LastLineAt    *time.Time `json:"last_line_at,omitempty"`
+}
+
+// StatusReporter is implemented by workers that track per-source status.
+type StatusReporter interface {
+	Status() []SourceStatus
+}
+
+// sourceTracker records status transitions for a single source.
+type sourceTracker struct {
+	mu      sync.Mutex
+	st      SourceStatus
+	started bool
+	now     func() time.Time
+}
+
+func newSourceTracker(driver, source string) *sourceTracker {
+	return &sourceTracker{
+		st:  SourceStatus{Source: source, Driver: driver, State: StateStarting},
+		now: time.Now,
+	}
+}
+
+func (t *sourceTracker) running(pid int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now().UTC()
+	if t.started {
+		t.st.Restarts++
+	}
+	t.started = true
+	t.st.State = StateRunning
+	t.st.PID = pid
+	t.st.StartedAt = &now
+	t.st.BackoffMS = 0
+}
+
+func (t *sourceTracker) exited(err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now().UTC()
+	t.st.PID = 0
+	t.st.LastExitAt = &now
+	if err != nil {
+		t.st.LastExitError = err.Error()
+	} else {
+		t.st.LastExitError = ""
+	}
+}
+
+func (t *sourceTracker) backingOff(d time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.st.State = StateBackingOff
+	t.st.PID = 0
+	t.st.BackoffMS = d.Milliseconds()
+}
+
+func (t *sourceTracker) stopped() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.st.State = StateStopped
+	t.st.PID = 0
+	t.st.BackoffMS = 0
+}
+
+func (t *sourceTracker) line() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now().UTC()
+	t.st.LinesRead++
+	t.st.LastLineAt = &now
+}
+
+func (t *sourceTracker) decodeError() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.st.DecodeErrors++
+}
+
+func (t *sourceTracker) insertError() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.st.InsertErrors++
+}
+
+func (t *sourceTracker) snapshot() SourceStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.st
+}
+
+func sortStatuses(statuses []SourceStatus) {
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Source < statuses[j].Source
+	})
+}
@@ -0,0 +1,87 @@
+package ingest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestGnastyProcessTracksPerURLStatus(t *testing.T) {
+	script := filepath.Join(t.TempDir(), "gnasty")
+	body := "#!/bin/sh\necho '{\"id\":\"1\"}'\necho 'not json'\necho '{\"id\":\"2\"}'\nexit 3\n"
+	if err := os.WriteFile(script, []byte(body), 0o755); err != nil {
+		t.Fatalf("write script: %v", err)
+	}
+
+	proc, err := NewGnasty(GnastyConfig{
+		Bin:         script,
+		BackoffBase: time.Hour,
+		Logger:      log.New(io.Discard, "", 0),
+		Insert: func(ctx context.Context, raw json.RawMessage) error {
+			if string(raw) == `{"id":"2"}` {
+				return errors.New("duplicate")
+			}
+			return nil
+		},
+	}, []string{"https://www.twitch.tv/dayoman"})
+	if err != nil {
+		t.Fatalf("new gnasty: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	proc.Start(ctx)
+	defer func() {
+		cancel()
+		proc.Wait()
+	}()
+
+	deadline := time.Now().Add(5 * time.Second)
+	var st SourceStatus
+	for {
+		st = proc.Status()[0]
+		if st.State == StateBackingOff {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected backing_off state, got %+v", st)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if st.Source != "https://www.twitch.tv/dayoman" || st.Driver != DriverGnasty {
+		t.Fatalf("unexpected identity: %+v", st)
+	}
+	if st.LinesRead != 3 || st.DecodeErrors != 1 || st.InsertErrors != 1 {
+		t.Fatalf("unexpected counters: %+v", st)
+	}
+	if st.LastExitError == "" || st.LastExitAt == nil || st.StartedAt == nil || st.LastLineAt == nil {
+		t.Fatalf("expected exit/start/line timestamps, got %+v", st)
+	}
+	if st.PID != 0 || st.BackoffMS != time.Hour.Milliseconds() {
+		t.Fatalf("expected cleared pid and hour backoff, got %+v", st)
+	}
+
+	cancel()
+	proc.Wait()
+	if st := proc.Status()[0]; st.State != StateStopped {
+		t.Fatalf("expected stopped state after cancel, got %+v", st)
+	}
+}
+
+func TestSourceTrackerCountsRestarts(t *testing.T) {
+	tr := newSourceTracker(DriverGnasty, "u")
+	tr.running(10)
+	tr.exited(nil)
+	tr.backingOff(time.Second)
+	tr.running(11)
+	st := tr.snapshot()
+	if st.Restarts != 1 || st.PID != 11 || st.State != StateRunning || st.BackoffMS != 0 {
+		t.Fatalf("unexpected status: %+v", st)
+	}
+}
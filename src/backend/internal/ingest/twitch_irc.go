@@ -0,0 +1,499 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTwitchIRCAddr = "irc.chat.twitch.tv:6697"
+	anonymousTwitchNick  = "justinfan12345"
+	twitchIRCReadTimeout = 6 * time.Minute
+)
+
+// errTwitchReconnect signals that the server asked us to reconnect.
+var errTwitchReconnect = errors.New("twitch-irc: server requested reconnect")
+
+// TwitchIRCConfig configures the native Twitch IRC driver.
+type TwitchIRCConfig struct {
+	Addr        string
+	TLS         bool
+	Nick        string
+	Token       string
+	TokenPath   string
+	BackoffBase time.Duration
+	BackoffMax  time.Duration
+	Logger      *log.Logger
+	Insert      InsertFn
+	Reject      RejectFn
+}
+
+// TwitchIRCClient reads chat from Twitch IRC and forwards it as Record lines.
+type TwitchIRCClient struct {
+	cfg      TwitchIRCConfig
+	channels []string
+	tracker  *sourceTracker
+
+	wg sync.WaitGroup
+}
+
+func NewTwitchIRC(cfg TwitchIRCConfig, channels []string) (*TwitchIRCClient, error) {
+	if strings.TrimSpace(cfg.Addr) == "" {
+		cfg.Addr = defaultTwitchIRCAddr
+	}
+	if cfg.BackoffBase <= 0 {
+		cfg.BackoffBase = time.Second
+	}
+	if cfg.BackoffMax <= 0 {
+		cfg.BackoffMax = 30 * time.Second
+	}
+	if cfg.BackoffBase > cfg.BackoffMax {
+		cfg.BackoffMax = cfg.BackoffBase
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = log.Default()
+	}
+	clean := make([]string, 0, len(channels))
+	for _, ch := range channels {
+		ch = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ch), "#"))
+		if ch != "" {
+			clean = append(clean, ch)
+		}
+	}
+	if len(clean) == 0 {
+		return nil, errors.New("twitch-irc: at least one channel is required")
+	}
+	return &TwitchIRCClient{
+		cfg:      cfg,
+		channels: clean,
+		tracker:  newSourceTracker(DriverTwitchIRC, strings.Join(clean, ",")),
+	}, nil
+}
+
+// Status reports the connection state for the joined channels.
+func (c *TwitchIRCClient) Status() []SourceStatus {
+	return []SourceStatus{c.tracker.snapshot()}
+}
+
+// Start connects in the background and keeps reconnecting until ctx is done.
+func (c *TwitchIRCClient) Start(ctx context.Context) {
+	c.wg.Add(1)
+	go c.run(ctx)
+}
+
+func (c *TwitchIRCClient) Wait() {
+	c.wg.Wait()
+}
+
+func (c *TwitchIRCClient) run(ctx context.Context) {
+	defer c.wg.Done()
+	defer c.tracker.stopped()
+	logger := c.cfg.Logger
+	base := c.cfg.BackoffBase
+	max := c.cfg.BackoffMax
+	backoff := base
+
+	for {
+		select {
+		case <-ctx.Done():
+			logger.Printf("ingest[twitch-irc]: context canceled")
+			return
+		default:
+		}
+
+		joined, err := c.session(ctx)
+		if ctx.Err() != nil {
+			logger.Printf("ingest[twitch-irc]: context canceled")
+			return
+		}
+		if joined {
+			backoff = base
+		}
+		if errors.Is(err, errTwitchReconnect) {
+			backoff = base
+		}
+		logger.Printf("ingest[twitch-irc]: disconnected: %v; reconnecting in %s", err, backoff)
+		c.tracker.exited(err)
+		c.tracker.backingOff(backoff)
+		if !sleepWithContext(ctx, backoff) {
+			return
+		}
+		backoff = nextBackoff(backoff, base, max)
+	}
+}
+
+// session runs one connection lifetime. It reports whether the connection got
+// far enough to join channels so the caller can reset its backoff.
+func (c *TwitchIRCClient) session(ctx context.Context) (bool, error) {
+	logger := c.cfg.Logger
+	conn, err := c.dial(ctx)
+	if err != nil {
+		return false, err
+	}
+	defer conn.Close()
+
+	stop := make(chan struct{})
+	defer close(stop)
+	go func() {
+		select {
+		case <-ctx.Done():
+			_ = conn.Close()
+		case <-stop:
+		}
+	}()
+
+	nick, pass := c.credentials()
+	lines := []string{"CAP REQ :twitch.tv/tags twitch.tv/commands"}
+	if pass != "" {
+		lines = append(lines, "PASS "+pass)
+	}
+	lines = append(lines, "NICK "+nick)
+	for _, ch := range c.channels {
+		lines = append(lines, "JOIN #"+ch)
+	}
+	for _, line := range lines {
+		if err := writeIRCLine(conn, line); err != nil {
+			return false, err
+		}
+	}
+	c.tracker.running(0)
+	logger.Printf("ingest[twitch-irc]: connected addr=%s nick=%s channels=%s", c.cfg.Addr, nick, strings.Join(c.channels, ","))
+
+	reader := bufio.NewReaderSize(conn, 64<<10)
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(twitchIRCReadTimeout))
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return true, errors.New("twitch-irc: connection closed")
+			}
+			return true, err
+		}
+		msg, ok := parseIRCLine(line)
+		if !ok {
+			continue
+		}
+		switch msg.Command {
+		case "PING":
+			if err := writeIRCLine(conn, "PONG :"+msg.Trailing()); err != nil {
+				return true, err
+			}
+		case "RECONNECT":
+			return true, errTwitchReconnect
+		case "NOTICE":
+			if text := msg.Trailing(); strings.Contains(strings.ToLower(text), "authentication failed") ||
+				strings.Contains(strings.ToLower(text), "improperly formatted auth") {
+				return false, fmt.Errorf("twitch-irc: %s", text)
+			}
+		case "PRIVMSG", "USERNOTICE":
+			c.handleMessage(ctx, msg)
+		}
+	}
+}
+
+func (c *TwitchIRCClient) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	if !c.cfg.TLS {
+		return dialer.DialContext(ctx, "tcp", c.cfg.Addr)
+	}
+	host, _, err := net.SplitHostPort(c.cfg.Addr)
+	if err != nil {
+		host = c.cfg.Addr
+	}
+	tlsDialer := &tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: host}}
+	return tlsDialer.DialContext(ctx, "tcp", c.cfg.Addr)
+}
+
+// credentials returns the nick and PASS value. The token file is re-read on
+// each connection so refreshed OAuth tokens are picked up on reconnect.
+func (c *TwitchIRCClient) credentials() (string, string) {
+	token := strings.TrimSpace(c.cfg.Token)
+	if token == "" && strings.TrimSpace(c.cfg.TokenPath) != "" {
+		if data, err := os.ReadFile(c.cfg.TokenPath); err == nil {
+			token = strings.TrimSpace(string(data))
+		} else if !errors.Is(err, os.ErrNotExist) {
+			c.cfg.Logger.Printf("ingest[twitch-irc]: read token file: %v", err)
+		}
+	}
+	nick := strings.ToLower(strings.TrimSpace(c.cfg.Nick))
+	if token == "" || nick == "" {
+		return anonymousTwitchNick, ""
+	}
+	if !strings.HasPrefix(token, "oauth:") {
+		token = "oauth:" + token
+	}
+	return nick, token
+}
+
+func (c *TwitchIRCClient) handleMessage(ctx context.Context, msg ircMessage) {
+	logger := c.cfg.Logger
+	c.tracker.line()
+	rec, ok := recordFromIRC(msg, time.Now())
+	if !ok {
+		c.tracker.decodeError()
+		return
+	}
+	line, err := json.Marshal(rec)
+	if err != nil {
+		logger.Printf("ingest[twitch-irc]: encode error: %v", err)
+		return
+	}
+	if c.cfg.Insert != nil {
+		if err := c.cfg.Insert(ctx, line); err != nil {
+			c.tracker.insertError()
+			logger.Printf("ingest[twitch-irc]: insert error: %v", err)
+			reject(ctx, c.cfg.Reject, DriverTwitchIRC, strings.Join(c.channels, ","), RejectInsert, err, line)
+		}
+		return
+	}
+	logger.Printf("ingest[twitch-irc]: line ok (len=%d)", len(line))
+}
+
+func writeIRCLine(w io.Writer, line string) error {
+	_, err := io.WriteString(w, line+"\r\n")
+	return err
+}
+
+// ircMessage is a parsed IRCv3 line.
+type ircMessage struct {
+	Tags    map[string]string
+	Prefix  string
+	Command string
+	Params  []string
+}
+
+// Trailing returns the last parameter, which carries the message text.
+func (m ircMessage) Trailing() string {
+	if len(m.Params) == 0 {
+		return ""
+	}
+	return m.Params[len(m.Params)-1]
+}
+
+// Login returns the nick portion of the prefix (nick!user@host).
+func (m ircMessage) Login() string {
+	prefix := m.Prefix
+	if i := strings.IndexByte(prefix, '!'); i >= 0 {
+		prefix = prefix[:i]
+	}
+	return strings.ToLower(prefix)
+}
+
+func parseIRCLine(line string) (ircMessage, bool) {
+	line = strings.TrimRight(line, "\r\n")
+	if line == "" {
+		return ircMessage{}, false
+	}
+	var msg ircMessage
+	if line[0] == '@' {
+		end := strings.IndexByte(line, ' ')
+		if end < 0 {
+			return ircMessage{}, false
+		}
+		msg.Tags = parseIRCTags(line[1:end])
+		line = strings.TrimLeft(line[end+1:], " ")
+	}
+	if strings.HasPrefix(line, ":") {
+		end := strings.IndexByte(line, ' ')
+		if end < 0 {
+			return ircMessage{}, false
+		}
+		msg.Prefix = line[1:end]
+		line = strings.TrimLeft(line[end+1:], " ")
+	}
+	for line != "" {
+		if line[0] == ':' {
+			msg.Params = append(msg.Params, line[1:])
+			break
+		}
+		end := strings.IndexByte(line, ' ')
+		if end < 0 {
+			msg.Params = append(msg.Params, line)
+			break
+		}
+		msg.Params = append(msg.Params, line[:end])
+		line = strings.TrimLeft(line[end+1:], " ")
+	}
+	if len(msg.Params) == 0 {
+		return ircMessage{}, false
+	}
+	msg.Command = strings.ToUpper(msg.Params[0])
+	msg.Params = msg.Params[1:]
+	return msg, true
+}
+
+func parseIRCTags(raw string) map[string]string {
+	tags := make(map[string]string)
+	for _, part := range strings.Split(raw, ";") {
+		if part == "" {
+			continue
+		}
+		key, value, _ := strings.Cut(part, "=")
+		tags[key] = unescapeIRCTagValue(value)
+	}
+	return tags
+}
+
+func unescapeIRCTagValue(v string) string {
+	if !strings.Contains(v, `\`) {
+		return v
+	}
+	var b strings.Builder
+	b.Grow(len(v))
+	for i := 0; i < len(v); i++ {
+		if v[i] != '\\' || i+1 >= len(v) {
+			if v[i] != '\\' {
+				b.WriteByte(v[i])
+			}
+			continue
+		}
+		i++
+		switch v[i] {
+		case ':':
+			b.WriteByte(';')
+		case 's':
+			b.WriteByte(' ')
+		case 'r':
+			b.WriteByte('\r')
+		case 'n':
+			b.WriteByte('\n')
+		default:
+			b.WriteByte(v[i])
+		}
+	}
+	return b.String()
+}
+
+// twitchRawJSON is the provider payload stored in raw_json for Twitch rows.
+type twitchRawJSON struct {
+	Command string            `json:"command"`
+	Channel string            `json:"channel"`
+	Login   string            `json:"login,omitempty"`
+	Body    string            `json:"body,omitempty"`
+	Tags    map[string]string `json:"tags"`
+}
+
+type twitchBadge struct {
+	Platform string `json:"platform"`
+	ID       string `json:"id"`
+	Version  string `json:"version"`
+}
+
+// recordFromIRC converts PRIVMSG and USERNOTICE lines into Records. CLEARMSG
+// (a moderator deleting a message) is dropped: it carries the deleted body, and
+// storing it as a row would replay that message on the overlay until deletions
+// are supported.
+func recordFromIRC(msg ircMessage, now time.Time) (Record, bool) {
+	if len(msg.Params) == 0 {
+		return Record{}, false
+	}
+	channel := strings.ToLower(strings.TrimPrefix(msg.Params[0], "#"))
+	tags := msg.Tags
+	if tags == nil {
+		tags = map[string]string{}
+	}
+	body := ""
+	if len(msg.Params) > 1 {
+		body = msg.Trailing()
+	}
+
+	login := strings.ToLower(tags["login"])
+	if login == "" {
+		login = msg.Login()
+	}
+
+	ts := now.UTC()
+	if ms, err := strconv.ParseInt(tags["tmi-sent-ts"], 10, 64); err == nil && ms > 0 {
+		ts = time.UnixMilli(ms).UTC()
+	}
+
+	raw := twitchRawJSON{Command: msg.Command, Channel: channel, Login: login, Body: body, Tags: tags}
+	id := tags["id"]
+	text := body
+	switch msg.Command {
+	case "PRIVMSG":
+		if login == "" {
+			return Record{}, false
+		}
+	case "USERNOTICE":
+		if text == "" {
+			text = tags["system-msg"]
+		}
+	default:
+		return Record{}, false
+	}
+	if id == "" {
+		return Record{}, false
+	}
+
+	username := strings.TrimSpace(tags["display-name"])
+	if username == "" {
+		username = login
+	}
+
+	rawJSON, err := json.Marshal(raw)
+	if err != nil {
+		return Record{}, false
+	}
+	return Record{
+		ID:         id,
+		Timestamp:  ts.Format(time.RFC3339Nano),
+		Username:   username,
+		Platform:   "Twitch",
+		Text:       text,
+		EmotesJSON: twitchEmotesJSON(tags["emotes"]),
+		BadgesJSON: twitchBadgesJSON(tags, channel),
+		RawJSON:    string(rawJSON),
+	}, true
+}
+
+// twitchEmotesJSON wraps the emotes tag in the span list format gnasty stores
+// (e.g. ["25:0-4/1902:6-10"]).
+func twitchEmotesJSON(spans string) string {
+	spans = strings.TrimSpace(spans)
+	if spans == "" {
+		return "[]"
+	}
+	data, err := json.Marshal([]string{spans})
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
+
+func twitchBadgesJSON(tags map[string]string, channel string) string {
+	badges := make([]twitchBadge, 0, 4)
+	for _, entry := range strings.Split(tags["badges"], ",") {
+		id, version, ok := strings.Cut(strings.TrimSpace(entry), "/")
+		if !ok || id == "" {
+			continue
+		}
+		badges = append(badges, twitchBadge{Platform: "twitch", ID: id, Version: version})
+	}
+	rawTwitch := map[string]string{"channel": channel}
+	for _, key := range []string{"badges", "badge-info", "room-id"} {
+		if v := tags[key]; v != "" {
+			rawTwitch[strings.ReplaceAll(key, "-", "_")] = v
+		}
+	}
+	data, err := json.Marshal(map[string]any{
+		"badges": badges,
+		"raw":    map[string]any{"twitch": rawTwitch},
+	})
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
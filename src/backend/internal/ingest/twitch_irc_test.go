@@ -0,0 +1,194 @@
+package ingest
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParseIRCLineWithTags(t *testing.T) {
+	msg, ok := parseIRCLine("@badges=subscriber/12;color=#1E90FF;display-name=Dagnel;id=abc;system-msg=hi\\sthere\\:x :dagnel!dagnel@dagnel.tmi.twitch.tv PRIVMSG #dayoman :hello :) world\r\n")
+	if !ok {
+		t.Fatalf("expected line to parse")
+	}
+	if msg.Command != "PRIVMSG" {
+		t.Fatalf("expected PRIVMSG, got %q", msg.Command)
+	}
+	if msg.Login() != "dagnel" {
+		t.Fatalf("expected login dagnel, got %q", msg.Login())
+	}
+	if msg.Trailing() != "hello :) world" {
+		t.Fatalf("expected trailing text, got %q", msg.Trailing())
+	}
+	if msg.Tags["system-msg"] != "hi there;x" {
+		t.Fatalf("expected unescaped tag, got %q", msg.Tags["system-msg"])
+	}
+}
+
+func TestRecordFromIRCPrivmsg(t *testing.T) {
+	msg, _ := parseIRCLine("@badge-info=subscriber/17;badges=subscriber/12,premium/1;color=#33CC66;display-name=Dagnel;emotes=25:0-4;id=msg-1;room-id=40934651;tmi-sent-ts=1700000000000;user-id=123 :dagnel!dagnel@dagnel.tmi.twitch.tv PRIVMSG #dayoman :Kappa hi")
+	rec, ok := recordFromIRC(msg, time.Now())
+	if !ok {
+		t.Fatalf("expected record")
+	}
+	if rec.ID != "msg-1" || rec.Username != "Dagnel" || rec.Platform != "Twitch" || rec.Text != "Kappa hi" {
+		t.Fatalf("unexpected record: %+v", rec)
+	}
+	if rec.Timestamp != "2023-11-14T22:13:20Z" {
+		t.Fatalf("expected tmi-sent-ts timestamp, got %q", rec.Timestamp)
+	}
+	if rec.EmotesJSON != `["25:0-4"]` {
+		t.Fatalf("expected span emotes, got %s", rec.EmotesJSON)
+	}
+	if !strings.Contains(rec.BadgesJSON, `{"platform":"twitch","id":"subscriber","version":"12"}`) ||
+		!strings.Contains(rec.BadgesJSON, `"badge_info":"subscriber/17"`) {
+		t.Fatalf("unexpected badges json: %s", rec.BadgesJSON)
+	}
+	var raw map[string]any
+	if err := json.Unmarshal([]byte(rec.RawJSON), &raw); err != nil {
+		t.Fatalf("raw json: %v", err)
+	}
+	if raw["channel"] != "dayoman" || raw["command"] != "PRIVMSG" {
+		t.Fatalf("unexpected raw json: %s", rec.RawJSON)
+	}
+	if _, ok := raw["message"]; ok {
+		t.Fatalf("raw json must stay provider-shaped, got %s", rec.RawJSON)
+	}
+}
+
+func TestRecordFromIRCUserNoticeAndClearMsg(t *testing.T) {
+	notice, _ := parseIRCLine(`@display-name=Raider;id=n-1;login=raider;msg-id=raid;system-msg=5\sraiders\sfrom\sRaider :tmi.twitch.tv USERNOTICE #dayoman`)
+	rec, ok := recordFromIRC(notice, time.Now())
+	if !ok {
+		t.Fatalf("expected usernotice record")
+	}
+	if rec.Text != "5 raiders from Raider" || rec.Username != "Raider" {
+		t.Fatalf("unexpected usernotice record: %+v", rec)
+	}
+
+	clear, _ := parseIRCLine(`@login=spammer;target-msg-id=msg-9;tmi-sent-ts=1700000000000 :tmi.twitch.tv CLEARMSG #dayoman :buy followers`)
+	if rec, ok := recordFromIRC(clear, time.Now()); ok {
+		t.Fatalf("expected clearmsg to be dropped, got %+v", rec)
+	}
+}
+
+func TestTwitchIRCClientAgainstFakeServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer ln.Close()
+
+	var (
+		mu       sync.Mutex
+		received []string
+		conns    int
+	)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			conns++
+			attempt := conns
+			mu.Unlock()
+			go func(conn net.Conn, attempt int) {
+				defer conn.Close()
+				reader := bufio.NewReader(conn)
+				for {
+					line, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					mu.Lock()
+					received = append(received, strings.TrimSpace(line))
+					mu.Unlock()
+					if !strings.HasPrefix(line, "JOIN ") {
+						continue
+					}
+					if attempt == 1 {
+						// Drop the first connection to exercise reconnect.
+						return
+					}
+					_, _ = io.WriteString(conn, "PING :tmi.twitch.tv\r\n")
+					_, _ = io.WriteString(conn, "@login=spammer;target-msg-id=msg-0;tmi-sent-ts=1700000000000 :tmi.twitch.tv CLEARMSG #dayoman :buy followers\r\n")
+					_, _ = io.WriteString(conn, "@display-name=Dagnel;id=msg-1;tmi-sent-ts=1700000000000 :dagnel!dagnel@dagnel.tmi.twitch.tv PRIVMSG #dayoman :hello\r\n")
+				}
+			}(conn, attempt)
+		}
+	}()
+
+	inserted := make(chan Record, 1)
+	client, err := NewTwitchIRC(TwitchIRCConfig{
+		Addr:        ln.Addr().String(),
+		BackoffBase: 10 * time.Millisecond,
+		BackoffMax:  20 * time.Millisecond,
+		Logger:      log.New(io.Discard, "", 0),
+		Insert: func(ctx context.Context, raw json.RawMessage) error {
+			rec, err := DecodeRecord(raw)
+			if err != nil {
+				return err
+			}
+			inserted <- rec
+			return nil
+		},
+	}, []string{"#DayoMan"})
+	if err != nil {
+		t.Fatalf("new client: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client.Start(ctx)
+	defer func() {
+		cancel()
+		client.Wait()
+	}()
+
+	select {
+	case rec := <-inserted:
+		if rec.ID != "msg-1" || rec.Text != "hello" {
+			t.Fatalf("unexpected record: %+v", rec)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out waiting for message")
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		mu.Lock()
+		got := append([]string(nil), received...)
+		n := conns
+		mu.Unlock()
+		if containsLine(got, "PONG :tmi.twitch.tv") {
+			if n < 2 {
+				t.Fatalf("expected reconnect, got %d connections", n)
+			}
+			if !containsLine(got, "NICK "+anonymousTwitchNick) || !containsLine(got, "JOIN #dayoman") {
+				t.Fatalf("expected anonymous login and join, got %v", got)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected PONG reply, got %v", got)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func containsLine(lines []string, want string) bool {
+	for _, line := range lines {
+		if line == want {
+			return true
+		}
+	}
+	return false
+}
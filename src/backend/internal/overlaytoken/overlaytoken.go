@@ -0,0 +1,99 @@
+// Package overlaytoken signs and verifies the bearer tokens that grant access
+// to private chat streams. A token is base64url(claims JSON) "." base64url(HMAC-SHA256).
+package overlaytoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrMalformed indicates the token is not two base64url segments of valid claims.
+	ErrMalformed = errors.New("overlaytoken: malformed token")
+	// ErrSignature indicates the token was not signed with the configured secret.
+	ErrSignature = errors.New("overlaytoken: invalid signature")
+	// ErrExpired indicates the token's expiry has passed.
+	ErrExpired = errors.New("overlaytoken: token expired")
+	// ErrNoSecret indicates signing or verification was attempted without a secret.
+	ErrNoSecret = errors.New("overlaytoken: secret is empty")
+)
+
+// Claims is the signed payload of an overlay token. Times are unix seconds.
+// An empty Sources list grants every source.
+type Claims struct {
+	ID        string   `json:"jti"`
+	Label     string   `json:"label,omitempty"`
+	Sources   []string `json:"sources,omitempty"`
+	IssuedAt  int64    `json:"iat"`
+	ExpiresAt int64    `json:"exp"`
+}
+
+var encoding = base64.RawURLEncoding
+
+// Sign encodes claims and signs them with secret.
+func Sign(secret []byte, claims Claims) (string, error) {
+	if len(secret) == 0 {
+		return "", ErrNoSecret
+	}
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	payload := encoding.EncodeToString(body)
+	return payload + "." + encoding.EncodeToString(mac(secret, payload)), nil
+}
+
+// Verify checks the token signature and expiry and returns its claims.
+func Verify(secret []byte, token string, now time.Time) (Claims, error) {
+	if len(secret) == 0 {
+		return Claims{}, ErrNoSecret
+	}
+	payload, sig, ok := strings.Cut(strings.TrimSpace(token), ".")
+	if !ok || payload == "" || sig == "" {
+		return Claims{}, ErrMalformed
+	}
+	rawSig, err := encoding.DecodeString(sig)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	if !hmac.Equal(rawSig, mac(secret, payload)) {
+		return Claims{}, ErrSignature
+	}
+	body, err := encoding.DecodeString(payload)
+	if err != nil {
+		return Claims{}, ErrMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(body, &claims); err != nil || claims.ID == "" {
+		return Claims{}, ErrMalformed
+	}
+	if claims.ExpiresAt > 0 && !now.Before(time.Unix(claims.ExpiresAt, 0)) {
+		return Claims{}, ErrExpired
+	}
+	return claims, nil
+}
+
+// Permits reports whether the claims grant access to source.
+func (c Claims) Permits(source string) bool {
+	if len(c.Sources) == 0 {
+		return true
+	}
+	source = strings.ToLower(strings.TrimSpace(source))
+	for _, s := range c.Sources {
+		if s == source {
+			return true
+		}
+	}
+	return false
+}
+
+func mac(secret []byte, payload string) []byte {
+	h := hmac.New(sha256.New, secret)
+	h.Write([]byte(payload))
+	return h.Sum(nil)
+}
@@ -0,0 +1,70 @@
+package overlaytoken
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignVerifyRoundTrip(t *testing.T) {
+	secret := []byte("s3cret")
+	now := time.Unix(1_700_000_000, 0)
+	claims := Claims{ID: "abc", Label: "members", Sources: []string{"twitch"}, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}
+
+	token, err := Sign(secret, claims)
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	got, err := Verify(secret, token, now)
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if got.ID != "abc" || got.Label != "members" || len(got.Sources) != 1 || got.Sources[0] != "twitch" {
+		t.Fatalf("unexpected claims: %+v", got)
+	}
+}
+
+func TestVerifyRejects(t *testing.T) {
+	secret := []byte("s3cret")
+	now := time.Unix(1_700_000_000, 0)
+	token, err := Sign(secret, Claims{ID: "abc", ExpiresAt: now.Add(time.Minute).Unix()})
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	payload, _, _ := strings.Cut(token, ".")
+
+	cases := []struct {
+		name   string
+		secret []byte
+		token  string
+		now    time.Time
+		want   error
+	}{
+		{"wrong secret", []byte("other"), token, now, ErrSignature},
+		{"tampered payload", secret, "e30." + strings.SplitN(token, ".", 2)[1], now, ErrSignature},
+		{"missing signature", secret, payload, now, ErrMalformed},
+		{"garbage", secret, "not a token", now, ErrMalformed},
+		{"expired", secret, token, now.Add(time.Minute), ErrExpired},
+		{"no secret", nil, token, now, ErrNoSecret},
+	}
+	for _, tc := range cases {
+		if _, err := Verify(tc.secret, tc.token, tc.now); !errors.Is(err, tc.want) {
+			t.Fatalf("%s: expected %v, got %v", tc.name, tc.want, err)
+		}
+	}
+}
+
+func TestClaimsPermits(t *testing.T) {
+	open := Claims{}
+	if !open.Permits("youtube") {
+		t.Fatalf("expected unscoped claims to permit every source")
+	}
+	scoped := Claims{Sources: []string{"twitch"}}
+	if !scoped.Permits(" Twitch ") {
+		t.Fatalf("expected scoped claims to permit twitch")
+	}
+	if scoped.Permits("youtube") {
+		t.Fatalf("expected scoped claims to reject youtube")
+	}
+}
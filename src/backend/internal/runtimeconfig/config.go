@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net/url"
 	"os"
+	"regexp"
 	"strconv"
 	"strings"
 )
@@ -25,6 +26,8 @@ type Config struct {
 	Websocket        WebsocketConfig `json:"websocket"`
 	Ingest           IngestConfig    `json:"ingest"`
 	Gnasty           GnastyConfig    `json:"gnasty"`
+	Filter           FilterConfig    `json:"filter"`
+	Bots             BotConfig       `json:"bots"`
 }
 
 type FeatureConfig struct {
@@ -35,12 +38,15 @@ type FeatureConfig struct {
 }
 
 type TailerConfig struct {
-	Enabled        bool   `json:"enabled"`
-	PollIntervalMS int    `json:"pollIntervalMs"`
-	MaxBatch       int    `json:"maxBatch"`
-	MaxLagMS       int    `json:"maxLagMs"`
-	PersistOffsets bool   `json:"persistOffsets"`
-	OffsetPath     string `json:"offsetPath"`
+	Enabled           bool   `json:"enabled"`
+	PollIntervalMS    int    `json:"pollIntervalMs"`
+	MinPollIntervalMS int    `json:"minPollIntervalMs"`
+	MaxPollIntervalMS int    `json:"maxPollIntervalMs"`
+	MaxBatch          int    `json:"maxBatch"`
+	BatchCeiling      int    `json:"batchCeiling"`
+	MaxLagMS          int    `json:"maxLagMs"`
+	PersistOffsets    bool   `json:"persistOffsets"`
+	OffsetPath        string `json:"offsetPath"`
 }
 
 type WebsocketConfig struct {
@@ -87,6 +93,39 @@ type GnastyYouTubeConfig struct {
 	Debug           bool `json:"debug"`
 }
 
+// Content filter actions. Mask replaces matched text with asterisks, drop
+// hides the message, and flag holds it for moderators before the curated feed.
+const (
+	FilterActionMask = "mask"
+	FilterActionDrop = "drop"
+	FilterActionFlag = "flag"
+)
+
+// FilterConfig is the server-side content filter applied to chat before it is
+// broadcast or replayed.
+type FilterConfig struct {
+	Rules []FilterRule `json:"rules"`
+}
+
+// FilterRule matches whole words or phrases (case-insensitive) and Go regular
+// expressions. An empty Platforms list applies the rule to every platform.
+type FilterRule struct {
+	Name      string   `json:"name"`
+	Words     []string `json:"words"`
+	Patterns  []string `json:"patterns"`
+	Platforms []string `json:"platforms"`
+	Action    string   `json:"action"`
+}
+
+// BotConfig extends the built-in bot registry. Accounts and Allow hold Twitch
+// logins, YouTube display names or YouTube channel IDs; Patterns are Go
+// regular expressions matched against usernames.
+type BotConfig struct {
+	Accounts []string `json:"accounts"`
+	Patterns []string `json:"patterns"`
+	Allow    []string `json:"allow"`
+}
+
 type ValidationError struct {
 	Field   string `json:"field"`
 	Message string `json:"message"`
@@ -156,12 +195,15 @@ func DefaultsFromEnv() Config {
 			WSDropEmpty:   envBool("ELORA_WS_DROP_EMPTY", true),
 		},
 		Tailer: TailerConfig{
-			Enabled:        envBoolAny([]string{"ELORA_TAILER_ENABLED", "ELORA_DB_TAIL_ENABLED"}, false),
-			PollIntervalMS: envIntAny([]string{"ELORA_TAILER_POLL_MS", "ELORA_DB_TAIL_INTERVAL_MS", "ELORA_DB_TAIL_POLL_MS"}, 1000),
-			MaxBatch:       envIntAny([]string{"ELORA_TAILER_MAX_BATCH", "ELORA_DB_TAIL_BATCH"}, 200),
-			MaxLagMS:       envInt("ELORA_TAILER_MAX_LAG_MS", 0),
-			PersistOffsets: envBool("ELORA_TAILER_PERSIST_OFFSETS", false),
-			OffsetPath:     strings.TrimSpace(os.Getenv("ELORA_TAILER_OFFSET_PATH")),
+			Enabled:           envBoolAny([]string{"ELORA_TAILER_ENABLED", "ELORA_DB_TAIL_ENABLED"}, false),
+			PollIntervalMS:    envIntAny([]string{"ELORA_TAILER_POLL_MS", "ELORA_DB_TAIL_INTERVAL_MS", "ELORA_DB_TAIL_POLL_MS"}, 1000),
+			MinPollIntervalMS: envInt("ELORA_TAILER_MIN_POLL_MS", 100),
+			MaxPollIntervalMS: envInt("ELORA_TAILER_MAX_POLL_MS", 5000),
+			MaxBatch:          envIntAny([]string{"ELORA_TAILER_MAX_BATCH", "ELORA_DB_TAIL_BATCH"}, 200),
+			BatchCeiling:      envInt("ELORA_TAILER_BATCH_CEILING", 2000),
+			MaxLagMS:          envInt("ELORA_TAILER_MAX_LAG_MS", 0),
+			PersistOffsets:    envBool("ELORA_TAILER_PERSIST_OFFSETS", false),
+			OffsetPath:        strings.TrimSpace(os.Getenv("ELORA_TAILER_OFFSET_PATH")),
 		},
 		Websocket: WebsocketConfig{
 			PingIntervalMS:  envInt("ELORA_WS_PING_INTERVAL_MS", 25000),
@@ -199,6 +241,9 @@ func DefaultsFromEnv() Config {
 			},
 		},
 	}
+	cfg.Filter = FilterConfig{Rules: []FilterRule{}}
+	cfg.Bots = BotConfig{Accounts: []string{}, Patterns: []string{}, Allow: []string{}}
+	cfg.Tailer = widenTailerBounds(cfg.Tailer)
 	if normalized, errs := Normalize(cfg); len(errs) == 0 {
 		return normalized
 	}
@@ -239,17 +284,56 @@ func Normalize(cfg Config) (Config, []ValidationError) {
 	}
 
 	cfg.Tailer.OffsetPath = strings.TrimSpace(cfg.Tailer.OffsetPath)
+	// Omitted adaptive bounds pin the tailer to its starting interval and batch.
+	if cfg.Tailer.MinPollIntervalMS == 0 {
+		cfg.Tailer.MinPollIntervalMS = cfg.Tailer.PollIntervalMS
+	}
+	if cfg.Tailer.MaxPollIntervalMS == 0 {
+		cfg.Tailer.MaxPollIntervalMS = cfg.Tailer.PollIntervalMS
+	}
+	if cfg.Tailer.BatchCeiling == 0 {
+		cfg.Tailer.BatchCeiling = cfg.Tailer.MaxBatch
+	}
 	cfg.Ingest.GnastyBin = strings.TrimSpace(cfg.Ingest.GnastyBin)
 	cfg.Ingest.GnastyArgs = normalizeCSV(cfg.Ingest.GnastyArgs)
 	cfg.Gnasty.Sinks.Enabled = normalizeSinks(cfg.Gnasty.Sinks.Enabled)
 	cfg.Gnasty.Twitch.Nick = strings.TrimSpace(cfg.Gnasty.Twitch.Nick)
+	var filterErrs []ValidationError
+	cfg.Filter, filterErrs = normalizeFilter(cfg.Filter)
+	errs = append(errs, filterErrs...)
+	cfg.Bots.Accounts = normalizeFilterList(cfg.Bots.Accounts, normalizeBotAccount)
+	cfg.Bots.Allow = normalizeFilterList(cfg.Bots.Allow, normalizeBotAccount)
+	cfg.Bots.Patterns = normalizeFilterList(cfg.Bots.Patterns, nil)
+	for i, pattern := range cfg.Bots.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, ValidationError{Field: fmt.Sprintf("bots.patterns[%d]", i), Message: "invalid regular expression: " + err.Error()})
+		}
+	}
 
 	if cfg.Tailer.PollIntervalMS < 25 || cfg.Tailer.PollIntervalMS > 60000 {
 		errs = append(errs, ValidationError{Field: "tailer.pollIntervalMs", Message: "must be between 25 and 60000"})
 	}
+	if cfg.Tailer.MinPollIntervalMS < 25 || cfg.Tailer.MinPollIntervalMS > 60000 {
+		errs = append(errs, ValidationError{Field: "tailer.minPollIntervalMs", Message: "must be between 25 and 60000"})
+	}
+	if cfg.Tailer.MaxPollIntervalMS < 25 || cfg.Tailer.MaxPollIntervalMS > 60000 {
+		errs = append(errs, ValidationError{Field: "tailer.maxPollIntervalMs", Message: "must be between 25 and 60000"})
+	}
+	if cfg.Tailer.MaxPollIntervalMS < cfg.Tailer.MinPollIntervalMS {
+		errs = append(errs, ValidationError{Field: "tailer.maxPollIntervalMs", Message: "must be greater than or equal to tailer.minPollIntervalMs"})
+	}
+	if cfg.Tailer.PollIntervalMS < cfg.Tailer.MinPollIntervalMS || cfg.Tailer.PollIntervalMS > cfg.Tailer.MaxPollIntervalMS {
+		errs = append(errs, ValidationError{Field: "tailer.pollIntervalMs", Message: "must be between tailer.minPollIntervalMs and tailer.maxPollIntervalMs"})
+	}
 	if cfg.Tailer.MaxBatch < 1 || cfg.Tailer.MaxBatch > 5000 {
 		errs = append(errs, ValidationError{Field: "tailer.maxBatch", Message: "must be between 1 and 5000"})
 	}
+	if cfg.Tailer.BatchCeiling < 1 || cfg.Tailer.BatchCeiling > 5000 {
+		errs = append(errs, ValidationError{Field: "tailer.batchCeiling", Message: "must be between 1 and 5000"})
+	}
+	if cfg.Tailer.BatchCeiling < cfg.Tailer.MaxBatch {
+		errs = append(errs, ValidationError{Field: "tailer.batchCeiling", Message: "must be greater than or equal to tailer.maxBatch"})
+	}
 	if cfg.Tailer.MaxLagMS < 0 || cfg.Tailer.MaxLagMS > 3600000 {
 		errs = append(errs, ValidationError{Field: "tailer.maxLagMs", Message: "must be between 0 and 3600000"})
 	}
@@ -336,8 +420,22 @@ func Merge(defaults, persisted Config) Config {
 
 	merged.Features = persisted.Features
 	merged.Tailer = persisted.Tailer
+	// Configs saved before adaptive polling existed carry zero bounds; inherit the
+	// defaults and widen them so the persisted interval and batch stay valid.
+	if merged.Tailer.MinPollIntervalMS == 0 {
+		merged.Tailer.MinPollIntervalMS = defaults.Tailer.MinPollIntervalMS
+	}
+	if merged.Tailer.MaxPollIntervalMS == 0 {
+		merged.Tailer.MaxPollIntervalMS = defaults.Tailer.MaxPollIntervalMS
+	}
+	if merged.Tailer.BatchCeiling == 0 {
+		merged.Tailer.BatchCeiling = defaults.Tailer.BatchCeiling
+	}
+	merged.Tailer = widenTailerBounds(merged.Tailer)
 	merged.Websocket = persisted.Websocket
 	merged.Ingest = persisted.Ingest
+	merged.Filter = persisted.Filter
+	merged.Bots = persisted.Bots
 	if persisted.SchemaVersion >= SchemaVersion {
 		merged.AllowedOrigins = persisted.AllowedOrigins
 		merged.Gnasty = persisted.Gnasty
@@ -346,6 +444,89 @@ func Merge(defaults, persisted Config) Config {
 	return merged
 }
 
+// widenTailerBounds stretches the adaptive min/max bounds so they always
+// contain the configured starting interval and batch size.
+func widenTailerBounds(cfg TailerConfig) TailerConfig {
+	if cfg.PollIntervalMS > 0 && cfg.MinPollIntervalMS > cfg.PollIntervalMS {
+		cfg.MinPollIntervalMS = cfg.PollIntervalMS
+	}
+	if cfg.MaxPollIntervalMS < cfg.PollIntervalMS {
+		cfg.MaxPollIntervalMS = cfg.PollIntervalMS
+	}
+	if cfg.BatchCeiling < cfg.MaxBatch {
+		cfg.BatchCeiling = cfg.MaxBatch
+	}
+	return cfg
+}
+
+// normalizeFilter trims and de-duplicates rule lists, defaults the action to
+// mask and rejects rules that could never match or would not compile.
+func normalizeFilter(cfg FilterConfig) (FilterConfig, []ValidationError) {
+	var errs []ValidationError
+	rules := make([]FilterRule, 0, len(cfg.Rules))
+	for i, rule := range cfg.Rules {
+		field := fmt.Sprintf("filter.rules[%d]", i)
+		rule.Name = strings.TrimSpace(rule.Name)
+		if rule.Name == "" {
+			rule.Name = fmt.Sprintf("rule-%d", i+1)
+		}
+		rule.Words = normalizeFilterList(rule.Words, strings.ToLower)
+		rule.Patterns = normalizeFilterList(rule.Patterns, nil)
+		rule.Platforms = normalizeFilterList(rule.Platforms, strings.ToLower)
+		rule.Action = strings.ToLower(strings.TrimSpace(rule.Action))
+		if rule.Action == "" {
+			rule.Action = FilterActionMask
+		}
+
+		switch rule.Action {
+		case FilterActionMask, FilterActionDrop, FilterActionFlag:
+		default:
+			errs = append(errs, ValidationError{Field: field + ".action", Message: "must be one of mask, drop or flag"})
+		}
+		if len(rule.Words) == 0 && len(rule.Patterns) == 0 {
+			errs = append(errs, ValidationError{Field: field, Message: "must include at least one word or pattern"})
+		}
+		for j, pattern := range rule.Patterns {
+			if _, err := regexp.Compile(pattern); err != nil {
+				errs = append(errs, ValidationError{Field: fmt.Sprintf("%s.patterns[%d]", field, j), Message: "invalid regular expression: " + err.Error()})
+			}
+		}
+		for _, platform := range rule.Platforms {
+			if platform != "twitch" && platform != "youtube" {
+				errs = append(errs, ValidationError{Field: field + ".platforms", Message: "contains unsupported platform " + platform})
+				break
+			}
+		}
+		rules = append(rules, rule)
+	}
+	cfg.Rules = rules
+	return cfg, errs
+}
+
+func normalizeFilterList(values []string, fold func(string) string) []string {
+	out := make([]string, 0, len(values))
+	seen := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		if fold != nil {
+			value = fold(value)
+		}
+		if value == "" {
+			continue
+		}
+		if _, ok := seen[value]; ok {
+			continue
+		}
+		seen[value] = struct{}{}
+		out = append(out, value)
+	}
+	return out
+}
+
+func normalizeBotAccount(account string) string {
+	return strings.ToLower(strings.TrimPrefix(account, "@"))
+}
+
 func RedactedSecretsFromEnv() EnvOnlySecrets {
 	redact := func(name string) SecretState {
 		configured := strings.TrimSpace(os.Getenv(name)) != ""
@@ -85,3 +85,98 @@ func TestNormalizeReturnsFieldSpecificErrorsForMalformed(t *testing.T) {
 		t.Fatalf("expected malformed youtube URL error")
 	}
 }
+
+func TestMergeFillsAdaptiveTailerBoundsForLegacyConfig(t *testing.T) {
+	defaults := DefaultsFromEnv()
+	defaults.Tailer.MinPollIntervalMS = 100
+	defaults.Tailer.MaxPollIntervalMS = 5000
+	defaults.Tailer.BatchCeiling = 2000
+
+	persisted := defaults
+	persisted.Tailer = TailerConfig{Enabled: true, PollIntervalMS: 8000, MaxBatch: 3000}
+
+	merged := Merge(defaults, persisted)
+	if merged.Tailer.MinPollIntervalMS != 100 {
+		t.Fatalf("expected min poll 100, got %d", merged.Tailer.MinPollIntervalMS)
+	}
+	if merged.Tailer.MaxPollIntervalMS != 8000 {
+		t.Fatalf("expected max poll widened to 8000, got %d", merged.Tailer.MaxPollIntervalMS)
+	}
+	if merged.Tailer.BatchCeiling != 3000 {
+		t.Fatalf("expected batch ceiling widened to 3000, got %d", merged.Tailer.BatchCeiling)
+	}
+	if _, errs := Normalize(merged); len(errs) != 0 {
+		t.Fatalf("expected merged config to validate, got %v", errs)
+	}
+}
+
+func TestNormalizeRejectsInvertedTailerBounds(t *testing.T) {
+	cfg := DefaultsFromEnv()
+	cfg.Tailer.PollIntervalMS = 500
+	cfg.Tailer.MinPollIntervalMS = 1000
+	cfg.Tailer.MaxPollIntervalMS = 200
+	cfg.Tailer.MaxBatch = 400
+	cfg.Tailer.BatchCeiling = 100
+
+	_, errs := Normalize(cfg)
+	fields := map[string]bool{}
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	for _, field := range []string{"tailer.maxPollIntervalMs", "tailer.pollIntervalMs", "tailer.batchCeiling"} {
+		if !fields[field] {
+			t.Fatalf("expected validation error for %s, got %v", field, errs)
+		}
+	}
+}
+
+func TestNormalizeFilterRules(t *testing.T) {
+	cfg := DefaultsFromEnv()
+	cfg.Filter.Rules = []FilterRule{
+		{Words: []string{" Heck ", "heck", ""}, Platforms: []string{"Twitch"}},
+		{Name: "links", Patterns: []string{`https?://\S+`}, Action: "DROP"},
+	}
+	normalized, errs := Normalize(cfg)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	first := normalized.Filter.Rules[0]
+	if first.Name != "rule-1" || first.Action != FilterActionMask || len(first.Words) != 1 || first.Words[0] != "heck" || first.Platforms[0] != "twitch" || first.Patterns == nil {
+		t.Fatalf("unexpected normalized rule: %+v", first)
+	}
+	if normalized.Filter.Rules[1].Action != FilterActionDrop {
+		t.Fatalf("expected lowercased action, got %q", normalized.Filter.Rules[1].Action)
+	}
+
+	cfg.Filter.Rules = []FilterRule{
+		{Words: []string{" "}},
+		{Patterns: []string{"("}, Platforms: []string{"kick"}, Action: "ban"},
+	}
+	_, errs = Normalize(cfg)
+	fields := map[string]bool{}
+	for _, e := range errs {
+		fields[e.Field] = true
+	}
+	for _, field := range []string{"filter.rules[0]", "filter.rules[1].action", "filter.rules[1].patterns[0]", "filter.rules[1].platforms"} {
+		if !fields[field] {
+			t.Fatalf("expected validation error for %s, got %v", field, errs)
+		}
+	}
+}
+
+func TestNormalizeBotConfig(t *testing.T) {
+	cfg := DefaultsFromEnv()
+	cfg.Bots = BotConfig{Accounts: []string{" @TimerPal ", "timerpal"}, Allow: []string{"Moobot"}, Patterns: []string{" ^relay_ "}}
+	normalized, errs := Normalize(cfg)
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	if got := normalized.Bots; len(got.Accounts) != 1 || got.Accounts[0] != "timerpal" || got.Allow[0] != "moobot" || got.Patterns[0] != "^relay_" {
+		t.Fatalf("unexpected bot config: %+v", got)
+	}
+
+	cfg.Bots.Patterns = []string{"("}
+	if _, errs := Normalize(cfg); len(errs) != 1 || errs[0].Field != "bots.patterns[0]" {
+		t.Fatalf("expected a pattern error, got %v", errs)
+	}
+}
@@ -0,0 +1,224 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// DeadLetter is an ingest line that failed to decode or insert.
+type DeadLetter struct {
+	ID          int64
+	CreatedAt   time.Time
+	Driver      string
+	Source      string
+	Reason      string
+	Error       string
+	Line        string
+	Attempts    int
+	LastRetryAt *time.Time
+}
+
+// DeadLetterQueryOpts controls filtering for ListDeadLetters.
+type DeadLetterQueryOpts struct {
+	Limit    int
+	BeforeID int64
+	Source   string
+	Reason   string
+}
+
+const deadLetterColumns = `id, created_at, driver, source, reason, error, line, attempts, last_retry_at`
+
+// InsertDeadLetter records a rejected ingest line.
+func (s *Store) InsertDeadLetter(ctx context.Context, dl *DeadLetter) error {
+	if s.db == nil {
+		return errors.New("sqlite: store not initialized")
+	}
+	if dl == nil {
+		return errors.New("sqlite: dead letter is nil")
+	}
+
+	createdAt := dl.CreatedAt.UTC()
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	var res sql.Result
+	err := s.execWithBusyRetry(ctx, "insert dead letter", func() error {
+		var execErr error
+		res, execErr = s.db.ExecContext(ctx,
+			`INSERT INTO ingest_dead_letters(created_at, driver, source, reason, error, line) VALUES(?, ?, ?, ?, ?, ?)`,
+			createdAt.UnixMilli(),
+			dl.Driver,
+			dl.Source,
+			dl.Reason,
+			dl.Error,
+			dl.Line,
+		)
+		return execErr
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite: insert dead letter: %w", err)
+	}
+	if id, err := res.LastInsertId(); err == nil {
+		dl.ID = id
+	}
+	dl.CreatedAt = createdAt
+	return nil
+}
+
+// ListDeadLetters returns dead letters newest first.
+func (s *Store) ListDeadLetters(ctx context.Context, opts DeadLetterQueryOpts) ([]DeadLetter, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	query := `SELECT ` + deadLetterColumns + ` FROM ingest_dead_letters`
+	var (
+		clauses []string
+		args    []any
+	)
+	if opts.BeforeID > 0 {
+		clauses = append(clauses, "id < ?")
+		args = append(args, opts.BeforeID)
+	}
+	if opts.Source != "" {
+		clauses = append(clauses, "source = ?")
+		args = append(args, opts.Source)
+	}
+	if opts.Reason != "" {
+		clauses = append(clauses, "reason = ?")
+		args = append(args, opts.Reason)
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY id DESC LIMIT ?"
+	args = append(args, limit)
+
+	return s.queryDeadLetters(ctx, query, args...)
+}
+
+// GetDeadLetters returns the dead letters with the given IDs, oldest first.
+// Unknown IDs are ignored.
+func (s *Store) GetDeadLetters(ctx context.Context, ids []int64) ([]DeadLetter, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	placeholders, args := int64Placeholders(ids)
+	query := `SELECT ` + deadLetterColumns + ` FROM ingest_dead_letters WHERE id IN (` + placeholders + `) ORDER BY id ASC`
+	return s.queryDeadLetters(ctx, query, args...)
+}
+
+// MarkDeadLetterRetried bumps the attempt counter and stores the latest error.
+func (s *Store) MarkDeadLetterRetried(ctx context.Context, id int64, retryErr string) error {
+	if s.db == nil {
+		return errors.New("sqlite: store not initialized")
+	}
+	err := s.execWithBusyRetry(ctx, "mark dead letter retried", func() error {
+		_, execErr := s.db.ExecContext(ctx,
+			`UPDATE ingest_dead_letters SET attempts = attempts + 1, last_retry_at = ?, error = ? WHERE id = ?`,
+			time.Now().UTC().UnixMilli(),
+			retryErr,
+			id,
+		)
+		return execErr
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite: mark dead letter retried: %w", err)
+	}
+	return nil
+}
+
+// DeleteDeadLetters removes the dead letters with the given IDs.
+func (s *Store) DeleteDeadLetters(ctx context.Context, ids []int64) (int, error) {
+	if s.db == nil {
+		return 0, errors.New("sqlite: store not initialized")
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	placeholders, args := int64Placeholders(ids)
+	return s.deleteDeadLetters(ctx, "delete dead letters", `DELETE FROM ingest_dead_letters WHERE id IN (`+placeholders+`)`, args...)
+}
+
+// PurgeDeadLettersBefore removes dead letters recorded strictly before the cutoff.
+// A zero cutoff removes every dead letter.
+func (s *Store) PurgeDeadLettersBefore(ctx context.Context, cutoff time.Time) (int, error) {
+	if s.db == nil {
+		return 0, errors.New("sqlite: store not initialized")
+	}
+	if cutoff.IsZero() {
+		return s.deleteDeadLetters(ctx, "purge dead letters", `DELETE FROM ingest_dead_letters`)
+	}
+	return s.deleteDeadLetters(ctx, "purge dead letters", `DELETE FROM ingest_dead_letters WHERE created_at < ?`, cutoff.UTC().UnixMilli())
+}
+
+func (s *Store) deleteDeadLetters(ctx context.Context, op, query string, args ...any) (int, error) {
+	var res sql.Result
+	err := s.execWithBusyRetry(ctx, op, func() error {
+		var execErr error
+		res, execErr = s.db.ExecContext(ctx, query, args...)
+		return execErr
+	})
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: %s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("sqlite: rows affected: %w", err)
+	}
+	return int(affected), nil
+}
+
+func (s *Store) queryDeadLetters(ctx context.Context, query string, args ...any) ([]DeadLetter, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: query dead letters: %w", err)
+	}
+	defer rows.Close()
+
+	var results []DeadLetter
+	for rows.Next() {
+		var (
+			dl        DeadLetter
+			createdAt int64
+			retriedAt sql.NullInt64
+		)
+		if err := rows.Scan(&dl.ID, &createdAt, &dl.Driver, &dl.Source, &dl.Reason, &dl.Error, &dl.Line, &dl.Attempts, &retriedAt); err != nil {
+			return nil, fmt.Errorf("sqlite: scan dead letter: %w", err)
+		}
+		dl.CreatedAt = time.UnixMilli(createdAt).UTC()
+		if retriedAt.Valid {
+			ts := time.UnixMilli(retriedAt.Int64).UTC()
+			dl.LastRetryAt = &ts
+		}
+		results = append(results, dl)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: iterate dead letters: %w", err)
+	}
+	return results, nil
+}
+
+func int64Placeholders(ids []int64) (string, []any) {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return strings.TrimSuffix(strings.Repeat("?,", len(ids)), ","), args
+}
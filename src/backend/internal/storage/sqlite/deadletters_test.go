@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDeadLetterLifecycle(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+	for i, src := range []string{"https://www.twitch.tv/a", "https://www.twitch.tv/b", "https://www.twitch.tv/a"} {
+		dl := &DeadLetter{
+			CreatedAt: base.Add(time.Duration(i) * time.Minute),
+			Driver:    "gnasty",
+			Source:    src,
+			Reason:    "insert",
+			Error:     "boom",
+			Line:      `{"id":"x"}`,
+		}
+		if err := store.InsertDeadLetter(ctx, dl); err != nil {
+			t.Fatalf("InsertDeadLetter %d returned error: %v", i, err)
+		}
+		if dl.ID == 0 {
+			t.Fatalf("expected dead letter %d to receive an id", i)
+		}
+	}
+
+	all, err := store.ListDeadLetters(ctx, DeadLetterQueryOpts{})
+	if err != nil {
+		t.Fatalf("ListDeadLetters returned error: %v", err)
+	}
+	if len(all) != 3 || all[0].ID <= all[1].ID {
+		t.Fatalf("expected 3 dead letters newest first, got %+v", all)
+	}
+
+	filtered, err := store.ListDeadLetters(ctx, DeadLetterQueryOpts{Source: "https://www.twitch.tv/a", BeforeID: all[0].ID})
+	if err != nil {
+		t.Fatalf("ListDeadLetters filtered returned error: %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].ID != all[2].ID {
+		t.Fatalf("expected only the oldest source a row, got %+v", filtered)
+	}
+
+	if err := store.MarkDeadLetterRetried(ctx, all[2].ID, "still broken"); err != nil {
+		t.Fatalf("MarkDeadLetterRetried returned error: %v", err)
+	}
+	got, err := store.GetDeadLetters(ctx, []int64{all[2].ID, 9999})
+	if err != nil {
+		t.Fatalf("GetDeadLetters returned error: %v", err)
+	}
+	if len(got) != 1 || got[0].Attempts != 1 || got[0].Error != "still broken" || got[0].LastRetryAt == nil {
+		t.Fatalf("unexpected retried dead letter: %+v", got)
+	}
+
+	deleted, err := store.DeleteDeadLetters(ctx, []int64{all[0].ID})
+	if err != nil || deleted != 1 {
+		t.Fatalf("expected 1 deleted, got %d (err=%v)", deleted, err)
+	}
+	deleted, err = store.PurgeDeadLettersBefore(ctx, base.Add(90*time.Second))
+	if err != nil || deleted != 2 {
+		t.Fatalf("expected 2 purged, got %d (err=%v)", deleted, err)
+	}
+	remaining, err := store.ListDeadLetters(ctx, DeadLetterQueryOpts{})
+	if err != nil {
+		t.Fatalf("ListDeadLetters returned error: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Fatalf("expected no dead letters left, got %+v", remaining)
+	}
+}
@@ -0,0 +1,197 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DonationSession is a ledger window, usually one stream. Donations are the
+// paid platform events recorded between StartedAt and EndedAt.
+type DonationSession struct {
+	ID         int64
+	Label      string
+	GoalMicros int64
+	StartedAt  time.Time
+	EndedAt    *time.Time
+}
+
+const donationSessionColumns = `id, label, goal_micros, started_at, ended_at`
+
+// StartDonationSession ends any open session and opens a new one.
+func (s *Store) StartDonationSession(ctx context.Context, label string, goalMicros int64) (*DonationSession, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	now := time.Now().UTC().Truncate(time.Millisecond)
+
+	var id int64
+	err := s.execWithBusyRetry(ctx, "start donation session", func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+		if _, err := tx.ExecContext(ctx, `UPDATE donation_sessions SET ended_at = ? WHERE ended_at IS NULL`, now.UnixMilli()); err != nil {
+			return err
+		}
+		res, err := tx.ExecContext(ctx,
+			`INSERT INTO donation_sessions(label, goal_micros, started_at) VALUES(?, ?, ?)`,
+			label,
+			goalMicros,
+			now.UnixMilli(),
+		)
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: start donation session: %w", err)
+	}
+	return &DonationSession{ID: id, Label: label, GoalMicros: goalMicros, StartedAt: now}, nil
+}
+
+// CurrentDonationSession returns the open session, or nil if there is none.
+func (s *Store) CurrentDonationSession(ctx context.Context) (*DonationSession, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	return scanOptionalDonationSession(s.db.QueryRowContext(ctx,
+		`SELECT `+donationSessionColumns+` FROM donation_sessions WHERE ended_at IS NULL ORDER BY id DESC LIMIT 1`))
+}
+
+// GetDonationSession returns the session with the given id, or nil if it
+// does not exist.
+func (s *Store) GetDonationSession(ctx context.Context, id int64) (*DonationSession, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	return scanOptionalDonationSession(s.db.QueryRowContext(ctx,
+		`SELECT `+donationSessionColumns+` FROM donation_sessions WHERE id = ?`, id))
+}
+
+// ListDonationSessions returns sessions newest first.
+func (s *Store) ListDonationSessions(ctx context.Context, limit int) ([]DonationSession, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT `+donationSessionColumns+` FROM donation_sessions ORDER BY id DESC LIMIT ?`, min(limit, 1000))
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: query donation sessions: %w", err)
+	}
+	defer rows.Close()
+
+	var results []DonationSession
+	for rows.Next() {
+		sess, err := scanDonationSessionRow(rows)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, *sess)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: iterate donation sessions: %w", err)
+	}
+	return results, nil
+}
+
+// UpdateDonationSession changes a session's label and goal, and ends it when
+// end is set. Nil fields are left alone. It reports whether the session exists.
+func (s *Store) UpdateDonationSession(ctx context.Context, id int64, label *string, goalMicros *int64, end bool) (bool, error) {
+	if s.db == nil {
+		return false, errors.New("sqlite: store not initialized")
+	}
+	var endedAt any
+	if end {
+		endedAt = time.Now().UTC().UnixMilli()
+	}
+
+	var res sql.Result
+	err := s.execWithBusyRetry(ctx, "update donation session", func() error {
+		var execErr error
+		res, execErr = s.db.ExecContext(ctx,
+			`UPDATE donation_sessions SET
+				label = COALESCE(?, label),
+				goal_micros = COALESCE(?, goal_micros),
+				ended_at = COALESCE(ended_at, ?)
+			WHERE id = ?`,
+			label,
+			goalMicros,
+			endedAt,
+			id,
+		)
+		return execErr
+	})
+	if err != nil {
+		return false, fmt.Errorf("sqlite: update donation session: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sqlite: rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// PaidPlatformEvents returns events with a positive amount recorded in
+// [since, until), oldest first. A zero until means no upper bound.
+func (s *Store) PaidPlatformEvents(ctx context.Context, since, until time.Time) ([]PlatformEvent, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	query := `SELECT ` + platformEventColumns + ` FROM platform_events WHERE amount_micros > 0 AND ts >= ?`
+	args := []any{since.UTC().UnixMilli()}
+	if !until.IsZero() {
+		query += ` AND ts < ?`
+		args = append(args, until.UTC().UnixMilli())
+	}
+	query += ` ORDER BY ts ASC, id ASC`
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: query paid platform events: %w", err)
+	}
+	defer rows.Close()
+	return scanPlatformEvents(rows)
+}
+
+func scanOptionalDonationSession(row *sql.Row) (*DonationSession, error) {
+	sess, err := scanDonationSessionRow(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return sess, err
+}
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanDonationSessionRow(row rowScanner) (*DonationSession, error) {
+	var (
+		sess      DonationSession
+		startedAt int64
+		endedAt   sql.NullInt64
+	)
+	if err := row.Scan(&sess.ID, &sess.Label, &sess.GoalMicros, &startedAt, &endedAt); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("sqlite: scan donation session: %w", err)
+	}
+	sess.StartedAt = time.UnixMilli(startedAt).UTC()
+	if endedAt.Valid {
+		ts := time.UnixMilli(endedAt.Int64).UTC()
+		sess.EndedAt = &ts
+	}
+	return &sess, nil
+}
@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestDonationSessionLifecycle(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	if cur, err := store.CurrentDonationSession(ctx); err != nil || cur != nil {
+		t.Fatalf("expected no current session, got %+v (err=%v)", cur, err)
+	}
+
+	first, err := store.StartDonationSession(ctx, "monday", 50_000_000)
+	if err != nil {
+		t.Fatalf("StartDonationSession returned error: %v", err)
+	}
+	second, err := store.StartDonationSession(ctx, "tuesday", 0)
+	if err != nil {
+		t.Fatalf("StartDonationSession returned error: %v", err)
+	}
+	got, err := store.GetDonationSession(ctx, first.ID)
+	if err != nil || got == nil || got.EndedAt == nil {
+		t.Fatalf("expected the first session to be ended, got %+v (err=%v)", got, err)
+	}
+	cur, err := store.CurrentDonationSession(ctx)
+	if err != nil || cur == nil || cur.ID != second.ID {
+		t.Fatalf("expected the second session to be current, got %+v (err=%v)", cur, err)
+	}
+
+	goal := int64(25_000_000)
+	if ok, err := store.UpdateDonationSession(ctx, second.ID, nil, &goal, false); err != nil || !ok {
+		t.Fatalf("UpdateDonationSession returned ok=%v err=%v", ok, err)
+	}
+	if ok, _ := store.UpdateDonationSession(ctx, 9999, nil, &goal, false); ok {
+		t.Fatalf("expected update of unknown session to report false")
+	}
+	got, _ = store.GetDonationSession(ctx, second.ID)
+	if got.GoalMicros != goal || got.Label != "tuesday" || got.EndedAt != nil {
+		t.Fatalf("unexpected updated session: %+v", got)
+	}
+
+	list, err := store.ListDonationSessions(ctx, 10)
+	if err != nil || len(list) != 2 || list[0].ID != second.ID {
+		t.Fatalf("expected 2 sessions newest first, got %+v (err=%v)", list, err)
+	}
+}
+
+func TestPaidPlatformEvents(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+	for i, ev := range []PlatformEvent{
+		{ID: "early", Timestamp: base.Add(-time.Minute), Platform: "YouTube", Type: "superchat", AmountMicros: 1_000_000, Currency: "USD"},
+		{ID: "sub", Timestamp: base.Add(time.Minute), Platform: "Twitch", Type: "sub"},
+		{ID: "cheer", Timestamp: base.Add(2 * time.Minute), Platform: "Twitch", Type: "cheer", AmountMicros: 100_000_000, Currency: "BITS"},
+		{ID: "late", Timestamp: base.Add(time.Hour), Platform: "YouTube", Type: "superchat", AmountMicros: 5_000_000, Currency: "EUR"},
+	} {
+		if err := store.InsertPlatformEvent(ctx, ev); err != nil {
+			t.Fatalf("InsertPlatformEvent %d returned error: %v", i, err)
+		}
+	}
+
+	paid, err := store.PaidPlatformEvents(ctx, base, base.Add(30*time.Minute))
+	if err != nil {
+		t.Fatalf("PaidPlatformEvents returned error: %v", err)
+	}
+	if len(paid) != 1 || paid[0].ID != "cheer" {
+		t.Fatalf("expected only the cheer in the window, got %+v", paid)
+	}
+	open, err := store.PaidPlatformEvents(ctx, base, time.Time{})
+	if err != nil || len(open) != 2 || open[1].ID != "late" {
+		t.Fatalf("expected cheer and late superchat oldest first, got %+v (err=%v)", open, err)
+	}
+}
@@ -0,0 +1,121 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// EmoteSet is the last successful download of one provider's emotes for one
+// channel scope, such as "twitch:somechannel". EmotesJSON is opaque to the
+// store; callers decide its shape.
+type EmoteSet struct {
+	Provider   string
+	Scope      string
+	EmotesJSON string
+	Count      int
+	FetchedAt  time.Time
+}
+
+// SaveEmoteSet replaces the stored set for the provider and scope.
+func (s *Store) SaveEmoteSet(ctx context.Context, set EmoteSet) error {
+	if s.db == nil {
+		return errors.New("sqlite: store not initialized")
+	}
+	provider := strings.TrimSpace(set.Provider)
+	scope := strings.TrimSpace(set.Scope)
+	if provider == "" || scope == "" {
+		return errors.New("sqlite: emote set needs a provider and scope")
+	}
+	fetchedAt := set.FetchedAt
+	if fetchedAt.IsZero() {
+		fetchedAt = time.Now()
+	}
+	emotesJSON := set.EmotesJSON
+	if strings.TrimSpace(emotesJSON) == "" {
+		emotesJSON = "{}"
+	}
+	err := s.execWithBusyRetry(ctx, "save emote set", func() error {
+		_, execErr := s.db.ExecContext(ctx, `
+INSERT INTO emote_sets(provider, scope, emotes_json, emote_count, fetched_at)
+VALUES(?, ?, ?, ?, ?)
+ON CONFLICT(provider, scope) DO UPDATE SET
+  emotes_json = excluded.emotes_json,
+  emote_count = excluded.emote_count,
+  fetched_at = excluded.fetched_at`,
+			provider, scope, emotesJSON, set.Count, fetchedAt.UTC().UnixMilli())
+		return execErr
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite: save emote set: %w", err)
+	}
+	return nil
+}
+
+// EmoteSets returns the stored sets for the given scopes, ordered by scope
+// and provider.
+func (s *Store) EmoteSets(ctx context.Context, scopes []string) ([]EmoteSet, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	if len(scopes) == 0 {
+		return []EmoteSet{}, nil
+	}
+	placeholders := make([]string, 0, len(scopes))
+	args := make([]any, 0, len(scopes))
+	for _, scope := range scopes {
+		placeholders = append(placeholders, "?")
+		args = append(args, scope)
+	}
+	rows, err := s.db.QueryContext(ctx, `
+SELECT provider, scope, emotes_json, emote_count, fetched_at
+FROM emote_sets
+WHERE scope IN (`+strings.Join(placeholders, ", ")+`)
+ORDER BY scope ASC, provider ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: query emote sets: %w", err)
+	}
+	defer rows.Close()
+
+	sets := []EmoteSet{}
+	for rows.Next() {
+		var (
+			set       EmoteSet
+			fetchedAt int64
+		)
+		if err := rows.Scan(&set.Provider, &set.Scope, &set.EmotesJSON, &set.Count, &fetchedAt); err != nil {
+			return nil, fmt.Errorf("sqlite: scan emote set: %w", err)
+		}
+		set.FetchedAt = time.UnixMilli(fetchedAt).UTC()
+		sets = append(sets, set)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: iterate emote sets: %w", err)
+	}
+	return sets, nil
+}
+
+// LatestEmoteSetScope returns the scope starting with prefix whose set was
+// fetched most recently, or "" when there is none.
+func (s *Store) LatestEmoteSetScope(ctx context.Context, prefix string) (string, error) {
+	if s.db == nil {
+		return "", errors.New("sqlite: store not initialized")
+	}
+	var scope string
+	err := s.db.QueryRowContext(ctx, `
+SELECT scope
+FROM emote_sets
+WHERE substr(scope, 1, ?) = ?
+ORDER BY fetched_at DESC, scope ASC
+LIMIT 1`, len(prefix), prefix).Scan(&scope)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("sqlite: query latest emote set scope: %w", err)
+	}
+	return scope, nil
+}
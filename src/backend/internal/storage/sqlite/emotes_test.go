@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEmoteSetsRoundTrip(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	fetched := time.Now().UTC().Truncate(time.Millisecond)
+	for _, set := range []EmoteSet{
+		{Provider: "bttv", Scope: "twitch:alice", EmotesJSON: `{"old":{}}`, Count: 1, FetchedAt: fetched.Add(-time.Hour)},
+		{Provider: "bttv", Scope: "twitch:alice", EmotesJSON: `{"new":{}}`, Count: 1, FetchedAt: fetched},
+		{Provider: "7tv", Scope: "twitch:alice", EmotesJSON: `{"a":{},"b":{}}`, Count: 2, FetchedAt: fetched},
+		{Provider: "ffz", Scope: "twitch:bob", Count: 0, FetchedAt: fetched},
+	} {
+		if err := store.SaveEmoteSet(ctx, set); err != nil {
+			t.Fatalf("SaveEmoteSet returned error: %v", err)
+		}
+	}
+	if err := store.SaveEmoteSet(ctx, EmoteSet{Provider: "bttv"}); err == nil {
+		t.Fatalf("expected an error for a set without a scope")
+	}
+
+	sets, err := store.EmoteSets(ctx, []string{"twitch:alice"})
+	if err != nil {
+		t.Fatalf("EmoteSets returned error: %v", err)
+	}
+	if len(sets) != 2 || sets[0].Provider != "7tv" || sets[1].EmotesJSON != `{"new":{}}` || !sets[1].FetchedAt.Equal(fetched) {
+		t.Fatalf("unexpected emote sets: %+v", sets)
+	}
+	if sets, err := store.EmoteSets(ctx, nil); err != nil || len(sets) != 0 {
+		t.Fatalf("expected no sets without scopes, got %+v (err=%v)", sets, err)
+	}
+
+	if scope, err := store.LatestEmoteSetScope(ctx, "youtube:"); err != nil || scope != "" {
+		t.Fatalf("expected no youtube scope, got %q (err=%v)", scope, err)
+	}
+	for _, set := range []EmoteSet{
+		{Provider: "7tv", Scope: "youtube:UColder", FetchedAt: fetched.Add(-time.Hour)},
+		{Provider: "7tv", Scope: "youtube:UCnewer", FetchedAt: fetched},
+	} {
+		if err := store.SaveEmoteSet(ctx, set); err != nil {
+			t.Fatalf("SaveEmoteSet returned error: %v", err)
+		}
+	}
+	if scope, err := store.LatestEmoteSetScope(ctx, "youtube:"); err != nil || scope != "youtube:UCnewer" {
+		t.Fatalf("expected the newest youtube scope, got %q (err=%v)", scope, err)
+	}
+}
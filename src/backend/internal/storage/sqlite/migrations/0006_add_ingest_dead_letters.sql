@@ -0,0 +1,13 @@
+-- 0006_add_ingest_dead_letters.sql
+CREATE TABLE IF NOT EXISTS ingest_dead_letters(
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  created_at INTEGER NOT NULL,
+  driver TEXT NOT NULL,
+  source TEXT NOT NULL,
+  reason TEXT NOT NULL,
+  error TEXT NOT NULL,
+  line TEXT NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  last_retry_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_ingest_dead_letters_source ON ingest_dead_letters(source, id DESC);
@@ -0,0 +1,10 @@
+-- 0007_add_overlay_tokens.sql
+CREATE TABLE IF NOT EXISTS overlay_tokens(
+  id TEXT PRIMARY KEY,
+  label TEXT NOT NULL DEFAULT '',
+  sources_json TEXT NOT NULL DEFAULT '[]',
+  created_at INTEGER NOT NULL,
+  expires_at INTEGER NOT NULL,
+  revoked_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_overlay_tokens_created ON overlay_tokens(created_at DESC);
@@ -0,0 +1,14 @@
+-- 0008_add_platform_events.sql
+CREATE TABLE IF NOT EXISTS platform_events(
+  id TEXT PRIMARY KEY,
+  ts INTEGER NOT NULL,
+  platform TEXT NOT NULL,
+  type TEXT NOT NULL,
+  channel TEXT NOT NULL DEFAULT '',
+  username TEXT NOT NULL DEFAULT '',
+  amount_micros INTEGER NOT NULL DEFAULT 0,
+  currency TEXT NOT NULL DEFAULT '',
+  data_json TEXT NOT NULL DEFAULT '{}'
+);
+CREATE INDEX IF NOT EXISTS idx_platform_events_ts ON platform_events(ts DESC);
+CREATE INDEX IF NOT EXISTS idx_platform_events_type_ts ON platform_events(type, ts DESC);
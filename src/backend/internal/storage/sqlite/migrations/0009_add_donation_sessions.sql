@@ -0,0 +1,9 @@
+-- 0009_add_donation_sessions.sql
+CREATE TABLE IF NOT EXISTS donation_sessions(
+  id INTEGER PRIMARY KEY AUTOINCREMENT,
+  label TEXT NOT NULL DEFAULT '',
+  goal_micros INTEGER NOT NULL DEFAULT 0,
+  started_at INTEGER NOT NULL,
+  ended_at INTEGER
+);
+CREATE INDEX IF NOT EXISTS idx_platform_events_paid_ts ON platform_events(ts) WHERE amount_micros > 0;
@@ -0,0 +1,26 @@
+-- 0010_add_viewer_identities.sql
+CREATE TABLE IF NOT EXISTS viewers(
+  id TEXT PRIMARY KEY,
+  display_name TEXT NOT NULL DEFAULT '',
+  colour TEXT NOT NULL DEFAULT '',
+  created_at INTEGER NOT NULL,
+  updated_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS viewer_accounts(
+  platform TEXT NOT NULL,
+  account_id TEXT NOT NULL,
+  viewer_id TEXT NOT NULL REFERENCES viewers(id) ON DELETE CASCADE,
+  username TEXT NOT NULL DEFAULT '',
+  colour TEXT NOT NULL DEFAULT '',
+  linked_at INTEGER NOT NULL,
+  PRIMARY KEY(platform, account_id)
+);
+CREATE INDEX IF NOT EXISTS idx_viewer_accounts_viewer ON viewer_accounts(viewer_id);
+CREATE TABLE IF NOT EXISTS viewer_link_codes(
+  code TEXT PRIMARY KEY,
+  created_at INTEGER NOT NULL,
+  expires_at INTEGER NOT NULL,
+  platform TEXT NOT NULL DEFAULT '',
+  account_id TEXT NOT NULL DEFAULT '',
+  username TEXT NOT NULL DEFAULT ''
+);
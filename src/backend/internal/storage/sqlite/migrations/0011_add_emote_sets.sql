@@ -0,0 +1,9 @@
+-- 0011_add_emote_sets.sql
+CREATE TABLE IF NOT EXISTS emote_sets(
+  provider TEXT NOT NULL,
+  scope TEXT NOT NULL,
+  emotes_json TEXT NOT NULL,
+  emote_count INTEGER NOT NULL DEFAULT 0,
+  fetched_at INTEGER NOT NULL,
+  PRIMARY KEY(provider, scope)
+);
@@ -0,0 +1,12 @@
+-- 0012_add_emote_usage.sql
+CREATE TABLE IF NOT EXISTS emote_usage(
+  bucket INTEGER NOT NULL,
+  platform TEXT NOT NULL,
+  provider TEXT NOT NULL,
+  emote_name TEXT NOT NULL,
+  emote_id TEXT NOT NULL DEFAULT '',
+  uses INTEGER NOT NULL DEFAULT 0,
+  messages INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(bucket, platform, provider, emote_name)
+);
+CREATE INDEX IF NOT EXISTS idx_emote_usage_bucket ON emote_usage(bucket);
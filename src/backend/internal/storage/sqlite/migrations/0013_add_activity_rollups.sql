@@ -0,0 +1,15 @@
+-- 0013_add_activity_rollups.sql
+CREATE TABLE IF NOT EXISTS activity_minutes(
+  minute INTEGER NOT NULL,
+  platform TEXT NOT NULL,
+  channel TEXT NOT NULL DEFAULT '',
+  messages INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY(minute, platform, channel)
+);
+CREATE TABLE IF NOT EXISTS activity_chatters(
+  minute INTEGER NOT NULL,
+  platform TEXT NOT NULL,
+  channel TEXT NOT NULL DEFAULT '',
+  chatter TEXT NOT NULL,
+  PRIMARY KEY(minute, platform, channel, chatter)
+);
@@ -0,0 +1,4 @@
+-- 0014_add_viewer_link_confirm_codes.sql
+ALTER TABLE viewer_link_codes ADD COLUMN secret TEXT NOT NULL DEFAULT '';
+ALTER TABLE viewer_link_codes ADD COLUMN confirm_code TEXT NOT NULL DEFAULT '';
+CREATE INDEX IF NOT EXISTS idx_viewer_link_codes_confirm ON viewer_link_codes(confirm_code);
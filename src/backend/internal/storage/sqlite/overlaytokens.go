@@ -0,0 +1,151 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// OverlayToken is the server-side record of a minted overlay token. The signed
+// token itself is never stored; the record exists so tokens can be listed and
+// revoked.
+type OverlayToken struct {
+	ID        string
+	Label     string
+	Sources   []string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt *time.Time
+}
+
+const overlayTokenColumns = `id, label, sources_json, created_at, expires_at, revoked_at`
+
+// InsertOverlayToken records a newly minted overlay token.
+func (s *Store) InsertOverlayToken(ctx context.Context, tok *OverlayToken) error {
+	if s.db == nil {
+		return errors.New("sqlite: store not initialized")
+	}
+	if tok == nil {
+		return errors.New("sqlite: overlay token is nil")
+	}
+	tok.ID = strings.TrimSpace(tok.ID)
+	if tok.ID == "" {
+		return errors.New("sqlite: overlay token id is empty")
+	}
+
+	sources := tok.Sources
+	if sources == nil {
+		sources = []string{}
+	}
+	sourcesJSON, err := json.Marshal(sources)
+	if err != nil {
+		return fmt.Errorf("sqlite: encode overlay token sources: %w", err)
+	}
+	createdAt := tok.CreatedAt.UTC()
+	if createdAt.IsZero() {
+		createdAt = time.Now().UTC()
+	}
+
+	err = s.execWithBusyRetry(ctx, "insert overlay token", func() error {
+		_, execErr := s.db.ExecContext(ctx,
+			`INSERT INTO overlay_tokens(id, label, sources_json, created_at, expires_at) VALUES(?, ?, ?, ?, ?)`,
+			tok.ID,
+			tok.Label,
+			string(sourcesJSON),
+			createdAt.UnixMilli(),
+			tok.ExpiresAt.UTC().UnixMilli(),
+		)
+		return execErr
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite: insert overlay token: %w", err)
+	}
+	tok.CreatedAt = createdAt
+	return nil
+}
+
+// GetOverlayToken returns the token record with the given id, or nil if none exists.
+func (s *Store) GetOverlayToken(ctx context.Context, id string) (*OverlayToken, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	tokens, err := s.queryOverlayTokens(ctx, `SELECT `+overlayTokenColumns+` FROM overlay_tokens WHERE id = ?`, strings.TrimSpace(id))
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+	return &tokens[0], nil
+}
+
+// ListOverlayTokens returns every token record, newest first.
+func (s *Store) ListOverlayTokens(ctx context.Context) ([]OverlayToken, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	return s.queryOverlayTokens(ctx, `SELECT `+overlayTokenColumns+` FROM overlay_tokens ORDER BY created_at DESC, id`)
+}
+
+// RevokeOverlayToken marks a token revoked. It reports false if the token does
+// not exist or was already revoked.
+func (s *Store) RevokeOverlayToken(ctx context.Context, id string) (bool, error) {
+	if s.db == nil {
+		return false, errors.New("sqlite: store not initialized")
+	}
+	var res sql.Result
+	err := s.execWithBusyRetry(ctx, "revoke overlay token", func() error {
+		var execErr error
+		res, execErr = s.db.ExecContext(ctx,
+			`UPDATE overlay_tokens SET revoked_at = ? WHERE id = ? AND revoked_at IS NULL`,
+			time.Now().UTC().UnixMilli(),
+			strings.TrimSpace(id),
+		)
+		return execErr
+	})
+	if err != nil {
+		return false, fmt.Errorf("sqlite: revoke overlay token: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sqlite: rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+func (s *Store) queryOverlayTokens(ctx context.Context, query string, args ...any) ([]OverlayToken, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: query overlay tokens: %w", err)
+	}
+	defer rows.Close()
+
+	var results []OverlayToken
+	for rows.Next() {
+		var (
+			tok         OverlayToken
+			sourcesJSON string
+			createdAt   int64
+			expiresAt   int64
+			revokedAt   sql.NullInt64
+		)
+		if err := rows.Scan(&tok.ID, &tok.Label, &sourcesJSON, &createdAt, &expiresAt, &revokedAt); err != nil {
+			return nil, fmt.Errorf("sqlite: scan overlay token: %w", err)
+		}
+		if err := json.Unmarshal([]byte(sourcesJSON), &tok.Sources); err != nil {
+			return nil, fmt.Errorf("sqlite: decode overlay token sources: %w", err)
+		}
+		tok.CreatedAt = time.UnixMilli(createdAt).UTC()
+		tok.ExpiresAt = time.UnixMilli(expiresAt).UTC()
+		if revokedAt.Valid {
+			ts := time.UnixMilli(revokedAt.Int64).UTC()
+			tok.RevokedAt = &ts
+		}
+		results = append(results, tok)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: iterate overlay tokens: %w", err)
+	}
+	return results, nil
+}
@@ -0,0 +1,56 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestOverlayTokenLifecycle(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	base := time.Now().UTC().Truncate(time.Millisecond)
+	first := &OverlayToken{ID: "tok-1", Label: "members", Sources: []string{"twitch"}, CreatedAt: base.Add(-time.Minute), ExpiresAt: base.Add(time.Hour)}
+	second := &OverlayToken{ID: "tok-2", CreatedAt: base, ExpiresAt: base.Add(time.Hour)}
+	for _, tok := range []*OverlayToken{first, second} {
+		if err := store.InsertOverlayToken(ctx, tok); err != nil {
+			t.Fatalf("InsertOverlayToken %s returned error: %v", tok.ID, err)
+		}
+	}
+
+	got, err := store.GetOverlayToken(ctx, "tok-1")
+	if err != nil {
+		t.Fatalf("GetOverlayToken returned error: %v", err)
+	}
+	if got == nil || got.Label != "members" || len(got.Sources) != 1 || got.Sources[0] != "twitch" || !got.ExpiresAt.Equal(first.ExpiresAt) {
+		t.Fatalf("unexpected token record: %+v", got)
+	}
+	if missing, err := store.GetOverlayToken(ctx, "nope"); err != nil || missing != nil {
+		t.Fatalf("expected nil for unknown token, got %+v err=%v", missing, err)
+	}
+
+	list, err := store.ListOverlayTokens(ctx)
+	if err != nil {
+		t.Fatalf("ListOverlayTokens returned error: %v", err)
+	}
+	if len(list) != 2 || list[0].ID != "tok-2" || len(list[0].Sources) != 0 {
+		t.Fatalf("expected tokens newest first, got %+v", list)
+	}
+
+	revoked, err := store.RevokeOverlayToken(ctx, "tok-1")
+	if err != nil || !revoked {
+		t.Fatalf("expected revoke to succeed, got %v err=%v", revoked, err)
+	}
+	if revoked, _ := store.RevokeOverlayToken(ctx, "tok-1"); revoked {
+		t.Fatalf("expected second revoke to report false")
+	}
+	got, _ = store.GetOverlayToken(ctx, "tok-1")
+	if got == nil || got.RevokedAt == nil {
+		t.Fatalf("expected revoked_at to be set, got %+v", got)
+	}
+}
@@ -0,0 +1,142 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// PlatformEvent is a structured event (sub, raid, Super Chat, ...) extracted
+// from a chat row. Data holds the full event JSON as delivered to clients.
+type PlatformEvent struct {
+	ID           string
+	Timestamp    time.Time
+	Platform     string
+	Type         string
+	Channel      string
+	Username     string
+	AmountMicros int64
+	Currency     string
+	Data         string
+}
+
+// PlatformEventQueryOpts controls filtering for ListPlatformEvents.
+type PlatformEventQueryOpts struct {
+	Limit    int
+	BeforeTS int64
+	Since    time.Time
+	Type     string
+	Platform string
+}
+
+const platformEventColumns = `id, ts, platform, type, channel, username, amount_micros, currency, data_json`
+
+// InsertPlatformEvent stores an event. Events are keyed by the chat row's id,
+// so re-ingesting the same row is a no-op.
+func (s *Store) InsertPlatformEvent(ctx context.Context, ev PlatformEvent) error {
+	if s.db == nil {
+		return errors.New("sqlite: store not initialized")
+	}
+	if strings.TrimSpace(ev.ID) == "" {
+		return errors.New("sqlite: platform event id is required")
+	}
+	ts := ev.Timestamp.UTC()
+	if ts.IsZero() {
+		ts = time.Now().UTC()
+	}
+	data := ev.Data
+	if data == "" {
+		data = "{}"
+	}
+
+	err := s.execWithBusyRetry(ctx, "insert platform event", func() error {
+		_, execErr := s.db.ExecContext(ctx,
+			`INSERT OR IGNORE INTO platform_events(`+platformEventColumns+`) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+			ev.ID,
+			ts.UnixMilli(),
+			ev.Platform,
+			ev.Type,
+			ev.Channel,
+			ev.Username,
+			ev.AmountMicros,
+			ev.Currency,
+			data,
+		)
+		return execErr
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite: insert platform event: %w", err)
+	}
+	return nil
+}
+
+// ListPlatformEvents returns events newest first.
+func (s *Store) ListPlatformEvents(ctx context.Context, opts PlatformEventQueryOpts) ([]PlatformEvent, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	query := `SELECT ` + platformEventColumns + ` FROM platform_events`
+	var (
+		clauses []string
+		args    []any
+	)
+	if opts.BeforeTS > 0 {
+		clauses = append(clauses, "ts < ?")
+		args = append(args, opts.BeforeTS)
+	}
+	if !opts.Since.IsZero() {
+		clauses = append(clauses, "ts >= ?")
+		args = append(args, opts.Since.UTC().UnixMilli())
+	}
+	if opts.Type != "" {
+		clauses = append(clauses, "type = ?")
+		args = append(args, opts.Type)
+	}
+	if opts.Platform != "" {
+		clauses = append(clauses, "LOWER(platform) = LOWER(?)")
+		args = append(args, opts.Platform)
+	}
+	if len(clauses) > 0 {
+		query += " WHERE " + strings.Join(clauses, " AND ")
+	}
+	query += " ORDER BY ts DESC, id DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: query platform events: %w", err)
+	}
+	defer rows.Close()
+	return scanPlatformEvents(rows)
+}
+
+func scanPlatformEvents(rows *sql.Rows) ([]PlatformEvent, error) {
+	var results []PlatformEvent
+	for rows.Next() {
+		var (
+			ev PlatformEvent
+			ts int64
+		)
+		if err := rows.Scan(&ev.ID, &ts, &ev.Platform, &ev.Type, &ev.Channel, &ev.Username, &ev.AmountMicros, &ev.Currency, &ev.Data); err != nil {
+			return nil, fmt.Errorf("sqlite: scan platform event: %w", err)
+		}
+		ev.Timestamp = time.UnixMilli(ts).UTC()
+		results = append(results, ev)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: iterate platform events: %w", err)
+	}
+	return results, nil
+}
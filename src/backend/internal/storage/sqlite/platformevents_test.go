@@ -0,0 +1,59 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestPlatformEventsInsertAndList(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+	events := []PlatformEvent{
+		{ID: "a", Timestamp: base, Platform: "Twitch", Type: "sub", Username: "alice", Data: `{"type":"sub"}`},
+		{ID: "b", Timestamp: base.Add(time.Minute), Platform: "YouTube", Type: "superchat", Username: "bob", AmountMicros: 5_000_000, Currency: "USD", Data: `{"type":"superchat"}`},
+		{ID: "c", Timestamp: base.Add(2 * time.Minute), Platform: "Twitch", Type: "raid", Username: "carol"},
+	}
+	for _, ev := range events {
+		if err := store.InsertPlatformEvent(ctx, ev); err != nil {
+			t.Fatalf("InsertPlatformEvent %s returned error: %v", ev.ID, err)
+		}
+	}
+	// Re-ingesting the same row must not duplicate or overwrite it.
+	if err := store.InsertPlatformEvent(ctx, PlatformEvent{ID: "a", Platform: "Twitch", Type: "resub"}); err != nil {
+		t.Fatalf("InsertPlatformEvent duplicate returned error: %v", err)
+	}
+
+	all, err := store.ListPlatformEvents(ctx, PlatformEventQueryOpts{})
+	if err != nil {
+		t.Fatalf("ListPlatformEvents returned error: %v", err)
+	}
+	if len(all) != 3 || all[0].ID != "c" || all[2].Type != "sub" {
+		t.Fatalf("expected 3 events newest first, got %+v", all)
+	}
+	if all[0].Data != "{}" {
+		t.Fatalf("expected empty data to default to {}, got %q", all[0].Data)
+	}
+
+	twitch, err := store.ListPlatformEvents(ctx, PlatformEventQueryOpts{Platform: "twitch", BeforeTS: all[0].Timestamp.UnixMilli()})
+	if err != nil {
+		t.Fatalf("ListPlatformEvents filtered returned error: %v", err)
+	}
+	if len(twitch) != 1 || twitch[0].ID != "a" {
+		t.Fatalf("expected only the twitch sub, got %+v", twitch)
+	}
+
+	chats, err := store.ListPlatformEvents(ctx, PlatformEventQueryOpts{Type: "superchat"})
+	if err != nil {
+		t.Fatalf("ListPlatformEvents by type returned error: %v", err)
+	}
+	if len(chats) != 1 || chats[0].AmountMicros != 5_000_000 || chats[0].Currency != "USD" {
+		t.Fatalf("unexpected superchat rows: %+v", chats)
+	}
+}
@@ -707,6 +707,7 @@ LIMIT ?`
 			return nil, after, fmt.Errorf("sqlite: tail next scan: %w", err)
 		}
 		msg.Timestamp = time.UnixMilli(ts).UTC()
+		msg.RowID = rowID
 		results = append(results, msg)
 		last = storage.TailPosition{TS: ts, RowID: rowID}
 	}
@@ -718,41 +719,121 @@ LIMIT ?`
 	return results, last, nil
 }
 
-// PurgeBefore deletes chat messages with timestamps strictly less than the provided cutoff.
+// MessagesBeforeRowID returns up to limit messages with rowid strictly below
+// beforeRowID, newest first. A non-positive beforeRowID starts from the newest row.
+func (s *Store) MessagesBeforeRowID(ctx context.Context, beforeRowID int64, limit int) ([]storage.Message, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	query := `SELECT rowid, id, ts, username, platform, text, emotes_json, COALESCE(badges_json, '[]'), COALESCE(raw_json, '') FROM messages`
+	args := []any{}
+	if beforeRowID > 0 {
+		query += " WHERE rowid < ?"
+		args = append(args, beforeRowID)
+	}
+	query += " ORDER BY rowid DESC LIMIT ?"
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: messages before rowid: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]storage.Message, 0, limit)
+	for rows.Next() {
+		var (
+			msg storage.Message
+			ts  int64
+		)
+		if err := rows.Scan(&msg.RowID, &msg.ID, &ts, &msg.Username, &msg.Platform, &msg.Text, &msg.EmotesJSON, &msg.BadgesJSON, &msg.RawJSON); err != nil {
+			return nil, fmt.Errorf("sqlite: scan message: %w", err)
+		}
+		msg.Timestamp = time.UnixMilli(ts).UTC()
+		results = append(results, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: iterate messages: %w", err)
+	}
+	return results, nil
+}
+
+// PurgeBefore deletes chat messages with timestamps strictly less than the
+// provided cutoff, along with the activity and emote rollup buckets that end
+// at or before it. The returned count is the number of messages deleted.
 func (s *Store) PurgeBefore(ctx context.Context, cutoff time.Time) (int, error) {
 	if s.db == nil {
 		return 0, errors.New("sqlite: store not initialized")
 	}
 
-	var (
-		res sql.Result
-		err error
-	)
-	err = s.execWithBusyRetry(ctx, "purge before", func() error {
-		res, err = s.db.ExecContext(ctx, `DELETE FROM messages WHERE ts < ?`, cutoff.UTC().UnixMilli())
-		return err
+	var affected int64
+	err := s.execWithBusyRetry(ctx, "purge before", func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+		res, err := tx.ExecContext(ctx, `DELETE FROM messages WHERE ts < ?`, cutoff.UTC().UnixMilli())
+		if err != nil {
+			return err
+		}
+		if affected, err = res.RowsAffected(); err != nil {
+			return err
+		}
+		if err := purgeRollupsBefore(ctx, tx, cutoff); err != nil {
+			return err
+		}
+		return tx.Commit()
 	})
 	if err != nil {
 		return 0, fmt.Errorf("sqlite: purge before: %w", err)
 	}
 
-	affected, err := res.RowsAffected()
-	if err != nil {
-		return 0, fmt.Errorf("sqlite: rows affected: %w", err)
-	}
-
 	return int(affected), nil
 }
 
-// PurgeAll removes all stored chat messages and compacts the database.
+// purgeRollupsBefore deletes the rollup buckets that lie wholly before cutoff.
+// A bucket straddling the cutoff is kept, since it still counts newer messages.
+func purgeRollupsBefore(ctx context.Context, tx *sql.Tx, cutoff time.Time) error {
+	minute := cutoff.UTC().Truncate(time.Minute).UnixMilli()
+	for _, stmt := range []struct {
+		query  string
+		cutoff int64
+	}{
+		{`DELETE FROM activity_minutes WHERE minute < ?`, minute},
+		{`DELETE FROM activity_chatters WHERE minute < ?`, minute},
+		{`DELETE FROM emote_usage WHERE bucket < ?`, cutoff.UTC().Truncate(StatsBucket).UnixMilli()},
+	} {
+		if _, err := tx.ExecContext(ctx, stmt.query, stmt.cutoff); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// PurgeAll removes all stored chat messages and their activity and emote
+// rollups, then compacts the database.
 func (s *Store) PurgeAll(ctx context.Context) error {
 	if s.db == nil {
 		return errors.New("sqlite: store not initialized")
 	}
 
 	if err := s.execWithBusyRetry(ctx, "purge messages", func() error {
-		_, execErr := s.db.ExecContext(ctx, `DELETE FROM messages`)
-		return execErr
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+		for _, table := range []string{"messages", "activity_minutes", "activity_chatters", "emote_usage"} {
+			if _, err := tx.ExecContext(ctx, `DELETE FROM `+table); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
 	}); err != nil {
 		return fmt.Errorf("sqlite: purge messages: %w", err)
 	}
@@ -0,0 +1,325 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StatsBucket is the width of the rollup buckets used for chat statistics.
+const StatsBucket = time.Hour
+
+// EmoteUsage counts how often one emote was used on one platform within a
+// bucket. Uses counts every occurrence; Messages counts messages containing
+// the emote at least once.
+type EmoteUsage struct {
+	Bucket    time.Time
+	Platform  string
+	Provider  string
+	EmoteName string
+	EmoteID   string
+	Uses      int64
+	Messages  int64
+}
+
+// EmoteUsageQueryOpts controls filtering for TopEmotes.
+type EmoteUsageQueryOpts struct {
+	Since    time.Time
+	Platform string
+	Provider string
+	// Limit caps the rows returned for each platform and provider.
+	Limit int
+}
+
+// AddEmoteUsage adds counts to the rollup buckets. Bucket is truncated to
+// StatsBucket.
+func (s *Store) AddEmoteUsage(ctx context.Context, usage []EmoteUsage) error {
+	if s.db == nil {
+		return errors.New("sqlite: store not initialized")
+	}
+	if len(usage) == 0 {
+		return nil
+	}
+	err := s.execWithBusyRetry(ctx, "add emote usage", func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+		for _, u := range usage {
+			if strings.TrimSpace(u.EmoteName) == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `
+INSERT INTO emote_usage(bucket, platform, provider, emote_name, emote_id, uses, messages)
+VALUES(?, ?, ?, ?, ?, ?, ?)
+ON CONFLICT(bucket, platform, provider, emote_name) DO UPDATE SET
+  emote_id = CASE WHEN excluded.emote_id != '' THEN excluded.emote_id ELSE emote_usage.emote_id END,
+  uses = emote_usage.uses + excluded.uses,
+  messages = emote_usage.messages + excluded.messages`,
+				u.Bucket.UTC().Truncate(StatsBucket).UnixMilli(),
+				strings.ToLower(u.Platform),
+				strings.ToLower(u.Provider),
+				u.EmoteName,
+				u.EmoteID,
+				u.Uses,
+				u.Messages,
+			); err != nil {
+				return err
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite: add emote usage: %w", err)
+	}
+	return nil
+}
+
+// TopEmotes sums usage since opts.Since and returns the most used emotes for
+// each platform and provider, ordered by platform, provider and uses. The
+// returned rows have a zero Bucket.
+func (s *Store) TopEmotes(ctx context.Context, opts EmoteUsageQueryOpts) ([]EmoteUsage, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = 10
+	}
+
+	where := []string{"bucket >= ?"}
+	args := []any{opts.Since.UTC().Truncate(StatsBucket).UnixMilli()}
+	if p := strings.ToLower(strings.TrimSpace(opts.Platform)); p != "" {
+		where = append(where, "platform = ?")
+		args = append(args, p)
+	}
+	if p := strings.ToLower(strings.TrimSpace(opts.Provider)); p != "" {
+		where = append(where, "provider = ?")
+		args = append(args, p)
+	}
+	args = append(args, limit)
+
+	rows, err := s.db.QueryContext(ctx, `
+SELECT platform, provider, emote_name, emote_id, uses, messages FROM (
+  SELECT platform, provider, emote_name, MAX(emote_id) AS emote_id,
+    SUM(uses) AS uses, SUM(messages) AS messages,
+    ROW_NUMBER() OVER (PARTITION BY platform, provider ORDER BY SUM(uses) DESC, emote_name ASC) AS rank
+  FROM emote_usage
+  WHERE `+strings.Join(where, " AND ")+`
+  GROUP BY platform, provider, emote_name
+)
+WHERE rank <= ?
+ORDER BY platform ASC, provider ASC, uses DESC, emote_name ASC`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: query top emotes: %w", err)
+	}
+	defer rows.Close()
+
+	out := []EmoteUsage{}
+	for rows.Next() {
+		var u EmoteUsage
+		if err := rows.Scan(&u.Platform, &u.Provider, &u.EmoteName, &u.EmoteID, &u.Uses, &u.Messages); err != nil {
+			return nil, fmt.Errorf("sqlite: scan top emotes: %w", err)
+		}
+		out = append(out, u)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: iterate top emotes: %w", err)
+	}
+	return out, nil
+}
+
+// ActivityMinute is the chat activity of one platform channel in one minute.
+// Chatters are platform account identifiers; repeated entries are ignored.
+type ActivityMinute struct {
+	Minute   time.Time
+	Platform string
+	Channel  string
+	Messages int64
+	Chatters []string
+}
+
+// ActivityQueryOpts controls filtering for ActivityReport. Bucket must be a
+// whole number of minutes; From is inclusive and To exclusive.
+type ActivityQueryOpts struct {
+	From     time.Time
+	To       time.Time
+	Bucket   time.Duration
+	Platform string
+	Channel  string
+}
+
+// ActivityRow counts messages and unique chatters. Start is set for bucketed
+// rows and Channel for per-channel rows.
+type ActivityRow struct {
+	Start    time.Time
+	Platform string
+	Channel  string
+	Messages int64
+	Chatters int64
+}
+
+// ActivityReport is chat activity over a time range. Unique chatters cannot
+// be summed across buckets, so range totals are computed separately.
+type ActivityReport struct {
+	// Buckets has one row per non-empty bucket and platform, oldest first.
+	Buckets []ActivityRow
+	// Platforms and Channels cover the whole range.
+	Platforms []ActivityRow
+	Channels  []ActivityRow
+}
+
+// AddActivity adds per-minute message counts and chatters to the rollups.
+// Minute is truncated to the minute.
+func (s *Store) AddActivity(ctx context.Context, minutes []ActivityMinute) error {
+	if s.db == nil {
+		return errors.New("sqlite: store not initialized")
+	}
+	if len(minutes) == 0 {
+		return nil
+	}
+	err := s.execWithBusyRetry(ctx, "add activity", func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+		for _, m := range minutes {
+			minute := m.Minute.UTC().Truncate(time.Minute).UnixMilli()
+			platform := strings.ToLower(strings.TrimSpace(m.Platform))
+			if platform == "" {
+				continue
+			}
+			if _, err := tx.ExecContext(ctx, `
+INSERT INTO activity_minutes(minute, platform, channel, messages) VALUES(?, ?, ?, ?)
+ON CONFLICT(minute, platform, channel) DO UPDATE SET messages = activity_minutes.messages + excluded.messages`,
+				minute, platform, m.Channel, m.Messages); err != nil {
+				return err
+			}
+			for _, chatter := range m.Chatters {
+				if strings.TrimSpace(chatter) == "" {
+					continue
+				}
+				if _, err := tx.ExecContext(ctx,
+					`INSERT OR IGNORE INTO activity_chatters(minute, platform, channel, chatter) VALUES(?, ?, ?, ?)`,
+					minute, platform, m.Channel, chatter); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite: add activity: %w", err)
+	}
+	return nil
+}
+
+// ActivityReport summarizes chat activity between opts.From and opts.To.
+func (s *Store) ActivityReport(ctx context.Context, opts ActivityQueryOpts) (*ActivityReport, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	if opts.Bucket < time.Minute || opts.Bucket%time.Minute != 0 {
+		return nil, errors.New("sqlite: activity bucket must be a whole number of minutes")
+	}
+	report := &ActivityReport{}
+	var err error
+	if report.Buckets, err = s.activityRows(ctx, opts, opts.Bucket, false); err != nil {
+		return nil, err
+	}
+	if report.Platforms, err = s.activityRows(ctx, opts, 0, false); err != nil {
+		return nil, err
+	}
+	if report.Channels, err = s.activityRows(ctx, opts, 0, true); err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// activityRows groups activity by platform, plus by bucket when bucket is
+// non-zero or by channel when byChannel is set.
+func (s *Store) activityRows(ctx context.Context, opts ActivityQueryOpts, bucket time.Duration, byChannel bool) ([]ActivityRow, error) {
+	bucketMs := bucket.Milliseconds()
+	startExpr := "0"
+	if bucketMs > 0 {
+		startExpr = fmt.Sprintf("(minute / %d) * %d", bucketMs, bucketMs)
+	}
+	channelExpr := "''"
+	if byChannel {
+		channelExpr = "channel"
+	}
+
+	where := []string{"minute >= ?", "minute < ?"}
+	args := []any{opts.From.UTC().UnixMilli(), opts.To.UTC().UnixMilli()}
+	if p := strings.ToLower(strings.TrimSpace(opts.Platform)); p != "" {
+		where = append(where, "platform = ?")
+		args = append(args, p)
+	}
+	if c := strings.TrimSpace(opts.Channel); c != "" {
+		where = append(where, "channel = ?")
+		args = append(args, c)
+	}
+	filter := strings.Join(where, " AND ")
+	group := "start, platform, ch"
+
+	type rowKey struct {
+		start    int64
+		platform string
+		channel  string
+	}
+	var order []rowKey
+	byKey := make(map[rowKey]*ActivityRow)
+	scan := func(query, label string, apply func(*ActivityRow, int64)) error {
+		rows, err := s.db.QueryContext(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("sqlite: query activity %s: %w", label, err)
+		}
+		defer rows.Close()
+		for rows.Next() {
+			var (
+				key   rowKey
+				value int64
+			)
+			if err := rows.Scan(&key.start, &key.platform, &key.channel, &value); err != nil {
+				return fmt.Errorf("sqlite: scan activity %s: %w", label, err)
+			}
+			row := byKey[key]
+			if row == nil {
+				row = &ActivityRow{Platform: key.platform, Channel: key.channel}
+				if bucketMs > 0 {
+					row.Start = time.UnixMilli(key.start).UTC()
+				}
+				byKey[key] = row
+				order = append(order, key)
+			}
+			apply(row, value)
+		}
+		if err := rows.Err(); err != nil {
+			return fmt.Errorf("sqlite: iterate activity %s: %w", label, err)
+		}
+		return nil
+	}
+
+	if err := scan(`
+SELECT `+startExpr+` AS start, platform, `+channelExpr+` AS ch, SUM(messages)
+FROM activity_minutes WHERE `+filter+`
+GROUP BY `+group+` ORDER BY `+group, "messages", func(r *ActivityRow, v int64) { r.Messages = v }); err != nil {
+		return nil, err
+	}
+	if err := scan(`
+SELECT `+startExpr+` AS start, platform, `+channelExpr+` AS ch, COUNT(DISTINCT chatter)
+FROM activity_chatters WHERE `+filter+`
+GROUP BY `+group+` ORDER BY `+group, "chatters", func(r *ActivityRow, v int64) { r.Chatters = v }); err != nil {
+		return nil, err
+	}
+
+	out := make([]ActivityRow, 0, len(order))
+	for _, key := range order {
+		out = append(out, *byKey[key])
+	}
+	return out, nil
+}
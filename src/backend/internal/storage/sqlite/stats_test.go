@@ -0,0 +1,158 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTopEmotes(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	now := time.Now().UTC()
+	old := now.Add(-48 * time.Hour)
+	if err := store.AddEmoteUsage(ctx, []EmoteUsage{
+		{Bucket: now, Platform: "Twitch", Provider: "7tv", EmoteName: "catJAM", EmoteID: "c1", Uses: 3, Messages: 2},
+		{Bucket: now, Platform: "twitch", Provider: "7tv", EmoteName: "peepoHey", Uses: 1, Messages: 1},
+		{Bucket: now, Platform: "twitch", Provider: "7tv", EmoteName: "rareOne", Uses: 1, Messages: 1},
+		{Bucket: now, Platform: "twitch", Provider: "twitch", EmoteName: "Kappa", Uses: 5, Messages: 5},
+		{Bucket: old, Platform: "twitch", Provider: "7tv", EmoteName: "rareOne", Uses: 50, Messages: 50},
+	}); err != nil {
+		t.Fatalf("AddEmoteUsage returned error: %v", err)
+	}
+	// Counts in the same bucket accumulate.
+	if err := store.AddEmoteUsage(ctx, []EmoteUsage{{Bucket: now, Platform: "twitch", Provider: "7tv", EmoteName: "peepoHey", Uses: 4, Messages: 1}}); err != nil {
+		t.Fatalf("AddEmoteUsage returned error: %v", err)
+	}
+
+	top, err := store.TopEmotes(ctx, EmoteUsageQueryOpts{Since: now.Add(-24 * time.Hour), Limit: 2})
+	if err != nil {
+		t.Fatalf("TopEmotes returned error: %v", err)
+	}
+	if len(top) != 3 {
+		t.Fatalf("expected two 7tv emotes and one twitch emote, got %+v", top)
+	}
+	if top[0].EmoteName != "peepoHey" || top[0].Uses != 5 || top[0].Messages != 2 || top[1].EmoteName != "catJAM" || top[1].EmoteID != "c1" || top[2].EmoteName != "Kappa" {
+		t.Fatalf("unexpected ranking: %+v", top)
+	}
+
+	all, err := store.TopEmotes(ctx, EmoteUsageQueryOpts{Since: old, Provider: "7TV"})
+	if err != nil {
+		t.Fatalf("TopEmotes returned error: %v", err)
+	}
+	if len(all) != 3 || all[0].EmoteName != "rareOne" || all[0].Uses != 51 {
+		t.Fatalf("unexpected 7tv totals: %+v", all)
+	}
+}
+
+func TestActivityReport(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	base := time.Date(2026, 3, 1, 20, 0, 0, 0, time.UTC)
+	if err := store.AddActivity(ctx, []ActivityMinute{
+		{Minute: base.Add(10 * time.Second), Platform: "Twitch", Channel: "elora", Messages: 3, Chatters: []string{"ann", "bob"}},
+		{Minute: base.Add(time.Minute), Platform: "twitch", Channel: "elora", Messages: 2, Chatters: []string{"ann"}},
+		{Minute: base.Add(2 * time.Minute), Platform: "youtube", Channel: "UCelora", Messages: 1, Chatters: []string{"UCcat"}},
+		{Minute: base.Add(5 * time.Minute), Platform: "twitch", Channel: "other", Messages: 4, Chatters: []string{"ann", "dan"}},
+	}); err != nil {
+		t.Fatalf("AddActivity returned error: %v", err)
+	}
+	// Adding to an existing minute sums messages and ignores repeat chatters.
+	if err := store.AddActivity(ctx, []ActivityMinute{{Minute: base, Platform: "twitch", Channel: "elora", Messages: 1, Chatters: []string{"bob"}}}); err != nil {
+		t.Fatalf("AddActivity returned error: %v", err)
+	}
+
+	report, err := store.ActivityReport(ctx, ActivityQueryOpts{From: base, To: base.Add(time.Hour), Bucket: 5 * time.Minute})
+	if err != nil {
+		t.Fatalf("ActivityReport returned error: %v", err)
+	}
+	want := []ActivityRow{
+		{Start: base, Platform: "twitch", Messages: 6, Chatters: 2},
+		{Start: base, Platform: "youtube", Messages: 1, Chatters: 1},
+		{Start: base.Add(5 * time.Minute), Platform: "twitch", Messages: 4, Chatters: 2},
+	}
+	if len(report.Buckets) != len(want) {
+		t.Fatalf("unexpected buckets: %+v", report.Buckets)
+	}
+	for i, row := range want {
+		if got := report.Buckets[i]; !got.Start.Equal(row.Start) || got.Platform != row.Platform || got.Messages != row.Messages || got.Chatters != row.Chatters {
+			t.Fatalf("bucket %d: got %+v, want %+v", i, got, row)
+		}
+	}
+	if len(report.Platforms) != 2 || report.Platforms[0].Messages != 10 || report.Platforms[0].Chatters != 3 {
+		t.Fatalf("unexpected platform totals: %+v", report.Platforms)
+	}
+	if len(report.Channels) != 3 || report.Channels[0].Channel != "elora" || report.Channels[0].Chatters != 2 {
+		t.Fatalf("unexpected channels: %+v", report.Channels)
+	}
+
+	filtered, err := store.ActivityReport(ctx, ActivityQueryOpts{From: base, To: base.Add(time.Hour), Bucket: time.Hour, Channel: "other"})
+	if err != nil || len(filtered.Buckets) != 1 || filtered.Buckets[0].Messages != 4 {
+		t.Fatalf("unexpected filtered report: %+v (err=%v)", filtered, err)
+	}
+	if _, err := store.ActivityReport(ctx, ActivityQueryOpts{From: base, To: base.Add(time.Hour), Bucket: 90 * time.Second}); err == nil {
+		t.Fatalf("expected an error for a partial-minute bucket")
+	}
+}
+
+func TestPurgeRemovesRollups(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	now := time.Now().UTC().Truncate(StatsBucket).Add(30 * time.Minute)
+	old := now.Add(-48 * time.Hour)
+	if err := store.AddEmoteUsage(ctx, []EmoteUsage{
+		{Bucket: old, Platform: "twitch", Provider: "7tv", EmoteName: "catJAM", Uses: 1, Messages: 1},
+		{Bucket: now, Platform: "twitch", Provider: "7tv", EmoteName: "catJAM", Uses: 1, Messages: 1},
+	}); err != nil {
+		t.Fatalf("AddEmoteUsage returned error: %v", err)
+	}
+	if err := store.AddActivity(ctx, []ActivityMinute{
+		{Minute: old, Platform: "twitch", Messages: 1, Chatters: []string{"erin"}},
+		{Minute: now, Platform: "twitch", Messages: 1, Chatters: []string{"erin"}},
+	}); err != nil {
+		t.Fatalf("AddActivity returned error: %v", err)
+	}
+
+	counts := func() []int {
+		t.Helper()
+		var out []int
+		for _, table := range []string{"emote_usage", "activity_minutes", "activity_chatters"} {
+			var n int
+			if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM `+table).Scan(&n); err != nil {
+				t.Fatalf("count %s: %v", table, err)
+			}
+			out = append(out, n)
+		}
+		return out
+	}
+
+	// The cutoff falls inside the current hour bucket, which still counts the
+	// newer emote use and so is kept.
+	if _, err := store.PurgeBefore(ctx, now.Add(time.Second)); err != nil {
+		t.Fatalf("PurgeBefore returned error: %v", err)
+	}
+	if got := counts(); got[0] != 1 || got[1] != 1 || got[2] != 1 {
+		t.Fatalf("expected only the newer rollups after PurgeBefore, got %v", got)
+	}
+	if err := store.PurgeAll(ctx); err != nil {
+		t.Fatalf("PurgeAll returned error: %v", err)
+	}
+	if got := counts(); got[0] != 0 || got[1] != 0 || got[2] != 0 {
+		t.Fatalf("expected no rollups after PurgeAll, got %v", got)
+	}
+}
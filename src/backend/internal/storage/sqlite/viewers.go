@@ -0,0 +1,580 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+// Viewer is one person across platforms. Colour is an optional override set
+// by a moderator; when empty, callers derive a colour from the accounts.
+type Viewer struct {
+	ID          string
+	DisplayName string
+	Colour      string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+	Accounts    []ViewerAccount
+}
+
+// ViewerAccount is a platform account linked to a viewer. AccountID is the
+// Twitch login or the YouTube channel ID. Colour is the last chat colour seen
+// for the account, if the platform has one.
+type ViewerAccount struct {
+	Platform  string
+	AccountID string
+	ViewerID  string
+	Username  string
+	Colour    string
+	LinkedAt  time.Time
+}
+
+const viewerColumns = `id, display_name, colour, created_at, updated_at`
+
+const viewerAccountColumns = `platform, account_id, viewer_id, username, colour, linked_at`
+
+func normalizeViewerAccount(a ViewerAccount) (ViewerAccount, error) {
+	a.Platform = strings.ToLower(strings.TrimSpace(a.Platform))
+	a.AccountID = strings.TrimSpace(a.AccountID)
+	a.Username = strings.TrimSpace(a.Username)
+	if a.Platform == "" || a.AccountID == "" {
+		return a, errors.New("sqlite: viewer account needs a platform and account id")
+	}
+	return a, nil
+}
+
+// LinkViewerAccounts ties accounts to one viewer. With a viewerID, that viewer
+// must exist and receives the accounts; otherwise accounts that already belong
+// to different viewers are merged into the oldest of them, and a new viewer is
+// created if none is linked yet. A non-empty displayName replaces the viewer's.
+// It returns nil if viewerID does not exist.
+func (s *Store) LinkViewerAccounts(ctx context.Context, viewerID string, accounts []ViewerAccount, displayName string) (*Viewer, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	if len(accounts) == 0 {
+		return nil, errors.New("sqlite: no viewer accounts to link")
+	}
+	for i := range accounts {
+		normalized, err := normalizeViewerAccount(accounts[i])
+		if err != nil {
+			return nil, err
+		}
+		accounts[i] = normalized
+	}
+
+	viewerID = strings.TrimSpace(viewerID)
+	var target string
+	err := s.execWithBusyRetry(ctx, "link viewer accounts", func() error {
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+		if target, err = linkViewerAccountsTx(ctx, tx, viewerID, accounts, strings.TrimSpace(displayName)); err != nil || target == "" {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: link viewer accounts: %w", err)
+	}
+	if target == "" {
+		return nil, nil
+	}
+	return s.GetViewer(ctx, target)
+}
+
+// linkViewerAccountsTx links accounts inside tx and returns the viewer id, or
+// "" if the requested viewerID does not exist.
+func linkViewerAccountsTx(ctx context.Context, tx *sql.Tx, viewerID string, accounts []ViewerAccount, displayName string) (string, error) {
+	now := time.Now().UTC().UnixMilli()
+
+	var existing []string
+	seen := map[string]bool{}
+	if viewerID != "" {
+		var n int
+		if err := tx.QueryRowContext(ctx, `SELECT COUNT(1) FROM viewers WHERE id = ?`, viewerID).Scan(&n); err != nil {
+			return "", err
+		}
+		if n == 0 {
+			return "", nil
+		}
+		seen[viewerID] = true
+		existing = append(existing, viewerID)
+	}
+	for _, a := range accounts {
+		var id string
+		err := tx.QueryRowContext(ctx,
+			`SELECT a.viewer_id FROM viewer_accounts a JOIN viewers v ON v.id = a.viewer_id WHERE a.platform = ? AND a.account_id = ?`,
+			a.Platform, a.AccountID,
+		).Scan(&id)
+		if errors.Is(err, sql.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		if !seen[id] {
+			seen[id] = true
+			existing = append(existing, id)
+		}
+	}
+
+	// Merges keep the requested viewer, or else the longest-lived id.
+	var target string
+	switch {
+	case viewerID != "":
+		target = viewerID
+	case len(existing) > 0:
+		placeholders := strings.TrimSuffix(strings.Repeat("?,", len(existing)), ",")
+		args := make([]any, len(existing))
+		for i, id := range existing {
+			args[i] = id
+		}
+		if err := tx.QueryRowContext(ctx,
+			`SELECT id FROM viewers WHERE id IN (`+placeholders+`) ORDER BY created_at ASC, rowid ASC LIMIT 1`, args...,
+		).Scan(&target); err != nil {
+			return "", err
+		}
+	default:
+		target = uuid.NewString()
+		name := displayName
+		if name == "" {
+			name = accounts[0].Username
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO viewers(id, display_name, colour, created_at, updated_at) VALUES(?, ?, '', ?, ?)`,
+			target, name, now, now,
+		); err != nil {
+			return "", err
+		}
+	}
+
+	for _, id := range existing {
+		if id == target {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE viewer_accounts SET viewer_id = ? WHERE viewer_id = ?`, target, id); err != nil {
+			return "", err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM viewers WHERE id = ?`, id); err != nil {
+			return "", err
+		}
+	}
+
+	for _, a := range accounts {
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO viewer_accounts(platform, account_id, viewer_id, username, colour, linked_at) VALUES(?, ?, ?, ?, ?, ?)
+			ON CONFLICT(platform, account_id) DO UPDATE SET
+				viewer_id = excluded.viewer_id,
+				username = CASE WHEN excluded.username <> '' THEN excluded.username ELSE viewer_accounts.username END,
+				colour = CASE WHEN excluded.colour <> '' THEN excluded.colour ELSE viewer_accounts.colour END`,
+			a.Platform, a.AccountID, target, a.Username, a.Colour, now,
+		); err != nil {
+			return "", err
+		}
+	}
+
+	if displayName != "" {
+		if _, err := tx.ExecContext(ctx, `UPDATE viewers SET display_name = ?, updated_at = ? WHERE id = ?`, displayName, now, target); err != nil {
+			return "", err
+		}
+	} else if _, err := tx.ExecContext(ctx, `UPDATE viewers SET updated_at = ? WHERE id = ?`, now, target); err != nil {
+		return "", err
+	}
+	return target, nil
+}
+
+// GetViewer returns the viewer with its accounts, or nil if it does not exist.
+func (s *Store) GetViewer(ctx context.Context, id string) (*Viewer, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	viewers, err := s.queryViewers(ctx, `SELECT `+viewerColumns+` FROM viewers WHERE id = ?`, strings.TrimSpace(id))
+	if err != nil || len(viewers) == 0 {
+		return nil, err
+	}
+	return &viewers[0], nil
+}
+
+// FindViewerByAccount returns the viewer an account is linked to, or nil.
+func (s *Store) FindViewerByAccount(ctx context.Context, platform, accountID string) (*Viewer, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	viewers, err := s.queryViewers(ctx,
+		`SELECT `+viewerColumns+` FROM viewers WHERE id = (SELECT viewer_id FROM viewer_accounts WHERE platform = ? AND account_id = ?)`,
+		strings.ToLower(strings.TrimSpace(platform)), strings.TrimSpace(accountID))
+	if err != nil || len(viewers) == 0 {
+		return nil, err
+	}
+	return &viewers[0], nil
+}
+
+// ListViewers returns every viewer with its accounts, most recently updated
+// first. Linked viewers are few enough to load whole.
+func (s *Store) ListViewers(ctx context.Context) ([]Viewer, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	return s.queryViewers(ctx, `SELECT `+viewerColumns+` FROM viewers ORDER BY updated_at DESC, id ASC`)
+}
+
+func (s *Store) queryViewers(ctx context.Context, query string, args ...any) ([]Viewer, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: query viewers: %w", err)
+	}
+	var (
+		viewers []Viewer
+		index   = map[string]int{}
+	)
+	for rows.Next() {
+		var (
+			v                    Viewer
+			createdAt, updatedAt int64
+		)
+		if err := rows.Scan(&v.ID, &v.DisplayName, &v.Colour, &createdAt, &updatedAt); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("sqlite: scan viewer: %w", err)
+		}
+		v.CreatedAt = time.UnixMilli(createdAt).UTC()
+		v.UpdatedAt = time.UnixMilli(updatedAt).UTC()
+		v.Accounts = []ViewerAccount{}
+		index[v.ID] = len(viewers)
+		viewers = append(viewers, v)
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: iterate viewers: %w", err)
+	}
+	if len(viewers) == 0 {
+		return viewers, nil
+	}
+
+	accountQuery := `SELECT ` + viewerAccountColumns + ` FROM viewer_accounts`
+	var accountArgs []any
+	if len(viewers) == 1 {
+		accountQuery += ` WHERE viewer_id = ?`
+		accountArgs = append(accountArgs, viewers[0].ID)
+	}
+	accountQuery += ` ORDER BY linked_at ASC, platform ASC, account_id ASC`
+	accountRows, err := s.db.QueryContext(ctx, accountQuery, accountArgs...)
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: query viewer accounts: %w", err)
+	}
+	defer accountRows.Close()
+	for accountRows.Next() {
+		var (
+			a        ViewerAccount
+			linkedAt int64
+		)
+		if err := accountRows.Scan(&a.Platform, &a.AccountID, &a.ViewerID, &a.Username, &a.Colour, &linkedAt); err != nil {
+			return nil, fmt.Errorf("sqlite: scan viewer account: %w", err)
+		}
+		a.LinkedAt = time.UnixMilli(linkedAt).UTC()
+		if i, ok := index[a.ViewerID]; ok {
+			viewers[i].Accounts = append(viewers[i].Accounts, a)
+		}
+	}
+	if err := accountRows.Err(); err != nil {
+		return nil, fmt.Errorf("sqlite: iterate viewer accounts: %w", err)
+	}
+	return viewers, nil
+}
+
+// UpdateViewer changes a viewer's display name and colour. Nil fields are left
+// alone. It reports whether the viewer exists.
+func (s *Store) UpdateViewer(ctx context.Context, id string, displayName, colour *string) (bool, error) {
+	if s.db == nil {
+		return false, errors.New("sqlite: store not initialized")
+	}
+	var res sql.Result
+	err := s.execWithBusyRetry(ctx, "update viewer", func() error {
+		var execErr error
+		res, execErr = s.db.ExecContext(ctx,
+			`UPDATE viewers SET
+				display_name = COALESCE(?, display_name),
+				colour = COALESCE(?, colour),
+				updated_at = ?
+			WHERE id = ?`,
+			displayName,
+			colour,
+			time.Now().UTC().UnixMilli(),
+			strings.TrimSpace(id),
+		)
+		return execErr
+	})
+	if err != nil {
+		return false, fmt.Errorf("sqlite: update viewer: %w", err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sqlite: rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// DeleteViewer removes a viewer and unlinks its accounts.
+func (s *Store) DeleteViewer(ctx context.Context, id string) (bool, error) {
+	return s.deleteViewerRows(ctx, "delete viewer", `DELETE FROM viewers WHERE id = ?`, strings.TrimSpace(id))
+}
+
+// UnlinkViewerAccount detaches one account from its viewer. The viewer itself
+// is kept, even with no accounts left.
+func (s *Store) UnlinkViewerAccount(ctx context.Context, platform, accountID string) (bool, error) {
+	return s.deleteViewerRows(ctx, "unlink viewer account",
+		`DELETE FROM viewer_accounts WHERE platform = ? AND account_id = ?`,
+		strings.ToLower(strings.TrimSpace(platform)), strings.TrimSpace(accountID))
+}
+
+func (s *Store) deleteViewerRows(ctx context.Context, op, query string, args ...any) (bool, error) {
+	if s.db == nil {
+		return false, errors.New("sqlite: store not initialized")
+	}
+	var res sql.Result
+	err := s.execWithBusyRetry(ctx, op, func() error {
+		var execErr error
+		res, execErr = s.db.ExecContext(ctx, query, args...)
+		return execErr
+	})
+	if err != nil {
+		return false, fmt.Errorf("sqlite: %s: %w", op, err)
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("sqlite: rows affected: %w", err)
+	}
+	return affected > 0, nil
+}
+
+// SetViewerAccountColour records the latest chat colour seen for an account.
+func (s *Store) SetViewerAccountColour(ctx context.Context, platform, accountID, colour string) error {
+	if s.db == nil {
+		return errors.New("sqlite: store not initialized")
+	}
+	err := s.execWithBusyRetry(ctx, "set viewer account colour", func() error {
+		_, execErr := s.db.ExecContext(ctx,
+			`UPDATE viewer_accounts SET colour = ? WHERE platform = ? AND account_id = ?`,
+			colour, strings.ToLower(strings.TrimSpace(platform)), strings.TrimSpace(accountID))
+		return execErr
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite: set viewer account colour: %w", err)
+	}
+	return nil
+}
+
+// ViewerLinkCode is a pending link between two accounts. Secret is only
+// given to whoever created the code. Once an account claims Code it becomes
+// Account, and ConfirmCode must then come from that person's account on the
+// other platform.
+type ViewerLinkCode struct {
+	Code        string
+	Secret      string
+	ConfirmCode string
+	Account     ViewerAccount
+	CreatedAt   time.Time
+	ExpiresAt   time.Time
+}
+
+// CreateViewerLinkCode stores a verification code and the secret that reveals
+// its confirm code. Expired codes are pruned.
+func (s *Store) CreateViewerLinkCode(ctx context.Context, code, secret string, expiresAt time.Time) error {
+	if s.db == nil {
+		return errors.New("sqlite: store not initialized")
+	}
+	now := time.Now().UTC()
+	err := s.execWithBusyRetry(ctx, "create viewer link code", func() error {
+		if _, err := s.db.ExecContext(ctx, `DELETE FROM viewer_link_codes WHERE expires_at <= ?`, now.UnixMilli()); err != nil {
+			return err
+		}
+		_, err := s.db.ExecContext(ctx,
+			`INSERT INTO viewer_link_codes(code, secret, created_at, expires_at) VALUES(?, ?, ?, ?)`,
+			code, secret, now.UnixMilli(), expiresAt.UTC().UnixMilli())
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("sqlite: create viewer link code: %w", err)
+	}
+	return nil
+}
+
+// GetViewerLinkCode returns a pending link code, or nil if it is unknown,
+// expired or already spent.
+func (s *Store) GetViewerLinkCode(ctx context.Context, code string) (*ViewerLinkCode, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	var (
+		lc                   ViewerLinkCode
+		createdAt, expiresAt int64
+	)
+	err := s.db.QueryRowContext(ctx, `
+SELECT code, secret, confirm_code, platform, account_id, username, created_at, expires_at
+FROM viewer_link_codes
+WHERE code = ? AND expires_at > ?`, code, time.Now().UTC().UnixMilli()).Scan(
+		&lc.Code, &lc.Secret, &lc.ConfirmCode,
+		&lc.Account.Platform, &lc.Account.AccountID, &lc.Account.Username,
+		&createdAt, &expiresAt,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: get viewer link code: %w", err)
+	}
+	lc.CreatedAt = time.UnixMilli(createdAt).UTC()
+	lc.ExpiresAt = time.UnixMilli(expiresAt).UTC()
+	return &lc, nil
+}
+
+// ClaimViewerLinkCode records that account typed code. Typing a fresh code
+// binds it to the account and issues confirmCode; the code itself is then
+// spent, so copying it from chat does nothing. Typing that confirm code from
+// the bound account's other platform links both accounts and returns the
+// viewer. Anything else returns nil.
+func (s *Store) ClaimViewerLinkCode(ctx context.Context, code, confirmCode string, account ViewerAccount) (*Viewer, error) {
+	if s.db == nil {
+		return nil, errors.New("sqlite: store not initialized")
+	}
+	account, err := normalizeViewerAccount(account)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(code) == "" || strings.TrimSpace(confirmCode) == "" {
+		return nil, errors.New("sqlite: link code and confirm code are required")
+	}
+
+	var viewerID string
+	err = s.execWithBusyRetry(ctx, "claim viewer link code", func() error {
+		viewerID = ""
+		tx, err := s.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = tx.Rollback() }()
+		now := time.Now().UTC().UnixMilli()
+
+		var first ViewerAccount
+		err = tx.QueryRowContext(ctx,
+			`SELECT platform, account_id, username FROM viewer_link_codes WHERE code = ? AND expires_at > ?`,
+			code, now,
+		).Scan(&first.Platform, &first.AccountID, &first.Username)
+		switch {
+		case err == nil:
+			if first.Platform != "" {
+				return nil
+			}
+			if _, err := tx.ExecContext(ctx,
+				`UPDATE viewer_link_codes SET platform = ?, account_id = ?, username = ?, confirm_code = ? WHERE code = ?`,
+				account.Platform, account.AccountID, account.Username, confirmCode, code,
+			); err != nil {
+				return err
+			}
+			return tx.Commit()
+		case !errors.Is(err, sql.ErrNoRows):
+			return err
+		}
+
+		var pending string
+		err = tx.QueryRowContext(ctx,
+			`SELECT code, platform, account_id, username FROM viewer_link_codes WHERE confirm_code = ? AND expires_at > ?`,
+			code, now,
+		).Scan(&pending, &first.Platform, &first.AccountID, &first.Username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if first.Platform == account.Platform {
+			return nil
+		}
+		if viewerID, err = linkViewerAccountsTx(ctx, tx, "", []ViewerAccount{first, account}, ""); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, `DELETE FROM viewer_link_codes WHERE code = ?`, pending); err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("sqlite: claim viewer link code: %w", err)
+	}
+	if viewerID == "" {
+		return nil, nil
+	}
+	return s.GetViewer(ctx, viewerID)
+}
+
+// ViewerMessages returns up to limit of the viewer's messages across linked
+// accounts, newest first, along with their total count. Twitch rows match on
+// the login; YouTube rows match on the channel ID in the raw payload.
+func (s *Store) ViewerMessages(ctx context.Context, v *Viewer, limit int) ([]storage.Message, int, error) {
+	if s.db == nil {
+		return nil, 0, errors.New("sqlite: store not initialized")
+	}
+	if v == nil || len(v.Accounts) == 0 {
+		return []storage.Message{}, 0, nil
+	}
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var (
+		clauses []string
+		args    []any
+	)
+	for _, a := range v.Accounts {
+		switch a.Platform {
+		case "twitch":
+			clauses = append(clauses, `(lower(platform) = 'twitch' AND lower(username) = ?)`)
+			args = append(args, strings.ToLower(a.AccountID))
+		default:
+			clauses = append(clauses, `(lower(platform) = ? AND instr(COALESCE(raw_json, ''), ?) > 0)`)
+			args = append(args, a.Platform, a.AccountID)
+		}
+	}
+	where := ` WHERE ` + strings.Join(clauses, " OR ")
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, `SELECT COUNT(1) FROM messages`+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("sqlite: count viewer messages: %w", err)
+	}
+
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT rowid, id, ts, username, platform, text, emotes_json, COALESCE(badges_json, '[]'), COALESCE(raw_json, '') FROM messages`+
+			where+` ORDER BY ts DESC, rowid DESC LIMIT ?`,
+		append(args, limit)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("sqlite: query viewer messages: %w", err)
+	}
+	defer rows.Close()
+
+	results := make([]storage.Message, 0, limit)
+	for rows.Next() {
+		var (
+			msg storage.Message
+			ts  int64
+		)
+		if err := rows.Scan(&msg.RowID, &msg.ID, &ts, &msg.Username, &msg.Platform, &msg.Text, &msg.EmotesJSON, &msg.BadgesJSON, &msg.RawJSON); err != nil {
+			return nil, 0, fmt.Errorf("sqlite: scan message: %w", err)
+		}
+		msg.Timestamp = time.UnixMilli(ts).UTC()
+		results = append(results, msg)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("sqlite: iterate messages: %w", err)
+	}
+	return results, total, nil
+}
@@ -0,0 +1,157 @@
+package sqlite
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func TestLinkViewerAccountsMergesViewers(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	twitch, err := store.LinkViewerAccounts(ctx, "", []ViewerAccount{{Platform: "Twitch", AccountID: "alice", Username: "Alice"}}, "")
+	if err != nil {
+		t.Fatalf("LinkViewerAccounts returned error: %v", err)
+	}
+	if twitch.DisplayName != "Alice" || len(twitch.Accounts) != 1 || twitch.Accounts[0].Platform != "twitch" {
+		t.Fatalf("unexpected viewer: %+v", twitch)
+	}
+	youtube, err := store.LinkViewerAccounts(ctx, "", []ViewerAccount{{Platform: "youtube", AccountID: "UCalice"}}, "")
+	if err != nil {
+		t.Fatalf("LinkViewerAccounts returned error: %v", err)
+	}
+
+	merged, err := store.LinkViewerAccounts(ctx, "", []ViewerAccount{
+		{Platform: "youtube", AccountID: "UCalice", Username: "Alice YT"},
+		{Platform: "twitch", AccountID: "alice"},
+	}, "Alice Everywhere")
+	if err != nil {
+		t.Fatalf("LinkViewerAccounts returned error: %v", err)
+	}
+	if merged.ID != twitch.ID || merged.DisplayName != "Alice Everywhere" || len(merged.Accounts) != 2 {
+		t.Fatalf("expected accounts merged into the oldest viewer, got %+v", merged)
+	}
+	if gone, err := store.GetViewer(ctx, youtube.ID); err != nil || gone != nil {
+		t.Fatalf("expected the merged viewer to be removed, got %+v (err=%v)", gone, err)
+	}
+	if missing, err := store.LinkViewerAccounts(ctx, "nope", []ViewerAccount{{Platform: "twitch", AccountID: "x"}}, ""); err != nil || missing != nil {
+		t.Fatalf("expected nil for an unknown viewer, got %+v (err=%v)", missing, err)
+	}
+	found, err := store.FindViewerByAccount(ctx, "YouTube", "UCalice")
+	if err != nil || found == nil || found.ID != twitch.ID {
+		t.Fatalf("FindViewerByAccount returned %+v (err=%v)", found, err)
+	}
+
+	colour := "#FF0000"
+	if ok, err := store.UpdateViewer(ctx, twitch.ID, nil, &colour); err != nil || !ok {
+		t.Fatalf("UpdateViewer returned ok=%v err=%v", ok, err)
+	}
+	if ok, err := store.UnlinkViewerAccount(ctx, "youtube", "UCalice"); err != nil || !ok {
+		t.Fatalf("UnlinkViewerAccount returned ok=%v err=%v", ok, err)
+	}
+	all, err := store.ListViewers(ctx)
+	if err != nil || len(all) != 1 || all[0].Colour != colour || len(all[0].Accounts) != 1 {
+		t.Fatalf("unexpected viewers after unlink: %+v (err=%v)", all, err)
+	}
+	if ok, err := store.DeleteViewer(ctx, twitch.ID); err != nil || !ok {
+		t.Fatalf("DeleteViewer returned ok=%v err=%v", ok, err)
+	}
+	if found, err := store.FindViewerByAccount(ctx, "twitch", "alice"); err != nil || found != nil {
+		t.Fatalf("expected accounts to be removed with the viewer, got %+v (err=%v)", found, err)
+	}
+}
+
+func TestClaimViewerLinkCode(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	if err := store.CreateViewerLinkCode(ctx, "LINK-OLD", "old-secret", time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("CreateViewerLinkCode returned error: %v", err)
+	}
+	if err := store.CreateViewerLinkCode(ctx, "LINK-1", "secret-1", time.Now().Add(time.Minute)); err != nil {
+		t.Fatalf("CreateViewerLinkCode returned error: %v", err)
+	}
+	var stored int
+	if err := store.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM viewer_link_codes`).Scan(&stored); err != nil || stored != 1 {
+		t.Fatalf("expected creating a code to prune the expired one, got %d rows (err=%v)", stored, err)
+	}
+
+	bob := ViewerAccount{Platform: "twitch", AccountID: "bob", Username: "Bob"}
+	if v, err := store.ClaimViewerLinkCode(ctx, "LINK-OLD", "CONFIRM-OLD", bob); err != nil || v != nil {
+		t.Fatalf("expected an expired code to be ignored, got %+v (err=%v)", v, err)
+	}
+	if v, err := store.ClaimViewerLinkCode(ctx, "LINK-1", "CONFIRM-1", bob); err != nil || v != nil {
+		t.Fatalf("expected the first claim to wait, got %+v (err=%v)", v, err)
+	}
+	lc, err := store.GetViewerLinkCode(ctx, "LINK-1")
+	if err != nil || lc == nil || lc.Secret != "secret-1" || lc.ConfirmCode != "CONFIRM-1" || lc.Account.AccountID != "bob" {
+		t.Fatalf("expected the code to be bound to bob, got %+v (err=%v)", lc, err)
+	}
+
+	// The code seen in chat is spent: pasting it from another platform does
+	// not link, and neither does the confirm code from bob's own platform.
+	if v, err := store.ClaimViewerLinkCode(ctx, "LINK-1", "CONFIRM-X", ViewerAccount{Platform: "youtube", AccountID: "UCmallory"}); err != nil || v != nil {
+		t.Fatalf("expected a copied code to be ignored, got %+v (err=%v)", v, err)
+	}
+	if v, err := store.ClaimViewerLinkCode(ctx, "CONFIRM-1", "CONFIRM-Y", ViewerAccount{Platform: "twitch", AccountID: "mallory"}); err != nil || v != nil {
+		t.Fatalf("expected a same-platform confirm to be ignored, got %+v (err=%v)", v, err)
+	}
+	v, err := store.ClaimViewerLinkCode(ctx, "CONFIRM-1", "CONFIRM-Z", ViewerAccount{Platform: "youtube", AccountID: "UCbob", Username: "Bob"})
+	if err != nil || v == nil || len(v.Accounts) != 2 || v.DisplayName != "Bob" {
+		t.Fatalf("expected the confirm code to link, got %+v (err=%v)", v, err)
+	}
+	if again, err := store.ClaimViewerLinkCode(ctx, "CONFIRM-1", "CONFIRM-W", ViewerAccount{Platform: "youtube", AccountID: "UCeve"}); err != nil || again != nil {
+		t.Fatalf("expected a spent confirm code to be ignored, got %+v (err=%v)", again, err)
+	}
+	if lc, err := store.GetViewerLinkCode(ctx, "LINK-1"); err != nil || lc != nil {
+		t.Fatalf("expected the link code to be removed, got %+v (err=%v)", lc, err)
+	}
+}
+
+func TestViewerMessages(t *testing.T) {
+	store := New(Config{})
+	ctx := context.Background()
+	if err := store.Init(ctx); err != nil {
+		t.Fatalf("Init returned error: %v", err)
+	}
+	t.Cleanup(func() { _ = store.Close(ctx) })
+
+	base := time.Now().UTC().Truncate(time.Millisecond)
+	for i, m := range []storage.Message{
+		{ID: "t1", Username: "Carol", Platform: "Twitch", Text: "hi from twitch"},
+		{ID: "y1", Username: "Carol C", Platform: "YouTube", Text: "hi from youtube", RawJSON: `{"author":{"channelId":"UCcarol"}}`},
+		{ID: "t2", Username: "dave", Platform: "Twitch", Text: "not carol"},
+	} {
+		m.Timestamp = base.Add(time.Duration(i) * time.Millisecond)
+		m.EmotesJSON = "[]"
+		if err := store.InsertMessage(ctx, &m); err != nil {
+			t.Fatalf("InsertMessage returned error: %v", err)
+		}
+	}
+
+	v, err := store.LinkViewerAccounts(ctx, "", []ViewerAccount{
+		{Platform: "twitch", AccountID: "carol"},
+		{Platform: "youtube", AccountID: "UCcarol"},
+	}, "")
+	if err != nil {
+		t.Fatalf("LinkViewerAccounts returned error: %v", err)
+	}
+	msgs, total, err := store.ViewerMessages(ctx, v, 10)
+	if err != nil {
+		t.Fatalf("ViewerMessages returned error: %v", err)
+	}
+	if total != 2 || len(msgs) != 2 || msgs[0].ID != "y1" || msgs[1].ID != "t1" {
+		t.Fatalf("unexpected viewer messages: total=%d %+v", total, msgs)
+	}
+}
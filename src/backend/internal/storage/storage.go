@@ -55,7 +55,8 @@ type Store interface {
 	Ping(ctx context.Context) error
 	InsertMessage(ctx context.Context, m *Message) error
 	GetRecent(ctx context.Context, q QueryOpts) ([]Message, error)
-	// PurgeBefore deletes messages with timestamps strictly before the cutoff.
+	// PurgeBefore deletes messages with timestamps strictly before the cutoff,
+	// along with any data derived from them.
 	PurgeBefore(ctx context.Context, cutoff time.Time) (int, error)
 	PurgeAll(ctx context.Context) error
 	GetSession(ctx context.Context, token string) (*Session, error)
@@ -25,15 +25,27 @@ type Store interface {
 }
 
 // Config controls how the database tailer operates.
+//
+// Interval and Batch are the starting values. While polls come back empty the
+// interval backs off toward MaxInterval; as soon as rows appear it snaps back to
+// MinInterval. Full batches double the batch size up to BatchCeiling.
 type Config struct {
 	Enabled        bool
 	Interval       time.Duration
+	MinInterval    time.Duration
+	MaxInterval    time.Duration
 	Batch          int
+	BatchCeiling   int
 	MaxLag         time.Duration
 	PersistOffsets bool
 	OffsetPath     string
 }
 
+const (
+	defaultInterval = 200 * time.Millisecond
+	defaultBatch    = 500
+)
+
 // Runner periodically polls the backing store for new messages and broadcasts them.
 type Runner struct {
 	cfg   Config
@@ -82,8 +94,10 @@ func (r *Runner) Start(ctx context.Context) error {
 		}
 	}
 
-	log.Printf("dbtailer: enabled interval=%s batch=%d start_pos ts=%d rowid=%d",
-		r.cfg.Interval, r.cfg.Batch, head.TS, head.RowID)
+	minInterval, maxInterval := r.intervalBounds()
+	baseBatch, ceiling := r.batchBounds()
+	log.Printf("dbtailer: enabled interval=%s min=%s max=%s batch=%d ceiling=%d start_pos ts=%d rowid=%d",
+		r.startInterval(), minInterval, maxInterval, baseBatch, ceiling, head.TS, head.RowID)
 
 	runCtx, cancel := context.WithCancel(ctx)
 	r.cancel = cancel
@@ -103,39 +117,128 @@ func (r *Runner) Stop() {
 }
 
 func (r *Runner) loop(ctx context.Context) {
-	interval := r.cfg.Interval
-	if interval <= 0 {
-		interval = 200 * time.Millisecond
-	}
-	ticker := time.NewTicker(interval)
-	defer ticker.Stop()
+	minInterval, maxInterval := r.intervalBounds()
+	baseBatch, ceiling := r.batchBounds()
+	interval := r.startInterval()
+	batchSize := baseBatch
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
 
 	for {
 		select {
 		case <-ctx.Done():
 			return
-		case <-ticker.C:
-			r.tick(ctx)
+		case <-timer.C:
+			fetched, err := r.tick(ctx, batchSize)
+			interval = nextPollInterval(interval, minInterval, maxInterval, fetched, err)
+			batchSize = nextBatchSize(batchSize, baseBatch, ceiling, fetched, err)
+			timer.Reset(interval)
+		}
+	}
+}
+
+// startInterval returns the configured starting interval clamped to the
+// adaptive bounds.
+func (r *Runner) startInterval() time.Duration {
+	interval := r.cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	minInterval, maxInterval := r.intervalBounds()
+	return clampDuration(interval, minInterval, maxInterval)
+}
+
+// intervalBounds resolves the adaptive polling bounds. Unset bounds collapse to
+// the starting interval, which preserves fixed-rate polling.
+func (r *Runner) intervalBounds() (time.Duration, time.Duration) {
+	interval := r.cfg.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	minInterval := r.cfg.MinInterval
+	if minInterval <= 0 {
+		minInterval = interval
+	}
+	maxInterval := r.cfg.MaxInterval
+	if maxInterval <= 0 {
+		maxInterval = interval
+	}
+	if maxInterval < minInterval {
+		maxInterval = minInterval
+	}
+	return minInterval, maxInterval
+}
+
+// batchBounds resolves the base batch size and the ceiling it may grow to.
+func (r *Runner) batchBounds() (int, int) {
+	base := r.cfg.Batch
+	if base <= 0 {
+		base = defaultBatch
+	}
+	ceiling := r.cfg.BatchCeiling
+	if ceiling < base {
+		ceiling = base
+	}
+	return base, ceiling
+}
+
+// nextPollInterval snaps to the minimum interval when rows were fetched and
+// doubles the interval (capped at the maximum) after an empty or failed poll.
+func nextPollInterval(current, minInterval, maxInterval time.Duration, fetched int, err error) time.Duration {
+	if err == nil && fetched > 0 {
+		return minInterval
+	}
+	return clampDuration(current*2, minInterval, maxInterval)
+}
+
+// nextBatchSize doubles the batch (capped at the ceiling) when the previous
+// batch came back full and halves it back toward the base otherwise.
+func nextBatchSize(current, base, ceiling, fetched int, err error) int {
+	if err != nil {
+		return current
+	}
+	if fetched >= current {
+		next := current * 2
+		if next > ceiling {
+			next = ceiling
 		}
+		return next
+	}
+	next := current / 2
+	if next < base {
+		next = base
+	}
+	return next
+}
+
+func clampDuration(d, lo, hi time.Duration) time.Duration {
+	if d < lo {
+		return lo
+	}
+	if d > hi {
+		return hi
 	}
+	return d
 }
 
-func (r *Runner) tick(ctx context.Context) {
-	batchSize := r.cfg.Batch
+// tick fetches and broadcasts the next batch, returning the number of rows
+// read from the store.
+func (r *Runner) tick(ctx context.Context, batchSize int) (int, error) {
 	if batchSize <= 0 {
-		batchSize = 500
+		batchSize = defaultBatch
 	}
 
 	msgs, last, err := r.store.TailNext(ctx, r.last, batchSize)
 	if err != nil {
 		log.Printf("dbtailer: error: %v", err)
-		return
+		return 0, err
 	}
 
 	r.last = last
 	r.persistPosition()
 	if len(msgs) == 0 {
-		return
+		return 0, nil
 	}
 
 	toBroadcast := make([]storage.Message, 0, len(msgs))
@@ -157,7 +260,7 @@ func (r *Runner) tick(ctx context.Context) {
 	r.mu.Unlock()
 
 	if len(toBroadcast) == 0 {
-		return
+		return len(msgs), nil
 	}
 
 	for _, msg := range toBroadcast {
@@ -171,8 +274,9 @@ func (r *Runner) tick(ctx context.Context) {
 		}
 	}
 
-	log.Printf("dbtailer: published n=%d new messages; last_pos ts=%d rowid=%d",
-		len(toBroadcast), r.last.TS, r.last.RowID)
+	log.Printf("dbtailer: published n=%d new messages; last_pos ts=%d rowid=%d batch=%d",
+		len(toBroadcast), r.last.TS, r.last.RowID, batchSize)
+	return len(msgs), nil
 }
 
 func (r *Runner) persistPosition() {
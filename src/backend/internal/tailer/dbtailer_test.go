@@ -0,0 +1,69 @@
+package tailer
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestNextPollIntervalBacksOffWhenIdle(t *testing.T) {
+	minInterval := 100 * time.Millisecond
+	maxInterval := time.Second
+
+	interval := minInterval
+	want := []time.Duration{200, 400, 800, 1000, 1000}
+	for i, w := range want {
+		interval = nextPollInterval(interval, minInterval, maxInterval, 0, nil)
+		if interval != w*time.Millisecond {
+			t.Fatalf("step %d: expected %s, got %s", i, w*time.Millisecond, interval)
+		}
+	}
+
+	interval = nextPollInterval(interval, minInterval, maxInterval, 3, nil)
+	if interval != minInterval {
+		t.Fatalf("expected snap back to %s, got %s", minInterval, interval)
+	}
+
+	interval = nextPollInterval(interval, minInterval, maxInterval, 3, errors.New("boom"))
+	if interval != 200*time.Millisecond {
+		t.Fatalf("expected error to back off to 200ms, got %s", interval)
+	}
+}
+
+func TestNextBatchSizeGrowsOnFullBatches(t *testing.T) {
+	base, ceiling := 100, 350
+
+	batch := nextBatchSize(base, base, ceiling, 100, nil)
+	if batch != 200 {
+		t.Fatalf("expected 200, got %d", batch)
+	}
+	batch = nextBatchSize(batch, base, ceiling, 200, nil)
+	if batch != 350 {
+		t.Fatalf("expected growth capped at 350, got %d", batch)
+	}
+	batch = nextBatchSize(batch, base, ceiling, 350, errors.New("boom"))
+	if batch != 350 {
+		t.Fatalf("expected error to keep 350, got %d", batch)
+	}
+	batch = nextBatchSize(batch, base, ceiling, 10, nil)
+	if batch != 175 {
+		t.Fatalf("expected shrink to 175, got %d", batch)
+	}
+	batch = nextBatchSize(batch, base, ceiling, 0, nil)
+	if batch != base {
+		t.Fatalf("expected shrink floor %d, got %d", base, batch)
+	}
+}
+
+func TestRunnerBoundsDefaultToFixedRate(t *testing.T) {
+	r := New(Config{Interval: 250 * time.Millisecond, Batch: 50}, nil)
+
+	minInterval, maxInterval := r.intervalBounds()
+	if minInterval != 250*time.Millisecond || maxInterval != 250*time.Millisecond {
+		t.Fatalf("expected fixed 250ms bounds, got %s..%s", minInterval, maxInterval)
+	}
+	base, ceiling := r.batchBounds()
+	if base != 50 || ceiling != 50 {
+		t.Fatalf("expected fixed batch 50, got %d..%d", base, ceiling)
+	}
+}
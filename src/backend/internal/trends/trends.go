@@ -0,0 +1,342 @@
+// Package trends spots what chat is spamming: copypastas, chants and short
+// phrases repeated by several chatters within a rolling window, across
+// platforms.
+package trends
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+const (
+	// KindPhrase is a short run of words repeated on its own or inside
+	// messages, such as "W" spam or a chant.
+	KindPhrase = "phrase"
+	// KindCopypasta is a long message repeated word for word.
+	KindCopypasta = "copypasta"
+)
+
+// Config tunes the analyzer. Zero fields take the defaults.
+type Config struct {
+	// Window is how far back messages count towards a trend.
+	Window time.Duration
+	// MinCount is how many messages must contain the text.
+	MinCount int
+	// MinChatters is how many different chatters must have sent it, so one
+	// spammer cannot start a trend.
+	MinChatters int
+	// MaxPhraseWords is the longest phrase tracked inside messages; longer
+	// repeated messages are copypastas.
+	MaxPhraseWords int
+	// Cooldown is the minimum time between two reports of the same trend.
+	Cooldown time.Duration
+}
+
+// DefaultConfig returns the defaults used for zero Config fields.
+func DefaultConfig() Config {
+	return Config{
+		Window:         time.Minute,
+		MinCount:       5,
+		MinChatters:    3,
+		MaxPhraseWords: 3,
+		Cooldown:       time.Minute,
+	}
+}
+
+func (c Config) withDefaults() Config {
+	d := DefaultConfig()
+	if c.Window <= 0 {
+		c.Window = d.Window
+	}
+	if c.MinCount <= 0 {
+		c.MinCount = d.MinCount
+	}
+	if c.MinChatters <= 0 {
+		c.MinChatters = d.MinChatters
+	}
+	if c.MaxPhraseWords <= 0 {
+		c.MaxPhraseWords = d.MaxPhraseWords
+	}
+	if c.Cooldown <= 0 {
+		c.Cooldown = d.Cooldown
+	}
+	return c
+}
+
+// maxScanWords caps the words a message may have for its phrases to count.
+// Longer messages only count as copypasta candidates, so one pasted wall of
+// text does not trend dozens of phrases at once.
+const maxScanWords = 8
+
+// stopWords never trend as single words.
+var stopWords = map[string]struct{}{
+	"a": {}, "an": {}, "and": {}, "are": {}, "at": {}, "be": {}, "but": {}, "for": {},
+	"he": {}, "i": {}, "in": {}, "is": {}, "it": {}, "its": {}, "me": {}, "my": {},
+	"no": {}, "not": {}, "of": {}, "on": {}, "or": {}, "so": {}, "that": {}, "the": {},
+	"this": {}, "to": {}, "u": {}, "we": {}, "what": {}, "yes": {}, "you": {},
+}
+
+// Trend is text chat is currently repeating.
+type Trend struct {
+	Kind      string    `json:"kind"`
+	Key       string    `json:"key"`
+	Text      string    `json:"text"`
+	Count     int       `json:"count"`
+	Chatters  int       `json:"chatters"`
+	Platforms []string  `json:"platforms"`
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+}
+
+type observation struct {
+	at       time.Time
+	platform string
+	chatter  string
+	keys     []string
+}
+
+type stat struct {
+	kind      string
+	sample    string
+	count     int
+	chatters  map[string]int
+	platforms map[string]int
+	firstSeen time.Time
+	lastSeen  time.Time
+}
+
+// Analyzer counts normalized text over a rolling window. It is safe for
+// concurrent use.
+type Analyzer struct {
+	mu        sync.Mutex
+	cfg       Config
+	window    []observation
+	stats     map[string]*stat
+	announced map[string]time.Time
+}
+
+// New returns an analyzer using cfg.
+func New(cfg Config) *Analyzer {
+	return &Analyzer{
+		cfg:       cfg.withDefaults(),
+		stats:     make(map[string]*stat),
+		announced: make(map[string]time.Time),
+	}
+}
+
+// Config returns the analyzer's settings with defaults applied.
+func (a *Analyzer) Config() Config {
+	return a.cfg
+}
+
+// Normalize folds text for comparison: lower case, punctuation trimmed from
+// word edges and whitespace collapsed. "W!!" and "w" compare equal.
+func Normalize(text string) string {
+	words := strings.Fields(strings.ToLower(text))
+	out := words[:0]
+	for _, w := range words {
+		w = strings.TrimFunc(w, func(r rune) bool { return unicode.IsPunct(r) })
+		if w != "" {
+			out = append(out, w)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// keys returns the distinct texts a message counts towards: the whole
+// message, plus its phrases when the message is short.
+func (a *Analyzer) keys(normalized string) []string {
+	words := strings.Fields(normalized)
+	if len(words) == 0 {
+		return nil
+	}
+	seen := map[string]struct{}{normalized: {}}
+	keys := []string{normalized}
+	if len(words) > maxScanWords {
+		return keys
+	}
+	for n := 1; n <= a.cfg.MaxPhraseWords && n <= len(words); n++ {
+		for i := 0; i+n <= len(words); i++ {
+			if n == 1 {
+				if _, stop := stopWords[words[i]]; stop {
+					continue
+				}
+			}
+			key := strings.Join(words[i:i+n], " ")
+			if _, ok := seen[key]; ok {
+				continue
+			}
+			seen[key] = struct{}{}
+			keys = append(keys, key)
+		}
+	}
+	return keys
+}
+
+func (a *Analyzer) kindOf(key string) string {
+	if len(strings.Fields(key)) > a.cfg.MaxPhraseWords {
+		return KindCopypasta
+	}
+	return KindPhrase
+}
+
+// expire drops observations older than the window. Callers hold a.mu.
+func (a *Analyzer) expire(now time.Time) {
+	cutoff := now.Add(-a.cfg.Window)
+	drop := 0
+	for drop < len(a.window) && !a.window[drop].at.After(cutoff) {
+		obs := a.window[drop]
+		for _, key := range obs.keys {
+			st := a.stats[key]
+			if st == nil {
+				continue
+			}
+			st.count--
+			if st.chatters[obs.chatter]--; st.chatters[obs.chatter] <= 0 {
+				delete(st.chatters, obs.chatter)
+			}
+			if st.platforms[obs.platform]--; st.platforms[obs.platform] <= 0 {
+				delete(st.platforms, obs.platform)
+			}
+			if st.count <= 0 {
+				delete(a.stats, key)
+			}
+		}
+		drop++
+	}
+	if drop > 0 {
+		a.window = append(a.window[:0], a.window[drop:]...)
+	}
+	for key, at := range a.announced {
+		if now.Sub(at) >= a.cfg.Cooldown {
+			delete(a.announced, key)
+		}
+	}
+}
+
+func (a *Analyzer) trending(st *stat) bool {
+	return st.count >= a.cfg.MinCount && len(st.chatters) >= a.cfg.MinChatters
+}
+
+func (a *Analyzer) snapshot(key string, st *stat) Trend {
+	platforms := make([]string, 0, len(st.platforms))
+	for p := range st.platforms {
+		platforms = append(platforms, p)
+	}
+	sort.Strings(platforms)
+	return Trend{
+		Kind:      st.kind,
+		Key:       key,
+		Text:      st.sample,
+		Count:     st.count,
+		Chatters:  len(st.chatters),
+		Platforms: platforms,
+		FirstSeen: st.firstSeen,
+		LastSeen:  st.lastSeen,
+	}
+}
+
+// Observe adds a message and returns the trends it pushed over the
+// threshold. A trend is reported again only after the cooldown.
+func (a *Analyzer) Observe(at time.Time, platform, chatter, text string) []Trend {
+	normalized := Normalize(text)
+	if normalized == "" {
+		return nil
+	}
+	platform = strings.ToLower(platform)
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expire(at)
+
+	keys := a.keys(normalized)
+	a.window = append(a.window, observation{at: at, platform: platform, chatter: chatter, keys: keys})
+	sample := strings.Join(strings.Fields(text), " ")
+
+	var fired []Trend
+	for _, key := range keys {
+		st := a.stats[key]
+		if st == nil {
+			st = &stat{
+				kind:      a.kindOf(key),
+				chatters:  make(map[string]int),
+				platforms: make(map[string]int),
+				firstSeen: at,
+			}
+			a.stats[key] = st
+		}
+		st.count++
+		st.chatters[chatter]++
+		st.platforms[platform]++
+		st.lastSeen = at
+		if key == normalized {
+			st.sample = sample
+		} else if st.sample == "" {
+			st.sample = key
+		}
+
+		if !a.trending(st) {
+			continue
+		}
+		if _, recent := a.announced[key]; recent {
+			continue
+		}
+		a.announced[key] = at
+		fired = append(fired, a.snapshot(key, st))
+	}
+	return fired
+}
+
+// Trending returns the current trends, most repeated first. Phrases inside a
+// longer trending phrase with the same count are left out.
+func (a *Analyzer) Trending(now time.Time, limit int) []Trend {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.expire(now)
+
+	out := []Trend{}
+	for key, st := range a.stats {
+		if a.trending(st) {
+			out = append(out, a.snapshot(key, st))
+		}
+	}
+	out = dropSubsumed(out)
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Count != out[j].Count {
+			return out[i].Count > out[j].Count
+		}
+		if len(out[i].Key) != len(out[j].Key) {
+			return len(out[i].Key) > len(out[j].Key)
+		}
+		return out[i].Key < out[j].Key
+	})
+	if limit > 0 && len(out) > limit {
+		out = out[:limit]
+	}
+	return out
+}
+
+// dropSubsumed removes trends that only appear as part of a longer trend:
+// when "lets go" is trending with the same count, "lets" adds nothing.
+func dropSubsumed(trends []Trend) []Trend {
+	out := trends[:0]
+	for i, t := range trends {
+		subsumed := false
+		for j, other := range trends {
+			if i == j || other.Count < t.Count || len(other.Key) <= len(t.Key) {
+				continue
+			}
+			if strings.Contains(" "+other.Key+" ", " "+t.Key+" ") {
+				subsumed = true
+				break
+			}
+		}
+		if !subsumed {
+			out = append(out, t)
+		}
+	}
+	return out
+}
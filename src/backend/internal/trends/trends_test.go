@@ -0,0 +1,75 @@
+package trends
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNormalize(t *testing.T) {
+	for in, want := range map[string]string{
+		"  W!!  ":             "w",
+		"LETS   GO\tboys":     "lets go boys",
+		"...":                 "",
+		"don't stop, please!": "don't stop please",
+	} {
+		if got := Normalize(in); got != want {
+			t.Fatalf("Normalize(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestObserveReportsPhraseOnceAcrossPlatforms(t *testing.T) {
+	a := New(Config{MinCount: 3, MinChatters: 3})
+	now := time.Unix(1_700_000_000, 0)
+
+	if got := a.Observe(now, "Twitch", "ann", "W"); len(got) != 0 {
+		t.Fatalf("unexpected trend after one message: %+v", got)
+	}
+	// One chatter repeating themselves is not a trend.
+	a.Observe(now, "Twitch", "ann", "W")
+	a.Observe(now, "Twitch", "ann", "w!")
+	if got := a.Trending(now, 0); len(got) != 0 {
+		t.Fatalf("expected no trend from a single chatter, got %+v", got)
+	}
+
+	a.Observe(now.Add(time.Second), "YouTube", "bea", "W W")
+	got := a.Observe(now.Add(2*time.Second), "twitch", "cat", "huge W")
+	if len(got) != 1 || got[0].Key != "w" || got[0].Kind != KindPhrase || got[0].Chatters != 3 || got[0].Count != 5 {
+		t.Fatalf("expected w to trend, got %+v", got)
+	}
+	if len(got[0].Platforms) != 2 || got[0].Platforms[0] != "twitch" || got[0].Platforms[1] != "youtube" {
+		t.Fatalf("unexpected platforms %v", got[0].Platforms)
+	}
+	if again := a.Observe(now.Add(3*time.Second), "twitch", "dan", "W"); len(again) != 0 {
+		t.Fatalf("expected cooldown to suppress repeat report, got %+v", again)
+	}
+
+	// Everything has left the window a minute later.
+	if got := a.Trending(now.Add(2*time.Minute), 0); len(got) != 0 {
+		t.Fatalf("expected trends to expire, got %+v", got)
+	}
+}
+
+func TestCopypastaAndSubsumedPhrases(t *testing.T) {
+	a := New(Config{MinCount: 3, MinChatters: 3})
+	now := time.Unix(1_700_000_000, 0)
+	pasta := "I used to be a streamer like you, then I took an arrow to the knee"
+	var fired []Trend
+	for i, chatter := range []string{"ann", "bea", "cat"} {
+		fired = append(fired, a.Observe(now.Add(time.Duration(i)*time.Second), "twitch", chatter, pasta+"!")...)
+	}
+	if len(fired) != 1 || fired[0].Kind != KindCopypasta || fired[0].Text != pasta+"!" {
+		t.Fatalf("expected a single copypasta, got %+v", fired)
+	}
+
+	for _, chatter := range []string{"ann", "bea", "cat"} {
+		a.Observe(now.Add(5*time.Second), "youtube", chatter, "lets go")
+	}
+	trending := a.Trending(now.Add(6*time.Second), 10)
+	if len(trending) != 2 || trending[0].Kind != KindCopypasta || trending[1].Key != "lets go" {
+		t.Fatalf("expected copypasta then lets go without its words, got %+v", trending)
+	}
+	if limited := a.Trending(now.Add(6*time.Second), 1); len(limited) != 1 {
+		t.Fatalf("expected limit to apply, got %d", len(limited))
+	}
+}
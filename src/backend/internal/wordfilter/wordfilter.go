@@ -0,0 +1,194 @@
+// Package wordfilter masks, drops or flags chat messages that match
+// configured word lists and regular expressions.
+package wordfilter
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Actions a rule can take. They match the runtime config values.
+const (
+	ActionMask = "mask"
+	ActionDrop = "drop"
+	ActionFlag = "flag"
+)
+
+// maskRune replaces every character of masked text.
+const maskRune = '*'
+
+// Rule is one filter entry. Words match whole words or phrases regardless of
+// case; Patterns are Go regular expressions matched as written. An empty
+// Platforms list applies the rule everywhere.
+type Rule struct {
+	Name      string
+	Words     []string
+	Patterns  []string
+	Platforms []string
+	Action    string
+}
+
+type compiledRule struct {
+	name      string
+	action    string
+	platforms map[string]struct{}
+	re        *regexp.Regexp
+}
+
+// Filter applies a set of rules. The zero value and nil filter match
+// nothing. A Filter is immutable and safe for concurrent use.
+type Filter struct {
+	rules []compiledRule
+}
+
+// Result is what the filter decided for one piece of text.
+type Result struct {
+	// Text is the input with every mask rule's matches masked.
+	Text string
+	// Drop is set when a drop rule matched.
+	Drop bool
+	// Flag is set when a flag rule matched.
+	Flag bool
+	// Rules names every rule that matched, in configuration order.
+	Rules []string
+}
+
+// Matched reports whether any rule matched.
+func (r Result) Matched() bool {
+	return len(r.Rules) > 0
+}
+
+// New compiles rules. Rules without words or patterns are skipped.
+func New(rules []Rule) (*Filter, error) {
+	f := &Filter{}
+	for _, rule := range rules {
+		action := strings.ToLower(strings.TrimSpace(rule.Action))
+		if action == "" {
+			action = ActionMask
+		}
+		switch action {
+		case ActionMask, ActionDrop, ActionFlag:
+		default:
+			return nil, fmt.Errorf("wordfilter: rule %q: unknown action %q", rule.Name, rule.Action)
+		}
+
+		var alternatives []string
+		for _, word := range rule.Words {
+			if expr := wordExpr(word); expr != "" {
+				alternatives = append(alternatives, expr)
+			}
+		}
+		for _, pattern := range rule.Patterns {
+			if strings.TrimSpace(pattern) == "" {
+				continue
+			}
+			if _, err := regexp.Compile(pattern); err != nil {
+				return nil, fmt.Errorf("wordfilter: rule %q: %w", rule.Name, err)
+			}
+			alternatives = append(alternatives, "(?:"+pattern+")")
+		}
+		if len(alternatives) == 0 {
+			continue
+		}
+		re, err := regexp.Compile(strings.Join(alternatives, "|"))
+		if err != nil {
+			return nil, fmt.Errorf("wordfilter: rule %q: %w", rule.Name, err)
+		}
+
+		var platforms map[string]struct{}
+		if len(rule.Platforms) > 0 {
+			platforms = make(map[string]struct{}, len(rule.Platforms))
+			for _, p := range rule.Platforms {
+				platforms[strings.ToLower(strings.TrimSpace(p))] = struct{}{}
+			}
+		}
+		f.rules = append(f.rules, compiledRule{name: rule.Name, action: action, platforms: platforms, re: re})
+	}
+	return f, nil
+}
+
+// wordExpr matches word case-insensitively, only at word boundaries where the
+// word itself starts or ends with a word character, so "ass" does not hit
+// "class" while "c++" still matches.
+func wordExpr(word string) string {
+	word = strings.TrimSpace(word)
+	if word == "" {
+		return ""
+	}
+	expr := "(?i:" + regexp.QuoteMeta(word) + ")"
+	if first, _ := utf8.DecodeRuneInString(word); isWordRune(first) {
+		expr = `\b` + expr
+	}
+	if last, _ := utf8.DecodeLastRuneInString(word); isWordRune(last) {
+		expr += `\b`
+	}
+	return expr
+}
+
+// isWordRune mirrors RE2's ASCII-only \b.
+func isWordRune(r rune) bool {
+	return r == '_' || (r >= '0' && r <= '9') || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// Empty reports whether the filter has no rules.
+func (f *Filter) Empty() bool {
+	return f == nil || len(f.rules) == 0
+}
+
+func (r compiledRule) appliesTo(platform string) bool {
+	if r.platforms == nil {
+		return true
+	}
+	_, ok := r.platforms[strings.ToLower(platform)]
+	return ok
+}
+
+// Check runs every rule scoped to platform against text without masking.
+func (f *Filter) Check(platform, text string) Result {
+	res := Result{Text: text}
+	if f.Empty() || text == "" {
+		return res
+	}
+	for _, rule := range f.rules {
+		if !rule.appliesTo(platform) || !rule.re.MatchString(text) {
+			continue
+		}
+		res.Rules = append(res.Rules, rule.name)
+		switch rule.action {
+		case ActionDrop:
+			res.Drop = true
+		case ActionFlag:
+			res.Flag = true
+		}
+	}
+	return res
+}
+
+// Apply is Check plus masking: matches of mask rules in text are replaced
+// with asterisks, one per character.
+func (f *Filter) Apply(platform, text string) Result {
+	res := f.Check(platform, text)
+	if !res.Matched() {
+		return res
+	}
+	res.Text = f.Mask(platform, text)
+	return res
+}
+
+// Mask replaces the matches of every mask rule scoped to platform.
+func (f *Filter) Mask(platform, text string) string {
+	if f.Empty() {
+		return text
+	}
+	for _, rule := range f.rules {
+		if rule.action != ActionMask || !rule.appliesTo(platform) {
+			continue
+		}
+		text = rule.re.ReplaceAllStringFunc(text, func(match string) string {
+			return strings.Repeat(string(maskRune), utf8.RuneCountInString(match))
+		})
+	}
+	return text
+}
@@ -0,0 +1,64 @@
+package wordfilter
+
+import "testing"
+
+func TestApplyMasksWholeWords(t *testing.T) {
+	f, err := New([]Rule{{Name: "slurs", Words: []string{"heck", "darn it", "c++"}}})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+	for in, want := range map[string]string{
+		"oh HECK no":           "oh **** no",
+		"heckin good":          "heckin good",
+		"Darn it, heck!":       "*******, ****!",
+		"i write c++ for fun":  "i write *** for fun",
+		"nothing to see here":  "nothing to see here",
+		"héck is not the word": "héck is not the word",
+	} {
+		res := f.Apply("twitch", in)
+		if res.Text != want {
+			t.Fatalf("Apply(%q) = %q, want %q", in, res.Text, want)
+		}
+		if res.Drop || res.Flag {
+			t.Fatalf("mask rule should not drop or flag: %+v", res)
+		}
+	}
+}
+
+func TestApplyActionsAndPlatformScope(t *testing.T) {
+	f, err := New([]Rule{
+		{Name: "links", Patterns: []string{`(?i)https?://\S+`}, Platforms: []string{"YouTube"}, Action: "drop"},
+		{Name: "spoilers", Words: []string{"dies"}, Action: "flag"},
+		{Name: "words", Words: []string{"heck"}},
+	})
+	if err != nil {
+		t.Fatalf("New returned error: %v", err)
+	}
+
+	res := f.Apply("youtube", "free stuff at https://spam.example")
+	if !res.Drop || len(res.Rules) != 1 || res.Rules[0] != "links" {
+		t.Fatalf("expected youtube link to drop, got %+v", res)
+	}
+	if res := f.Apply("twitch", "free stuff at https://spam.example"); res.Matched() {
+		t.Fatalf("expected link rule to skip twitch, got %+v", res)
+	}
+
+	res = f.Apply("twitch", "heck, the hero dies")
+	if !res.Flag || res.Drop || res.Text != "****, the hero dies" || len(res.Rules) != 2 {
+		t.Fatalf("expected masked and flagged message, got %+v", res)
+	}
+
+	var empty *Filter
+	if res := empty.Apply("twitch", "heck"); res.Matched() || res.Text != "heck" {
+		t.Fatalf("nil filter should pass text through, got %+v", res)
+	}
+}
+
+func TestNewRejectsBadRules(t *testing.T) {
+	if _, err := New([]Rule{{Name: "bad", Patterns: []string{"("}}}); err == nil {
+		t.Fatalf("expected error for an invalid pattern")
+	}
+	if _, err := New([]Rule{{Name: "bad", Words: []string{"x"}, Action: "ban"}}); err == nil {
+		t.Fatalf("expected error for an unknown action")
+	}
+}
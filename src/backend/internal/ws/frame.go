@@ -2,6 +2,8 @@ package ws
 
 // ChatPayload represents the JSON payload delivered over WebSocket chat frames.
 type ChatPayload struct {
+	Cursor        int64  `json:"cursor,omitempty"`
+	ID            string `json:"id,omitempty"`
 	Author        string `json:"author"`
 	Message       string `json:"message"`
 	Fragments     []any  `json:"fragments"`
@@ -13,4 +15,17 @@ type ChatPayload struct {
 	SourceURL     string `json:"source_url,omitempty"`
 	Colour        string `json:"colour"`
 	UsernameColor string `json:"username_color,omitempty"`
+	// OriginalColour is the username colour before it was adjusted for a
+	// dark background.
+	OriginalColour string  `json:"original_colour,omitempty"`
+	Viewer         *Viewer `json:"viewer,omitempty"`
+	Bot            bool    `json:"bot,omitempty"`
+}
+
+// Viewer identifies the person behind a message whose accounts are linked
+// across platforms.
+type Viewer struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name,omitempty"`
+	Platforms []string `json:"platforms"`
 }
@@ -0,0 +1,128 @@
+package ws
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+)
+
+// MsgpackFromJSON re-encodes a JSON document as MessagePack. Object keys are
+// written in sorted order so identical payloads produce identical frames.
+func MsgpackFromJSON(data []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return appendMsgpack(make([]byte, 0, len(data)), v)
+}
+
+func appendMsgpack(dst []byte, v any) ([]byte, error) {
+	switch val := v.(type) {
+	case nil:
+		return append(dst, 0xc0), nil
+	case bool:
+		if val {
+			return append(dst, 0xc3), nil
+		}
+		return append(dst, 0xc2), nil
+	case json.Number:
+		if i, err := val.Int64(); err == nil {
+			return appendMsgpackInt(dst, i), nil
+		}
+		f, err := val.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return appendMsgpackFloat(dst, f), nil
+	case float64:
+		return appendMsgpackFloat(dst, val), nil
+	case string:
+		return appendMsgpackString(dst, val), nil
+	case []any:
+		dst = appendMsgpackHeader(dst, len(val), 0x90, 16, 0xdc, 0xdd)
+		var err error
+		for _, item := range val {
+			if dst, err = appendMsgpack(dst, item); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		dst = appendMsgpackHeader(dst, len(val), 0x80, 16, 0xde, 0xdf)
+		var err error
+		for _, k := range keys {
+			dst = appendMsgpackString(dst, k)
+			if dst, err = appendMsgpack(dst, val[k]); err != nil {
+				return nil, err
+			}
+		}
+		return dst, nil
+	default:
+		return nil, fmt.Errorf("msgpack: unsupported type %T", v)
+	}
+}
+
+func appendMsgpackInt(dst []byte, i int64) []byte {
+	switch {
+	case i >= 0 && i <= math.MaxInt8:
+		return append(dst, byte(i))
+	case i < 0 && i >= -32:
+		return append(dst, byte(int8(i)))
+	case i >= 0 && i <= math.MaxUint8:
+		return append(dst, 0xcc, byte(i))
+	case i >= 0 && i <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, 0xcd), uint16(i))
+	case i >= 0 && i <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(dst, 0xce), uint32(i))
+	case i >= math.MinInt8 && i < 0:
+		return append(dst, 0xd0, byte(int8(i)))
+	case i >= math.MinInt16 && i < 0:
+		return binary.BigEndian.AppendUint16(append(dst, 0xd1), uint16(int16(i)))
+	case i >= math.MinInt32 && i < 0:
+		return binary.BigEndian.AppendUint32(append(dst, 0xd2), uint32(int32(i)))
+	default:
+		return binary.BigEndian.AppendUint64(append(dst, 0xd3), uint64(i))
+	}
+}
+
+func appendMsgpackFloat(dst []byte, f float64) []byte {
+	return binary.BigEndian.AppendUint64(append(dst, 0xcb), math.Float64bits(f))
+}
+
+func appendMsgpackString(dst []byte, s string) []byte {
+	n := len(s)
+	switch {
+	case n < 32:
+		dst = append(dst, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		dst = append(dst, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		dst = binary.BigEndian.AppendUint16(append(dst, 0xda), uint16(n))
+	default:
+		dst = binary.BigEndian.AppendUint32(append(dst, 0xdb), uint32(n))
+	}
+	return append(dst, s...)
+}
+
+// appendMsgpackHeader writes an array or map length using the fix, 16-bit, or
+// 32-bit form.
+func appendMsgpackHeader(dst []byte, n int, fix byte, fixMax int, code16, code32 byte) []byte {
+	switch {
+	case n < fixMax:
+		return append(dst, fix|byte(n))
+	case n <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(dst, code16), uint16(n))
+	default:
+		return binary.BigEndian.AppendUint32(append(dst, code32), uint32(n))
+	}
+}
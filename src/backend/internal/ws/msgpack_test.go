@@ -0,0 +1,48 @@
+package ws
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMsgpackFromJSON(t *testing.T) {
+	cases := []struct {
+		name string
+		in   string
+		want []byte
+	}{
+		{"nil", `null`, []byte{0xc0}},
+		{"bools", `[true,false]`, []byte{0x92, 0xc3, 0xc2}},
+		{"fixint", `[0,127,-1,-32]`, []byte{0x94, 0x00, 0x7f, 0xff, 0xe0}},
+		{"ints", `[200,-100,65536]`, []byte{0x93, 0xcc, 0xc8, 0xd0, 0x9c, 0xce, 0x00, 0x01, 0x00, 0x00}},
+		{"float", `1.5`, []byte{0xcb, 0x3f, 0xf8, 0, 0, 0, 0, 0, 0}},
+		{"sorted map", `{"b":1,"a":"x"}`, []byte{0x82, 0xa1, 'a', 0xa1, 'x', 0xa1, 'b', 0x01}},
+	}
+	for _, tc := range cases {
+		got, err := MsgpackFromJSON([]byte(tc.in))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if !bytes.Equal(got, tc.want) {
+			t.Fatalf("%s: expected % x, got % x", tc.name, tc.want, got)
+		}
+	}
+}
+
+func TestMsgpackFromJSONLongString(t *testing.T) {
+	s := strings.Repeat("x", 40)
+	got, err := MsgpackFromJSON([]byte(`"` + s + `"`))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got[0] != 0xd9 || got[1] != 40 || string(got[2:]) != s {
+		t.Fatalf("expected str8 encoding, got % x", got[:2])
+	}
+}
+
+func TestMsgpackFromJSONInvalid(t *testing.T) {
+	if _, err := MsgpackFromJSON([]byte(`{`)); err == nil {
+		t.Fatalf("expected error for invalid json")
+	}
+}
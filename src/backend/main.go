@@ -17,6 +17,7 @@ import (
 	"github.com/gorilla/handlers"
 	"github.com/gorilla/mux"
 
+	"github.com/hpwn/EloraChat/src/backend/internal/broadcast"
 	"github.com/hpwn/EloraChat/src/backend/internal/configreporter"
 	httpapi "github.com/hpwn/EloraChat/src/backend/internal/http"
 	"github.com/hpwn/EloraChat/src/backend/internal/ingest"
@@ -91,6 +92,8 @@ func main() {
 	log.Printf("storage: using sqlite store (mode=%s)", sqliteCfg.Mode)
 
 	routes.InitRoutes(store)
+	routes.StartThirdPartyEmoteRefresher()
+	routes.StartStatsFlusher()
 	runtimeCfg := routes.EffectiveRuntimeConfig()
 	go func() {
 		// Retry startup gnasty sync after services settle to reduce cold-start race failures.
@@ -111,6 +114,15 @@ func main() {
 	routes.SetupAuthRoutes(r)
 	routes.SetupSendRoutes(r)
 	routes.SetupMessageRoutes(r)
+	routes.SetupDeadLetterRoutes(r)
+	routes.SetupPlatformEventRoutes(r)
+	routes.SetupDonationRoutes(r)
+	routes.SetupOverlayTokenRoutes(r)
+	routes.SetupWSAdminRoutes(r)
+	routes.SetupApprovalRoutes(r)
+	routes.SetupViewerRoutes(r)
+	routes.SetupEmoteRoutes(r)
+	routes.SetupStatsRoutes(r)
 	routes.SetupAlertRoutes(r)
 	routes.SetupDevRoutes(r)
 	routes.SetupDebugRoutes(r)
@@ -121,6 +133,18 @@ func main() {
 	httpapi.RegisterHealth(rootMux, store)
 	rootMux.Handle("/", r)
 
+	if peer, err := buildPeerBroadcaster(); err != nil {
+		log.Printf("broadcast: peer fan-out disabled: %v", err)
+	} else if peer != nil {
+		peer.Start(baseCtx)
+		routes.SetBroadcaster(peer)
+		rootMux.Handle(broadcast.PeerPath, peer)
+		rootMux.Handle("/api/broadcast/peers", routes.SessionMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			json.NewEncoder(w).Encode(map[string]any{"peers": peer.Status()})
+		})))
+	}
+
 	allowedOrigins := append([]string(nil), runtimeCfg.AllowedOrigins...)
 	routes.SetAllowedOrigins(allowedOrigins)
 	corsHandler := handlers.CORS(
@@ -153,11 +177,31 @@ func main() {
 	})
 	defer routes.RegisterTailerConfigApplier(nil)
 
-	ingestEnv := ingest.FromEnv()
-	ingestEnv.GnastyBin = runtimeCfg.Ingest.GnastyBin
-	ingestEnv.GnastyArgs = runtimeCfg.Ingest.GnastyArgs
-	ingestEnv.BackoffBaseMS = runtimeCfg.Ingest.BackoffBaseMS
-	ingestEnv.BackoffMaxMS = runtimeCfg.Ingest.BackoffMaxMS
+	ingestEnv := buildIngestEnv(ingest.FromEnv(), runtimeCfg.Ingest)
+	ingestMgr := ingest.NewManager(baseCtx, ingest.StoreInsert(store), deadLetterRecorder(store), log.Default())
+	if err := ingestMgr.StartInitial(ingestEnv, ingestSourceURLs(runtimeCfg)); err != nil {
+		log.Printf("ingest: error: %v", err)
+	}
+	defer ingestMgr.Stop()
+
+	routes.RegisterIngestConfigApplier(func(cfg runtimeconfig.Config) error {
+		return ingestMgr.Apply(buildIngestEnv(ingestEnv, cfg.Ingest), ingestSourceURLs(cfg))
+	})
+	defer routes.RegisterIngestConfigApplier(nil)
+
+	ingestPush, err := ingestEnv.BuildPush(ingest.StoreInsert(store), deadLetterRecorder(store), log.Default())
+	if err != nil {
+		log.Printf("ingest: push endpoint disabled: %v", err)
+	} else if ingestPush != nil {
+		if err := ingestPush.ListenUnix(baseCtx); err != nil {
+			log.Printf("ingest: push socket disabled: %v", err)
+		}
+	}
+	httpapi.RegisterIngest(rootMux, ingestPush)
+	httpapi.RegisterIngestStatus(rootMux, func() httpapi.IngestStatus {
+		liveIngest, _ := ingestMgr.SnapshotEnv()
+		return httpapi.IngestStatus{Driver: liveIngest.Driver, Sources: ingestMgr.Status()}
+	})
 	twitchClientID := strings.TrimSpace(os.Getenv("TWITCH_OAUTH_CLIENT_ID"))
 	twitchRedirectURL := strings.TrimSpace(os.Getenv("TWITCH_OAUTH_REDIRECT_URL"))
 	twitchWriteGnastyTokens := twitchGnastyWritesEnabled()
@@ -200,11 +244,19 @@ func main() {
 		snapshot.Tailer = configreporter.TailerSnapshot{
 			Enabled:        liveTailer.Enabled,
 			IntervalMS:     int(liveTailer.Interval / time.Millisecond),
+			MinIntervalMS:  int(liveTailer.MinInterval / time.Millisecond),
+			MaxIntervalMS:  int(liveTailer.MaxInterval / time.Millisecond),
 			Batch:          liveTailer.Batch,
+			BatchCeiling:   liveTailer.BatchCeiling,
 			MaxLagMS:       int(liveTailer.MaxLag / time.Millisecond),
 			PersistOffsets: liveTailer.PersistOffsets,
 			OffsetPath:     liveTailer.OffsetPath,
 		}
+		liveIngest, _ := ingestMgr.SnapshotEnv()
+		if liveIngest.Driver != "" {
+			snapshot.Ingest.Driver = liveIngest.Driver
+		}
+		snapshot.Ingest.Sources = ingestMgr.Status()
 		gnastySync := routes.GnastySyncStatusSnapshot()
 		snapshot.GnastySync = configreporter.GnastySyncSnapshot{
 			LastAttemptAt: gnastySync.LastAttemptAt,
@@ -225,7 +277,14 @@ func main() {
 	fs := http.FileServer(http.Dir("public"))
 	r.PathPrefix("/").Handler(http.StripPrefix("/", fs))
 
-	log.Printf("ingest: selected driver=%q (harvested via gnasty-chat)", ingestEnv.Driver)
+	switch ingestEnv.Driver {
+	case ingest.DriverTwitchIRC:
+		log.Printf("ingest: selected driver=%q (in-process Twitch IRC)", ingestEnv.Driver)
+	case ingest.DriverReplay:
+		log.Printf("ingest: selected driver=%q (replaying %s)", ingestEnv.Driver, ingestEnv.ReplayPath)
+	default:
+		log.Printf("ingest: selected driver=%q (harvested via gnasty-chat)", ingestEnv.Driver)
+	}
 
 	// Create server
 	listenAddr := net.JoinHostPort(httpAddr, httpPort)
@@ -317,11 +376,79 @@ func parseCSV(raw string) []string {
 	return out
 }
 
+// buildIngestEnv overlays the persisted runtime ingest settings on the env defaults.
+func buildIngestEnv(base ingest.Env, src runtimeconfig.IngestConfig) ingest.Env {
+	env := base
+	env.GnastyBin = src.GnastyBin
+	env.GnastyArgs = append([]string(nil), src.GnastyArgs...)
+	env.BackoffBaseMS = src.BackoffBaseMS
+	env.BackoffMaxMS = src.BackoffMaxMS
+	return env
+}
+
+// ingestSourceURLs returns the per-worker source URLs for the configured channels.
+func ingestSourceURLs(cfg runtimeconfig.Config) []string {
+	sources := make([]string, 0, 2)
+	if channel := strings.TrimSpace(cfg.TwitchChannel); channel != "" {
+		sources = append(sources, "https://www.twitch.tv/"+channel)
+	}
+	if yt := strings.TrimSpace(cfg.YouTubeSourceURL); yt != "" {
+		sources = append(sources, yt)
+	}
+	return sources
+}
+
+// deadLetterRecorder persists rejected ingest lines so they can be retried later.
+// Writes use a detached context so lines rejected during shutdown are still kept.
+func deadLetterRecorder(store *sqlite.Store) ingest.RejectFn {
+	return func(ctx context.Context, rej ingest.Rejection) {
+		writeCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+		defer cancel()
+		err := store.InsertDeadLetter(writeCtx, &sqlite.DeadLetter{
+			Driver: rej.Driver,
+			Source: rej.Source,
+			Reason: rej.Reason,
+			Error:  rej.Error,
+			Line:   string(rej.Line),
+		})
+		if err != nil {
+			log.Printf("ingest[%s]: dead letter write failed: %v", rej.Driver, err)
+		}
+	}
+}
+
+// buildPeerBroadcaster returns a peer broadcaster when ELORA_BROADCAST_PEERS
+// lists other instances, or nil to keep in-process fan-out.
+func buildPeerBroadcaster() (*broadcast.Peer, error) {
+	peers := parseCSV(os.Getenv("ELORA_BROADCAST_PEERS"))
+	if len(peers) == 0 {
+		return nil, nil
+	}
+	instanceID := strings.TrimSpace(os.Getenv("ELORA_BROADCAST_INSTANCE_ID"))
+	if instanceID == "" {
+		instanceID, _ = os.Hostname()
+	}
+	peer, err := broadcast.NewPeer(routes.NewLocalBroadcaster(), broadcast.PeerConfig{
+		InstanceID: instanceID,
+		Peers:      peers,
+		Secret:     strings.TrimSpace(os.Getenv("ELORA_BROADCAST_SECRET")),
+		Logger:     log.Default(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	log.Printf("broadcast: peer fan-out enabled (instance=%s peers=%s)", instanceID, strings.Join(peers, ","))
+	return peer, nil
+}
+
 func buildTailerConfig(src runtimeconfig.TailerConfig, sqlitePath string) tailer.Config {
 	cfg := tailer.Config{
 		Enabled:        src.Enabled,
 		Interval:       time.Duration(src.PollIntervalMS) * time.Millisecond,
+		MinInterval:    time.Duration(src.MinPollIntervalMS) * time.Millisecond,
+		MaxInterval:    time.Duration(src.MaxPollIntervalMS) * time.Millisecond,
 		Batch:          src.MaxBatch,
+		BatchCeiling:   src.BatchCeiling,
 		MaxLag:         time.Duration(src.MaxLagMS) * time.Millisecond,
 		PersistOffsets: src.PersistOffsets,
 		OffsetPath:     src.OffsetPath,
@@ -0,0 +1,528 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// Chat streams serve one of two feeds. The raw feed carries every message as
+// it arrives; the curated feed carries only what moderators approved while
+// approval mode is on, and everything while it is off.
+const (
+	feedRaw     = "raw"
+	feedCurated = "curated"
+)
+
+const (
+	// approvalQueueMax bounds the pending queue; the oldest held message is
+	// rejected when it overflows.
+	approvalQueueMax = 500
+	// curatedRecentMax is how many curated deliveries are kept for replay.
+	curatedRecentMax = 100
+)
+
+// Frame names used by approval mode. held and approved wrap a message payload
+// and are unwrapped for the raw and curated feed respectively; the others only
+// inform moderators.
+const (
+	frameHeld         = "held"
+	frameApproved     = "approved"
+	frameRejected     = "rejected"
+	frameApprovalMode = "approval_mode"
+)
+
+// feedFrame is the bus payload for moderation traffic. Frame is declared first
+// so isFramePayload recognizes it.
+type feedFrame struct {
+	Frame   string          `json:"frame"`
+	ID      string          `json:"id,omitempty"`
+	HeldAt  string          `json:"held_at,omitempty"`
+	Reason  string          `json:"reason,omitempty"`
+	Enabled *bool           `json:"enabled,omitempty"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// heldMessage is one payload waiting for a moderator.
+type heldMessage struct {
+	ID      string          `json:"id"`
+	HeldAt  time.Time       `json:"held_at"`
+	Kind    string          `json:"kind"`
+	Source  string          `json:"source"`
+	User    string          `json:"user"`
+	Text    string          `json:"text"`
+	Reason  string          `json:"reason,omitempty"`
+	Payload json.RawMessage `json:"payload"`
+
+	// release runs once the message reaches the curated feed, so held text
+	// only counts towards trends after a moderator approves it.
+	release func()
+}
+
+type curatedEntry struct {
+	seq     int64
+	payload []byte
+}
+
+// approvalQueue holds messages while approval mode is on and remembers recent
+// curated deliveries for replay. It lives in memory on the instance that
+// ingested the messages.
+type approvalQueue struct {
+	mu      sync.Mutex
+	mode    *bool
+	pending []*heldMessage
+	recent  []curatedEntry
+	seq     int64
+}
+
+var approvals = &approvalQueue{}
+
+// enabled reports whether approval mode is on. Until a moderator changes it,
+// the mode comes from ELORA_APPROVAL_MODE.
+func (q *approvalQueue) enabled() bool {
+	q.mu.Lock()
+	mode := q.mode
+	q.mu.Unlock()
+	if mode != nil {
+		return *mode
+	}
+	return isTruthy(os.Getenv("ELORA_APPROVAL_MODE"))
+}
+
+func (q *approvalQueue) setEnabled(on bool) {
+	q.mu.Lock()
+	q.mode = &on
+	q.mu.Unlock()
+}
+
+// hold queues a payload and returns it with any message evicted to make room.
+// reason says why it was held when approval mode alone did not hold it;
+// release, if set, runs when the message is approved.
+func (q *approvalQueue) hold(payload []byte, reason string, release func()) (*heldMessage, *heldMessage) {
+	var meta struct {
+		Frame   string `json:"frame"`
+		Source  string `json:"source"`
+		Author  string `json:"author"`
+		User    string `json:"user"`
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(payload, &meta)
+	item := &heldMessage{
+		ID:      uuid.NewString(),
+		HeldAt:  time.Now().UTC(),
+		Kind:    "chat",
+		Source:  meta.Source,
+		User:    meta.Author,
+		Text:    meta.Message,
+		Reason:  reason,
+		Payload: json.RawMessage(payload),
+		release: release,
+	}
+	if meta.Frame != "" {
+		item.Kind = meta.Frame
+		item.User = meta.User
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var evicted *heldMessage
+	if len(q.pending) >= approvalQueueMax {
+		evicted = q.pending[0]
+		q.pending = q.pending[1:]
+	}
+	q.pending = append(q.pending, item)
+	return item, evicted
+}
+
+// take removes a pending message so it can be approved or rejected.
+func (q *approvalQueue) take(id string) (*heldMessage, bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for i, item := range q.pending {
+		if item.ID == id {
+			q.pending = append(q.pending[:i], q.pending[i+1:]...)
+			return item, true
+		}
+	}
+	return nil, false
+}
+
+func (q *approvalQueue) list() []heldMessage {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]heldMessage, 0, len(q.pending))
+	for _, item := range q.pending {
+		out = append(out, *item)
+	}
+	return out
+}
+
+// remember records a payload delivered to the curated feed.
+func (q *approvalQueue) remember(payload []byte) {
+	sanitized, err := sanitizeStreamPayload(payload)
+	if err != nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.seq++
+	if len(q.recent) >= curatedRecentMax {
+		q.recent = q.recent[1:]
+	}
+	q.recent = append(q.recent, curatedEntry{seq: q.seq, payload: sanitized})
+}
+
+// currentSeq returns the sequence of the latest curated delivery.
+func (q *approvalQueue) currentSeq() int64 {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.seq
+}
+
+// curatedAfter returns remembered curated payloads delivered after seq, in
+// delivery order, skipping those that do not match sourceFilter.
+func (q *approvalQueue) curatedAfter(seq int64, sourceFilter string) [][]byte {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([][]byte, 0, len(q.recent))
+	for _, entry := range q.recent {
+		if entry.seq > seq && !shouldSkipSource(entry.payload, sourceFilter) {
+			out = append(out, entry.payload)
+		}
+	}
+	return out
+}
+
+func (q *approvalQueue) reset() {
+	q.mu.Lock()
+	q.mode = nil
+	q.pending = nil
+	q.recent = nil
+	q.mu.Unlock()
+}
+
+// publishMessagePayload sends a message payload from the tailer. In approval
+// mode it is held: the raw feed still sees it, the curated feed waits for a
+// moderator. release, if set, runs once the payload reaches the curated feed.
+func publishMessagePayload(payload []byte, release func()) {
+	if !approvals.enabled() {
+		approvals.remember(payload)
+		broadcastChatMessage(payload)
+		if release != nil {
+			release()
+		}
+		return
+	}
+	holdMessagePayload(payload, "", release)
+}
+
+// holdMessagePayload queues a payload for moderators. The raw feed shows it
+// right away; the curated feed waits for approval.
+func holdMessagePayload(payload []byte, reason string, release func()) {
+	item, evicted := approvals.hold(payload, reason, release)
+	if evicted != nil {
+		log.Printf("approval: queue full, rejected %s", evicted.ID)
+		publishFeedFrame(feedFrame{Frame: frameRejected, ID: evicted.ID, Reason: "queue full"})
+	}
+	publishFeedFrame(feedFrame{
+		Frame:   frameHeld,
+		ID:      item.ID,
+		HeldAt:  item.HeldAt.Format(time.RFC3339Nano),
+		Reason:  item.Reason,
+		Payload: item.Payload,
+	})
+}
+
+func publishFeedFrame(frame feedFrame) {
+	payload, err := json.Marshal(frame)
+	if err != nil {
+		log.Printf("approval: marshal %s frame: %v", frame.Frame, err)
+		return
+	}
+	broadcastChatMessage(payload)
+}
+
+// approveHeld releases a held message to the curated feed.
+func approveHeld(id string) (*heldMessage, bool) {
+	item, ok := approvals.take(id)
+	if !ok {
+		return nil, false
+	}
+	approvals.remember(item.Payload)
+	publishFeedFrame(feedFrame{Frame: frameApproved, ID: item.ID, Payload: item.Payload})
+	if item.release != nil {
+		item.release()
+	}
+	return item, true
+}
+
+// rejectHeld drops a held message; the curated feed never sees it.
+func rejectHeld(id string) (*heldMessage, bool) {
+	item, ok := approvals.take(id)
+	if !ok {
+		return nil, false
+	}
+	publishFeedFrame(feedFrame{Frame: frameRejected, ID: item.ID})
+	return item, true
+}
+
+func setApprovalMode(on bool) {
+	approvals.setEnabled(on)
+	publishFeedFrame(feedFrame{Frame: frameApprovalMode, Enabled: &on})
+}
+
+// chatFeedFromQuery reads ?feed=, defaulting to the raw feed.
+func chatFeedFromQuery(query url.Values) (string, error) {
+	switch feed := strings.ToLower(strings.TrimSpace(query.Get("feed"))); feed {
+	case "", feedRaw:
+		return feedRaw, nil
+	case feedCurated:
+		return feedCurated, nil
+	default:
+		return "", fmt.Errorf("unknown feed %q", feed)
+	}
+}
+
+// feedPayload picks what a bus payload means for a feed subscriber: held
+// messages are unwrapped for the raw feed, approved ones for the curated feed,
+// and moderator-only frames are dropped.
+func feedPayload(feed string, payload []byte) ([]byte, bool) {
+	if !isFramePayload(payload) {
+		return payload, true
+	}
+	name := framePayloadName(payload)
+	switch name {
+	case frameHeld, frameApproved:
+		if (name == frameHeld) != (feed == feedRaw) {
+			return nil, false
+		}
+		var frame feedFrame
+		if err := json.Unmarshal(payload, &frame); err != nil || len(frame.Payload) == 0 {
+			return nil, false
+		}
+		return frame.Payload, true
+	case frameRejected, frameApprovalMode:
+		return nil, false
+	}
+	return payload, true
+}
+
+func isModerationFrame(payload []byte) bool {
+	if !isFramePayload(payload) {
+		return false
+	}
+	switch framePayloadName(payload) {
+	case frameHeld, frameApproved, frameRejected, frameApprovalMode:
+		return true
+	}
+	return false
+}
+
+// SetupApprovalRoutes registers the moderator endpoints for approval mode.
+// All of them require a logged-in session.
+func SetupApprovalRoutes(r *mux.Router) {
+	protected := r.PathPrefix("").Subrouter()
+	protected.Use(SessionMiddleware)
+	protected.HandleFunc("/api/moderation/queue", handleListHeld).Methods(http.MethodGet)
+	protected.HandleFunc("/api/moderation/queue/{id}/approve", handleApproveHeld).Methods(http.MethodPost)
+	protected.HandleFunc("/api/moderation/queue/{id}/reject", handleRejectHeld).Methods(http.MethodPost)
+	protected.HandleFunc("/api/moderation/mode", handleGetApprovalMode).Methods(http.MethodGet)
+	protected.HandleFunc("/api/moderation/mode", handlePutApprovalMode).Methods(http.MethodPut)
+	protected.HandleFunc("/ws/moderation", StreamModeration).Methods(http.MethodGet)
+}
+
+func handleListHeld(w http.ResponseWriter, r *http.Request) {
+	items := approvals.list()
+	writeJSON(w, map[string]any{"enabled": approvals.enabled(), "count": len(items), "items": items})
+}
+
+func handleApproveHeld(w http.ResponseWriter, r *http.Request) {
+	item, ok := approveHeld(mux.Vars(r)["id"])
+	if !ok {
+		http.Error(w, "held message not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]any{"status": frameApproved, "item": item})
+}
+
+func handleRejectHeld(w http.ResponseWriter, r *http.Request) {
+	item, ok := rejectHeld(mux.Vars(r)["id"])
+	if !ok {
+		http.Error(w, "held message not found", http.StatusNotFound)
+		return
+	}
+	writeJSON(w, map[string]any{"status": frameRejected, "item": item})
+}
+
+func handleGetApprovalMode(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, map[string]any{"enabled": approvals.enabled()})
+}
+
+func handlePutApprovalMode(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Enabled *bool `json:"enabled"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if body.Enabled == nil {
+		http.Error(w, "enabled is required", http.StatusBadRequest)
+		return
+	}
+	setApprovalMode(*body.Enabled)
+	log.Printf("approval: mode set to %t", *body.Enabled)
+	writeJSON(w, map[string]any{"enabled": *body.Enabled})
+}
+
+// modCommand is a moderator→server message on /ws/moderation.
+type modCommand struct {
+	Type    string `json:"type"`
+	ID      string `json:"id,omitempty"`
+	Item    string `json:"item,omitempty"`
+	Enabled *bool  `json:"enabled,omitempty"`
+}
+
+func handleModCommand(data []byte) wsReply {
+	var cmd modCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return wsReply{Type: "error", Data: map[string]any{"message": "invalid command json"}}
+	}
+	cmd.Type = strings.ToLower(strings.TrimSpace(cmd.Type))
+	modError := func(message string) wsReply {
+		return wsReply{Type: "error", ID: cmd.ID, Data: map[string]any{"command": cmd.Type, "message": message}}
+	}
+	switch cmd.Type {
+	case "approve", "reject":
+		resolve := approveHeld
+		if cmd.Type == "reject" {
+			resolve = rejectHeld
+		}
+		if _, ok := resolve(cmd.Item); !ok {
+			return modError("held message not found")
+		}
+		return wsReply{Type: "resolved", ID: cmd.ID, Data: map[string]any{"item": cmd.Item, "action": cmd.Type}}
+	case "set_mode":
+		if cmd.Enabled == nil {
+			return modError("enabled is required")
+		}
+		setApprovalMode(*cmd.Enabled)
+		return wsReply{Type: "mode", ID: cmd.ID, Data: map[string]any{"enabled": *cmd.Enabled}}
+	case "queue":
+		return queueReply(cmd.ID)
+	case "":
+		return modError("command type required")
+	default:
+		return modError(fmt.Sprintf("unknown command %q", cmd.Type))
+	}
+}
+
+func queueReply(id string) wsReply {
+	items := approvals.list()
+	return wsReply{Type: "queue", ID: id, Data: map[string]any{"enabled": approvals.enabled(), "items": items}}
+}
+
+// StreamModeration is the moderator WebSocket. It opens with the pending
+// queue, then streams held, approved, rejected and approval_mode frames, and
+// accepts approve, reject, set_mode and queue commands.
+func StreamModeration(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Print("ws: moderation upgrade error:", err)
+		return
+	}
+	defer conn.Close()
+
+	cfg := activeWebsocketConfig
+	encoding := wsEncodingFor(conn.Subprotocol())
+	if cfg.maxBytes > 0 {
+		conn.SetReadLimit(cfg.maxBytes)
+	}
+
+	messageChan := addSubscriber()
+	defer removeSubscriber(messageChan)
+
+	commands := make(chan []byte, 16)
+	done := make(chan struct{})
+	writerDone := make(chan struct{})
+
+	go func() {
+		defer close(writerDone)
+		ticker := time.NewTicker(cfg.pingInterval)
+		defer ticker.Stop()
+		writeReply := func(reply wsReply) error {
+			frameType, frame, err := encoding.replyFrame(reply)
+			if err != nil {
+				log.Println("ws: encode error:", err)
+				return nil
+			}
+			return writeWSMessage(conn, frameType, frame, cfg.writeDeadline)
+		}
+		if err := writeReply(queueReply("")); err != nil {
+			return
+		}
+		for {
+			select {
+			case m, ok := <-messageChan:
+				if !ok {
+					return
+				}
+				if !isModerationFrame(m) {
+					continue
+				}
+				frameType, frame, err := encoding.frame(m)
+				if err != nil {
+					log.Println("ws: encode error:", err)
+					continue
+				}
+				if err := writeWSMessage(conn, frameType, frame, cfg.writeDeadline); err != nil {
+					log.Println("ws: moderation write error:", err)
+					return
+				}
+			case raw := <-commands:
+				if err := writeReply(handleModCommand(raw)); err != nil {
+					log.Println("ws: moderation write error:", err)
+					return
+				}
+			case <-ticker.C:
+				deadline := time.Now().Add(cfg.writeDeadline)
+				if cfg.writeDeadline <= 0 {
+					deadline = time.Now().Add(5 * time.Second)
+				}
+				if err := conn.WriteControl(websocket.PingMessage, []byte{}, deadline); err != nil {
+					return
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			close(done)
+			break
+		}
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+		select {
+		case commands <- data:
+		case <-writerDone:
+		}
+	}
+	<-writerDone
+}
@@ -0,0 +1,191 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func approvalTestRow(id, text string) storage.Message {
+	return storage.Message{
+		ID:        id,
+		Timestamp: time.Now().UTC(),
+		Username:  "viewer",
+		Platform:  "Twitch",
+		Text:      text,
+		RawJSON:   "{}",
+	}
+}
+
+// chatFrameText returns the message text of an enveloped chat frame.
+func chatFrameText(t *testing.T, frame wsTestFrame) string {
+	t.Helper()
+	if frame.Type != "chat" {
+		t.Fatalf("expected chat frame, got %+v", frame)
+	}
+	var raw string
+	if err := json.Unmarshal(frame.Data, &raw); err != nil {
+		t.Fatalf("decode chat data: %v", err)
+	}
+	var msg struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		t.Fatalf("decode chat payload: %v", err)
+	}
+	return msg.Message
+}
+
+func approvalRequest(t *testing.T, r *mux.Router, cookie *http.Cookie, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	req.AddCookie(cookie)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestApprovalModeHoldsMessagesForCuratedFeed(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	defer approvals.reset()
+
+	router := mux.NewRouter()
+	SetupApprovalRoutes(router)
+	cookie := seedTwitchSession(t)
+
+	if rec := approvalRequest(t, router, cookie, http.MethodPut, "/api/moderation/mode", `{"enabled":true}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected mode to be set, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	raw, cleanupRaw := dialChatWS(t, "", nil)
+	defer cleanupRaw()
+	curated, cleanupCurated := dialChatWS(t, "?feed=curated", nil)
+	defer cleanupCurated()
+
+	BroadcastFromTailer(approvalTestRow("held-1", "first"))
+	BroadcastFromTailer(approvalTestRow("held-2", "second"))
+
+	// The raw feed is unchanged.
+	if got := chatFrameText(t, readWSFrame(t, raw)); got != "first" {
+		t.Fatalf("raw feed: expected first, got %q", got)
+	}
+	if got := chatFrameText(t, readWSFrame(t, raw)); got != "second" {
+		t.Fatalf("raw feed: expected second, got %q", got)
+	}
+
+	rec := approvalRequest(t, router, cookie, http.MethodGet, "/api/moderation/queue", "")
+	var queue struct {
+		Enabled bool          `json:"enabled"`
+		Items   []heldMessage `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &queue); err != nil {
+		t.Fatalf("decode queue: %v", err)
+	}
+	if !queue.Enabled || len(queue.Items) != 2 || queue.Items[0].Text != "first" || queue.Items[0].Kind != "chat" {
+		t.Fatalf("unexpected queue: %+v", queue)
+	}
+
+	if rec := approvalRequest(t, router, cookie, http.MethodPost, "/api/moderation/queue/"+queue.Items[0].ID+"/reject", ""); rec.Code != http.StatusOK {
+		t.Fatalf("reject: expected 200, got %d", rec.Code)
+	}
+	if rec := approvalRequest(t, router, cookie, http.MethodPost, "/api/moderation/queue/"+queue.Items[1].ID+"/approve", ""); rec.Code != http.StatusOK {
+		t.Fatalf("approve: expected 200, got %d", rec.Code)
+	}
+	if rec := approvalRequest(t, router, cookie, http.MethodPost, "/api/moderation/queue/"+queue.Items[1].ID+"/approve", ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("approving twice: expected 404, got %d", rec.Code)
+	}
+
+	// Only the approved message reaches the curated feed.
+	if got := chatFrameText(t, readWSFrame(t, curated)); got != "second" {
+		t.Fatalf("curated feed: expected second, got %q", got)
+	}
+
+	// Late curated subscribers replay what was approved.
+	replay, cleanupReplay := dialChatWS(t, "?feed=curated&replay=1", nil)
+	defer cleanupReplay()
+	if got := chatFrameText(t, readWSFrame(t, replay)); got != "second" {
+		t.Fatalf("curated replay: expected second, got %q", got)
+	}
+
+	// With approval mode off, the curated feed passes messages straight through.
+	setApprovalMode(false)
+	BroadcastFromTailer(approvalTestRow("live-3", "third"))
+	if got := chatFrameText(t, readWSFrame(t, curated)); got != "third" {
+		t.Fatalf("curated feed: expected third, got %q", got)
+	}
+}
+
+func TestModerationWebSocket(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	defer approvals.reset()
+
+	router := mux.NewRouter()
+	SetupApprovalRoutes(router)
+	cookie := seedTwitchSession(t)
+	server := httptest.NewServer(router)
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/ws/moderation"
+
+	if _, resp, err := websocket.DefaultDialer.Dial(url, nil); err == nil || resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a session, got %v", err)
+	}
+
+	before := subscriberCount()
+	conn, _, err := websocket.DefaultDialer.Dial(url, http.Header{"Cookie": {cookie.String()}})
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	waitForSubscriberCount(t, before+1)
+
+	if frame := readWSFrame(t, conn); frame.Type != "queue" {
+		t.Fatalf("expected queue snapshot, got %+v", frame)
+	}
+	sendWSCommand(t, conn, `{"type":"set_mode","id":"m1","enabled":true}`)
+	if frame := readWSFrame(t, conn); frame.Type != "mode" || frame.ID != "m1" {
+		t.Fatalf("expected mode reply, got %+v", frame)
+	}
+	if frame := readWSFrame(t, conn); frame.Type != frameApprovalMode {
+		t.Fatalf("expected approval_mode frame, got %+v", frame)
+	}
+
+	BroadcastFromTailer(approvalTestRow("mod-1", "check me"))
+	frame := readWSFrame(t, conn)
+	if frame.Type != frameHeld {
+		t.Fatalf("expected held frame, got %+v", frame)
+	}
+	var held feedFrame
+	if err := json.Unmarshal(frame.Data, &held); err != nil || held.ID == "" {
+		t.Fatalf("decode held frame: %v %s", err, frame.Data)
+	}
+
+	sendWSCommand(t, conn, `{"type":"approve","id":"a1","item":"`+held.ID+`"}`)
+	if frame := readWSFrame(t, conn); frame.Type != "resolved" || frame.ID != "a1" {
+		t.Fatalf("expected resolved reply, got %+v", frame)
+	}
+	if frame := readWSFrame(t, conn); frame.Type != frameApproved {
+		t.Fatalf("expected approved frame, got %+v", frame)
+	}
+	sendWSCommand(t, conn, `{"type":"reject","id":"r1","item":"`+held.ID+`"}`)
+	if frame := readWSFrame(t, conn); frame.Type != "error" || frame.ID != "r1" {
+		t.Fatalf("expected error for a resolved item, got %+v", frame)
+	}
+}
+
+func TestChatStreamRejectsUnknownFeed(t *testing.T) {
+	if status := dialChatWSStatus(t, "?feed=bogus"); status != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", status)
+	}
+}
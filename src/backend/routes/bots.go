@@ -0,0 +1,103 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/bots"
+	"github.com/hpwn/EloraChat/src/backend/internal/runtimeconfig"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+// botRegistry is rebuilt from the runtime config on every change. Until the
+// config is applied it holds only the defaults.
+var botRegistry = struct {
+	mu       sync.RWMutex
+	registry *bots.Registry
+}{}
+
+// SetBotRegistry applies the runtime bot settings on top of the built-in
+// registry. Patterns are validated by runtimeconfig.Normalize, so a compile
+// error keeps the previous registry and is only logged.
+func SetBotRegistry(cfg runtimeconfig.BotConfig) {
+	registry, err := bots.New(bots.Config{
+		Accounts: cfg.Accounts,
+		Patterns: cfg.Patterns,
+		Allow:    cfg.Allow,
+	})
+	if err != nil {
+		log.Printf("bots: keeping previous registry: %v", err)
+		return
+	}
+	botRegistry.mu.Lock()
+	botRegistry.registry = registry
+	botRegistry.mu.Unlock()
+}
+
+func activeBotRegistry() *bots.Registry {
+	botRegistry.mu.RLock()
+	registry := botRegistry.registry
+	botRegistry.mu.RUnlock()
+	if registry == nil {
+		registry, _ = bots.New(bots.Config{})
+	}
+	return registry
+}
+
+// markBot flags messages from bots and service accounts. It runs before
+// normalize, which drops badges when they are hidden. A producer that already
+// marked the payload as a bot is trusted.
+func markBot(msg *Message, m storage.Message) {
+	if msg.Bot {
+		return
+	}
+	_, account := viewerAccountOf(msg.Source, m)
+	badges := make([]string, 0, len(msg.Badges))
+	for _, badge := range msg.Badges {
+		badges = append(badges, badge.ID)
+	}
+	_, msg.Bot = activeBotRegistry().Detect(bots.Author{
+		Username:  msg.Author,
+		AccountID: account,
+		Badges:    badges,
+	})
+}
+
+// hideBotsFromQuery reads ?bots=, which is "include" (the default) or
+// "exclude".
+func hideBotsFromQuery(query url.Values) (bool, error) {
+	switch mode := strings.ToLower(strings.TrimSpace(query.Get("bots"))); mode {
+	case "", "include":
+		return false, nil
+	case "exclude":
+		return true, nil
+	default:
+		return false, fmt.Errorf("unknown bots mode %q", mode)
+	}
+}
+
+func isBotPayload(payload []byte) bool {
+	var meta struct {
+		Bot bool `json:"bot"`
+	}
+	_ = json.Unmarshal(payload, &meta)
+	return meta.Bot
+}
+
+// filterBotPayloads drops bot messages when hide is set.
+func filterBotPayloads(hide bool, payloads [][]byte) [][]byte {
+	if !hide {
+		return payloads
+	}
+	out := payloads[:0]
+	for _, p := range payloads {
+		if !isBotPayload(p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
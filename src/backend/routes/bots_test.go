@@ -0,0 +1,76 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/runtimeconfig"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func chatFrameBot(t *testing.T, frame wsTestFrame) (string, bool) {
+	t.Helper()
+	var raw string
+	if err := json.Unmarshal(frame.Data, &raw); err != nil {
+		t.Fatalf("decode chat data: %v", err)
+	}
+	var msg struct {
+		Author string `json:"author"`
+		Bot    bool   `json:"bot"`
+	}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		t.Fatalf("decode chat payload: %v", err)
+	}
+	return msg.Author, msg.Bot
+}
+
+func TestBotMessagesAreMarkedAndExcludable(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	SetBotRegistry(runtimeconfig.BotConfig{Accounts: []string{"TimerPal"}, Allow: []string{"moobot"}})
+	defer SetBotRegistry(runtimeconfig.BotConfig{})
+
+	all, cleanupAll := dialChatWS(t, "", nil)
+	defer cleanupAll()
+	humans, cleanupHumans := dialChatWS(t, "?bots=exclude", nil)
+	defer cleanupHumans()
+
+	now := time.Now().UTC()
+	rows := []storage.Message{
+		{ID: "b1", Username: "Nightbot", Platform: "Twitch", Text: "Follow the socials!", RawJSON: `{"login":"nightbot"}`},
+		{ID: "b2", Username: "TimerPal", Platform: "YouTube", Text: "Hydrate!", RawJSON: `{"author":{"channelId":"UCtimer"}}`},
+		{ID: "b3", Username: "Moobot", Platform: "Twitch", Text: "allowed", RawJSON: "{}"},
+		{ID: "b4", Username: "ModBot", Platform: "Twitch", Text: "!uptime", BadgesJSON: `["moderator/1"]`, RawJSON: "{}"},
+	}
+	for i := range rows {
+		rows[i].Timestamp = now
+		if err := chatStore.InsertMessage(ctx, &rows[i]); err != nil {
+			t.Fatalf("InsertMessage returned error: %v", err)
+		}
+		BroadcastFromTailer(rows[i])
+	}
+
+	want := []bool{true, true, false, true}
+	for i, bot := range want {
+		author, got := chatFrameBot(t, readWSFrame(t, all))
+		if author != rows[i].Username || got != bot {
+			t.Fatalf("frame %d: got author %q bot=%v, want %q bot=%v", i, author, got, rows[i].Username, bot)
+		}
+	}
+	if author, bot := chatFrameBot(t, readWSFrame(t, humans)); author != "Moobot" || bot {
+		t.Fatalf("expected only the allowed account on the filtered stream, got %q bot=%v", author, bot)
+	}
+
+	replay, cleanupReplay := dialChatWS(t, "?bots=exclude&replay=1", nil)
+	defer cleanupReplay()
+	if author, _ := chatFrameBot(t, readWSFrame(t, replay)); author != "Moobot" {
+		t.Fatalf("expected replay to skip bots, got %q", author)
+	}
+
+	if code := dialChatWSStatus(t, "?bots=maybe"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown bots mode, got %d", code)
+	}
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/gorilla/websocket"
 
 	"github.com/hpwn/EloraChat/src/backend/internal/authutil"
+	"github.com/hpwn/EloraChat/src/backend/internal/broadcast"
 	"github.com/hpwn/EloraChat/src/backend/internal/runtimeconfig"
 	"github.com/hpwn/EloraChat/src/backend/internal/storage"
 	"github.com/hpwn/EloraChat/src/backend/internal/tokenfile"
@@ -33,11 +34,14 @@ import (
 
 var chatStore storage.Store
 var ctx = context.Background()
-var subscribersMu sync.Mutex
-var subscribers map[chan []byte]struct{}
+
+// broadcaster fans chat payloads out to /ws/chat and /sse/chat subscribers.
+// main swaps in a peer broadcaster when other instances are configured.
+var broadcaster broadcast.Broadcaster = NewLocalBroadcaster()
 
 var (
 	upgrader = websocket.Upgrader{
+		Subprotocols: wsSubprotocols,
 		CheckOrigin: func(r *http.Request) bool {
 			return originAllowed(r.Header.Get("Origin"))
 		},
@@ -141,6 +145,9 @@ type Emote struct {
 	Name      string   `json:"name"`
 	Locations []string `json:"locations"`
 	Images    []Image  `json:"images"`
+	// Provider is the third-party provider (7tv, bttv or ffz) that serves the
+	// emote; it is empty for first-party emotes.
+	Provider string `json:"provider,omitempty"`
 }
 
 type Badge struct {
@@ -391,67 +398,18 @@ func buildEmodlOptions(cfg runtimeconfig.Config) (emodl.DownloaderOptions, emodl
 	return options, targets, nil
 }
 
-func loadEmoteCache(options emodl.DownloaderOptions) (map[string]Emote, error) {
-	downloader := emodl.NewDownloader(options)
-	emoteCacheTmp, err := downloader.Load()
-	if err != nil {
-		return nil, err
-	}
-
-	out := make(map[string]Emote, len(emoteCacheTmp))
-	for name, emote := range emoteCacheTmp {
-		next := Emote{
-			ID:        emote.ID,
-			Name:      emote.Name,
-			Locations: emote.Locations,
-			Images:    []Image{},
-		}
-		if len(emote.Images) > 0 {
-			next.Images = append(next.Images, Image(emote.Images[0]))
-		}
-		out[name] = next
-	}
-	return out, nil
-}
-
-func reloadThirdPartyEmotes(cfg runtimeconfig.Config) error {
-	options, targets, buildErr := buildEmodlOptions(cfg)
-	if buildErr != nil {
-		log.Printf("emodl: target resolution warning: %v", buildErr)
-	}
-
-	log.Printf(
-		"emodl: reload targets twitch_login=%q twitch_id=%q youtube_source=%q youtube_channel_id=%q",
-		targets.twitchLogin,
-		targets.twitchBroadcasterID,
-		targets.youTubeSourceURL,
-		targets.youTubeChannelID,
-	)
-
-	next, err := loadEmoteCache(options)
-	if err != nil {
-		return fmt.Errorf("failed to load third party emotes: %w", err)
+// emoteFromEmodl keeps the first image of a downloaded emote.
+func emoteFromEmodl(emote emodl.Emote) Emote {
+	next := Emote{
+		ID:        emote.ID,
+		Name:      emote.Name,
+		Locations: emote.Locations,
+		Images:    []Image{},
 	}
-
-	if targets.twitchBroadcasterID != "" && targets.youTubeChannelID != "" {
-		ytOptions := emodl.DownloaderOptions{
-			SevenTV: &emodl.SevenTVOptions{Platform: "youtube", PlatformID: targets.youTubeChannelID},
-			BTTV:    &emodl.BTTVOptions{Platform: "youtube", PlatformID: targets.youTubeChannelID},
-			FFZ:     &emodl.FFZOptions{Platform: "youtube", PlatformID: targets.youTubeChannelID},
-		}
-		ytCache, ytErr := loadEmoteCache(ytOptions)
-		if ytErr != nil {
-			log.Printf("emodl: youtube scoped load warning (channel_id=%s): %v", targets.youTubeChannelID, ytErr)
-		} else {
-			for name, emote := range ytCache {
-				next[name] = emote
-			}
-		}
+	if len(emote.Images) > 0 {
+		next.Images = append(next.Images, Image(emote.Images[0]))
 	}
-
-	replaceEmoteCache(next)
-	log.Printf("emodl: cache size = %d", len(next))
-	return nil
+	return next
 }
 
 func (b *Badge) UnmarshalJSON(data []byte) error {
@@ -579,6 +537,9 @@ func (b *Badge) UnmarshalJSON(data []byte) error {
 }
 
 type Message struct {
+	// Cursor is the storage rowid, used by stream clients to resume.
+	Cursor        int64   `json:"cursor,omitempty"`
+	ID            string  `json:"id,omitempty"`
 	Author        string  `json:"author"` // Adjusted to directly receive the author's name as a string
 	Message       string  `json:"message"`
 	Tokens        []Token `json:"fragments"`
@@ -590,6 +551,13 @@ type Message struct {
 	SourceURL     string  `json:"source_url,omitempty"`
 	Colour        string  `json:"colour"`
 	UsernameColor string  `json:"username_color,omitempty"`
+	// OriginalColour is the colour before the dark-background adjustment, so
+	// other themes can start from it.
+	OriginalColour string `json:"original_colour,omitempty"`
+	// Viewer is set when the author's accounts are linked across platforms.
+	Viewer *ws.Viewer `json:"viewer,omitempty"`
+	// Bot is set for messages from bots and service accounts.
+	Bot bool `json:"bot,omitempty"`
 }
 
 var errDropMessage = errors.New("chat: drop empty message")
@@ -1026,17 +994,22 @@ func (m Message) toChatPayload() ws.ChatPayload {
 	}
 
 	return ws.ChatPayload{
-		Author:        m.Author,
-		Message:       m.Message,
-		Fragments:     fragments,
-		Emotes:        emotes,
-		Badges:        badges,
-		BadgesRaw:     m.BadgesRaw,
-		Source:        m.Source,
-		SourceChannel: m.SourceChannel,
-		SourceURL:     m.SourceURL,
-		Colour:        m.Colour,
-		UsernameColor: m.UsernameColor,
+		Cursor:         m.Cursor,
+		ID:             m.ID,
+		Author:         m.Author,
+		Message:        m.Message,
+		Fragments:      fragments,
+		Emotes:         emotes,
+		Badges:         badges,
+		BadgesRaw:      m.BadgesRaw,
+		Source:         m.Source,
+		SourceChannel:  m.SourceChannel,
+		SourceURL:      m.SourceURL,
+		Colour:         m.Colour,
+		UsernameColor:  m.UsernameColor,
+		OriginalColour: m.OriginalColour,
+		Viewer:         m.Viewer,
+		Bot:            m.Bot,
 	}
 }
 
@@ -1060,6 +1033,8 @@ var fallbackColourPalette = []string{
 
 var hexUsernameColourRe = regexp.MustCompile(`^#[0-9A-Fa-f]{6}$`)
 
+// sanitizeUsernameColorForDarkBG lifts colours to a luminance of 0.10, which is
+// a 3:1 contrast against black, blending toward white in steps.
 const (
 	usernameColourDarkBGMinLuminance = 0.10
 	usernameColourBlendTowardWhite   = 0.60
@@ -1367,7 +1342,9 @@ func detectYouTubeRole(msg Message, rawJSON string) youtubeRole {
 	return role
 }
 
-func computeUsernameColor(msg Message, row storage.Message) string {
+// computeOriginalUsernameColor picks the author's colour as the platform or
+// palette gives it, before any adjustment for the background.
+func computeOriginalUsernameColor(msg Message, row storage.Message) string {
 	author := strings.TrimSpace(msg.Author)
 
 	source := normalizeSource(msg.Source)
@@ -1377,51 +1354,62 @@ func computeUsernameColor(msg Message, row storage.Message) string {
 	if strings.EqualFold(source, "youtube") {
 		switch detectYouTubeRole(msg, row.RawJSON) {
 		case youtubeRoleOwner:
-			return sanitizeUsernameColorForDarkBG(youtubeOwnerColour)
+			return youtubeOwnerColour
 		case youtubeRoleModerator:
-			return sanitizeUsernameColorForDarkBG(youtubeModeratorColour)
+			return youtubeModeratorColour
 		case youtubeRoleMember:
-			return sanitizeUsernameColorForDarkBG(youtubeMemberColour)
+			return youtubeMemberColour
 		}
 	}
 	if author != "" {
 		if colour, ok := userColorMap[author]; ok {
 			if normalized := normalizeHexUsernameColour(colour); normalized != "" {
-				return sanitizeUsernameColorForDarkBG(normalized)
+				return normalized
 			}
 		}
 	}
+	if colour := linkedViewerColour(msg.Viewer); colour != "" {
+		return colour
+	}
 
 	switch strings.ToLower(source) {
 	case "twitch":
 		if colour := normalizeHexUsernameColour(msg.UsernameColor); colour != "" {
-			return sanitizeUsernameColorForDarkBG(colour)
+			return colour
 		}
 		if colour := normalizeHexUsernameColour(msg.Colour); colour != "" {
-			return sanitizeUsernameColorForDarkBG(colour)
+			return colour
 		}
 		if colour := extractTwitchRawUsernameColour(row.RawJSON); colour != "" {
-			return sanitizeUsernameColorForDarkBG(colour)
+			return colour
 		}
 	default:
 		if colour := normalizeHexUsernameColour(msg.UsernameColor); colour != "" {
-			return sanitizeUsernameColorForDarkBG(colour)
+			return colour
 		}
 		if colour := normalizeHexUsernameColour(msg.Colour); colour != "" {
-			return sanitizeUsernameColorForDarkBG(colour)
+			return colour
 		}
 	}
 
 	if identity := extractAuthorIdentity(row.RawJSON); identity != "" {
-		return sanitizeUsernameColorForDarkBG(colorFromName(identity))
+		return colorFromName(identity)
 	}
 	if author != "" {
-		return sanitizeUsernameColorForDarkBG(colorFromName(strings.ToLower(author)))
+		return colorFromName(strings.ToLower(author))
 	}
 	if username := strings.TrimSpace(row.Username); username != "" {
-		return sanitizeUsernameColorForDarkBG(colorFromName(strings.ToLower(username)))
+		return colorFromName(strings.ToLower(username))
 	}
-	return sanitizeUsernameColorForDarkBG(colorFromName(""))
+	return colorFromName("")
+}
+
+// setUsernameColour fills in the original colour and the copy made readable on
+// a dark background, which is what payloads carry by default.
+func setUsernameColour(msg *Message, row storage.Message) {
+	msg.OriginalColour = computeOriginalUsernameColor(*msg, row)
+	msg.UsernameColor = sanitizeUsernameColorForDarkBG(msg.OriginalColour)
+	msg.Colour = msg.UsernameColor
 }
 
 func normalizeTwitchChannelIdentity(raw string) string {
@@ -2469,9 +2457,17 @@ func messagePayloadFromStorage(m storage.Message) ([]byte, error) {
 			if len(msg.Badges) > 0 {
 				msg.Badges = enrichTwitchBadgesWithImages(msg.Badges, msg.BadgesRaw, msg.SourceChannel)
 			}
-			msg.UsernameColor = computeUsernameColor(msg, m)
-			msg.Colour = msg.UsernameColor
+			msg.Viewer = linkedViewerFor(msg.Source, m)
+			setUsernameColour(&msg, m)
+			msg.Cursor = m.RowID
+			if m.ID != "" {
+				msg.ID = m.ID
+			}
+			markBot(&msg, m)
 			msg.normalize()
+			if filterMessage(&msg).Drop {
+				return nil, errDropMessage
+			}
 			return json.Marshal(msg.toChatPayload())
 		}
 	}
@@ -2484,6 +2480,8 @@ func messagePayloadFromStorage(m storage.Message) ([]byte, error) {
 	emotes := decodeEmotesJSON(m.EmotesJSON)
 
 	fallback := Message{
+		Cursor:    m.RowID,
+		ID:        m.ID,
 		Author:    m.Username,
 		Message:   m.Text,
 		Tokens:    []Token{},
@@ -2496,9 +2494,13 @@ func messagePayloadFromStorage(m storage.Message) ([]byte, error) {
 	if len(fallback.Badges) > 0 {
 		fallback.Badges = enrichTwitchBadgesWithImages(fallback.Badges, fallback.BadgesRaw, fallback.SourceChannel)
 	}
-	fallback.UsernameColor = computeUsernameColor(fallback, m)
-	fallback.Colour = fallback.UsernameColor
+	fallback.Viewer = linkedViewerFor(fallback.Source, m)
+	setUsernameColour(&fallback, m)
+	markBot(&fallback, m)
 	fallback.normalize()
+	if filterMessage(&fallback).Drop {
+		return nil, errDropMessage
+	}
 
 	data, err := json.Marshal(fallback.toChatPayload())
 	if err != nil {
@@ -2531,12 +2533,6 @@ func InitRoutes(store storage.Store) {
 	chatStore = store
 	maybeExportStoredTwitchToken(store)
 	startServiceTokenMaintainer()
-	subscribersMu.Lock()
-	if subscribers == nil {
-		subscribers = make(map[chan []byte]struct{})
-	}
-	subscribersMu.Unlock()
-
 	initRuntimeConfig(store)
 	RegisterThirdPartyEmoteReloader(reloadThirdPartyEmotes)
 
@@ -2551,9 +2547,9 @@ func InitRoutes(store storage.Store) {
 		HelpResetDuration: 10 * time.Second,
 	}
 
-	if err := reloadThirdPartyEmotes(currentRuntimeConfig()); err != nil {
-		log.Printf("emodl: failed to load third party emotes: %v", err)
-	}
+	// Serve the last downloaded emotes right away; StartThirdPartyEmoteRefresher
+	// fetches fresh sets in the background.
+	restoreThirdPartyEmotes(currentRuntimeConfig())
 }
 
 func maybeExportStoredTwitchToken(store storage.Store) {
@@ -2593,31 +2589,25 @@ func maybeExportStoredTwitchToken(store storage.Store) {
 	}
 }
 
-func broadcastChatMessage(msg []byte) {
-	subscribersMu.Lock()
-	if len(subscribers) == 0 {
-		subscribersMu.Unlock()
-		return
-	}
-	targets := make([]chan []byte, 0, len(subscribers))
-	for ch := range subscribers {
-		targets = append(targets, ch)
-	}
-	subscribersMu.Unlock()
-
-	for _, ch := range targets {
-		payload := make([]byte, len(msg))
-		copy(payload, msg)
+// NewLocalBroadcaster returns the in-process broadcaster, wired to count
+// messages lost to stalled WebSocket clients.
+func NewLocalBroadcaster() *broadcast.Local {
+	local := broadcast.NewLocal()
+	local.OnStall = func(ch chan []byte) { wsConnections.noteStalled(ch) }
+	return local
+}
 
-		select {
-		case ch <- payload:
-		case <-time.After(2 * time.Second):
-			log.Printf("ws: subscriber stalled; dropping connection")
-			removeSubscriber(ch)
-		}
+// SetBroadcaster replaces the broadcaster. It must be called before serving.
+func SetBroadcaster(b broadcast.Broadcaster) {
+	if b != nil {
+		broadcaster = b
 	}
 }
 
+func broadcastChatMessage(msg []byte) {
+	broadcaster.Publish(msg)
+}
+
 func enrichTailerMessage(m storage.Message) Message {
 	var msg Message
 	raw := strings.TrimSpace(m.RawJSON)
@@ -2685,58 +2675,86 @@ func enrichTailerMessage(m storage.Message) Message {
 		}
 	}
 
-	msg.UsernameColor = computeUsernameColor(msg, m)
-	msg.Colour = msg.UsernameColor
+	learnViewerColour(msg, m)
+	msg.Viewer = linkedViewerFor(msg.Source, m)
+	setUsernameColour(&msg, m)
 
 	if msg.Source == "" {
 		msg.Source = m.Platform
 	}
 
 	msg.Source = normalizeSource(msg.Source)
+	msg.Cursor = m.RowID
+	if m.ID != "" {
+		msg.ID = m.ID
+	}
 	resolveMessageSourceIdentity(&msg, m)
 	if len(msg.Badges) > 0 {
 		msg.Badges = enrichTwitchBadgesWithImages(msg.Badges, msg.BadgesRaw, msg.SourceChannel)
 	}
+	markBot(&msg, m)
 	msg.normalize()
 
 	return msg
 }
 
 // BroadcastFromTailer enqueues a stored message onto the WebSocket broadcast loop.
+// Rows carrying a platform event (sub, raid, Super Chat, ...) are published as
+// an event frame; only cheers also keep their chat line. In approval mode both
+// are held for moderators before reaching the curated feed. Identity link
+// commands are consumed and never shown. The content filter masks the text of
+// messages and events, drops them, or holds them for moderators even outside
+// approval mode.
 func BroadcastFromTailer(m storage.Message) {
+	if claimViewerLinkCode(m) {
+		return
+	}
+	if ev, payload, filtered, ok := platformEventFor(m); ok {
+		switch {
+		case filtered.Drop:
+		case filtered.Flag:
+			holdMessagePayload(payload, filterReason(filtered), func() { recordPlatformEvent(ev, payload) })
+		default:
+			recordPlatformEvent(ev, payload)
+			publishMessagePayload(payload, nil)
+		}
+		recordDonation(ev)
+		if ev.ReplacesChat() {
+			return
+		}
+	}
 	msg := enrichTailerMessage(m)
+	filtered := filterMessage(&msg)
+	if filtered.Drop {
+		return
+	}
 	if wsDropEmptyEnabled() && (msg.Source == "" || msg.Message == "") {
 		return
 	}
+	recordEmoteUsage(msg, m)
+	recordActivity(msg, m)
 	payload, err := json.Marshal(msg.toChatPayload())
 	if err != nil {
 		log.Printf("dbtailer: failed to marshal enriched message: %v", err)
 		return
 	}
 
-	broadcastChatMessage(payload)
+	// Trends are published to every feed, so held text only counts once it
+	// is released.
+	release := func() { recordTrends(msg, m) }
+	if filtered.Flag {
+		holdMessagePayload(payload, filterReason(filtered), release)
+		return
+	}
+	publishMessagePayload(payload, release)
 }
 
 func addSubscriber() chan []byte {
-	ch := make(chan []byte, 64)
-	subscribersMu.Lock()
-	if subscribers == nil {
-		subscribers = make(map[chan []byte]struct{})
-	}
-	subscribers[ch] = struct{}{}
-	subscribersMu.Unlock()
-	return ch
+	return broadcaster.Subscribe()
 }
 
 func removeSubscriber(ch chan []byte) {
-	subscribersMu.Lock()
-	if subscribers != nil {
-		if _, ok := subscribers[ch]; ok {
-			delete(subscribers, ch)
-			close(ch)
-		}
-	}
-	subscribersMu.Unlock()
+	broadcaster.Unsubscribe(ch)
 }
 
 func replayEnabled(raw string) bool {
@@ -2750,6 +2768,27 @@ func replayEnabled(raw string) bool {
 
 // StreamChat initializes a WebSocket connection and streams chat messages
 func StreamChat(w http.ResponseWriter, r *http.Request) {
+	feed, err := chatFeedFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hideBots, err := hideBotsFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	theme, err := usernameThemeFromQuery(r.URL.Query())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	grant, sourceFilter, status, err := authorizeChatStream(r, strings.ToLower(strings.TrimSpace(r.URL.Query().Get("source"))))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
 		log.Print("ws: WebSocket upgrade error:", err)
@@ -2758,7 +2797,8 @@ func StreamChat(w http.ResponseWriter, r *http.Request) {
 	defer conn.Close()
 
 	cfg := activeWebsocketConfig
-	sourceFilter := strings.ToLower(strings.TrimSpace(r.URL.Query().Get("source")))
+	encoding := wsEncodingFor(conn.Subprotocol())
+	batch := wsBatchConfigFromQuery(r.URL.Query())
 	shouldReplay := replayEnabled(r.URL.Query().Get("replay"))
 	if cfg.maxBytes > 0 {
 		conn.SetReadLimit(cfg.maxBytes)
@@ -2788,49 +2828,133 @@ func StreamChat(w http.ResponseWriter, r *http.Request) {
 	messageChan := addSubscriber()
 	defer removeSubscriber(messageChan)
 
-	// Read the last 100 messages from the backing store to send to the client immediately.
-	if shouldReplay && chatStore != nil {
-		history, err := chatStore.GetRecent(ctx, storage.QueryOpts{Limit: 100})
-		if err != nil {
-			log.Printf("storage: Failed to read messages from store: %v\n", err)
-		} else {
-			for i := len(history) - 1; i >= 0; i-- {
-				payload, marshalErr := messagePayloadFromStorage(history[i])
-				if marshalErr != nil {
-					log.Printf("chat: Failed to marshal history message: %v\n", marshalErr)
-					continue
-				}
-				sanitized, marshalErr := sanitizeMessagePayload(payload)
-				if marshalErr != nil {
-					if errors.Is(marshalErr, errDropMessage) {
-						continue
-					}
-					log.Printf("chat: Failed to sanitize history message: %v\n", marshalErr)
-					continue
-				}
-				if shouldSkipSource(sanitized, sourceFilter) {
-					continue
-				}
-				if err := conn.WriteMessage(websocket.TextMessage, maybeEnvelope(sanitized)); err != nil {
-					log.Println("ws: WebSocket write error:", err)
-					return
-				}
+	tokenID := ""
+	if grant != nil {
+		tokenID = grant.ID
+	}
+	info := newWSConnInfo(r, string(encoding), sourceFilter, tokenID, messageChan)
+	wsConnections.add(info)
+	defer wsConnections.remove(info.id)
+
+	// Commands read from the client are handed to the writer, which owns the
+	// connection's writes and view state.
+	commands := make(chan []byte, 16)
+	writerDone := make(chan struct{})
+	session := newWSSession(feed, sourceFilter, grant)
+	session.hideBots = hideBots
+	session.theme = theme
+
+	// Send the last 100 messages from the backing store to the client immediately.
+	if shouldReplay {
+		history := filterGrantedPayloads(grant, session.replayPayloads())
+		for _, payload := range history {
+			session.noteReplayed(payload)
+		}
+		for len(history) > 0 {
+			var (
+				frameType int
+				frame     []byte
+				err       error
+			)
+			frameType, frame, history, err = encoding.nextStreamFrame(batch, history)
+			if err != nil {
+				log.Printf("chat: Failed to encode history message: %v\n", err)
+				continue
+			}
+			if err := info.write(conn, frameType, frame, cfg.writeDeadline); err != nil {
+				log.Println("ws: WebSocket write error:", err)
+				return
 			}
 		}
 	}
 
 	// Websocket writer
 	go func() {
+		defer close(writerDone)
 		ticker := time.NewTicker(cfg.pingInterval)
 		defer ticker.Stop()
 
+		// Coalescing state; flushC stays nil unless a batch is pending.
+		var (
+			pending    [][]byte
+			flushTimer *time.Timer
+			flushC     <-chan time.Time
+		)
+		defer func() {
+			if flushTimer != nil {
+				flushTimer.Stop()
+			}
+		}()
+		flush := func() error {
+			flushC = nil
+			if flushTimer != nil {
+				flushTimer.Stop()
+			}
+			if len(pending) == 0 {
+				return nil
+			}
+			frameType, frame, err := encoding.batchFrame(pending)
+			pending = pending[:0]
+			if err != nil {
+				log.Println("ws: encode error:", err)
+				info.dropped()
+				return nil
+			}
+			return info.write(conn, frameType, frame, cfg.writeDeadline)
+		}
+		deliver := func(sanitized []byte) error {
+			if !session.admit(sanitized) {
+				return nil
+			}
+			sanitized = session.theme.apply(sanitized)
+			if isFramePayload(sanitized) {
+				// Events and other frames are never coalesced; flush so they keep their place.
+				if err := flush(); err != nil {
+					return err
+				}
+				frameType, frame, err := encoding.frame(sanitized)
+				if err != nil {
+					log.Println("ws: encode error:", err)
+					info.dropped()
+					return nil
+				}
+				return info.write(conn, frameType, frame, cfg.writeDeadline)
+			}
+			if batch.enabled() {
+				pending = append(pending, sanitized)
+				if len(pending) < batch.maxItems {
+					if flushC == nil {
+						flushTimer = time.NewTimer(batch.window)
+						flushC = flushTimer.C
+					}
+					return nil
+				}
+				return flush()
+			}
+			frameType, frame, err := encoding.chatFrame(sanitized)
+			if err != nil {
+				log.Println("ws: encode error:", err)
+				info.dropped()
+				return nil
+			}
+			return info.write(conn, frameType, frame, cfg.writeDeadline)
+		}
+
 		for {
 			select {
 			case m, ok := <-messageChan:
 				if !ok {
 					return
 				}
-				sanitized, err := sanitizeMessagePayload(m)
+				if session.paused {
+					info.skippedPaused()
+					continue
+				}
+				m, ok = feedPayload(session.feed, m)
+				if !ok {
+					continue
+				}
+				sanitized, err := sanitizeStreamPayload(m)
 				if err != nil {
 					if errors.Is(err, errDropMessage) {
 						continue
@@ -2838,15 +2962,54 @@ func StreamChat(w http.ResponseWriter, r *http.Request) {
 					log.Println("json: ", err)
 					continue
 				}
-				if shouldSkipSource(sanitized, sourceFilter) {
+				if err := deliver(sanitized); err != nil {
+					log.Println("ws: WebSocket write error:", err)
+					return
+				}
+			case raw := <-commands:
+				var (
+					reply   wsReply
+					backlog [][]byte
+				)
+				if cmd, err := parseWSCommand(raw); err != nil {
+					reply = wsErrorReply(wsCommand{}, err.Error())
+				} else {
+					reply, backlog = session.handle(cmd)
+					info.syncSession(session)
+				}
+				// Flush first so the reply is ordered after already-queued chat.
+				if err := flush(); err != nil {
+					log.Println("ws: WebSocket write error:", err)
+					return
+				}
+				frameType, frame, err := encoding.replyFrame(reply)
+				if err != nil {
+					log.Println("ws: encode error:", err)
 					continue
 				}
-				if err := writeWSMessage(conn, websocket.TextMessage, maybeEnvelope(sanitized), cfg.writeDeadline); err != nil {
+				if err := info.write(conn, frameType, frame, cfg.writeDeadline); err != nil {
+					log.Println("ws: WebSocket write error:", err)
+					return
+				}
+				for _, payload := range backlog {
+					if err := deliver(payload); err != nil {
+						log.Println("ws: WebSocket write error:", err)
+						return
+					}
+				}
+			case reason := <-info.kick:
+				if err := flush(); err != nil {
+					log.Println("ws: WebSocket write error:", err)
+				}
+				kickWS(conn, reason, cfg.writeDeadline)
+				return
+			case <-flushC:
+				if err := flush(); err != nil {
 					log.Println("ws: WebSocket write error:", err)
 					return
 				}
 			case <-ticker.C:
-				if err := writeWSMessage(conn, websocket.TextMessage, []byte("__keepalive__"), cfg.writeDeadline); err != nil {
+				if err := info.write(conn, websocket.TextMessage, []byte("__keepalive__"), cfg.writeDeadline); err != nil {
 					log.Println("ws: Failed to send keep-alive message:", err)
 					return
 				}
@@ -2864,9 +3027,11 @@ func StreamChat(w http.ResponseWriter, r *http.Request) {
 		}
 	}()
 
-	// Read loop to keep connection alive and detect close
+	// Read loop: keeps the connection alive, detects close, and forwards
+	// control commands to the writer.
 	for {
-		if _, _, err := conn.ReadMessage(); err != nil {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseNormalClosure, websocket.CloseGoingAway, websocket.CloseAbnormalClosure, websocket.CloseNoStatusReceived) {
 				log.Println("ws: WebSocket read error, closing connection:", err)
 			}
@@ -2876,7 +3041,64 @@ func StreamChat(w http.ResponseWriter, r *http.Request) {
 		if cfg.pongWait > 0 {
 			_ = conn.SetReadDeadline(time.Now().Add(cfg.pongWait))
 		}
+		if len(strings.TrimSpace(string(data))) == 0 {
+			continue
+		}
+		select {
+		case commands <- data:
+		case <-writerDone:
+		}
+	}
+}
+
+// replayHistoryPayloads returns sanitized payloads for the last 100 stored
+// messages, oldest first, skipping rows that do not match sourceFilter.
+func replayHistoryPayloads(sourceFilter string) [][]byte {
+	if chatStore == nil {
+		return nil
+	}
+	history, err := chatStore.GetRecent(ctx, storage.QueryOpts{Limit: 100})
+	if err != nil {
+		log.Printf("storage: Failed to read messages from store: %v\n", err)
+		return nil
+	}
+	out := make([][]byte, 0, len(history))
+	for i := len(history) - 1; i >= 0; i-- {
+		if sanitized, ok := storedPayload(history[i], sourceFilter); ok {
+			out = append(out, sanitized)
+		}
+	}
+	return out
+}
+
+// storedPayload renders a stored row as a sanitized chat payload, or as its
+// event payload when a platform event replaces the chat line.
+func storedPayload(row storage.Message, sourceFilter string) ([]byte, bool) {
+	if isViewerLinkCommand(row) {
+		return nil, false
+	}
+	if ev, payload, filtered, ok := platformEventFor(row); ok && ev.ReplacesChat() {
+		if filtered.Drop || (sourceFilter != "" && strings.ToLower(ev.Source) != sourceFilter) {
+			return nil, false
+		}
+		return payload, true
+	}
+	payload, err := messagePayloadFromStorage(row)
+	if err != nil {
+		log.Printf("chat: Failed to marshal history message: %v\n", err)
+		return nil, false
+	}
+	sanitized, err := sanitizeMessagePayload(payload)
+	if err != nil {
+		if !errors.Is(err, errDropMessage) {
+			log.Printf("chat: Failed to sanitize history message: %v\n", err)
+		}
+		return nil, false
+	}
+	if shouldSkipSource(sanitized, sourceFilter) {
+		return nil, false
 	}
+	return sanitized, true
 }
 
 func writeWSMessage(conn *websocket.Conn, messageType int, payload []byte, deadline time.Duration) error {
@@ -2899,6 +3121,9 @@ func shouldSkipSource(payload []byte, filter string) bool {
 	}
 
 	source := strings.ToLower(strings.TrimSpace(msg.Source))
+	if source == "" && isFramePayload(payload) {
+		return false
+	}
 	return source != filter
 }
 
@@ -3131,6 +3356,7 @@ func StopChatFetches(w http.ResponseWriter, r *http.Request) {
 func SetupChatRoutes(router *mux.Router) {
 	// Public routes
 	router.HandleFunc("/ws/chat", StreamChat).Methods("GET")
+	router.HandleFunc("/sse/chat", StreamChatSSE).Methods("GET")
 	router.HandleFunc("/imageproxy", ImageProxy).Methods("GET")
 
 	// Subrouter for chat routes that require authentication
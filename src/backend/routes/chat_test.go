@@ -898,9 +898,7 @@ func TestBroadcastFromTailerEnrichesPayload(t *testing.T) {
 		runtimeState.mu.Unlock()
 	})
 
-	subscribersMu.Lock()
-	subscribers = nil
-	subscribersMu.Unlock()
+	broadcaster = NewLocalBroadcaster()
 
 	ch := addSubscriber()
 	defer removeSubscriber(ch)
@@ -943,9 +941,7 @@ func TestBroadcastFromTailerConvertsLegacyBadges(t *testing.T) {
 	tokenizer.TextCommandPrefix = '!'
 	tokenizer.EmoteCache = make(map[string]Emote)
 
-	subscribersMu.Lock()
-	subscribers = nil
-	subscribersMu.Unlock()
+	broadcaster = NewLocalBroadcaster()
 
 	ch := addSubscriber()
 	defer removeSubscriber(ch)
@@ -989,9 +985,7 @@ func TestBroadcastFromTailerStructuredBadgesAndRaw(t *testing.T) {
 	tokenizer.TextCommandPrefix = '!'
 	tokenizer.EmoteCache = make(map[string]Emote)
 
-	subscribersMu.Lock()
-	subscribers = nil
-	subscribersMu.Unlock()
+	broadcaster = NewLocalBroadcaster()
 
 	ch := addSubscriber()
 	defer removeSubscriber(ch)
@@ -1033,9 +1027,7 @@ func TestBroadcastFromTailerDropsYouTubeOwnerBadgeWithoutImages(t *testing.T) {
 	tokenizer.TextCommandPrefix = '!'
 	tokenizer.EmoteCache = make(map[string]Emote)
 
-	subscribersMu.Lock()
-	subscribers = nil
-	subscribersMu.Unlock()
+	broadcaster = NewLocalBroadcaster()
 
 	ch := addSubscriber()
 	defer removeSubscriber(ch)
@@ -1075,9 +1067,7 @@ func TestBroadcastFromTailerYouTubeEmoteFragments(t *testing.T) {
 	tokenizer.TextCommandPrefix = '!'
 	tokenizer.EmoteCache = make(map[string]Emote)
 
-	subscribersMu.Lock()
-	subscribers = nil
-	subscribersMu.Unlock()
+	broadcaster = NewLocalBroadcaster()
 
 	ch := addSubscriber()
 	defer removeSubscriber(ch)
@@ -1127,9 +1117,7 @@ func TestBroadcastFromTailerTwitchFirstPartyRepeatedEmoteFragments(t *testing.T)
 	tokenizer.TextCommandPrefix = '!'
 	tokenizer.EmoteCache = make(map[string]Emote)
 
-	subscribersMu.Lock()
-	subscribers = nil
-	subscribersMu.Unlock()
+	broadcaster = NewLocalBroadcaster()
 
 	ch := addSubscriber()
 	defer removeSubscriber(ch)
@@ -1215,6 +1203,12 @@ func TestMessagePayloadFromStorageIncludesCanonicalYouTubeSourceURL(t *testing.T
 	}
 }
 
+// usernameColourFor returns the colour setUsernameColour gives msg.
+func usernameColourFor(msg Message, row storage.Message) string {
+	setUsernameColour(&msg, row)
+	return msg.Colour
+}
+
 func TestComputeUsernameColorTwitchExtractionAndFallback(t *testing.T) {
 	row := storage.Message{
 		Username: "tw-user",
@@ -1222,17 +1216,17 @@ func TestComputeUsernameColorTwitchExtractionAndFallback(t *testing.T) {
 		RawJSON:  `{"tags":{"color":"#33CC66"}}`,
 	}
 	msg := Message{Author: "tw-user", Source: "twitch"}
-	if got := computeUsernameColor(msg, row); got != "#33CC66" {
+	if got := usernameColourFor(msg, row); got != "#33CC66" {
 		t.Fatalf("expected twitch color extraction, got %q", got)
 	}
 
 	row.RawJSON = `{"tags":{"color":""}}`
-	if got := computeUsernameColor(msg, row); got != sanitizeUsernameColorForDarkBG(colorFromName("tw-user")) {
+	if got := usernameColourFor(msg, row); got != sanitizeUsernameColorForDarkBG(colorFromName("tw-user")) {
 		t.Fatalf("expected fallback color for empty twitch color, got %q", got)
 	}
 
 	row.RawJSON = `{"foo":"bar"}`
-	if got := computeUsernameColor(msg, row); got != sanitizeUsernameColorForDarkBG(colorFromName("tw-user")) {
+	if got := usernameColourFor(msg, row); got != sanitizeUsernameColorForDarkBG(colorFromName("tw-user")) {
 		t.Fatalf("expected fallback color for missing twitch color, got %q", got)
 	}
 }
@@ -1245,7 +1239,7 @@ func TestComputeUsernameColorYouTubeRoleOverridesFallback(t *testing.T) {
 		Platform: "youtube",
 		RawJSON:  `{"isChatSponsor":true}`,
 	}
-	if got := computeUsernameColor(base, member); got != youtubeMemberColour {
+	if got := usernameColourFor(base, member); got != youtubeMemberColour {
 		t.Fatalf("expected youtube member color %q, got %q", youtubeMemberColour, got)
 	}
 
@@ -1254,7 +1248,7 @@ func TestComputeUsernameColorYouTubeRoleOverridesFallback(t *testing.T) {
 		Platform: "youtube",
 		RawJSON:  `{"isChatModerator":true}`,
 	}
-	if got := computeUsernameColor(base, mod); got != youtubeModeratorColour {
+	if got := usernameColourFor(base, mod); got != youtubeModeratorColour {
 		t.Fatalf("expected youtube moderator color %q, got %q", youtubeModeratorColour, got)
 	}
 
@@ -1263,7 +1257,7 @@ func TestComputeUsernameColorYouTubeRoleOverridesFallback(t *testing.T) {
 		Platform: "youtube",
 		RawJSON:  `{"author":{"isChatOwner":true}}`,
 	}
-	if got := computeUsernameColor(base, owner); got != youtubeOwnerColour {
+	if got := usernameColourFor(base, owner); got != youtubeOwnerColour {
 		t.Fatalf("expected youtube owner color %q, got %q", youtubeOwnerColour, got)
 	}
 }
@@ -1288,7 +1282,7 @@ func TestComputeUsernameColorYouTubeRoleOverridesAnyExtractedColor(t *testing.T)
 		RawJSON:  `{"isChatOwner":true,"isChatModerator":true,"isChatSponsor":true,"author":{"isChatModerator":true}}`,
 	}
 
-	if got := computeUsernameColor(msg, row); got != youtubeOwnerColour {
+	if got := usernameColourFor(msg, row); got != youtubeOwnerColour {
 		t.Fatalf("expected owner precedence color %q, got %q", youtubeOwnerColour, got)
 	}
 }
@@ -1301,7 +1295,7 @@ func TestComputeUsernameColorInvalidTwitchColorFallsBack(t *testing.T) {
 	}
 	msg := Message{Author: "tw-invalid", Source: "twitch"}
 	want := sanitizeUsernameColorForDarkBG(colorFromName("tw-invalid"))
-	if got := computeUsernameColor(msg, row); got != want {
+	if got := usernameColourFor(msg, row); got != want {
 		t.Fatalf("expected fallback color %q for invalid twitch color, got %q", want, got)
 	}
 }
@@ -1313,8 +1307,12 @@ func TestComputeUsernameColorDarkTwitchColorSanitized(t *testing.T) {
 		RawJSON:  `{"tags":{"color":"#000000"}}`,
 	}
 	msg := Message{Author: "tw-dark", Source: "twitch"}
-	got := computeUsernameColor(msg, row)
+	setUsernameColour(&msg, row)
+	got := msg.Colour
 
+	if msg.OriginalColour != "#000000" || msg.UsernameColor != got {
+		t.Fatalf("expected the original colour kept beside the sanitized one, got %+v", msg)
+	}
 	if got == "#000000" {
 		t.Fatalf("expected dark twitch color to be sanitized, got %q", got)
 	}
@@ -42,6 +42,7 @@ var runtimeState = struct {
 var runtimeHooks = struct {
 	mu                   sync.RWMutex
 	applyTailer          func(runtimeconfig.TailerConfig) error
+	applyIngest          func(runtimeconfig.Config) error
 	applyThirdPartyEmote func(runtimeconfig.Config) error
 }{}
 
@@ -52,6 +53,13 @@ func RegisterTailerConfigApplier(fn func(runtimeconfig.TailerConfig) error) {
 	runtimeHooks.applyTailer = fn
 }
 
+// RegisterIngestConfigApplier sets a hot-apply hook for ingest driver and source updates.
+func RegisterIngestConfigApplier(fn func(runtimeconfig.Config) error) {
+	runtimeHooks.mu.Lock()
+	defer runtimeHooks.mu.Unlock()
+	runtimeHooks.applyIngest = fn
+}
+
 // RegisterThirdPartyEmoteReloader sets the hot-apply hook for channel-aware emote cache reloads.
 func RegisterThirdPartyEmoteReloader(fn func(runtimeconfig.Config) error) {
 	runtimeHooks.mu.Lock()
@@ -69,6 +77,22 @@ func applyTailerConfig(cfg runtimeconfig.TailerConfig) error {
 	return fn(cfg)
 }
 
+func applyIngestConfig(cfg runtimeconfig.Config) error {
+	runtimeHooks.mu.RLock()
+	fn := runtimeHooks.applyIngest
+	runtimeHooks.mu.RUnlock()
+	if fn == nil {
+		return nil
+	}
+	return fn(cfg)
+}
+
+func ingestApplierRegistered() bool {
+	runtimeHooks.mu.RLock()
+	defer runtimeHooks.mu.RUnlock()
+	return runtimeHooks.applyIngest != nil
+}
+
 func applyThirdPartyEmoteReload(cfg runtimeconfig.Config) error {
 	runtimeHooks.mu.RLock()
 	fn := runtimeHooks.applyThirdPartyEmote
@@ -163,6 +187,8 @@ func applyRuntimeConfig(cfg runtimeconfig.Config) {
 		WriteDeadline: time.Duration(cfg.Websocket.WriteDeadlineMS) * time.Millisecond,
 		MaxMessage:    cfg.Websocket.MaxMessageBytes,
 	})
+	SetContentFilter(cfg.Filter)
+	SetBotRegistry(cfg.Bots)
 }
 
 func SetupConfigRoutes(r *mux.Router) {
@@ -246,6 +272,39 @@ func handlePutConfig(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Hot-apply the subsystems that can fail before persisting, so a rejected
+	// config is neither live nor reloaded on restart.
+	ingestChanged := ingestConfigChanged(previous, normalized)
+	if ingestChanged {
+		if err := applyIngestConfig(normalized); err != nil {
+			log.Printf("config: ingest apply failed: %v", err)
+			writeJSONStatus(w, http.StatusInternalServerError, map[string]any{
+				"error":   "ingest_apply_failed",
+				"message": "failed to apply ingest config",
+			})
+			return
+		}
+	}
+	rollbackIngest := func() {
+		if !ingestChanged {
+			return
+		}
+		if err := applyIngestConfig(previous); err != nil {
+			log.Printf("config: ingest rollback failed: %v", err)
+		}
+	}
+	tailerChanged := previous.Tailer != normalized.Tailer
+	if tailerChanged {
+		if err := applyTailerConfig(normalized.Tailer); err != nil {
+			rollbackIngest()
+			writeJSONStatus(w, http.StatusInternalServerError, map[string]any{
+				"error":   "tailer_apply_failed",
+				"message": "failed to apply tailer config",
+			})
+			return
+		}
+	}
+
 	if store != nil {
 		err = store.UpsertConfig(r.Context(), &storage.ConfigRecord{
 			Key:       runtimeconfig.StorageKey,
@@ -254,6 +313,12 @@ func handlePutConfig(w http.ResponseWriter, r *http.Request) {
 			UpdatedAt: time.Now().UTC(),
 		})
 		if err != nil {
+			if tailerChanged {
+				if err := applyTailerConfig(previous.Tailer); err != nil {
+					log.Printf("config: tailer rollback failed: %v", err)
+				}
+			}
+			rollbackIngest()
 			writeJSONStatus(w, http.StatusInternalServerError, map[string]any{
 				"error":   "persist_failed",
 				"message": "failed to persist config",
@@ -263,15 +328,6 @@ func handlePutConfig(w http.ResponseWriter, r *http.Request) {
 	}
 
 	applyRuntimeConfig(normalized)
-	if previous.Tailer != normalized.Tailer {
-		if err := applyTailerConfig(normalized.Tailer); err != nil {
-			writeJSONStatus(w, http.StatusInternalServerError, map[string]any{
-				"error":   "tailer_apply_failed",
-				"message": "failed to apply tailer config",
-			})
-			return
-		}
-	}
 
 	log.Printf(
 		"config: applied twitch_channel=%q youtube_source_url=%q api_base=%q ws_url=%q",
@@ -317,13 +373,27 @@ func handlePutConfig(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// changedSubsystemsRequiringRestart lists subsystems whose changes cannot be
+// hot-applied. Ingest only lands here when no ingest applier is registered.
 func changedSubsystemsRequiringRestart(before, after runtimeconfig.Config) []string {
 	restart := make([]string, 0, 1)
-	if before.Ingest.GnastyBin != after.Ingest.GnastyBin ||
-		before.Ingest.BackoffBaseMS != after.Ingest.BackoffBaseMS ||
-		before.Ingest.BackoffMaxMS != after.Ingest.BackoffMaxMS ||
-		!reflect.DeepEqual(before.Ingest.GnastyArgs, after.Ingest.GnastyArgs) {
+	if !ingestApplierRegistered() && ingestSettingsChanged(before, after) {
 		restart = append(restart, "ingest")
 	}
 	return restart
 }
+
+func ingestSettingsChanged(before, after runtimeconfig.Config) bool {
+	return before.Ingest.GnastyBin != after.Ingest.GnastyBin ||
+		before.Ingest.BackoffBaseMS != after.Ingest.BackoffBaseMS ||
+		before.Ingest.BackoffMaxMS != after.Ingest.BackoffMaxMS ||
+		!reflect.DeepEqual(before.Ingest.GnastyArgs, after.Ingest.GnastyArgs)
+}
+
+// ingestConfigChanged reports whether ingest workers need to be re-applied,
+// including channel/source switches that only affect individual workers.
+func ingestConfigChanged(before, after runtimeconfig.Config) bool {
+	return ingestSettingsChanged(before, after) ||
+		before.TwitchChannel != after.TwitchChannel ||
+		before.YouTubeSourceURL != after.YouTubeSourceURL
+}
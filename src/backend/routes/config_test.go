@@ -5,9 +5,11 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"net/http"
 	"net/http/httptest"
+	"path/filepath"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -16,6 +18,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/hpwn/EloraChat/src/backend/internal/ingest"
 	"github.com/hpwn/EloraChat/src/backend/internal/runtimeconfig"
 	"github.com/hpwn/EloraChat/src/backend/internal/storage"
 )
@@ -41,7 +44,10 @@ func resetRuntimeConfigForTest(t *testing.T) {
 		overrideWSEnvelope = nil
 		overrideWSDropEmpty = nil
 		RegisterTailerConfigApplier(nil)
+		RegisterIngestConfigApplier(nil)
 		RegisterThirdPartyEmoteReloader(nil)
+		SetContentFilter(runtimeconfig.FilterConfig{})
+		SetBotRegistry(runtimeconfig.BotConfig{})
 	})
 }
 
@@ -748,6 +754,93 @@ func TestPutConfigTailerHotApplyFailure(t *testing.T) {
 	}
 }
 
+func TestPutConfigIngestHotApply(t *testing.T) {
+	resetRuntimeConfigForTest(t)
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+
+	InitRoutes(chatStore)
+	router := newConfigRouter()
+
+	var applied atomic.Int32
+	RegisterIngestConfigApplier(func(cfg runtimeconfig.Config) error {
+		applied.Add(1)
+		if cfg.Ingest.GnastyBin != "/usr/local/bin/gnasty" {
+			t.Fatalf("expected updated gnasty bin in hook, got %q", cfg.Ingest.GnastyBin)
+		}
+		return nil
+	})
+
+	current := currentRuntimeConfig()
+	current.Ingest.GnastyBin = "/usr/local/bin/gnasty"
+	body, _ := json.Marshal(current)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/config", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	if got := applied.Load(); got != 1 {
+		t.Fatalf("expected ingest hook once, got %d", got)
+	}
+
+	var updated configAPIResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &updated); err != nil {
+		t.Fatalf("decode updated config: %v", err)
+	}
+	for _, item := range updated.RestartRequired {
+		if item == "ingest" {
+			t.Fatalf("ingest should not require restart after hot-apply")
+		}
+	}
+}
+
+func TestPutConfigIngestHotApplyFailure(t *testing.T) {
+	resetRuntimeConfigForTest(t)
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+
+	InitRoutes(chatStore)
+	router := newConfigRouter()
+
+	// A replay driver pointed at a missing file makes Manager.Apply fail.
+	mgr := ingest.NewManager(context.Background(), nil, nil, log.New(io.Discard, "", 0))
+	defer mgr.Stop()
+	RegisterIngestConfigApplier(func(cfg runtimeconfig.Config) error {
+		return mgr.Apply(ingest.Env{Driver: ingest.DriverReplay, ReplayPath: filepath.Join(t.TempDir(), "missing.ndjson")}, []string{cfg.TwitchChannel})
+	})
+
+	current := currentRuntimeConfig()
+	current.TwitchChannel = "dagnel"
+	body, _ := json.Marshal(current)
+
+	req := httptest.NewRequest(http.MethodPut, "/api/config", bytes.NewReader(body))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(rr.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("decode error payload: %v", err)
+	}
+	if payload["error"] != "ingest_apply_failed" {
+		t.Fatalf("expected ingest_apply_failed, got %+v", payload)
+	}
+
+	if got := currentRuntimeConfig().TwitchChannel; got == "dagnel" {
+		t.Fatalf("expected the rejected config not to be applied")
+	}
+	rec, err := chatStore.GetConfig(ctx, runtimeconfig.StorageKey)
+	if err != nil {
+		t.Fatalf("GetConfig returned error: %v", err)
+	}
+	if rec != nil && strings.Contains(rec.ValueJSON, `"dagnel"`) {
+		t.Fatalf("expected the rejected config not to be persisted, got %s", rec.ValueJSON)
+	}
+}
+
 func TestPutConfigGnastyUnreachableIsWarningOnly(t *testing.T) {
 	resetRuntimeConfigForTest(t)
 	t.Setenv("ELORA_GNASTY_ADMIN_BASE", "http://127.0.0.1:1")
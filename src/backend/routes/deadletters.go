@@ -0,0 +1,235 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/ingest"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+)
+
+const (
+	defaultDeadLettersLimit = 50
+	maxDeadLettersLimit     = 500
+)
+
+type deadLetterResponse struct {
+	ID          int64   `json:"id"`
+	CreatedAt   string  `json:"created_at"`
+	Driver      string  `json:"driver"`
+	Source      string  `json:"source"`
+	Reason      string  `json:"reason"`
+	Error       string  `json:"error"`
+	Line        string  `json:"line"`
+	Attempts    int     `json:"attempts"`
+	LastRetryAt *string `json:"last_retry_at,omitempty"`
+}
+
+type deadLettersEnvelope struct {
+	Items        []deadLetterResponse `json:"items"`
+	NextBeforeID *int64               `json:"next_before_id,omitempty"`
+}
+
+type deadLetterRetryError struct {
+	ID    int64  `json:"id"`
+	Error string `json:"error"`
+}
+
+// SetupDeadLetterRoutes registers list/retry/purge endpoints for ingest lines
+// that failed to decode or insert. They require a logged-in session.
+func SetupDeadLetterRoutes(r *mux.Router) {
+	protected := r.PathPrefix("/api/ingest/dead-letters").Subrouter()
+	protected.Use(SessionMiddleware)
+	protected.HandleFunc("", handleListDeadLetters).Methods(http.MethodGet)
+	protected.HandleFunc("/retry", handleRetryDeadLetters).Methods(http.MethodPost)
+	protected.HandleFunc("/purge", handlePurgeDeadLetters).Methods(http.MethodPost)
+}
+
+func deadLetterStore(w http.ResponseWriter) (*sqlite.Store, bool) {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		http.Error(w, "dead letters only supported with sqlite backend", http.StatusNotImplemented)
+		return nil, false
+	}
+	return store, true
+}
+
+func handleListDeadLetters(w http.ResponseWriter, r *http.Request) {
+	store, ok := deadLetterStore(w)
+	if !ok {
+		return
+	}
+
+	q := r.URL.Query()
+	limit := defaultDeadLettersLimit
+	if raw := strings.TrimSpace(q.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxDeadLettersLimit)
+	}
+	var beforeID int64
+	if raw := strings.TrimSpace(q.Get("before_id")); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid before_id", http.StatusBadRequest)
+			return
+		}
+		beforeID = n
+	}
+
+	items, err := store.ListDeadLetters(r.Context(), sqlite.DeadLetterQueryOpts{
+		Limit:    limit,
+		BeforeID: beforeID,
+		Source:   strings.TrimSpace(q.Get("source")),
+		Reason:   strings.TrimSpace(q.Get("reason")),
+	})
+	if err != nil {
+		http.Error(w, "failed to list dead letters", http.StatusInternalServerError)
+		return
+	}
+
+	env := deadLettersEnvelope{Items: make([]deadLetterResponse, 0, len(items))}
+	for _, dl := range items {
+		env.Items = append(env.Items, toDeadLetterResponse(dl))
+	}
+	if len(items) == limit {
+		next := items[len(items)-1].ID
+		env.NextBeforeID = &next
+	}
+	writeJSON(w, env)
+}
+
+// handleRetryDeadLetters re-runs decode and insert for the requested rows.
+// Rows that succeed are deleted; rows that fail again keep the new error.
+func handleRetryDeadLetters(w http.ResponseWriter, r *http.Request) {
+	store, ok := deadLetterStore(w)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		IDs []int64 `json:"ids"`
+		All bool    `json:"all"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		items []sqlite.DeadLetter
+		err   error
+	)
+	switch {
+	case len(payload.IDs) > 0:
+		if len(payload.IDs) > maxDeadLettersLimit {
+			http.Error(w, "too many ids", http.StatusBadRequest)
+			return
+		}
+		items, err = store.GetDeadLetters(r.Context(), payload.IDs)
+	case payload.All:
+		items, err = store.ListDeadLetters(r.Context(), sqlite.DeadLetterQueryOpts{Limit: maxDeadLettersLimit})
+	default:
+		http.Error(w, "ids or all required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to load dead letters", http.StatusInternalServerError)
+		return
+	}
+
+	resp := struct {
+		Retried   int                    `json:"retried"`
+		Succeeded int                    `json:"succeeded"`
+		Failed    int                    `json:"failed"`
+		Errors    []deadLetterRetryError `json:"errors,omitempty"`
+	}{}
+	insert := ingest.StoreInsert(chatStore)
+	var done []int64
+	for _, dl := range items {
+		resp.Retried++
+		if err := ingest.Redeliver(r.Context(), insert, []byte(dl.Line)); err != nil {
+			resp.Failed++
+			resp.Errors = append(resp.Errors, deadLetterRetryError{ID: dl.ID, Error: err.Error()})
+			if markErr := store.MarkDeadLetterRetried(r.Context(), dl.ID, err.Error()); markErr != nil {
+				http.Error(w, "failed to update dead letter", http.StatusInternalServerError)
+				return
+			}
+			continue
+		}
+		resp.Succeeded++
+		done = append(done, dl.ID)
+	}
+	if _, err := store.DeleteDeadLetters(r.Context(), done); err != nil {
+		http.Error(w, "failed to delete retried dead letters", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func handlePurgeDeadLetters(w http.ResponseWriter, r *http.Request) {
+	store, ok := deadLetterStore(w)
+	if !ok {
+		return
+	}
+
+	var payload struct {
+		IDs      []int64 `json:"ids"`
+		BeforeTS int64   `json:"before_ts"`
+		All      bool    `json:"all"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		http.Error(w, "invalid json", http.StatusBadRequest)
+		return
+	}
+
+	var (
+		deleted int
+		err     error
+	)
+	switch {
+	case len(payload.IDs) > 0:
+		deleted, err = store.DeleteDeadLetters(r.Context(), payload.IDs)
+	case payload.BeforeTS > 0:
+		deleted, err = store.PurgeDeadLettersBefore(r.Context(), time.UnixMilli(payload.BeforeTS).UTC())
+	case payload.All:
+		deleted, err = store.PurgeDeadLettersBefore(r.Context(), time.Time{})
+	default:
+		http.Error(w, "ids, before_ts, or all required", http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, "failed to purge", http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, struct {
+		Deleted int `json:"deleted"`
+	}{Deleted: deleted})
+}
+
+func toDeadLetterResponse(dl sqlite.DeadLetter) deadLetterResponse {
+	resp := deadLetterResponse{
+		ID:        dl.ID,
+		CreatedAt: dl.CreatedAt.UTC().Format(time.RFC3339Nano),
+		Driver:    dl.Driver,
+		Source:    dl.Source,
+		Reason:    dl.Reason,
+		Error:     dl.Error,
+		Line:      dl.Line,
+		Attempts:  dl.Attempts,
+	}
+	if dl.LastRetryAt != nil {
+		ts := dl.LastRetryAt.UTC().Format(time.RFC3339Nano)
+		resp.LastRetryAt = &ts
+	}
+	return resp
+}
@@ -0,0 +1,109 @@
+package routes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+)
+
+func newDeadLetterRouter() *mux.Router {
+	r := mux.NewRouter()
+	SetupDeadLetterRoutes(r)
+	return r
+}
+
+func deadLetterRequest(cookie *http.Cookie, method, path, body string) *http.Request {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	req.AddCookie(cookie)
+	return req
+}
+
+func seedDeadLetter(t *testing.T, line string) int64 {
+	t.Helper()
+	dl := &sqlite.DeadLetter{Driver: "gnasty", Source: "https://www.twitch.tv/dayoman", Reason: "insert", Error: "boom", Line: line}
+	if err := chatStore.(*sqlite.Store).InsertDeadLetter(context.Background(), dl); err != nil {
+		t.Fatalf("insert dead letter: %v", err)
+	}
+	return dl.ID
+}
+
+func TestDeadLetterListRetryPurge(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+
+	good := seedDeadLetter(t, `{"id":"dl-1","ts":"2024-05-01T20:00:00Z","username":"u","platform":"Twitch","text":"recovered","emotes_json":"[]","raw_json":"{}"}`)
+	bad := seedDeadLetter(t, `{"id":""}`)
+	router := newDeadLetterRouter()
+	cookie := seedTwitchSession(t)
+
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodPost, "/api/ingest/dead-letters/purge", bytes.NewBufferString(`{"all":true}`)))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without session, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, deadLetterRequest(cookie, http.MethodGet, "/api/ingest/dead-letters?limit=1", ""))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d", rr.Code)
+	}
+	var list deadLettersEnvelope
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].ID != bad || list.NextBeforeID == nil || *list.NextBeforeID != bad {
+		t.Fatalf("unexpected list response: %+v", list)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, deadLetterRequest(cookie, http.MethodPost, "/api/ingest/dead-letters/retry", `{"all":true}`))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200 OK, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var retry struct {
+		Retried   int `json:"retried"`
+		Succeeded int `json:"succeeded"`
+		Failed    int `json:"failed"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &retry); err != nil {
+		t.Fatalf("decode retry: %v", err)
+	}
+	if retry.Retried != 2 || retry.Succeeded != 1 || retry.Failed != 1 {
+		t.Fatalf("unexpected retry result: %+v", retry)
+	}
+
+	msgs, err := chatStore.GetRecent(context.Background(), storage.QueryOpts{Limit: 10})
+	if err != nil {
+		t.Fatalf("get recent: %v", err)
+	}
+	if len(msgs) != 1 || msgs[0].ID != "dl-1" {
+		t.Fatalf("expected recovered message to be stored, got %+v", msgs)
+	}
+	remaining, err := chatStore.(*sqlite.Store).GetDeadLetters(context.Background(), []int64{good, bad})
+	if err != nil {
+		t.Fatalf("get dead letters: %v", err)
+	}
+	if len(remaining) != 1 || remaining[0].ID != bad || remaining[0].Attempts != 1 {
+		t.Fatalf("expected only the failing row to remain with one attempt, got %+v", remaining)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, deadLetterRequest(cookie, http.MethodPost, "/api/ingest/dead-letters/purge", `{}`))
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for unscoped purge, got %d", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, deadLetterRequest(cookie, http.MethodPost, "/api/ingest/dead-letters/purge", `{"all":true}`))
+	if rr.Code != http.StatusOK || rr.Body.String() != "{\"deleted\":1}\n" {
+		t.Fatalf("unexpected purge response: %d %s", rr.Code, rr.Body.String())
+	}
+}
@@ -0,0 +1,509 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/donations"
+	"github.com/hpwn/EloraChat/src/backend/internal/events"
+	"github.com/hpwn/EloraChat/src/backend/internal/ingest"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+)
+
+const (
+	donationRatesConfigKey  = "donation_rates"
+	defaultTopSupporters    = 10
+	maxTopSupporters        = 100
+	liveTopSupporters       = 5
+	maxDonationSessionLabel = 100
+)
+
+// donationRatesCache holds the saved rate table. Before anything is saved the
+// table defaults to ELORA_DONATIONS_BASE_CURRENCY (USD) with bits at $0.01.
+var donationRatesCache struct {
+	mu    sync.Mutex
+	value *donations.Rates
+}
+
+type donationSessionResponse struct {
+	ID         int64   `json:"id"`
+	Label      string  `json:"label"`
+	Goal       float64 `json:"goal"`
+	GoalMicros int64   `json:"goal_micros"`
+	StartedAt  string  `json:"started_at"`
+	EndedAt    *string `json:"ended_at,omitempty"`
+	Active     bool    `json:"active"`
+}
+
+type donationEntryResponse struct {
+	ID           string  `json:"id"`
+	Platform     string  `json:"platform"`
+	Type         string  `json:"type"`
+	User         string  `json:"user"`
+	Amount       float64 `json:"amount"`
+	AmountMicros int64   `json:"amount_micros"`
+	Currency     string  `json:"currency"`
+	BaseAmount   float64 `json:"base_amount"`
+	BaseMicros   int64   `json:"base_micros"`
+	Converted    bool    `json:"converted"`
+	TS           string  `json:"ts"`
+}
+
+// donationSummaryResponse is both the summary API body and, with Frame set,
+// the live "donations" frame. Frame must stay the first field.
+type donationSummaryResponse struct {
+	Frame   string                   `json:"frame,omitempty"`
+	Session *donationSessionResponse `json:"session"`
+	Latest  *donationEntryResponse   `json:"latest,omitempty"`
+	donations.Summary
+}
+
+type donationSessionRequest struct {
+	Label *string  `json:"label"`
+	Goal  *float64 `json:"goal"`
+	End   bool     `json:"end"`
+}
+
+// SetupDonationRoutes registers the donation ledger endpoints. Reads are
+// public so goal overlays can poll them; changes require a logged-in session.
+func SetupDonationRoutes(r *mux.Router) {
+	r.HandleFunc("/api/donations", handleListDonations).Methods(http.MethodGet)
+	r.HandleFunc("/api/donations/summary", handleDonationSummary).Methods(http.MethodGet)
+	r.HandleFunc("/api/donations/sessions", handleListDonationSessions).Methods(http.MethodGet)
+	r.HandleFunc("/api/donations/rates", handleGetDonationRates).Methods(http.MethodGet)
+
+	protected := r.PathPrefix("/api/donations").Subrouter()
+	protected.Use(SessionMiddleware)
+	protected.HandleFunc("/sessions", handleStartDonationSession).Methods(http.MethodPost)
+	protected.HandleFunc("/sessions/{id}", handleUpdateDonationSession).Methods(http.MethodPatch)
+	protected.HandleFunc("/rates", handlePutDonationRates).Methods(http.MethodPut)
+}
+
+func donationStore(w http.ResponseWriter) (*sqlite.Store, bool) {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		http.Error(w, "donations only supported with sqlite backend", http.StatusNotImplemented)
+		return nil, false
+	}
+	return store, true
+}
+
+func currentDonationRates(c context.Context) donations.Rates {
+	donationRatesCache.mu.Lock()
+	defer donationRatesCache.mu.Unlock()
+	if donationRatesCache.value != nil {
+		return *donationRatesCache.value
+	}
+
+	rates := donations.DefaultRates(os.Getenv("ELORA_DONATIONS_BASE_CURRENCY"))
+	if chatStore != nil {
+		rec, err := chatStore.GetConfig(c, donationRatesConfigKey)
+		if err != nil {
+			log.Printf("donations: load rates: %v", err)
+			return rates
+		}
+		if rec != nil {
+			var stored donations.Rates
+			if err := json.Unmarshal([]byte(rec.ValueJSON), &stored); err == nil {
+				if normalized, err := stored.Normalize(); err == nil {
+					rates = normalized
+				}
+			} else {
+				log.Printf("donations: stored rates unreadable, using defaults: %v", err)
+			}
+		}
+	}
+	donationRatesCache.value = &rates
+	return rates
+}
+
+func saveDonationRates(c context.Context, rates donations.Rates) error {
+	raw, err := json.Marshal(rates)
+	if err != nil {
+		return err
+	}
+	if err := chatStore.UpsertConfig(c, &storage.ConfigRecord{
+		Key:       donationRatesConfigKey,
+		Version:   1,
+		ValueJSON: string(raw),
+	}); err != nil {
+		return err
+	}
+	donationRatesCache.mu.Lock()
+	donationRatesCache.value = &rates
+	donationRatesCache.mu.Unlock()
+	return nil
+}
+
+func toDonationSessionResponse(sess *sqlite.DonationSession) *donationSessionResponse {
+	if sess == nil {
+		return nil
+	}
+	resp := &donationSessionResponse{
+		ID:         sess.ID,
+		Label:      sess.Label,
+		Goal:       float64(sess.GoalMicros) / 1e6,
+		GoalMicros: sess.GoalMicros,
+		StartedAt:  sess.StartedAt.Format(time.RFC3339Nano),
+		Active:     sess.EndedAt == nil,
+	}
+	if sess.EndedAt != nil {
+		ended := sess.EndedAt.Format(time.RFC3339Nano)
+		resp.EndedAt = &ended
+	}
+	return resp
+}
+
+func toDonationEntry(ev sqlite.PlatformEvent) donations.Entry {
+	return donations.Entry{
+		ID:           ev.ID,
+		Platform:     ev.Platform,
+		Type:         ev.Type,
+		User:         ev.Username,
+		AmountMicros: ev.AmountMicros,
+		Currency:     ev.Currency,
+		Timestamp:    ev.Timestamp,
+	}
+}
+
+func toDonationEntryResponse(e donations.Entry, rates donations.Rates) donationEntryResponse {
+	base, ok := rates.Convert(e.AmountMicros, e.Currency)
+	return donationEntryResponse{
+		ID:           e.ID,
+		Platform:     e.Platform,
+		Type:         e.Type,
+		User:         e.User,
+		Amount:       float64(e.AmountMicros) / 1e6,
+		AmountMicros: e.AmountMicros,
+		Currency:     e.Currency,
+		BaseAmount:   float64(base) / 1e6,
+		BaseMicros:   base,
+		Converted:    ok,
+		TS:           e.Timestamp.Format(time.RFC3339Nano),
+	}
+}
+
+// donationSessionEntries returns the ledger entries inside a session window.
+func donationSessionEntries(c context.Context, store *sqlite.Store, sess *sqlite.DonationSession) ([]donations.Entry, error) {
+	if sess == nil {
+		return nil, nil
+	}
+	var until time.Time
+	if sess.EndedAt != nil {
+		until = *sess.EndedAt
+	}
+	rows, err := store.PaidPlatformEvents(c, sess.StartedAt, until)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]donations.Entry, 0, len(rows))
+	for _, row := range rows {
+		entries = append(entries, toDonationEntry(row))
+	}
+	return entries, nil
+}
+
+func buildDonationSummary(c context.Context, store *sqlite.Store, sess *sqlite.DonationSession, top int) (donationSummaryResponse, error) {
+	entries, err := donationSessionEntries(c, store, sess)
+	if err != nil {
+		return donationSummaryResponse{}, err
+	}
+	var goal int64
+	if sess != nil {
+		goal = sess.GoalMicros
+	}
+	return donationSummaryResponse{
+		Session: toDonationSessionResponse(sess),
+		Summary: donations.Summarize(entries, currentDonationRates(c), goal, top),
+	}, nil
+}
+
+// resolveDonationSession reads ?session=<id>, defaulting to the open session.
+// It returns a nil session with ok set when no session is open.
+func resolveDonationSession(w http.ResponseWriter, r *http.Request, store *sqlite.Store) (*sqlite.DonationSession, bool) {
+	raw := strings.TrimSpace(r.URL.Query().Get("session"))
+	if raw == "" || raw == "current" {
+		sess, err := store.CurrentDonationSession(r.Context())
+		if err != nil {
+			http.Error(w, "failed to load donation session", http.StatusInternalServerError)
+			return nil, false
+		}
+		return sess, true
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid session", http.StatusBadRequest)
+		return nil, false
+	}
+	sess, err := store.GetDonationSession(r.Context(), id)
+	if err != nil {
+		http.Error(w, "failed to load donation session", http.StatusInternalServerError)
+		return nil, false
+	}
+	if sess == nil {
+		http.Error(w, "donation session not found", http.StatusNotFound)
+		return nil, false
+	}
+	return sess, true
+}
+
+func handleDonationSummary(w http.ResponseWriter, r *http.Request) {
+	store, ok := donationStore(w)
+	if !ok {
+		return
+	}
+	sess, ok := resolveDonationSession(w, r, store)
+	if !ok {
+		return
+	}
+	top := defaultTopSupporters
+	if raw := strings.TrimSpace(r.URL.Query().Get("top")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid top", http.StatusBadRequest)
+			return
+		}
+		top = min(n, maxTopSupporters)
+	}
+	resp, err := buildDonationSummary(r.Context(), store, sess, top)
+	if err != nil {
+		http.Error(w, "failed to summarize donations", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, resp)
+}
+
+func handleListDonations(w http.ResponseWriter, r *http.Request) {
+	store, ok := donationStore(w)
+	if !ok {
+		return
+	}
+	sess, ok := resolveDonationSession(w, r, store)
+	if !ok {
+		return
+	}
+	entries, err := donationSessionEntries(r.Context(), store, sess)
+	if err != nil {
+		http.Error(w, "failed to list donations", http.StatusInternalServerError)
+		return
+	}
+	rates := currentDonationRates(r.Context())
+	items := make([]donationEntryResponse, 0, len(entries))
+	for _, e := range entries {
+		items = append(items, toDonationEntryResponse(e, rates))
+	}
+	writeJSON(w, map[string]any{
+		"session":       toDonationSessionResponse(sess),
+		"base_currency": rates.Base,
+		"items":         items,
+	})
+}
+
+func handleListDonationSessions(w http.ResponseWriter, r *http.Request) {
+	store, ok := donationStore(w)
+	if !ok {
+		return
+	}
+	sessions, err := store.ListDonationSessions(r.Context(), 100)
+	if err != nil {
+		http.Error(w, "failed to list donation sessions", http.StatusInternalServerError)
+		return
+	}
+	items := make([]*donationSessionResponse, 0, len(sessions))
+	for i := range sessions {
+		items = append(items, toDonationSessionResponse(&sessions[i]))
+	}
+	writeJSON(w, map[string]any{"items": items})
+}
+
+func decodeDonationSessionRequest(r *http.Request) (donationSessionRequest, error) {
+	var req donationSessionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return req, errors.New("invalid JSON body")
+	}
+	if req.Label != nil {
+		label := strings.TrimSpace(*req.Label)
+		if len(label) > maxDonationSessionLabel {
+			return req, fmt.Errorf("label must be at most %d characters", maxDonationSessionLabel)
+		}
+		req.Label = &label
+	}
+	if req.Goal != nil && *req.Goal < 0 {
+		return req, errors.New("goal must not be negative")
+	}
+	return req, nil
+}
+
+// handleStartDonationSession opens a new ledger session, ending the current one.
+func handleStartDonationSession(w http.ResponseWriter, r *http.Request) {
+	store, ok := donationStore(w)
+	if !ok {
+		return
+	}
+	req, err := decodeDonationSessionRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	label := ""
+	if req.Label != nil {
+		label = *req.Label
+	}
+	var goal int64
+	if req.Goal != nil {
+		goal = donations.ToMicros(*req.Goal)
+	}
+	sess, err := store.StartDonationSession(r.Context(), label, goal)
+	if err != nil {
+		log.Printf("donations: start session: %v", err)
+		http.Error(w, "failed to start donation session", http.StatusInternalServerError)
+		return
+	}
+	publishDonationSummary(r.Context(), store)
+	writeJSONStatus(w, http.StatusCreated, toDonationSessionResponse(sess))
+}
+
+// handleUpdateDonationSession changes the label or goal, or ends the session
+// with {"end":true}.
+func handleUpdateDonationSession(w http.ResponseWriter, r *http.Request) {
+	store, ok := donationStore(w)
+	if !ok {
+		return
+	}
+	id, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil || id <= 0 {
+		http.Error(w, "invalid session id", http.StatusBadRequest)
+		return
+	}
+	req, err := decodeDonationSessionRequest(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	var goal *int64
+	if req.Goal != nil {
+		micros := donations.ToMicros(*req.Goal)
+		goal = &micros
+	}
+	found, err := store.UpdateDonationSession(r.Context(), id, req.Label, goal, req.End)
+	if err != nil {
+		log.Printf("donations: update session %d: %v", id, err)
+		http.Error(w, "failed to update donation session", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "donation session not found", http.StatusNotFound)
+		return
+	}
+	sess, err := store.GetDonationSession(r.Context(), id)
+	if err != nil || sess == nil {
+		http.Error(w, "failed to load donation session", http.StatusInternalServerError)
+		return
+	}
+	if sess.EndedAt == nil {
+		publishDonationSummary(r.Context(), store)
+	}
+	writeJSON(w, toDonationSessionResponse(sess))
+}
+
+func handleGetDonationRates(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, currentDonationRates(r.Context()))
+}
+
+// handlePutDonationRates replaces the rate table. Totals are converted at read
+// time, so new rates apply to past sessions too.
+func handlePutDonationRates(w http.ResponseWriter, r *http.Request) {
+	store, ok := donationStore(w)
+	if !ok {
+		return
+	}
+	var req donations.Rates
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	rates, err := req.Normalize()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := saveDonationRates(r.Context(), rates); err != nil {
+		log.Printf("donations: save rates: %v", err)
+		http.Error(w, "failed to save donation rates", http.StatusInternalServerError)
+		return
+	}
+	publishDonationSummary(r.Context(), store)
+	writeJSON(w, rates)
+}
+
+// recordDonation publishes an updated "donations" frame when a paid event
+// lands inside the open ledger session. Replayed events never count.
+func recordDonation(ev events.Event) {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil || ingest.IsReplayID(ev.ID) {
+		return
+	}
+	micros, currency := donations.Amount(ev)
+	if micros <= 0 {
+		return
+	}
+	sess, err := store.CurrentDonationSession(ctx)
+	if err != nil {
+		log.Printf("donations: load current session: %v", err)
+		return
+	}
+	if sess == nil || ev.Timestamp.Before(sess.StartedAt) {
+		return
+	}
+	latest := toDonationEntryResponse(donations.Entry{
+		ID:           ev.ID,
+		Platform:     ev.Source,
+		Type:         ev.Type,
+		User:         ev.User,
+		AmountMicros: micros,
+		Currency:     currency,
+		Timestamp:    ev.Timestamp,
+	}, currentDonationRates(ctx))
+	publishSessionSummary(ctx, store, sess, &latest)
+}
+
+// publishDonationSummary broadcasts the open session's summary after a
+// session or rate change. Nothing is sent while no session is open.
+func publishDonationSummary(c context.Context, store *sqlite.Store) {
+	sess, err := store.CurrentDonationSession(c)
+	if err != nil {
+		log.Printf("donations: load current session: %v", err)
+		return
+	}
+	if sess != nil {
+		publishSessionSummary(c, store, sess, nil)
+	}
+}
+
+func publishSessionSummary(c context.Context, store *sqlite.Store, sess *sqlite.DonationSession, latest *donationEntryResponse) {
+	resp, err := buildDonationSummary(c, store, sess, liveTopSupporters)
+	if err != nil {
+		log.Printf("donations: summarize session %d: %v", sess.ID, err)
+		return
+	}
+	resp.Frame = "donations"
+	resp.Latest = latest
+	payload, err := json.Marshal(resp)
+	if err != nil {
+		log.Printf("donations: marshal summary: %v", err)
+		return
+	}
+	broadcastChatMessage(payload)
+}
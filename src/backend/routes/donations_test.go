@@ -0,0 +1,186 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/ingest"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func donationTestRouter(t *testing.T) (*mux.Router, *http.Cookie) {
+	t.Helper()
+	donationRatesCache.value = nil
+	t.Cleanup(func() { donationRatesCache.value = nil })
+	r := mux.NewRouter()
+	SetupDonationRoutes(r)
+	return r, seedTwitchSession(t)
+}
+
+func donationRequest(t *testing.T, r *mux.Router, cookie *http.Cookie, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestDonationLedgerSummaryAndLiveFrame(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	r, cookie := donationTestRouter(t)
+
+	if rec := donationRequest(t, r, nil, http.MethodPost, "/api/donations/sessions", `{}`); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a session, got %d", rec.Code)
+	}
+	if rec := donationRequest(t, r, cookie, http.MethodPut, "/api/donations/rates", `{"base":"USD","rates":{"EUR":1.1,"BITS":0.01}}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected rates to save, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec := donationRequest(t, r, cookie, http.MethodPost, "/api/donations/sessions", `{"label":"friday","goal":20}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var sess donationSessionResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &sess)
+
+	conn, cleanup := dialChatWS(t, "?source=twitch", nil)
+	defer cleanup()
+
+	now, err := time.Parse(time.RFC3339Nano, sess.StartedAt)
+	if err != nil {
+		t.Fatalf("parse started_at: %v", err)
+	}
+	BroadcastFromTailer(storage.Message{
+		ID:        "sc-1",
+		Timestamp: now,
+		Username:  "Erin",
+		Platform:  "YouTube",
+		RawJSON:   `{"liveChatPaidMessageRenderer":{"authorName":{"simpleText":"Erin"},"purchaseAmountText":{"simpleText":"€10,00"}}}`,
+	})
+	// The YouTube event itself is filtered out, but the totals frame is not
+	// tied to a source and still reaches a Twitch-only overlay.
+	frame := readWSFrame(t, conn)
+	if frame.Type != "donations" {
+		t.Fatalf("expected donations frame, got %+v", frame)
+	}
+	var live donationSummaryResponse
+	if err := json.Unmarshal(frame.Data, &live); err != nil {
+		t.Fatalf("decode donations frame: %v", err)
+	}
+	if live.Latest == nil || live.Latest.User != "Erin" || live.Latest.BaseMicros != 11_000_000 || live.Total != 11 {
+		t.Fatalf("unexpected live summary: %+v", live)
+	}
+
+	BroadcastFromTailer(storage.Message{
+		ID:        "cheer-1",
+		Timestamp: now.Add(time.Millisecond),
+		Username:  "Bob",
+		Platform:  "Twitch",
+		Text:      "Cheer500",
+		RawJSON:   `{"command":"PRIVMSG","tags":{"bits":"500","display-name":"Bob"},"body":"Cheer500"}`,
+	})
+
+	rec = donationRequest(t, r, nil, http.MethodGet, "/api/donations/summary", "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var summary donationSummaryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if summary.Frame != "" || summary.Session == nil || summary.Session.ID != sess.ID {
+		t.Fatalf("unexpected summary session: %+v", summary)
+	}
+	if summary.Total != 16 || summary.Count != 2 || summary.ByPlatform["Twitch"] != 5 || summary.ByPlatform["YouTube"] != 11 {
+		t.Fatalf("unexpected totals: %+v", summary.Summary)
+	}
+	if len(summary.TopSupporters) != 2 || summary.TopSupporters[0].User != "Erin" {
+		t.Fatalf("unexpected supporters: %+v", summary.TopSupporters)
+	}
+	if summary.Goal == nil || summary.Goal.Progress != 0.8 || summary.Goal.Remaining != 4 {
+		t.Fatalf("unexpected goal: %+v", summary.Goal)
+	}
+
+	// Ending the session closes its window at the current time, which must
+	// fall after both contributions.
+	time.Sleep(5 * time.Millisecond)
+	rec = donationRequest(t, r, cookie, http.MethodPatch, "/api/donations/sessions/"+jsonInt(sess.ID), `{"end":true}`)
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"active":false`) {
+		t.Fatalf("expected session to end, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = donationRequest(t, r, nil, http.MethodGet, "/api/donations?session="+jsonInt(sess.ID), "")
+	if rec.Code != http.StatusOK || strings.Count(rec.Body.String(), `"converted":true`) != 2 {
+		t.Fatalf("expected 2 converted entries, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := donationRequest(t, r, cookie, http.MethodPatch, "/api/donations/sessions/999", `{"goal":5}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for unknown session, got %d", rec.Code)
+	}
+}
+
+func TestReplayedEventsStayOutOfTheLedger(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	r, cookie := donationTestRouter(t)
+
+	if rec := donationRequest(t, r, cookie, http.MethodPost, "/api/donations/sessions", `{"label":"replay"}`); rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	conn, cleanup := dialChatWS(t, "", nil)
+	defer cleanup()
+
+	// A replay re-stamps captured rows with the current time, so only the ID
+	// tells them apart from live events.
+	BroadcastFromTailer(superChatRow(ingest.ReplayIDPrefix+"run:0:sc-old", "from the capture"))
+	BroadcastFromTailer(superChatRow("sc-live", "live one"))
+
+	var types []string
+	for len(types) < 3 {
+		types = append(types, readWSFrame(t, conn).Type)
+	}
+	if strings.Join(types, ",") != "event,event,donations" {
+		t.Fatalf("expected both events shown and one donations frame, got %v", types)
+	}
+
+	rec := donationRequest(t, r, nil, http.MethodGet, "/api/donations/summary", "")
+	var summary donationSummaryResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &summary); err != nil {
+		t.Fatalf("decode summary: %v", err)
+	}
+	if summary.Count != 1 || summary.Total != 5 {
+		t.Fatalf("expected only the live super chat in the ledger, got %+v", summary.Summary)
+	}
+	rec = httptest.NewRecorder()
+	handleListPlatformEvents(rec, httptest.NewRequest(http.MethodGet, "/api/events", nil))
+	if strings.Contains(rec.Body.String(), "sc-old") || !strings.Contains(rec.Body.String(), "sc-live") {
+		t.Fatalf("expected only the live event in history, got %s", rec.Body.String())
+	}
+}
+
+func TestDonationRatesValidation(t *testing.T) {
+	defer withSQLiteStore(t)()
+	r, cookie := donationTestRouter(t)
+
+	rec := donationRequest(t, r, nil, http.MethodGet, "/api/donations/rates", "")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"BITS":0.01`) {
+		t.Fatalf("expected default USD rates with bits, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := donationRequest(t, r, cookie, http.MethodPut, "/api/donations/rates", `{"base":"USD","rates":{"EUR":-1}}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a negative rate, got %d", rec.Code)
+	}
+}
+
+func jsonInt(n int64) string {
+	b, _ := json.Marshal(n)
+	return string(b)
+}
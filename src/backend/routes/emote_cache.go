@@ -0,0 +1,566 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/jdavasligil/emodl"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/runtimeconfig"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+)
+
+const defaultEmoteRefreshInterval = 6 * time.Hour
+
+// thirdPartyEmoteProviders is also the merge order: when two providers share an
+// emote name, the later provider wins.
+var thirdPartyEmoteProviders = []string{"7tv", "bttv", "ffz"}
+
+// emoteSetState is what we know about one provider's emotes for one scope.
+type emoteSetState struct {
+	emotes      map[string]Emote
+	origin      string
+	fetchedAt   time.Time
+	lastAttempt time.Time
+	lastError   string
+}
+
+type thirdPartyEmoteState struct {
+	// refreshMu serializes downloads; mu guards the fields below.
+	refreshMu   sync.Mutex
+	mu          sync.Mutex
+	scopes      []string
+	sets        map[string]*emoteSetState
+	lastRefresh time.Time
+	interval    time.Duration
+	started     bool
+}
+
+var thirdPartyEmotes thirdPartyEmoteState
+
+// Overridable in tests so refreshes do not reach Helix or the emote providers.
+var (
+	resolveThirdPartyEmoteTargets = func(cfg runtimeconfig.Config) (emodlTargets, error) {
+		_, targets, err := buildEmodlOptions(cfg)
+		return targets, err
+	}
+	downloadThirdPartyEmotes = downloadProviderEmotes
+)
+
+type thirdPartyEmoteStatus struct {
+	Provider    string `json:"provider"`
+	Scope       string `json:"scope"`
+	Count       int    `json:"count"`
+	Origin      string `json:"origin,omitempty"`
+	FetchedAt   string `json:"fetched_at,omitempty"`
+	LastAttempt string `json:"last_attempt,omitempty"`
+	LastError   string `json:"last_error,omitempty"`
+}
+
+type thirdPartyEmoteStatusResponse struct {
+	EmoteCount             int                     `json:"emote_count"`
+	RefreshIntervalSeconds int64                   `json:"refresh_interval_seconds"`
+	LastRefresh            string                  `json:"last_refresh,omitempty"`
+	Providers              []thirdPartyEmoteStatus `json:"providers"`
+	Error                  string                  `json:"error,omitempty"`
+}
+
+func emoteSetKey(provider, scope string) string {
+	return provider + "\x00" + scope
+}
+
+const (
+	// thirdPartyEmoteGlobalScope holds the providers' global emotes. It comes
+	// first, so channel emotes win a name clash.
+	thirdPartyEmoteGlobalScope = "global"
+	youTubeEmoteScopePrefix    = "youtube:"
+)
+
+// YouTube sets are keyed by channel ID rather than the source URL: a watch URL
+// changes with every stream, the channel does not.
+func youTubeEmoteScope(channelID string) string {
+	return youTubeEmoteScopePrefix + channelID
+}
+
+// youTubeChannelIDFromSource returns the channel ID when the source names it
+// directly, without asking the YouTube API.
+func youTubeChannelIDFromSource(source string) string {
+	if id := normalizeYouTubeChannelIDIdentity(source); id != "" {
+		return id
+	}
+	return normalizeYouTubeChannelIDIdentity(normalizeYouTubeSourceIdentity(source))
+}
+
+// withYouTubeEmoteScope replaces any YouTube scope in scopes with scope.
+func withYouTubeEmoteScope(scopes []string, scope string) []string {
+	next := make([]string, 0, len(scopes)+1)
+	for _, existing := range scopes {
+		if !strings.HasPrefix(existing, youTubeEmoteScopePrefix) {
+			next = append(next, existing)
+		}
+	}
+	return append(next, scope)
+}
+
+// thirdPartyEmoteScopes names the channels emotes are loaded for, after the
+// global scope every channel shares. Scopes come straight from config so the
+// cache can be restored without network access. A YouTube source that does
+// not name its channel yields no scope here; restoreThirdPartyEmotes falls
+// back to the last YouTube set instead.
+func thirdPartyEmoteScopes(cfg runtimeconfig.Config) []string {
+	scopes := []string{thirdPartyEmoteGlobalScope}
+	if login := normalizeTwitchChannelIdentity(cfg.TwitchChannel); login != "" {
+		scopes = append(scopes, "twitch:"+login)
+	}
+	if channelID := youTubeChannelIDFromSource(cfg.YouTubeSourceURL); channelID != "" {
+		scopes = append(scopes, youTubeEmoteScope(channelID))
+	}
+	return scopes
+}
+
+// fallbackYouTubeEmoteScope picks the YouTube scope to serve while the
+// channel behind a watch URL is unresolved: the one already served, else the
+// most recently fetched persisted set.
+func fallbackYouTubeEmoteScope() string {
+	thirdPartyEmotes.mu.Lock()
+	for _, scope := range thirdPartyEmotes.scopes {
+		if strings.HasPrefix(scope, youTubeEmoteScopePrefix) {
+			thirdPartyEmotes.mu.Unlock()
+			return scope
+		}
+	}
+	thirdPartyEmotes.mu.Unlock()
+
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		return ""
+	}
+	scope, err := store.LatestEmoteSetScope(ctx, youTubeEmoteScopePrefix)
+	if err != nil {
+		log.Printf("emodl: find cached youtube emotes: %v", err)
+	}
+	return scope
+}
+
+// providerEmotes is one provider's part of a download. err is set when that
+// provider could not be reached; its previous set is kept.
+type providerEmotes struct {
+	emotes map[string]Emote
+	err    error
+}
+
+// downloadProviderEmotes loads a channel's emotes from every provider, or only
+// the global ones when platform is empty, and splits them by provider. emodl
+// fetches the global sets on every load, so refreshThirdPartyEmotes strips
+// them from channel sets.
+func downloadProviderEmotes(platform, platformID string) map[string]providerEmotes {
+	options := emodl.DownloaderOptions{}
+	if platform != "" {
+		options.SevenTV = &emodl.SevenTVOptions{Platform: platform, PlatformID: platformID}
+		options.BTTV = &emodl.BTTVOptions{Platform: platform, PlatformID: platformID}
+		options.FFZ = &emodl.FFZOptions{Platform: platform, PlatformID: platformID}
+	}
+	downloader := emodl.NewDownloader(options)
+	_, err := downloader.Load()
+
+	out := make(map[string]providerEmotes, len(thirdPartyEmoteProviders))
+	for _, provider := range thirdPartyEmoteProviders {
+		out[provider] = providerEmotes{emotes: map[string]Emote{}}
+	}
+	for name, emote := range downloader.SevenTVEmotes {
+		converted, convErr := emote.AsEmote()
+		if convErr != nil {
+			continue
+		}
+		out["7tv"].emotes[name] = emoteFromEmodl(converted)
+	}
+	for name, emote := range downloader.BTTVEmotes {
+		out["bttv"].emotes[name] = emoteFromEmodl(emote.AsEmote())
+	}
+	for name, emote := range downloader.FFZEmotes {
+		out["ffz"].emotes[name] = emoteFromEmodl(emote.AsEmote())
+	}
+	if err != nil {
+		if provider := emodlErrorProvider(err); provider != "" {
+			out[provider] = providerEmotes{err: err}
+		}
+	}
+	return out
+}
+
+// emodlErrorProvider names the provider a Load error came from. emodl only
+// reports its last error and FFZ errors do not name FFZ, so anything that is
+// not 7TV or BTTV is FFZ. A 7TV emote that fails to convert is skipped rather
+// than failing the whole provider.
+func emodlErrorProvider(err error) string {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "7TV Emote"):
+		return ""
+	case strings.Contains(msg, "7TV"):
+		return "7tv"
+	case strings.Contains(msg, "BTTV"):
+		return "bttv"
+	}
+	return "ffz"
+}
+
+// withoutGlobalEmotes drops the global emotes emodl mixed into a channel set.
+// A channel emote that shadows a global one under the same name is kept.
+// Callers must hold thirdPartyEmotes.mu.
+func withoutGlobalEmotes(provider string, emotes map[string]Emote) map[string]Emote {
+	globals := thirdPartyEmotes.sets[emoteSetKey(provider, thirdPartyEmoteGlobalScope)]
+	if globals == nil || len(globals.emotes) == 0 {
+		return emotes
+	}
+	out := make(map[string]Emote, len(emotes))
+	for name, emote := range emotes {
+		if global, ok := globals.emotes[name]; ok && global.ID == emote.ID {
+			continue
+		}
+		out[name] = emote
+	}
+	return out
+}
+
+// mergedThirdPartyEmotes flattens the sets for the current scopes. Callers
+// must hold thirdPartyEmotes.mu.
+func mergedThirdPartyEmotes() map[string]Emote {
+	next := make(map[string]Emote)
+	for _, scope := range thirdPartyEmotes.scopes {
+		for _, provider := range thirdPartyEmoteProviders {
+			set := thirdPartyEmotes.sets[emoteSetKey(provider, scope)]
+			if set == nil {
+				continue
+			}
+			for name, emote := range set.emotes {
+				next[name] = emote
+			}
+		}
+	}
+	return next
+}
+
+// thirdPartyEmoteProvider returns the provider whose emote is served for
+// name, or "" if no third-party provider has it.
+func thirdPartyEmoteProvider(name string) string {
+	emoteCacheMu.RLock()
+	defer emoteCacheMu.RUnlock()
+	return tokenizer.EmoteCache[name].Provider
+}
+
+// withEmoteProvider tags every emote in a set with the provider it came from.
+func withEmoteProvider(provider string, emotes map[string]Emote) map[string]Emote {
+	out := make(map[string]Emote, len(emotes))
+	for name, emote := range emotes {
+		emote.Provider = provider
+		out[name] = emote
+	}
+	return out
+}
+
+// restoreThirdPartyEmotes switches to the scopes in cfg and serves whatever
+// emotes are already known for them, loading persisted sets from SQLite.
+func restoreThirdPartyEmotes(cfg runtimeconfig.Config) {
+	scopes := thirdPartyEmoteScopes(cfg)
+	if youTubeChannelIDFromSource(cfg.YouTubeSourceURL) == "" && normalizeYouTubeSourceIdentity(cfg.YouTubeSourceURL) != "" {
+		if scope := fallbackYouTubeEmoteScope(); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	thirdPartyEmotes.mu.Lock()
+	if thirdPartyEmotes.sets == nil {
+		thirdPartyEmotes.sets = make(map[string]*emoteSetState)
+	}
+	var missing []string
+	for _, scope := range scopes {
+		known := false
+		for _, provider := range thirdPartyEmoteProviders {
+			if thirdPartyEmotes.sets[emoteSetKey(provider, scope)] != nil {
+				known = true
+				break
+			}
+		}
+		if !known {
+			missing = append(missing, scope)
+		}
+	}
+	thirdPartyEmotes.mu.Unlock()
+
+	var restored []sqlite.EmoteSet
+	if store, ok := chatStore.(*sqlite.Store); ok && store != nil && len(missing) > 0 {
+		sets, err := store.EmoteSets(ctx, missing)
+		if err != nil {
+			log.Printf("emodl: restore cached emotes: %v", err)
+		}
+		restored = sets
+	}
+
+	thirdPartyEmotes.mu.Lock()
+	thirdPartyEmotes.scopes = scopes
+	for _, set := range restored {
+		emotes := map[string]Emote{}
+		if err := json.Unmarshal([]byte(set.EmotesJSON), &emotes); err != nil {
+			log.Printf("emodl: skip cached %s emotes for %s: %v", set.Provider, set.Scope, err)
+			continue
+		}
+		thirdPartyEmotes.sets[emoteSetKey(set.Provider, set.Scope)] = &emoteSetState{
+			emotes:    withEmoteProvider(set.Provider, emotes),
+			origin:    "cache",
+			fetchedAt: set.FetchedAt,
+		}
+	}
+	next := mergedThirdPartyEmotes()
+	thirdPartyEmotes.mu.Unlock()
+
+	replaceEmoteCache(next)
+	log.Printf("emodl: serving %d emotes (%d cached sets restored)", len(next), len(restored))
+}
+
+// refreshThirdPartyEmotes downloads every provider for the global scope and
+// every resolvable channel. A provider that fails keeps its previous set. It returns an error only when
+// nothing could be downloaded.
+func refreshThirdPartyEmotes(cfg runtimeconfig.Config) error {
+	thirdPartyEmotes.refreshMu.Lock()
+	defer thirdPartyEmotes.refreshMu.Unlock()
+
+	targets, buildErr := resolveThirdPartyEmoteTargets(cfg)
+	if buildErr != nil {
+		log.Printf("emodl: target resolution warning: %v", buildErr)
+	}
+	log.Printf(
+		"emodl: reload targets twitch_login=%q twitch_id=%q youtube_source=%q youtube_channel_id=%q",
+		targets.twitchLogin,
+		targets.twitchBroadcasterID,
+		targets.youTubeSourceURL,
+		targets.youTubeChannelID,
+	)
+
+	type scopeTarget struct {
+		scope      string
+		platform   string
+		platformID string
+	}
+	// The global scope is downloaded even when no channel resolves.
+	resolved := []scopeTarget{{scope: thirdPartyEmoteGlobalScope}}
+	thirdPartyEmotes.mu.Lock()
+	if !slices.Contains(thirdPartyEmotes.scopes, thirdPartyEmoteGlobalScope) {
+		thirdPartyEmotes.scopes = append([]string{thirdPartyEmoteGlobalScope}, thirdPartyEmotes.scopes...)
+	}
+	thirdPartyEmotes.mu.Unlock()
+	if targets.twitchLogin != "" && targets.twitchBroadcasterID != "" {
+		resolved = append(resolved, scopeTarget{"twitch:" + targets.twitchLogin, "twitch", targets.twitchBroadcasterID})
+	}
+	if targets.youTubeSourceURL != "" && targets.youTubeChannelID != "" {
+		scope := youTubeEmoteScope(targets.youTubeChannelID)
+		resolved = append(resolved, scopeTarget{scope, "youtube", targets.youTubeChannelID})
+		thirdPartyEmotes.mu.Lock()
+		thirdPartyEmotes.scopes = withYouTubeEmoteScope(thirdPartyEmotes.scopes, scope)
+		thirdPartyEmotes.mu.Unlock()
+	}
+
+	store, _ := chatStore.(*sqlite.Store)
+	var errs []error
+	updated := 0
+	for _, target := range resolved {
+		attempted := time.Now().UTC()
+		downloads := downloadThirdPartyEmotes(target.platform, target.platformID)
+		for _, provider := range thirdPartyEmoteProviders {
+			download, ok := downloads[provider]
+			if !ok {
+				download.err = errors.New("no emotes returned")
+			}
+			emotes, err := download.emotes, download.err
+			key := emoteSetKey(provider, target.scope)
+
+			thirdPartyEmotes.mu.Lock()
+			if thirdPartyEmotes.sets == nil {
+				thirdPartyEmotes.sets = make(map[string]*emoteSetState)
+			}
+			if err == nil {
+				emotes = withEmoteProvider(provider, emotes)
+				if target.scope != thirdPartyEmoteGlobalScope {
+					emotes = withoutGlobalEmotes(provider, emotes)
+				}
+			}
+			set := thirdPartyEmotes.sets[key]
+			if set == nil {
+				set = &emoteSetState{}
+				thirdPartyEmotes.sets[key] = set
+			}
+			set.lastAttempt = attempted
+			if err != nil {
+				set.lastError = err.Error()
+			} else {
+				set.emotes, set.origin, set.fetchedAt, set.lastError = emotes, "download", attempted, ""
+			}
+			thirdPartyEmotes.mu.Unlock()
+
+			if err != nil {
+				errs = append(errs, fmt.Errorf("%s (%s): %w", provider, target.scope, err))
+				continue
+			}
+			updated++
+			if store != nil {
+				encoded, encErr := json.Marshal(emotes)
+				if encErr == nil {
+					encErr = store.SaveEmoteSet(ctx, sqlite.EmoteSet{
+						Provider:   provider,
+						Scope:      target.scope,
+						EmotesJSON: string(encoded),
+						Count:      len(emotes),
+						FetchedAt:  attempted,
+					})
+				}
+				if encErr != nil {
+					log.Printf("emodl: persist %s emotes for %s: %v", provider, target.scope, encErr)
+				}
+			}
+		}
+	}
+
+	thirdPartyEmotes.mu.Lock()
+	thirdPartyEmotes.lastRefresh = time.Now().UTC()
+	var next map[string]Emote
+	if updated > 0 {
+		next = mergedThirdPartyEmotes()
+	}
+	thirdPartyEmotes.mu.Unlock()
+
+	if updated > 0 {
+		replaceEmoteCache(next)
+		log.Printf("emodl: cache size = %d", len(next))
+	}
+	if buildErr != nil {
+		errs = append(errs, fmt.Errorf("failed to resolve emote targets: %w", buildErr))
+	}
+	if len(errs) > 0 {
+		if updated == 0 {
+			return fmt.Errorf("failed to load third party emotes: %w", errors.Join(errs...))
+		}
+		log.Printf("emodl: partial refresh: %v", errors.Join(errs...))
+	}
+	return nil
+}
+
+// reloadThirdPartyEmotes switches to the channels in cfg, serving cached sets
+// immediately, then downloads fresh ones.
+func reloadThirdPartyEmotes(cfg runtimeconfig.Config) error {
+	restoreThirdPartyEmotes(cfg)
+	return refreshThirdPartyEmotes(cfg)
+}
+
+func thirdPartyEmoteRefreshInterval() time.Duration {
+	raw := strings.TrimSpace(os.Getenv("ELORA_EMOTE_REFRESH_INTERVAL"))
+	if raw == "" {
+		return defaultEmoteRefreshInterval
+	}
+	if raw == "0" {
+		return 0
+	}
+	interval, err := time.ParseDuration(raw)
+	if err != nil || interval < 0 {
+		log.Printf("emodl: invalid ELORA_EMOTE_REFRESH_INTERVAL %q, using %s", raw, defaultEmoteRefreshInterval)
+		return defaultEmoteRefreshInterval
+	}
+	return interval
+}
+
+// StartThirdPartyEmoteRefresher downloads emotes in the background now and
+// then every ELORA_EMOTE_REFRESH_INTERVAL (default 6h; 0 refreshes only at
+// startup). Calling it again is a no-op.
+func StartThirdPartyEmoteRefresher() {
+	interval := thirdPartyEmoteRefreshInterval()
+	thirdPartyEmotes.mu.Lock()
+	if thirdPartyEmotes.started {
+		thirdPartyEmotes.mu.Unlock()
+		return
+	}
+	thirdPartyEmotes.started = true
+	thirdPartyEmotes.interval = interval
+	thirdPartyEmotes.mu.Unlock()
+
+	go func() {
+		if err := refreshThirdPartyEmotes(currentRuntimeConfig()); err != nil {
+			log.Printf("emodl: %v", err)
+		}
+		if interval <= 0 {
+			return
+		}
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := refreshThirdPartyEmotes(currentRuntimeConfig()); err != nil {
+				log.Printf("emodl: %v", err)
+			}
+		}
+	}()
+}
+
+func thirdPartyEmoteStatusSnapshot() thirdPartyEmoteStatusResponse {
+	thirdPartyEmotes.mu.Lock()
+	defer thirdPartyEmotes.mu.Unlock()
+
+	resp := thirdPartyEmoteStatusResponse{
+		EmoteCount:             len(emoteCacheSnapshot()),
+		RefreshIntervalSeconds: int64(thirdPartyEmotes.interval / time.Second),
+		Providers:              []thirdPartyEmoteStatus{},
+	}
+	if !thirdPartyEmotes.lastRefresh.IsZero() {
+		resp.LastRefresh = thirdPartyEmotes.lastRefresh.Format(time.RFC3339)
+	}
+	for _, scope := range thirdPartyEmotes.scopes {
+		for _, provider := range thirdPartyEmoteProviders {
+			status := thirdPartyEmoteStatus{Provider: provider, Scope: scope}
+			if set := thirdPartyEmotes.sets[emoteSetKey(provider, scope)]; set != nil {
+				status.Count = len(set.emotes)
+				status.Origin = set.origin
+				status.LastError = set.lastError
+				if !set.fetchedAt.IsZero() {
+					status.FetchedAt = set.fetchedAt.Format(time.RFC3339)
+				}
+				if !set.lastAttempt.IsZero() {
+					status.LastAttempt = set.lastAttempt.Format(time.RFC3339)
+				}
+			}
+			resp.Providers = append(resp.Providers, status)
+		}
+	}
+	return resp
+}
+
+// SetupEmoteRoutes registers the third-party emote cache admin endpoints.
+func SetupEmoteRoutes(r *mux.Router) {
+	protected := r.PathPrefix("/api/emotes").Subrouter()
+	protected.Use(SessionMiddleware)
+	protected.HandleFunc("/status", handleThirdPartyEmoteStatus).Methods(http.MethodGet)
+	protected.HandleFunc("/reload", handleReloadThirdPartyEmotes).Methods(http.MethodPost)
+}
+
+func handleThirdPartyEmoteStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, thirdPartyEmoteStatusSnapshot())
+}
+
+// handleReloadThirdPartyEmotes forces a download of every provider. It
+// responds 502 when no provider could be reached; the previous emotes stay
+// in place either way.
+func handleReloadThirdPartyEmotes(w http.ResponseWriter, r *http.Request) {
+	err := reloadThirdPartyEmotes(currentRuntimeConfig())
+	resp := thirdPartyEmoteStatusSnapshot()
+	if err != nil {
+		resp.Error = err.Error()
+		writeJSONStatus(w, http.StatusBadGateway, resp)
+		return
+	}
+	writeJSON(w, resp)
+}
@@ -0,0 +1,265 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/runtimeconfig"
+)
+
+// withFakeEmoteProviders points the emote cache at the twitch channel "elora"
+// and serves downloads from fn instead of the network.
+// withFakeEmoteProviders serves downloads from fn, with platform "" for the
+// global scope and "twitch" for the fake channel.
+func withFakeEmoteProviders(t *testing.T, fn func(provider, platform string) (map[string]Emote, error)) runtimeconfig.Config {
+	t.Helper()
+	prevResolve, prevDownload := resolveThirdPartyEmoteTargets, downloadThirdPartyEmotes
+	runtimeState.mu.Lock()
+	prevConfig := runtimeState.current
+	runtimeState.current.TwitchChannel = "elora"
+	runtimeState.current.YouTubeSourceURL = ""
+	cfg := runtimeState.current
+	runtimeState.mu.Unlock()
+
+	resolveThirdPartyEmoteTargets = func(runtimeconfig.Config) (emodlTargets, error) {
+		return emodlTargets{twitchLogin: "elora", twitchBroadcasterID: "1234"}, nil
+	}
+	downloadThirdPartyEmotes = func(platform, platformID string) map[string]providerEmotes {
+		if platform != "" && (platform != "twitch" || platformID != "1234") {
+			t.Errorf("unexpected download target %s/%s", platform, platformID)
+		}
+		return fakeProviderDownload(func(provider string) (map[string]Emote, error) {
+			return fn(provider, platform)
+		})
+	}
+	resetThirdPartyEmotes()
+	t.Cleanup(func() {
+		resolveThirdPartyEmoteTargets, downloadThirdPartyEmotes = prevResolve, prevDownload
+		runtimeState.mu.Lock()
+		runtimeState.current = prevConfig
+		runtimeState.mu.Unlock()
+		resetThirdPartyEmotes()
+		replaceEmoteCache(nil)
+	})
+	return cfg
+}
+
+func fakeProviderDownload(fn func(provider string) (map[string]Emote, error)) map[string]providerEmotes {
+	out := map[string]providerEmotes{}
+	for _, provider := range thirdPartyEmoteProviders {
+		emotes, err := fn(provider)
+		out[provider] = providerEmotes{emotes: emotes, err: err}
+	}
+	return out
+}
+
+func resetThirdPartyEmotes() {
+	thirdPartyEmotes.mu.Lock()
+	thirdPartyEmotes.scopes = nil
+	thirdPartyEmotes.sets = nil
+	thirdPartyEmotes.lastRefresh = time.Time{}
+	thirdPartyEmotes.mu.Unlock()
+}
+
+func TestThirdPartyEmotesPersistAcrossRestart(t *testing.T) {
+	defer withSQLiteStore(t)()
+
+	providersDown := false
+	cfg := withFakeEmoteProviders(t, func(provider, platform string) (map[string]Emote, error) {
+		if providersDown || provider == "bttv" {
+			return nil, errors.New(provider + " unavailable")
+		}
+		if platform == "" {
+			return map[string]Emote{}, nil
+		}
+		name := provider + "Emote"
+		return map[string]Emote{name: {ID: provider + "-1", Name: name}}, nil
+	})
+
+	if err := reloadThirdPartyEmotes(cfg); err != nil {
+		t.Fatalf("reload returned error: %v", err)
+	}
+	cache := emoteCacheSnapshot()
+	if _, ok := cache["7tvEmote"]; !ok || len(cache) != 2 {
+		t.Fatalf("expected 7tv and ffz emotes, got %v", cache)
+	}
+	status := thirdPartyEmoteStatusSnapshot()
+	if len(status.Providers) != 6 || status.Providers[4].Provider != "bttv" || status.Providers[4].LastError == "" || status.Providers[3].Origin != "download" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	// A restart with every provider down still serves the persisted sets.
+	providersDown = true
+	resetThirdPartyEmotes()
+	replaceEmoteCache(nil)
+	restoreThirdPartyEmotes(cfg)
+	if cache := emoteCacheSnapshot(); len(cache) != 2 {
+		t.Fatalf("expected persisted emotes after restart, got %v", cache)
+	}
+	if err := refreshThirdPartyEmotes(cfg); err == nil {
+		t.Fatalf("expected an error when every provider fails")
+	}
+	status = thirdPartyEmoteStatusSnapshot()
+	if status.EmoteCount != 2 || status.Providers[3].Origin != "cache" || status.Providers[3].Count != 1 || status.Providers[3].LastError == "" {
+		t.Fatalf("unexpected status after failed refresh: %+v", status)
+	}
+}
+
+func TestGlobalEmotesLoadWithoutAChannel(t *testing.T) {
+	defer withSQLiteStore(t)()
+
+	cfg := withFakeEmoteProviders(t, func(provider, platform string) (map[string]Emote, error) {
+		global := provider + "Global"
+		emotes := map[string]Emote{global: {ID: provider + "-global", Name: global}}
+		if platform != "" {
+			// emodl mixes the globals into every channel download.
+			channel := provider + "Channel"
+			emotes[channel] = Emote{ID: provider + "-channel", Name: channel}
+		}
+		return emotes, nil
+	})
+	cfg.TwitchChannel = ""
+	resolved := false
+	resolveThirdPartyEmoteTargets = func(runtimeconfig.Config) (emodlTargets, error) {
+		if !resolved {
+			return emodlTargets{}, errors.New("no channel configured")
+		}
+		return emodlTargets{twitchLogin: "elora", twitchBroadcasterID: "1234"}, nil
+	}
+
+	if err := reloadThirdPartyEmotes(cfg); err != nil {
+		t.Fatalf("reload returned error: %v", err)
+	}
+	if cache := emoteCacheSnapshot(); len(cache) != 3 {
+		t.Fatalf("expected the global emotes without a channel, got %v", cache)
+	}
+	status := thirdPartyEmoteStatusSnapshot()
+	if len(status.Providers) != 3 || status.Providers[0].Scope != thirdPartyEmoteGlobalScope || status.Providers[0].Count != 1 {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+
+	// Channel sets keep only the channel's own emotes.
+	resolved = true
+	cfg.TwitchChannel = "elora"
+	if err := reloadThirdPartyEmotes(cfg); err != nil {
+		t.Fatalf("reload returned error: %v", err)
+	}
+	if cache := emoteCacheSnapshot(); len(cache) != 6 {
+		t.Fatalf("expected global and channel emotes, got %v", cache)
+	}
+	status = thirdPartyEmoteStatusSnapshot()
+	if len(status.Providers) != 6 || status.Providers[3].Scope != "twitch:elora" || status.Providers[3].Count != 1 {
+		t.Fatalf("expected channel sets without the globals, got %+v", status)
+	}
+}
+
+func TestYouTubeEmotesSurviveANewStreamURL(t *testing.T) {
+	defer withSQLiteStore(t)()
+
+	const channelID = "UC0123456789abcdefghijkl"
+	providersDown := false
+	cfg := withFakeEmoteProviders(t, nil)
+	cfg.TwitchChannel = ""
+	cfg.YouTubeSourceURL = "https://www.youtube.com/watch?v=aaaaaaaaaaa"
+	resolveThirdPartyEmoteTargets = func(cfg runtimeconfig.Config) (emodlTargets, error) {
+		if providersDown {
+			return emodlTargets{}, errors.New("youtube api unavailable")
+		}
+		return emodlTargets{youTubeSourceURL: normalizeYouTubeSourceIdentity(cfg.YouTubeSourceURL), youTubeChannelID: channelID}, nil
+	}
+	downloadThirdPartyEmotes = func(platform, platformID string) map[string]providerEmotes {
+		return fakeProviderDownload(func(provider string) (map[string]Emote, error) {
+			if platform == "" {
+				return map[string]Emote{}, nil
+			}
+			if platform != "youtube" || platformID != channelID {
+				t.Errorf("unexpected download target %s/%s", platform, platformID)
+			}
+			name := provider + "Emote"
+			return map[string]Emote{name: {ID: provider + "-1", Name: name}}, nil
+		})
+	}
+
+	if err := reloadThirdPartyEmotes(cfg); err != nil {
+		t.Fatalf("reload returned error: %v", err)
+	}
+	if cache := emoteCacheSnapshot(); len(cache) != 3 {
+		t.Fatalf("expected youtube emotes after reload, got %v", cache)
+	}
+
+	// The next stream has a new watch URL and the API is down at restart.
+	providersDown = true
+	cfg.YouTubeSourceURL = "https://www.youtube.com/watch?v=bbbbbbbbbbb"
+	resetThirdPartyEmotes()
+	replaceEmoteCache(nil)
+	restoreThirdPartyEmotes(cfg)
+	if cache := emoteCacheSnapshot(); len(cache) != 3 {
+		t.Fatalf("expected persisted youtube emotes for a new stream, got %v", cache)
+	}
+	status := thirdPartyEmoteStatusSnapshot()
+	if len(status.Providers) != 6 || status.Providers[3].Scope != youTubeEmoteScope(channelID) || status.Providers[3].Origin != "cache" {
+		t.Fatalf("unexpected status: %+v", status)
+	}
+}
+
+func TestThirdPartyEmoteRoutes(t *testing.T) {
+	defer withSQLiteStore(t)()
+
+	calls := 0
+	withFakeEmoteProviders(t, func(provider, platform string) (map[string]Emote, error) {
+		calls++
+		if platform == "" {
+			return map[string]Emote{}, nil
+		}
+		return map[string]Emote{provider: {ID: provider, Name: provider}}, nil
+	})
+	router := mux.NewRouter()
+	SetupEmoteRoutes(router)
+	cookie := seedTwitchSession(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/emotes/reload", nil)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusUnauthorized || calls != 0 {
+		t.Fatalf("expected 401 without a session, got %d (calls=%d)", rec.Code, calls)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/emotes/reload", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK || calls != 6 {
+		t.Fatalf("expected reload, got %d (calls=%d): %s", rec.Code, calls, rec.Body.String())
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/emotes/status", nil)
+	req.AddCookie(cookie)
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	var status thirdPartyEmoteStatusResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if status.EmoteCount != 3 || status.LastRefresh == "" || len(status.Providers) != 6 || status.Providers[5].Scope != "twitch:elora" {
+		t.Fatalf("unexpected status: %s", rec.Body.String())
+	}
+}
+
+func TestEmodlErrorProvider(t *testing.T) {
+	for msg, want := range map[string]string{
+		"emodl: 500: failure getting global BTTV emotes":       "bttv",
+		"emodl: 404: failure getting user 7TV emotes with opt": "7tv",
+		"7TV Emote has no host files":                          "",
+		"emodl: FFZ Emote Set ID=3 does not exist":             "ffz",
+	} {
+		if got := emodlErrorProvider(errors.New(msg)); got != want {
+			t.Fatalf("%q: expected %q, got %q", msg, want, got)
+		}
+	}
+}
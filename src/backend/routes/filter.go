@@ -0,0 +1,88 @@
+package routes
+
+import (
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/events"
+	"github.com/hpwn/EloraChat/src/backend/internal/runtimeconfig"
+	"github.com/hpwn/EloraChat/src/backend/internal/wordfilter"
+)
+
+// contentFilter is the compiled filter from the runtime config. It is swapped
+// whole on every config change, so readers never see a half-applied rule set.
+var contentFilter = struct {
+	mu     sync.RWMutex
+	filter *wordfilter.Filter
+}{}
+
+// SetContentFilter compiles and applies the runtime content filter. Rules are
+// validated by runtimeconfig.Normalize, so a compile error keeps the previous
+// filter and is only logged.
+func SetContentFilter(cfg runtimeconfig.FilterConfig) {
+	rules := make([]wordfilter.Rule, 0, len(cfg.Rules))
+	for _, rule := range cfg.Rules {
+		rules = append(rules, wordfilter.Rule{
+			Name:      rule.Name,
+			Words:     rule.Words,
+			Patterns:  rule.Patterns,
+			Platforms: rule.Platforms,
+			Action:    rule.Action,
+		})
+	}
+	filter, err := wordfilter.New(rules)
+	if err != nil {
+		log.Printf("filter: keeping previous rules: %v", err)
+		return
+	}
+	contentFilter.mu.Lock()
+	contentFilter.filter = filter
+	contentFilter.mu.Unlock()
+}
+
+func activeContentFilter() *wordfilter.Filter {
+	contentFilter.mu.RLock()
+	defer contentFilter.mu.RUnlock()
+	return contentFilter.filter
+}
+
+// filterMessage runs the content filter over a chat message, masking its text
+// and text fragments in place. Emote fragments are left alone.
+func filterMessage(msg *Message) wordfilter.Result {
+	filter := activeContentFilter()
+	if filter.Empty() {
+		return wordfilter.Result{Text: msg.Message}
+	}
+	platform := strings.ToLower(normalizeSource(msg.Source))
+	res := filter.Apply(platform, msg.Message)
+	if !res.Matched() {
+		return res
+	}
+	msg.Message = res.Text
+	for i, token := range msg.Tokens {
+		if token.Type == TokenTypeText || token.Type == TokenTypeCommand {
+			msg.Tokens[i].Text = filter.Mask(platform, token.Text)
+		}
+	}
+	return res
+}
+
+// filterPlatformEvent runs the content filter over the viewer's own text in a
+// platform event, such as a Super Chat message, masking it in place.
+func filterPlatformEvent(ev *events.Event) wordfilter.Result {
+	filter := activeContentFilter()
+	if filter.Empty() || ev.Message == "" {
+		return wordfilter.Result{Text: ev.Message}
+	}
+	res := filter.Apply(strings.ToLower(normalizeSource(ev.Source)), ev.Message)
+	if res.Matched() {
+		ev.Message = res.Text
+	}
+	return res
+}
+
+// filterReason describes a filter hit for the moderation queue.
+func filterReason(res wordfilter.Result) string {
+	return "filter: " + strings.Join(res.Rules, ", ")
+}
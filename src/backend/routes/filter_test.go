@@ -0,0 +1,84 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/runtimeconfig"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func TestContentFilterHotReloadsFromConfig(t *testing.T) {
+	resetRuntimeConfigForTest(t)
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	defer approvals.reset()
+	InitRoutes(chatStore)
+
+	cfg := currentRuntimeConfig()
+	cfg.Filter.Rules = []runtimeconfig.FilterRule{
+		{Name: "words", Words: []string{" Heck "}},
+		{Name: "links", Patterns: []string{`https?://\S+`}, Platforms: []string{"youtube"}, Action: "drop"},
+		{Name: "spoilers", Words: []string{"spoiler"}, Action: "flag"},
+	}
+	body, _ := json.Marshal(cfg)
+	rr := httptest.NewRecorder()
+	newConfigRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/api/config", bytes.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d body=%s", rr.Code, rr.Body.String())
+	}
+	var applied configAPIResponse
+	_ = json.Unmarshal(rr.Body.Bytes(), &applied)
+	if rules := applied.Config.Filter.Rules; len(rules) != 3 || rules[0].Words[0] != "heck" || rules[0].Action != runtimeconfig.FilterActionMask {
+		t.Fatalf("expected normalized rules, got %+v", rules)
+	}
+
+	conn, cleanup := dialChatWS(t, "", nil)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	BroadcastFromTailer(storage.Message{ID: "f1", Timestamp: now, Username: "yt", Platform: "YouTube", Text: "visit https://spam.example", RawJSON: "{}"})
+	BroadcastFromTailer(storage.Message{ID: "f2", Timestamp: now, Username: "tw", Platform: "Twitch", Text: "visit https://ok.example", RawJSON: "{}"})
+	BroadcastFromTailer(storage.Message{ID: "f3", Timestamp: now, Username: "tw", Platform: "Twitch", Text: "oh heck yes", RawJSON: "{}"})
+	BroadcastFromTailer(storage.Message{ID: "f4", Timestamp: now, Username: "tw", Platform: "Twitch", Text: "spoiler: heck", RawJSON: "{}"})
+
+	if got := chatFrameText(t, readWSFrame(t, conn)); got != "visit https://ok.example" {
+		t.Fatalf("expected the youtube link to be dropped, got %q", got)
+	}
+	if got := chatFrameText(t, readWSFrame(t, conn)); got != "oh **** yes" {
+		t.Fatalf("expected masked text, got %q", got)
+	}
+	// Flagged messages are held even outside approval mode; the raw feed
+	// still shows them.
+	if got := chatFrameText(t, readWSFrame(t, conn)); got != "spoiler: ****" {
+		t.Fatalf("expected flagged message on the raw feed, got %q", got)
+	}
+	held := approvals.list()
+	if len(held) != 1 || held[0].Reason != "filter: words, spoilers" || held[0].Text != "spoiler: ****" {
+		t.Fatalf("unexpected held messages: %+v", held)
+	}
+
+	// Replay applies the same rules to stored rows.
+	if payload, ok := storedPayload(storage.Message{ID: "f1", Timestamp: now, Username: "yt", Platform: "YouTube", Text: "visit https://spam.example", RawJSON: "{}"}, ""); ok {
+		t.Fatalf("expected dropped row to stay hidden on replay, got %s", payload)
+	}
+	payload, ok := storedPayload(storage.Message{ID: "f3", Timestamp: now, Username: "tw", Platform: "Twitch", Text: "oh heck yes", RawJSON: "{}"}, "")
+	if !ok || !bytes.Contains(payload, []byte(`"message":"oh **** yes"`)) {
+		t.Fatalf("expected masked replay, got %s", payload)
+	}
+
+	cfg.Filter.Rules = []runtimeconfig.FilterRule{{Name: "bad", Patterns: []string{"("}, Action: "ban"}}
+	body, _ = json.Marshal(cfg)
+	rr = httptest.NewRecorder()
+	newConfigRouter().ServeHTTP(rr, httptest.NewRequest(http.MethodPut, "/api/config", bytes.NewReader(body)))
+	var validation configValidationError
+	_ = json.Unmarshal(rr.Body.Bytes(), &validation)
+	if rr.Code != http.StatusBadRequest || len(validation.Details) != 2 {
+		t.Fatalf("expected action and pattern errors, got %d %s", rr.Code, rr.Body.String())
+	}
+}
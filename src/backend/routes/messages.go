@@ -110,6 +110,9 @@ func handleGetRecentMessages(w http.ResponseWriter, r *http.Request) {
 
 	resp := make([]messageResponse, 0, len(messages))
 	for _, msg := range messages {
+		if isViewerLinkCommand(msg) {
+			continue
+		}
 		resp = append(resp, messageResponse{
 			ID:         msg.ID,
 			Timestamp:  msg.Timestamp.UTC().Format(time.RFC3339Nano),
@@ -16,6 +16,8 @@ const (
 	maxExportLimit     = 100000
 )
 
+// exportRecord is one NDJSON export line. It matches the ingest record shape
+// so an export can be fed back through the replay driver with badges intact.
 type exportRecord struct {
 	ID         string `json:"id"`
 	Timestamp  string `json:"ts"`
@@ -23,6 +25,7 @@ type exportRecord struct {
 	Platform   string `json:"platform"`
 	Text       string `json:"text"`
 	EmotesJSON string `json:"emotes_json"`
+	BadgesJSON string `json:"badges_json,omitempty"`
 	RawJSON    string `json:"raw_json"`
 }
 
@@ -95,6 +98,9 @@ func handleExportMessages(w http.ResponseWriter, r *http.Request) {
 		enc := json.NewEncoder(w)
 		enc.SetEscapeHTML(false)
 		for _, msg := range messages {
+			if isViewerLinkCommand(msg) {
+				continue
+			}
 			record := exportRecord{
 				ID:         msg.ID,
 				Timestamp:  msg.Timestamp.UTC().Format(time.RFC3339Nano),
@@ -102,6 +108,7 @@ func handleExportMessages(w http.ResponseWriter, r *http.Request) {
 				Platform:   msg.Platform,
 				Text:       msg.Text,
 				EmotesJSON: msg.EmotesJSON,
+				BadgesJSON: msg.BadgesJSON,
 				RawJSON:    msg.RawJSON,
 			}
 			if err := enc.Encode(record); err != nil {
@@ -115,6 +122,9 @@ func handleExportMessages(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 		for _, msg := range messages {
+			if isViewerLinkCommand(msg) {
+				continue
+			}
 			row := []string{
 				msg.ID,
 				msg.Timestamp.UTC().Format(time.RFC3339Nano),
@@ -15,6 +15,7 @@ import (
 
 	"github.com/gorilla/mux"
 
+	"github.com/hpwn/EloraChat/src/backend/internal/ingest"
 	"github.com/hpwn/EloraChat/src/backend/internal/storage"
 	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
 )
@@ -46,6 +47,7 @@ func withSQLiteStore(t *testing.T) func() {
 	}
 
 	chatStore = store
+	resetTrendAnalyzer()
 
 	return func() {
 		if err := store.Close(context.Background()); err != nil {
@@ -434,6 +436,29 @@ func TestHandleExportMessagesNDJSON(t *testing.T) {
 	}
 }
 
+func TestHandleExportMessagesNDJSONKeepsBadgesForReplay(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+
+	badges := `[{"id":"subscriber","version":"12"}]`
+	msg := storage.Message{ID: "badged", Timestamp: time.Now().UTC(), Username: "erin", Platform: "twitch", Text: "hi", EmotesJSON: "[]", BadgesJSON: badges, RawJSON: "{}"}
+	if err := chatStore.InsertMessage(context.Background(), &msg); err != nil {
+		t.Fatalf("insert message: %v", err)
+	}
+
+	req := httptest.NewRequest(http.MethodGet, "/api/messages/export?format=ndjson", nil)
+	rr := httptest.NewRecorder()
+	newMessagesRouter().ServeHTTP(rr, req)
+
+	rec, err := ingest.DecodeRecord(json.RawMessage(strings.TrimSpace(rr.Body.String())))
+	if err != nil {
+		t.Fatalf("decode export line as an ingest record: %v", err)
+	}
+	if rec.ID != "badged" || rec.BadgesJSON != badges {
+		t.Fatalf("expected badges to survive the export, got %+v", rec)
+	}
+}
+
 func TestHandleExportMessagesCSV(t *testing.T) {
 	cleanup := withSQLiteStore(t)
 	defer cleanup()
@@ -466,6 +491,34 @@ func TestHandleExportMessagesCSV(t *testing.T) {
 	}
 }
 
+func TestHandleExportMessagesSkipsLinkCommands(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+
+	seedMessagesCount(t, 2)
+	link := storage.Message{ID: "link-e", Timestamp: time.Now().UTC(), Username: "Erin", Platform: "twitch", Text: "!link ELORA-ABCDEF", EmotesJSON: "[]", RawJSON: "{}"}
+	if err := chatStore.InsertMessage(context.Background(), &link); err != nil {
+		t.Fatalf("insert link command: %v", err)
+	}
+
+	router := newMessagesRouter()
+	for _, format := range []string{"ndjson", "csv"} {
+		req := httptest.NewRequest(http.MethodGet, "/api/messages/export?format="+format, nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: unexpected status: %d", format, rr.Code)
+		}
+		if strings.Contains(rr.Body.String(), "ELORA-ABCDEF") {
+			t.Fatalf("%s: expected the link command to be left out, got %s", format, rr.Body.String())
+		}
+		if !strings.Contains(rr.Body.String(), "text-1") {
+			t.Fatalf("%s: expected chat rows in the export, got %s", format, rr.Body.String())
+		}
+	}
+}
+
 func TestHandleExportMessagesConflictingCursors(t *testing.T) {
 	cleanup := withSQLiteStore(t)
 	defer cleanup()
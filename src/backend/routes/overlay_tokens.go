@@ -0,0 +1,426 @@
+package routes
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/overlaytoken"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+)
+
+const (
+	overlaySecretConfigKey = "overlay_token_secret"
+	defaultOverlayTokenTTL = 30 * 24 * time.Hour
+	maxOverlayTokenTTL     = 365 * 24 * time.Hour
+	maxOverlayTokenLabel   = 100
+)
+
+var errOverlayTokenRevoked = errors.New("overlay token revoked")
+
+// overlaySecret caches the generated signing secret. ELORA_OVERLAY_TOKEN_SECRET
+// takes precedence and is read on every call so rotation only needs a restart.
+var overlaySecret struct {
+	mu    sync.Mutex
+	value []byte
+}
+
+// sseTokenStreams tracks open /sse/chat streams by overlay token ID so
+// revoking a token ends them. WebSocket connections are found through
+// wsConnections instead.
+var sseTokenStreams = &tokenStreamRegistry{streams: make(map[string]map[chan struct{}]struct{})}
+
+type tokenStreamRegistry struct {
+	mu      sync.Mutex
+	streams map[string]map[chan struct{}]struct{}
+}
+
+// add registers a stream and returns a channel closed when its token is
+// revoked, plus a release func for when the stream ends on its own.
+func (reg *tokenStreamRegistry) add(tokenID string) (<-chan struct{}, func()) {
+	done := make(chan struct{})
+	reg.mu.Lock()
+	if reg.streams[tokenID] == nil {
+		reg.streams[tokenID] = make(map[chan struct{}]struct{})
+	}
+	reg.streams[tokenID][done] = struct{}{}
+	reg.mu.Unlock()
+	return done, func() {
+		reg.mu.Lock()
+		delete(reg.streams[tokenID], done)
+		if len(reg.streams[tokenID]) == 0 {
+			delete(reg.streams, tokenID)
+		}
+		reg.mu.Unlock()
+	}
+}
+
+// revoke ends every stream using the token and returns how many there were.
+func (reg *tokenStreamRegistry) revoke(tokenID string) int {
+	reg.mu.Lock()
+	streams := reg.streams[tokenID]
+	delete(reg.streams, tokenID)
+	reg.mu.Unlock()
+	for done := range streams {
+		close(done)
+	}
+	return len(streams)
+}
+
+type overlayTokenRequest struct {
+	Label      string   `json:"label"`
+	Sources    []string `json:"sources"`
+	TTLSeconds int64    `json:"ttl_seconds"`
+}
+
+type overlayTokenResponse struct {
+	ID        string   `json:"id"`
+	Token     string   `json:"token,omitempty"`
+	Label     string   `json:"label"`
+	Sources   []string `json:"sources"`
+	CreatedAt string   `json:"created_at"`
+	ExpiresAt string   `json:"expires_at"`
+	RevokedAt *string  `json:"revoked_at,omitempty"`
+	Status    string   `json:"status"`
+}
+
+// SetupOverlayTokenRoutes registers the endpoints streamers use to mint, list
+// and revoke overlay tokens. All of them require a logged-in session.
+func SetupOverlayTokenRoutes(r *mux.Router) {
+	protected := r.PathPrefix("/api/overlay-tokens").Subrouter()
+	protected.Use(SessionMiddleware)
+	protected.HandleFunc("", handleMintOverlayToken).Methods(http.MethodPost)
+	protected.HandleFunc("", handleListOverlayTokens).Methods(http.MethodGet)
+	protected.HandleFunc("/{id}", handleRevokeOverlayToken).Methods(http.MethodDelete)
+}
+
+func overlayTokenStore(w http.ResponseWriter) (*sqlite.Store, bool) {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		http.Error(w, "overlay tokens only supported with sqlite backend", http.StatusNotImplemented)
+		return nil, false
+	}
+	return store, true
+}
+
+func handleMintOverlayToken(w http.ResponseWriter, r *http.Request) {
+	store, ok := overlayTokenStore(w)
+	if !ok {
+		return
+	}
+
+	var req overlayTokenRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	label := strings.TrimSpace(req.Label)
+	if len(label) > maxOverlayTokenLabel {
+		http.Error(w, fmt.Sprintf("label must be at most %d characters", maxOverlayTokenLabel), http.StatusBadRequest)
+		return
+	}
+	sources, err := normalizeOverlaySources(req.Sources)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ttl := defaultOverlayTokenTTL
+	if req.TTLSeconds < 0 {
+		http.Error(w, "ttl_seconds must be positive", http.StatusBadRequest)
+		return
+	}
+	if req.TTLSeconds > 0 {
+		ttl = min(time.Duration(req.TTLSeconds)*time.Second, maxOverlayTokenTTL)
+	}
+
+	secret, err := overlayTokenSecret(r.Context())
+	if err != nil {
+		log.Printf("overlay: load signing secret: %v", err)
+		http.Error(w, "overlay token signing unavailable", http.StatusInternalServerError)
+		return
+	}
+
+	now := time.Now().UTC().Truncate(time.Second)
+	record := &sqlite.OverlayToken{
+		ID:        uuid.NewString(),
+		Label:     label,
+		Sources:   sources,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}
+	token, err := overlaytoken.Sign(secret, overlaytoken.Claims{
+		ID:        record.ID,
+		Label:     record.Label,
+		Sources:   record.Sources,
+		IssuedAt:  record.CreatedAt.Unix(),
+		ExpiresAt: record.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		log.Printf("overlay: sign token: %v", err)
+		http.Error(w, "overlay token signing failed", http.StatusInternalServerError)
+		return
+	}
+	if err := store.InsertOverlayToken(r.Context(), record); err != nil {
+		log.Printf("overlay: store token: %v", err)
+		http.Error(w, "failed to store overlay token", http.StatusInternalServerError)
+		return
+	}
+
+	resp := overlayTokenResponseFrom(*record, now)
+	resp.Token = token
+	writeJSONStatus(w, http.StatusCreated, resp)
+}
+
+func handleListOverlayTokens(w http.ResponseWriter, r *http.Request) {
+	store, ok := overlayTokenStore(w)
+	if !ok {
+		return
+	}
+	records, err := store.ListOverlayTokens(r.Context())
+	if err != nil {
+		log.Printf("overlay: list tokens: %v", err)
+		http.Error(w, "failed to list overlay tokens", http.StatusInternalServerError)
+		return
+	}
+	now := time.Now().UTC()
+	items := make([]overlayTokenResponse, 0, len(records))
+	for _, rec := range records {
+		items = append(items, overlayTokenResponseFrom(rec, now))
+	}
+	writeJSON(w, map[string]any{"items": items})
+}
+
+func handleRevokeOverlayToken(w http.ResponseWriter, r *http.Request) {
+	store, ok := overlayTokenStore(w)
+	if !ok {
+		return
+	}
+	id := mux.Vars(r)["id"]
+	revoked, err := store.RevokeOverlayToken(r.Context(), id)
+	if err != nil {
+		log.Printf("overlay: revoke token %s: %v", id, err)
+		http.Error(w, "failed to revoke overlay token", http.StatusInternalServerError)
+		return
+	}
+	if !revoked {
+		http.Error(w, "overlay token not found", http.StatusNotFound)
+		return
+	}
+	// Tokens are only verified at connect, so end streams already using it.
+	if n := wsConnections.disconnectToken(id, errOverlayTokenRevoked.Error()) + sseTokenStreams.revoke(id); n > 0 {
+		log.Printf("overlay: revoked token %s closed %d streams", id, n)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func overlayTokenResponseFrom(rec sqlite.OverlayToken, now time.Time) overlayTokenResponse {
+	sources := rec.Sources
+	if sources == nil {
+		sources = []string{}
+	}
+	resp := overlayTokenResponse{
+		ID:        rec.ID,
+		Label:     rec.Label,
+		Sources:   sources,
+		CreatedAt: rec.CreatedAt.Format(time.RFC3339),
+		ExpiresAt: rec.ExpiresAt.Format(time.RFC3339),
+		Status:    "active",
+	}
+	switch {
+	case rec.RevokedAt != nil:
+		ts := rec.RevokedAt.Format(time.RFC3339)
+		resp.RevokedAt = &ts
+		resp.Status = "revoked"
+	case !now.Before(rec.ExpiresAt):
+		resp.Status = "expired"
+	}
+	return resp
+}
+
+func normalizeOverlaySources(raw []string) ([]string, error) {
+	seen := make(map[string]struct{}, len(raw))
+	out := make([]string, 0, len(raw))
+	for _, s := range raw {
+		s = strings.ToLower(strings.TrimSpace(s))
+		if s == "" {
+			return nil, errors.New("sources must not contain empty values")
+		}
+		if _, dup := seen[s]; dup {
+			continue
+		}
+		seen[s] = struct{}{}
+		out = append(out, s)
+	}
+	return out, nil
+}
+
+// overlayTokenSecret returns the HMAC key for overlay tokens. Without
+// ELORA_OVERLAY_TOKEN_SECRET a random key is generated once and persisted in
+// config_kv so tokens survive restarts.
+func overlayTokenSecret(c context.Context) ([]byte, error) {
+	if env := strings.TrimSpace(os.Getenv("ELORA_OVERLAY_TOKEN_SECRET")); env != "" {
+		return []byte(env), nil
+	}
+
+	overlaySecret.mu.Lock()
+	defer overlaySecret.mu.Unlock()
+	if overlaySecret.value != nil {
+		return overlaySecret.value, nil
+	}
+	if chatStore == nil {
+		return nil, errors.New("storage not configured")
+	}
+
+	type secretRecord struct {
+		Secret string `json:"secret"`
+	}
+	rec, err := chatStore.GetConfig(c, overlaySecretConfigKey)
+	if err != nil {
+		return nil, err
+	}
+	if rec != nil {
+		var stored secretRecord
+		if err := json.Unmarshal([]byte(rec.ValueJSON), &stored); err == nil && stored.Secret != "" {
+			if key, err := base64.StdEncoding.DecodeString(stored.Secret); err == nil && len(key) > 0 {
+				overlaySecret.value = key
+				return key, nil
+			}
+		}
+		log.Printf("overlay: stored signing secret unreadable, generating a new one")
+	}
+
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, err
+	}
+	raw, err := json.Marshal(secretRecord{Secret: base64.StdEncoding.EncodeToString(key)})
+	if err != nil {
+		return nil, err
+	}
+	if err := chatStore.UpsertConfig(c, &storage.ConfigRecord{
+		Key:       overlaySecretConfigKey,
+		Version:   1,
+		ValueJSON: string(raw),
+	}); err != nil {
+		return nil, err
+	}
+	overlaySecret.value = key
+	return key, nil
+}
+
+func overlayTokensRequired() bool {
+	return isTruthy(os.Getenv("ELORA_OVERLAY_TOKENS_REQUIRED"))
+}
+
+// overlayTokenFromRequest reads a token from ?token= or an Authorization
+// bearer header. Browsers cannot set headers on WebSocket or EventSource
+// requests, so the query parameter is the common case.
+func overlayTokenFromRequest(r *http.Request) string {
+	if tok := strings.TrimSpace(r.URL.Query().Get("token")); tok != "" {
+		return tok
+	}
+	auth := strings.TrimSpace(r.Header.Get("Authorization"))
+	if len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+		return strings.TrimSpace(auth[7:])
+	}
+	return ""
+}
+
+// verifyOverlayToken checks the signature and expiry, then the stored record
+// so revoked tokens stop working immediately.
+func verifyOverlayToken(c context.Context, token string) (*overlaytoken.Claims, error) {
+	secret, err := overlayTokenSecret(c)
+	if err != nil {
+		return nil, err
+	}
+	claims, err := overlaytoken.Verify(secret, token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		return nil, errors.New("overlay tokens only supported with sqlite backend")
+	}
+	rec, err := store.GetOverlayToken(c, claims.ID)
+	if err != nil {
+		return nil, err
+	}
+	if rec == nil || rec.RevokedAt != nil {
+		return nil, errOverlayTokenRevoked
+	}
+	return &claims, nil
+}
+
+// sessionAuthorized reports whether the request carries a logged-in Twitch
+// session, which is always allowed to view streams.
+func sessionAuthorized(r *http.Request) bool {
+	cookie, err := r.Cookie("session_token")
+	if err != nil {
+		return false
+	}
+	_, data, err := loadSession(r.Context(), cookie.Value)
+	if err != nil || data == nil {
+		return false
+	}
+	services, _ := data["services"].([]any)
+	for _, s := range services {
+		if name, ok := s.(string); ok && name == "twitch" {
+			return true
+		}
+	}
+	return false
+}
+
+// authorizeChatStream gates /ws/chat and /sse/chat. It returns the verified
+// token claims (nil for unscoped access) and the effective source filter, or
+// an HTTP status and error when the request must be refused.
+func authorizeChatStream(r *http.Request, sourceFilter string) (*overlaytoken.Claims, string, int, error) {
+	token := overlayTokenFromRequest(r)
+	if token == "" {
+		if overlayTokensRequired() && !sessionAuthorized(r) {
+			return nil, "", http.StatusUnauthorized, errors.New("overlay token required")
+		}
+		return nil, sourceFilter, 0, nil
+	}
+
+	claims, err := verifyOverlayToken(r.Context(), token)
+	if err != nil {
+		log.Printf("overlay: rejected token: %v", err)
+		return nil, "", http.StatusUnauthorized, errors.New("invalid overlay token")
+	}
+	if sourceFilter != "" && !claims.Permits(sourceFilter) {
+		return nil, "", http.StatusForbidden, errors.New("source not permitted by overlay token")
+	}
+	// A token scoped to one source behaves like ?source= for that source.
+	if sourceFilter == "" && len(claims.Sources) == 1 {
+		sourceFilter = claims.Sources[0]
+	}
+	return claims, sourceFilter, 0, nil
+}
+
+// filterGrantedPayloads drops payloads whose source the token does not grant.
+func filterGrantedPayloads(grant *overlaytoken.Claims, payloads [][]byte) [][]byte {
+	if grant == nil || len(grant.Sources) == 0 {
+		return payloads
+	}
+	out := payloads[:0]
+	for _, p := range payloads {
+		if grantPermits(grant, p) {
+			out = append(out, p)
+		}
+	}
+	return out
+}
@@ -0,0 +1,291 @@
+package routes
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func seedTwitchSession(t *testing.T) *http.Cookie {
+	t.Helper()
+	data, _ := json.Marshal(map[string]any{
+		"services":     []string{"twitch"},
+		"token_expiry": time.Now().Add(time.Hour).Unix(),
+	})
+	err := chatStore.UpsertSession(context.Background(), &storage.Session{
+		Token:       "overlay-session",
+		Service:     "twitch",
+		DataJSON:    string(data),
+		TokenExpiry: time.Now().UTC().Add(time.Hour),
+		UpdatedAt:   time.Now().UTC(),
+	})
+	if err != nil {
+		t.Fatalf("seed session: %v", err)
+	}
+	return &http.Cookie{Name: "session_token", Value: "overlay-session"}
+}
+
+func mintOverlayToken(t *testing.T, router *mux.Router, cookie *http.Cookie, body string) overlayTokenResponse {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodPost, "/api/overlay-tokens", strings.NewReader(body))
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusCreated {
+		t.Fatalf("mint: expected 201, got %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp overlayTokenResponse
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode mint response: %v", err)
+	}
+	return resp
+}
+
+func dialChatWSStatus(t *testing.T, query string) int {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(StreamChat))
+	defer server.Close()
+	conn, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+query, nil)
+	if err == nil {
+		conn.Close()
+		t.Fatalf("expected dial %q to be refused", query)
+	}
+	if resp == nil {
+		t.Fatalf("dial %q: %v", query, err)
+	}
+	return resp.StatusCode
+}
+
+func TestOverlayTokenRoutesRequireSession(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	SetupOverlayTokenRoutes(router)
+
+	req := httptest.NewRequest(http.MethodPost, "/api/overlay-tokens", strings.NewReader(`{}`))
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without session, got %d", rr.Code)
+	}
+}
+
+func TestOverlayTokenMintListRevoke(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+	t.Setenv("ELORA_OVERLAY_TOKEN_SECRET", "test-secret")
+
+	router := mux.NewRouter()
+	SetupOverlayTokenRoutes(router)
+	cookie := seedTwitchSession(t)
+
+	minted := mintOverlayToken(t, router, cookie, `{"label":"members","sources":["Twitch","twitch"],"ttl_seconds":3600}`)
+	if minted.Token == "" || minted.Status != "active" || len(minted.Sources) != 1 || minted.Sources[0] != "twitch" {
+		t.Fatalf("unexpected mint response: %+v", minted)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/overlay-tokens", strings.NewReader(`{"sources":[" "]}`))
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for empty source, got %d", rr.Code)
+	}
+
+	claims, err := verifyOverlayToken(context.Background(), minted.Token)
+	if err != nil || claims.ID != minted.ID {
+		t.Fatalf("expected minted token to verify, got %+v err=%v", claims, err)
+	}
+
+	req = httptest.NewRequest(http.MethodDelete, "/api/overlay-tokens/"+minted.ID, nil)
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNoContent {
+		t.Fatalf("expected 204 on revoke, got %d", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 on second revoke, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodGet, "/api/overlay-tokens", nil)
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	var list struct {
+		Items []overlayTokenResponse `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.Items) != 1 || list.Items[0].Status != "revoked" || list.Items[0].Token != "" {
+		t.Fatalf("expected one revoked token without secret material, got %+v", list.Items)
+	}
+
+	if _, err := verifyOverlayToken(context.Background(), minted.Token); err == nil {
+		t.Fatalf("expected revoked token to be rejected")
+	}
+}
+
+func TestOverlayTokenSecretPersists(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+	t.Setenv("ELORA_OVERLAY_TOKEN_SECRET", "")
+
+	reset := func() {
+		overlaySecret.mu.Lock()
+		overlaySecret.value = nil
+		overlaySecret.mu.Unlock()
+	}
+	reset()
+	defer reset()
+
+	first, err := overlayTokenSecret(context.Background())
+	if err != nil || len(first) != 32 {
+		t.Fatalf("expected generated 32-byte secret, got %d bytes err=%v", len(first), err)
+	}
+	reset()
+	second, err := overlayTokenSecret(context.Background())
+	if err != nil || !bytes.Equal(first, second) {
+		t.Fatalf("expected persisted secret to be reused")
+	}
+}
+
+func TestStreamChatEnforcesOverlayTokens(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+	t.Setenv("ELORA_OVERLAY_TOKEN_SECRET", "test-secret")
+	t.Setenv("ELORA_WS_ENVELOPE", "false")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	router := mux.NewRouter()
+	SetupOverlayTokenRoutes(router)
+	minted := mintOverlayToken(t, router, seedTwitchSession(t), `{"label":"staff","sources":["twitch"]}`)
+	token := url.QueryEscape(minted.Token)
+
+	if code := dialChatWSStatus(t, "?token=bogus"); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for bad token, got %d", code)
+	}
+	if code := dialChatWSStatus(t, "?token="+token+"&source=youtube"); code != http.StatusForbidden {
+		t.Fatalf("expected 403 for ungranted source, got %d", code)
+	}
+
+	t.Setenv("ELORA_OVERLAY_TOKENS_REQUIRED", "true")
+	if code := dialChatWSStatus(t, ""); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token when required, got %d", code)
+	}
+
+	conn, closeConn := dialChatWS(t, "?token="+token, nil)
+	defer closeConn()
+	broadcastChatMessage([]byte(`{"author":"a","message":"hidden","source":"YouTube"}`))
+	broadcastChatMessage([]byte(`{"author":"a","message":"shown","source":"Twitch"}`))
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, frame, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if !strings.Contains(string(frame), `"shown"`) {
+		t.Fatalf("expected only the granted source, got %s", frame)
+	}
+
+	if err := conn.WriteJSON(map[string]any{"type": "set_filter", "id": "f", "source": "youtube"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_, frame, err = conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read reply: %v", err)
+	}
+	var reply wsReply
+	if err := json.Unmarshal(frame, &reply); err != nil || reply.Type != "error" {
+		t.Fatalf("expected error reply for ungranted filter, got %s", frame)
+	}
+
+	req := httptest.NewRequest(http.MethodDelete, "/api/overlay-tokens/"+minted.ID, nil)
+	req.AddCookie(&http.Cookie{Name: "session_token", Value: "overlay-session"})
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	expectKicked(t, conn, errOverlayTokenRevoked.Error())
+	if code := dialChatWSStatus(t, "?token="+token); code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 for revoked token, got %d", code)
+	}
+}
+
+func TestStreamChatSSEAcceptsBearerOverlayToken(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+	t.Setenv("ELORA_OVERLAY_TOKEN_SECRET", "test-secret")
+	t.Setenv("ELORA_OVERLAY_TOKENS_REQUIRED", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	router := mux.NewRouter()
+	SetupOverlayTokenRoutes(router)
+	minted := mintOverlayToken(t, router, seedTwitchSession(t), `{"sources":["twitch"]}`)
+
+	server := httptest.NewServer(http.HandlerFunc(StreamChatSSE))
+	defer server.Close()
+
+	resp, err := http.Get(server.URL)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without token, got %d", resp.StatusCode)
+	}
+
+	reqCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, server.URL, nil)
+	req.Header.Set("Authorization", "Bearer "+minted.Token)
+	before := subscriberCount()
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected 200 with bearer token, got %d", resp.StatusCode)
+	}
+	waitForSubscriberCount(t, before+1)
+
+	broadcastChatMessage([]byte(`{"author":"a","message":"hidden","source":"YouTube"}`))
+	broadcastChatMessage([]byte(`{"author":"a","message":"shown","source":"Twitch"}`))
+	reader := bufio.NewReader(resp.Body)
+	ev := readSSEEvent(t, reader)
+	if !strings.Contains(ev.Data, `"shown"`) {
+		t.Fatalf("expected only the granted source, got %s", ev.Data)
+	}
+
+	// Revoking the token ends the open stream.
+	revoke := httptest.NewRequest(http.MethodDelete, "/api/overlay-tokens/"+minted.ID, nil)
+	revoke.AddCookie(&http.Cookie{Name: "session_token", Value: "overlay-session"})
+	router.ServeHTTP(httptest.NewRecorder(), revoke)
+	done := make(chan error, 1)
+	go func() {
+		_, err := io.Copy(io.Discard, reader)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("expected the stream to end cleanly, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected revoking the token to end the SSE stream")
+	}
+}
@@ -0,0 +1,139 @@
+package routes
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/donations"
+	"github.com/hpwn/EloraChat/src/backend/internal/events"
+	"github.com/hpwn/EloraChat/src/backend/internal/ingest"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+	"github.com/hpwn/EloraChat/src/backend/internal/wordfilter"
+)
+
+const (
+	defaultPlatformEventsLimit = 50
+	maxPlatformEventsLimit     = 500
+)
+
+// eventPayload is the bus/history form of a platform event. Cursor is the
+// chat row's rowid when the event stands in for the chat line, so resume and
+// replay dedupe treat it like the message it replaced.
+type eventPayload struct {
+	Frame  string `json:"frame"`
+	Cursor int64  `json:"cursor,omitempty"`
+	events.Event
+}
+
+type platformEventsEnvelope struct {
+	Items        []json.RawMessage `json:"items"`
+	NextBeforeTS *int64            `json:"next_before_ts,omitempty"`
+}
+
+// SetupPlatformEventRoutes registers the platform event history endpoint.
+func SetupPlatformEventRoutes(r *mux.Router) {
+	r.HandleFunc("/api/events", handleListPlatformEvents).Methods(http.MethodGet)
+}
+
+// platformEventFor extracts the structured event carried by a stored row. The
+// content filter has already masked the event's text; callers act on Drop and
+// Flag in the returned result.
+func platformEventFor(m storage.Message) (events.Event, []byte, wordfilter.Result, bool) {
+	ev, ok := events.FromMessage(m)
+	if !ok {
+		return events.Event{}, nil, wordfilter.Result{}, false
+	}
+	ev.Source = normalizeSource(ev.Source)
+	filtered := filterPlatformEvent(&ev)
+	p := eventPayload{Frame: "event", Event: ev}
+	if ev.ReplacesChat() {
+		p.Cursor = m.RowID
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		log.Printf("events: failed to marshal %s event %s: %v", ev.Type, ev.ID, err)
+		return events.Event{}, nil, wordfilter.Result{}, false
+	}
+	return ev, data, filtered, true
+}
+
+// recordPlatformEvent stores the event for /api/events. Only the sqlite
+// backend keeps event history. Replayed events are shown but not stored: they
+// happened in the captured session, not this one.
+func recordPlatformEvent(ev events.Event, data []byte) {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil || ev.ID == "" || ingest.IsReplayID(ev.ID) {
+		return
+	}
+	// Amounts are stored in ledger form, so cheers record their bits.
+	micros, currency := donations.Amount(ev)
+	err := store.InsertPlatformEvent(ctx, sqlite.PlatformEvent{
+		ID:           ev.ID,
+		Timestamp:    ev.Timestamp,
+		Platform:     ev.Source,
+		Type:         ev.Type,
+		Channel:      ev.Channel,
+		Username:     ev.User,
+		AmountMicros: micros,
+		Currency:     currency,
+		Data:         string(data),
+	})
+	if err != nil {
+		log.Printf("events: failed to store %s event %s: %v", ev.Type, ev.ID, err)
+	}
+}
+
+func handleListPlatformEvents(w http.ResponseWriter, r *http.Request) {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		http.Error(w, "platform events only supported with sqlite backend", http.StatusNotImplemented)
+		return
+	}
+
+	q := r.URL.Query()
+	limit := defaultPlatformEventsLimit
+	if raw := strings.TrimSpace(q.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxPlatformEventsLimit)
+	}
+	var beforeTS int64
+	if raw := strings.TrimSpace(q.Get("before_ts")); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid before_ts", http.StatusBadRequest)
+			return
+		}
+		beforeTS = n
+	}
+
+	items, err := store.ListPlatformEvents(r.Context(), sqlite.PlatformEventQueryOpts{
+		Limit:    limit,
+		BeforeTS: beforeTS,
+		Type:     strings.ToLower(strings.TrimSpace(q.Get("type"))),
+		Platform: strings.TrimSpace(q.Get("source")),
+	})
+	if err != nil {
+		http.Error(w, "failed to list platform events", http.StatusInternalServerError)
+		return
+	}
+
+	env := platformEventsEnvelope{Items: make([]json.RawMessage, 0, len(items))}
+	for _, item := range items {
+		env.Items = append(env.Items, json.RawMessage(item.Data))
+	}
+	if len(items) == limit {
+		next := items[len(items)-1].Timestamp.UnixMilli()
+		env.NextBeforeTS = &next
+	}
+	writeJSON(w, env)
+}
@@ -0,0 +1,185 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/runtimeconfig"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func resubRow() storage.Message {
+	return storage.Message{
+		ID:        "evt-resub",
+		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+		Username:  "Alice",
+		Platform:  "Twitch",
+		Text:      "Alice subscribed for 14 months",
+		RawJSON:   `{"command":"USERNOTICE","channel":"chan","tags":{"msg-id":"resub","display-name":"Alice","msg-param-sub-plan":"1000","msg-param-cumulative-months":"14"},"body":"still here"}`,
+	}
+}
+
+func TestBroadcastFromTailerSendsEventFrames(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+
+	conn, cleanup := dialChatWS(t, "", nil)
+	defer cleanup()
+
+	BroadcastFromTailer(resubRow())
+	frame := readWSFrame(t, conn)
+	if frame.Type != "event" {
+		t.Fatalf("expected event frame, got %+v", frame)
+	}
+	var ev map[string]any
+	if err := json.Unmarshal(frame.Data, &ev); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	if ev["type"] != "resub" || ev["months"] != float64(14) || ev["tier"] != "1" || ev["message"] != "still here" {
+		t.Fatalf("unexpected event data: %v", ev)
+	}
+
+	// A cheer keeps its chat line and adds an event ahead of it.
+	BroadcastFromTailer(storage.Message{
+		ID:        "evt-cheer",
+		Timestamp: time.Now().UTC(),
+		Username:  "Bob",
+		Platform:  "Twitch",
+		Text:      "Cheer100 gg",
+		RawJSON:   `{"command":"PRIVMSG","channel":"chan","tags":{"bits":"100","display-name":"Bob"},"body":"Cheer100 gg"}`,
+	})
+	if frame := readWSFrame(t, conn); frame.Type != "event" {
+		t.Fatalf("expected cheer event first, got %+v", frame)
+	}
+	if frame := readWSFrame(t, conn); frame.Type != "chat" {
+		t.Fatalf("expected cheer chat line, got %+v", frame)
+	}
+
+	rec := httptest.NewRecorder()
+	handleListPlatformEvents(rec, httptest.NewRequest(http.MethodGet, "/api/events?type=resub", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var env struct {
+		Items []map[string]any `json:"items"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+		t.Fatalf("decode events: %v", err)
+	}
+	if len(env.Items) != 1 || env.Items[0]["id"] != "evt-resub" || env.Items[0]["frame"] != "event" {
+		t.Fatalf("unexpected stored events: %+v", env.Items)
+	}
+}
+
+func superChatRow(id, text string) storage.Message {
+	return storage.Message{
+		ID:        id,
+		Timestamp: time.Now().UTC().Truncate(time.Millisecond),
+		Username:  "Erin",
+		Platform:  "YouTube",
+		Text:      text,
+		RawJSON:   `{"liveChatPaidMessageRenderer":{"authorName":{"simpleText":"Erin"},"purchaseAmountText":{"simpleText":"$5.00"},"message":{"runs":[{"text":"` + text + `"}]}}}`,
+	}
+}
+
+func TestContentFilterAppliesToEventText(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	defer approvals.reset()
+	SetContentFilter(runtimeconfig.FilterConfig{Rules: []runtimeconfig.FilterRule{
+		{Name: "words", Words: []string{"heck"}, Action: "mask"},
+		{Name: "spam", Words: []string{"spam"}, Action: "drop"},
+		{Name: "spoilers", Words: []string{"spoiler"}, Action: "flag"},
+	}})
+	defer SetContentFilter(runtimeconfig.FilterConfig{})
+
+	conn, cleanup := dialChatWS(t, "", nil)
+	defer cleanup()
+
+	BroadcastFromTailer(superChatRow("sc-mask", "oh heck yes"))
+	BroadcastFromTailer(superChatRow("sc-drop", "buy spam"))
+	BroadcastFromTailer(superChatRow("sc-flag", "spoiler ahead"))
+
+	for _, want := range []string{"oh **** yes", "spoiler ahead"} {
+		frame := readWSFrame(t, conn)
+		var ev map[string]any
+		if err := json.Unmarshal(frame.Data, &ev); err != nil {
+			t.Fatalf("decode event: %v", err)
+		}
+		if frame.Type != "event" || ev["type"] != "superchat" || ev["message"] != want {
+			t.Fatalf("expected a super chat event with %q, got %s %v", want, frame.Type, ev)
+		}
+	}
+	held := approvals.list()
+	if len(held) != 1 || held[0].Kind != "event" || held[0].Reason != "filter: spoilers" {
+		t.Fatalf("expected the flagged super chat to be held, got %+v", held)
+	}
+
+	// Event history only has the masked super chat until the held one is
+	// approved.
+	listSuperChats := func() []map[string]any {
+		t.Helper()
+		rec := httptest.NewRecorder()
+		handleListPlatformEvents(rec, httptest.NewRequest(http.MethodGet, "/api/events?type=superchat", nil))
+		var env struct {
+			Items []map[string]any `json:"items"`
+		}
+		if err := json.Unmarshal(rec.Body.Bytes(), &env); err != nil {
+			t.Fatalf("decode events: %v", err)
+		}
+		return env.Items
+	}
+	if items := listSuperChats(); len(items) != 1 || items[0]["message"] != "oh **** yes" {
+		t.Fatalf("unexpected stored events: %+v", items)
+	}
+	approveHeld(held[0].ID)
+	if items := listSuperChats(); len(items) != 2 {
+		t.Fatalf("expected the approved super chat in history, got %+v", items)
+	}
+
+	// Replay applies the same rules.
+	if payload, ok := storedPayload(superChatRow("sc-drop", "buy spam"), ""); ok {
+		t.Fatalf("expected the dropped super chat to stay hidden on replay, got %s", payload)
+	}
+	payload, ok := storedPayload(superChatRow("sc-mask", "oh heck yes"), "")
+	if !ok || !strings.Contains(string(payload), `"message":"oh **** yes"`) {
+		t.Fatalf("expected a masked super chat on replay, got %s", payload)
+	}
+}
+
+func TestReplaySendsStoredEventsUnbatched(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Millisecond)
+	rows := []storage.Message{
+		{ID: "plain-1", Timestamp: base, Username: "a", Platform: "Twitch", Text: "one"},
+		resubRow(),
+		{ID: "plain-2", Timestamp: base.Add(2 * time.Second), Username: "b", Platform: "Twitch", Text: "two"},
+	}
+	rows[1].Timestamp = base.Add(time.Second)
+	for i := range rows {
+		if err := chatStore.InsertMessage(context.Background(), &rows[i]); err != nil {
+			t.Fatalf("insert %d: %v", i, err)
+		}
+	}
+
+	conn, cleanup := dialChatWS(t, "?replay=1&batch_ms=50&batch_max=10", nil)
+	defer cleanup()
+
+	var types []string
+	for range 3 {
+		types = append(types, readWSFrame(t, conn).Type)
+	}
+	if types[0] != "batch" || types[1] != "event" || types[2] != "batch" {
+		t.Fatalf("expected batch, event, batch frames, got %v", types)
+	}
+}
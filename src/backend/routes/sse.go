@@ -0,0 +1,265 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+)
+
+const (
+	sseResumeBatch       = 200
+	sseResumeMaxMessages = 1000
+)
+
+// StreamChatSSE streams the same sanitized chat payloads as /ws/chat as
+// Server-Sent Events. Each event id is the message cursor, so a reconnecting
+// EventSource resumes from Last-Event-ID without gaps or duplicates.
+func StreamChatSSE(w http.ResponseWriter, r *http.Request) {
+	if origin := r.Header.Get("Origin"); !originAllowed(origin) {
+		http.Error(w, "origin not allowed", http.StatusForbidden)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+
+	cfg := activeWebsocketConfig
+	query := r.URL.Query()
+	feed, err := chatFeedFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	hideBots, err := hideBotsFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	theme, err := usernameThemeFromQuery(query)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	grant, sourceFilter, status, err := authorizeChatStream(r, strings.ToLower(strings.TrimSpace(query.Get("source"))))
+	if err != nil {
+		http.Error(w, err.Error(), status)
+		return
+	}
+	shouldReplay := replayEnabled(query.Get("replay"))
+	lastID, err := parseLastEventID(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	// A nil channel never fires, so streams without a token are unaffected.
+	var revoked <-chan struct{}
+	if grant != nil {
+		var release func()
+		revoked, release = sseTokenStreams.add(grant.ID)
+		defer release()
+	}
+
+	// Subscribe before reading history so nothing broadcast in between is lost;
+	// overlap is dropped below by comparing cursors.
+	messageChan := addSubscriber()
+	defer removeSubscriber(messageChan)
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+
+	fmt.Fprint(w, "retry: 3000\n\n")
+
+	var backlog [][]byte
+	switch {
+	case feed == feedCurated:
+		if shouldReplay {
+			backlog = approvals.curatedAfter(0, sourceFilter)
+		}
+	case lastID > 0:
+		backlog = resumePayloadsAfter(lastID, sourceFilter)
+	case shouldReplay:
+		backlog = replayHistoryPayloads(sourceFilter)
+	}
+	backlog = theme.applyAll(filterBotPayloads(hideBots, filterGrantedPayloads(grant, backlog)))
+	// Approvals arrive out of cursor order, so the curated feed sends no event
+	// ids and does not resume from Last-Event-ID.
+	sent := &lastID
+	if feed == feedCurated {
+		sent = nil
+	}
+	for _, payload := range backlog {
+		if err := writeSSEChat(w, payload, sent); err != nil {
+			return
+		}
+	}
+	flusher.Flush()
+
+	ticker := time.NewTicker(cfg.pingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case m, ok := <-messageChan:
+			if !ok {
+				return
+			}
+			m, ok = feedPayload(feed, m)
+			if !ok {
+				continue
+			}
+			sanitized, err := sanitizeStreamPayload(m)
+			if err != nil {
+				if !errors.Is(err, errDropMessage) {
+					log.Println("json: ", err)
+				}
+				continue
+			}
+			if shouldSkipSource(sanitized, sourceFilter) {
+				continue
+			}
+			if !grantPermits(grant, sanitized) {
+				continue
+			}
+			if hideBots && isBotPayload(sanitized) {
+				continue
+			}
+			if err := writeSSEChat(w, theme.apply(sanitized), sent); err != nil {
+				log.Println("sse: write error:", err)
+				return
+			}
+			flusher.Flush()
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": keepalive\n\n"); err != nil {
+				return
+			}
+			flusher.Flush()
+		case <-revoked:
+			return
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// writeSSEChat writes one chat event. Payloads at or below the last sent
+// cursor are skipped; payloads without a cursor, or any payload when sent is
+// nil, are sent without an id. Frame payloads are sent under their frame name;
+// chat uses the default.
+func writeSSEChat(w http.ResponseWriter, payload []byte, sent *int64) error {
+	if cursor := payloadCursor(payload); cursor > 0 && sent != nil {
+		if cursor <= *sent {
+			return nil
+		}
+		*sent = cursor
+		if _, err := fmt.Fprintf(w, "id: %d\n", cursor); err != nil {
+			return err
+		}
+	}
+	if isFramePayload(payload) {
+		if _, err := fmt.Fprintf(w, "event: %s\n", sseEventName(framePayloadName(payload))); err != nil {
+			return err
+		}
+	}
+	// json.Marshal never emits raw newlines, but guard against pre-encoded input.
+	for _, line := range bytes.Split(payload, []byte("\n")) {
+		if _, err := fmt.Fprintf(w, "data: %s\n", line); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprint(w, "\n")
+	return err
+}
+
+// sseEventName maps a frame name to its SSE event name. "event" is a reserved
+// word for EventSource listeners, so platform events use "platform_event".
+func sseEventName(frame string) string {
+	if frame == "event" {
+		return "platform_event"
+	}
+	return frame
+}
+
+func parseLastEventID(r *http.Request) (int64, error) {
+	raw := strings.TrimSpace(r.Header.Get("Last-Event-ID"))
+	if raw == "" {
+		raw = strings.TrimSpace(r.URL.Query().Get("last_event_id"))
+	}
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, errors.New("invalid Last-Event-ID")
+	}
+	return id, nil
+}
+
+func payloadCursor(payload []byte) int64 {
+	var meta struct {
+		Cursor int64 `json:"cursor"`
+	}
+	if err := json.Unmarshal(payload, &meta); err != nil {
+		return 0
+	}
+	return meta.Cursor
+}
+
+// resumeGapFrame ends a resume that hit sseResumeMaxMessages while more rows
+// remained. Clients page on from next_cursor (Last-Event-ID or the WS history
+// command) or reload.
+type resumeGapFrame struct {
+	Frame      string `json:"frame"`
+	NextCursor int64  `json:"next_cursor"`
+}
+
+// resumePayloadsAfter returns stored messages after cursor, oldest first.
+// Resume needs rowid ordering, so it is only available on the sqlite store.
+// When the cap is reached with rows left over, the backlog ends with a gap frame.
+func resumePayloadsAfter(cursor int64, sourceFilter string) [][]byte {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		return nil
+	}
+	var out [][]byte
+	pos := storage.TailPosition{RowID: cursor}
+	for read := 0; ; {
+		if read >= sseResumeMaxMessages {
+			if more, _, err := store.TailNext(ctx, pos, 1); err == nil && len(more) > 0 {
+				if gap, err := json.Marshal(resumeGapFrame{Frame: "gap", NextCursor: pos.RowID}); err == nil {
+					out = append(out, gap)
+				}
+			}
+			break
+		}
+		rows, next, err := store.TailNext(ctx, pos, sseResumeBatch)
+		if err != nil {
+			log.Printf("sse: resume after cursor=%d failed: %v", cursor, err)
+			return out
+		}
+		for _, row := range rows {
+			if sanitized, ok := storedPayload(row, sourceFilter); ok {
+				out = append(out, sanitized)
+			}
+		}
+		read += len(rows)
+		if len(rows) < sseResumeBatch {
+			break
+		}
+		pos = next
+	}
+	return out
+}
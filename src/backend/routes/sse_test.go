@@ -0,0 +1,158 @@
+package routes
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+type sseEvent struct {
+	ID   string
+	Data string
+}
+
+func readSSEEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var ev sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatalf("read sse: %v", err)
+		}
+		line = strings.TrimRight(line, "\n")
+		switch {
+		case line == "":
+			if ev.Data != "" {
+				return ev
+			}
+		case strings.HasPrefix(line, "id: "):
+			ev.ID = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "data: "):
+			ev.Data += strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func TestStreamChatSSEResumesFromLastEventID(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	base := time.Now().UTC().Add(-time.Minute).Truncate(time.Millisecond)
+	for i := 0; i < 3; i++ {
+		msg := storage.Message{
+			ID:         fmt.Sprintf("sse-%d", i),
+			Timestamp:  base.Add(time.Duration(i) * time.Second),
+			Username:   "tester",
+			Platform:   "Twitch",
+			Text:       fmt.Sprintf("hello %d", i),
+			EmotesJSON: "[]",
+			RawJSON:    "{}",
+		}
+		if err := chatStore.InsertMessage(context.Background(), &msg); err != nil {
+			t.Fatalf("insert message %d: %v", i, err)
+		}
+	}
+	rows, err := chatStore.GetRecent(context.Background(), storage.QueryOpts{Limit: 3})
+	if err != nil || len(rows) != 3 {
+		t.Fatalf("get recent: %v (%d rows)", err, len(rows))
+	}
+	first := rows[len(rows)-1].RowID
+
+	server := httptest.NewServer(http.HandlerFunc(StreamChatSSE))
+	defer server.Close()
+	reqCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(reqCtx, http.MethodGet, server.URL, nil)
+	req.Header.Set("Last-Event-ID", fmt.Sprint(first))
+	before := subscriberCount()
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatalf("request: %v", err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Fatalf("expected text/event-stream, got %q", ct)
+	}
+	reader := bufio.NewReader(resp.Body)
+
+	for i, want := range []string{"hello 1", "hello 2"} {
+		ev := readSSEEvent(t, reader)
+		var payload struct {
+			Cursor  int64  `json:"cursor"`
+			Message string `json:"message"`
+		}
+		if err := json.Unmarshal([]byte(ev.Data), &payload); err != nil {
+			t.Fatalf("decode event %d: %v", i, err)
+		}
+		if payload.Message != want || ev.ID != fmt.Sprint(payload.Cursor) || payload.Cursor <= first {
+			t.Fatalf("unexpected resumed event %d: id=%s payload=%+v", i, ev.ID, payload)
+		}
+	}
+
+	waitForSubscriberCount(t, before+1)
+	// A live duplicate of an already-sent row is dropped; a new one is streamed.
+	broadcastChatMessage([]byte(fmt.Sprintf(`{"cursor":%d,"author":"tester","message":"dup","source":"Twitch"}`, rows[0].RowID)))
+	broadcastChatMessage([]byte(fmt.Sprintf(`{"cursor":%d,"author":"tester","message":"live","source":"Twitch"}`, rows[0].RowID+1)))
+	ev := readSSEEvent(t, reader)
+	if !strings.Contains(ev.Data, `"message":"live"`) || ev.ID != fmt.Sprint(rows[0].RowID+1) {
+		t.Fatalf("expected live event after resume, got id=%s data=%s", ev.ID, ev.Data)
+	}
+}
+
+func TestStreamChatSSERejectsBadLastEventID(t *testing.T) {
+	req := httptest.NewRequest(http.MethodGet, "/sse/chat", nil)
+	req.Header.Set("Last-Event-ID", "nope")
+	rr := httptest.NewRecorder()
+	StreamChatSSE(rr, req)
+	if rr.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rr.Code)
+	}
+}
+
+func TestResumePayloadsAfterEndsWithGapWhenTruncated(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	base := time.Now().UTC().Add(-time.Hour).Truncate(time.Millisecond)
+	for i := 0; i < sseResumeMaxMessages+5; i++ {
+		msg := storage.Message{
+			ID:         fmt.Sprintf("gap-%d", i),
+			Timestamp:  base.Add(time.Duration(i) * time.Millisecond),
+			Username:   "tester",
+			Platform:   "Twitch",
+			Text:       fmt.Sprintf("hello %d", i),
+			EmotesJSON: "[]",
+			RawJSON:    "{}",
+		}
+		if err := chatStore.InsertMessage(context.Background(), &msg); err != nil {
+			t.Fatalf("insert message %d: %v", i, err)
+		}
+	}
+
+	out := resumePayloadsAfter(0, "")
+	if len(out) != sseResumeMaxMessages+1 {
+		t.Fatalf("expected %d messages and a gap frame, got %d payloads", sseResumeMaxMessages, len(out))
+	}
+	var gap resumeGapFrame
+	if err := json.Unmarshal(out[len(out)-1], &gap); err != nil || gap.Frame != "gap" {
+		t.Fatalf("expected a trailing gap frame, got %s", out[len(out)-1])
+	}
+	if last := payloadCursor(out[len(out)-2]); gap.NextCursor != last {
+		t.Fatalf("expected next_cursor %d, got %d", last, gap.NextCursor)
+	}
+
+	rest := resumePayloadsAfter(gap.NextCursor, "")
+	if len(rest) != 5 || isFramePayload(rest[len(rest)-1]) {
+		t.Fatalf("expected the remaining 5 messages without a gap, got %d payloads", len(rest))
+	}
+}
@@ -0,0 +1,641 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+)
+
+const (
+	statsFlushInterval = 10 * time.Second
+	defaultStatsWindow = 24 * time.Hour
+	maxStatsWindow     = 90 * 24 * time.Hour
+	defaultTopEmotes   = 10
+	maxTopEmotes       = 100
+	emoteProviderOther = "other"
+	maxActivityBuckets = 2000
+)
+
+type emoteUsageKey struct {
+	bucket   int64
+	platform string
+	provider string
+	name     string
+}
+
+// emoteUsage buffers counts in memory; they are written to the rollup
+// buckets every statsFlushInterval and before every read.
+var emoteUsage = struct {
+	mu      sync.Mutex
+	pending map[emoteUsageKey]*sqlite.EmoteUsage
+}{}
+
+type activityKey struct {
+	minute   int64
+	platform string
+	channel  string
+}
+
+type activityCounts struct {
+	messages int64
+	chatters map[string]struct{}
+}
+
+func (c *activityCounts) add(chatter string) {
+	c.messages++
+	if chatter == "" {
+		return
+	}
+	if c.chatters == nil {
+		c.chatters = make(map[string]struct{})
+	}
+	c.chatters[chatter] = struct{}{}
+}
+
+func (c *activityCounts) merge(o *activityCounts) {
+	c.messages += o.messages
+	for chatter := range o.chatters {
+		if c.chatters == nil {
+			c.chatters = make(map[string]struct{})
+		}
+		c.chatters[chatter] = struct{}{}
+	}
+}
+
+// activity buffers per-minute rollups like emoteUsage, and keeps the current
+// minute per platform for the live stats frame.
+var activity = struct {
+	mu         sync.Mutex
+	pending    map[activityKey]*activityCounts
+	liveMinute time.Time
+	live       map[string]*activityCounts
+	lastFrame  []byte
+}{}
+
+var statsFlusher sync.Once
+
+type emoteStatsEntry struct {
+	Name     string `json:"name"`
+	ID       string `json:"id,omitempty"`
+	Uses     int64  `json:"uses"`
+	Messages int64  `json:"messages"`
+}
+
+type emoteStatsGroup struct {
+	Platform string            `json:"platform"`
+	Provider string            `json:"provider"`
+	Emotes   []emoteStatsEntry `json:"emotes"`
+}
+
+// emoteProviderFor attributes an emote used in a message: the platform itself
+// for first-party emotes carried on the message, else the third-party
+// provider serving the name.
+func emoteProviderFor(name, platform string, native map[string]struct{}) string {
+	if _, ok := native[name]; ok {
+		return platform
+	}
+	if provider := thirdPartyEmoteProvider(name); provider != "" {
+		return provider
+	}
+	return emoteProviderOther
+}
+
+// recordEmoteUsage counts the emote tokens of a live message.
+func recordEmoteUsage(msg Message, m storage.Message) {
+	if _, ok := chatStore.(*sqlite.Store); !ok {
+		return
+	}
+	platform := strings.ToLower(normalizeSource(msg.Source))
+	if platform == "" {
+		return
+	}
+
+	native := make(map[string]struct{}, len(msg.Emotes))
+	for _, e := range msg.Emotes {
+		native[e.Name] = struct{}{}
+	}
+	if platform == "twitch" {
+		for _, e := range decodeTwitchSpans(m.Text, m.EmotesJSON) {
+			native[e.Name] = struct{}{}
+		}
+	}
+
+	counts := make(map[string]int64)
+	ids := make(map[string]string)
+	for _, tok := range msg.Tokens {
+		if tok.Type != TokenTypeEmote {
+			continue
+		}
+		name := tok.Emote.Name
+		if name == "" {
+			name = tok.Text
+		}
+		if strings.TrimSpace(name) == "" {
+			continue
+		}
+		counts[name]++
+		if tok.Emote.ID != "" {
+			ids[name] = tok.Emote.ID
+		}
+	}
+	if len(counts) == 0 {
+		return
+	}
+
+	ts := m.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	bucket := ts.UTC().Truncate(sqlite.StatsBucket)
+
+	emoteUsage.mu.Lock()
+	if emoteUsage.pending == nil {
+		emoteUsage.pending = make(map[emoteUsageKey]*sqlite.EmoteUsage)
+	}
+	for name, uses := range counts {
+		provider := emoteProviderFor(name, platform, native)
+		key := emoteUsageKey{bucket: bucket.UnixMilli(), platform: platform, provider: provider, name: name}
+		entry := emoteUsage.pending[key]
+		if entry == nil {
+			entry = &sqlite.EmoteUsage{Bucket: bucket, Platform: platform, Provider: provider, EmoteName: name}
+			emoteUsage.pending[key] = entry
+		}
+		entry.Uses += uses
+		entry.Messages++
+		if ids[name] != "" {
+			entry.EmoteID = ids[name]
+		}
+	}
+	emoteUsage.mu.Unlock()
+}
+
+// flushEmoteUsage writes buffered counts. On failure they are kept for the
+// next flush.
+func flushEmoteUsage() error {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		return nil
+	}
+	emoteUsage.mu.Lock()
+	pending := emoteUsage.pending
+	emoteUsage.pending = nil
+	emoteUsage.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	usage := make([]sqlite.EmoteUsage, 0, len(pending))
+	for _, entry := range pending {
+		usage = append(usage, *entry)
+	}
+	if err := store.AddEmoteUsage(ctx, usage); err != nil {
+		emoteUsage.mu.Lock()
+		if emoteUsage.pending == nil {
+			emoteUsage.pending = make(map[emoteUsageKey]*sqlite.EmoteUsage)
+		}
+		for key, entry := range pending {
+			if existing := emoteUsage.pending[key]; existing != nil {
+				existing.Uses += entry.Uses
+				existing.Messages += entry.Messages
+				continue
+			}
+			emoteUsage.pending[key] = entry
+		}
+		emoteUsage.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// recordActivity counts a live message towards the per-minute rollups and the
+// live stats frame.
+func recordActivity(msg Message, m storage.Message) {
+	platform := strings.ToLower(normalizeSource(msg.Source))
+	if platform == "" {
+		return
+	}
+	_, chatter := viewerAccountOf(platform, m)
+	if chatter == "" {
+		chatter = strings.ToLower(strings.TrimSpace(m.Username))
+	}
+	ts := m.Timestamp
+	if ts.IsZero() {
+		ts = time.Now()
+	}
+	key := activityKey{
+		minute:   ts.UTC().Truncate(time.Minute).UnixMilli(),
+		platform: platform,
+		channel:  msg.SourceChannel,
+	}
+	_, persist := chatStore.(*sqlite.Store)
+
+	activity.mu.Lock()
+	defer activity.mu.Unlock()
+	if persist {
+		if activity.pending == nil {
+			activity.pending = make(map[activityKey]*activityCounts)
+		}
+		counts := activity.pending[key]
+		if counts == nil {
+			counts = &activityCounts{}
+			activity.pending[key] = counts
+		}
+		counts.add(chatter)
+	}
+	rollLiveActivity(time.Now())
+	live := activity.live[platform]
+	if live == nil {
+		live = &activityCounts{}
+		activity.live[platform] = live
+	}
+	live.add(chatter)
+}
+
+// rollLiveActivity starts a new live minute when now has moved past the
+// current one. Callers must hold activity.mu.
+func rollLiveActivity(now time.Time) {
+	minute := now.UTC().Truncate(time.Minute)
+	if activity.live == nil || !activity.liveMinute.Equal(minute) {
+		activity.liveMinute = minute
+		activity.live = make(map[string]*activityCounts)
+	}
+}
+
+// flushActivity writes buffered activity. On failure it is kept for the next
+// flush.
+func flushActivity() error {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		return nil
+	}
+	activity.mu.Lock()
+	pending := activity.pending
+	activity.pending = nil
+	activity.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+
+	minutes := make([]sqlite.ActivityMinute, 0, len(pending))
+	for key, counts := range pending {
+		chatters := make([]string, 0, len(counts.chatters))
+		for chatter := range counts.chatters {
+			chatters = append(chatters, chatter)
+		}
+		minutes = append(minutes, sqlite.ActivityMinute{
+			Minute:   time.UnixMilli(key.minute),
+			Platform: key.platform,
+			Channel:  key.channel,
+			Messages: counts.messages,
+			Chatters: chatters,
+		})
+	}
+	if err := store.AddActivity(ctx, minutes); err != nil {
+		activity.mu.Lock()
+		if activity.pending == nil {
+			activity.pending = make(map[activityKey]*activityCounts)
+		}
+		for key, counts := range pending {
+			existing := activity.pending[key]
+			if existing == nil {
+				activity.pending[key] = counts
+				continue
+			}
+			existing.merge(counts)
+		}
+		activity.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+type activityCountResponse struct {
+	Messages int64 `json:"messages"`
+	Chatters int64 `json:"chatters"`
+}
+
+// statsFrame is the live "stats" frame: activity in the current minute.
+type statsFrame struct {
+	Frame     string                           `json:"frame"`
+	Minute    string                           `json:"minute"`
+	Messages  int64                            `json:"messages"`
+	Chatters  int64                            `json:"chatters"`
+	Platforms map[string]activityCountResponse `json:"platforms"`
+}
+
+// publishStatsFrame broadcasts the current minute's activity if it changed
+// since the last frame. A new minute always sends a frame, so overlays see the
+// counters reset.
+func publishStatsFrame(now time.Time) {
+	activity.mu.Lock()
+	rollLiveActivity(now)
+	frame := statsFrame{
+		Frame:     "stats",
+		Minute:    activity.liveMinute.Format(time.RFC3339),
+		Platforms: make(map[string]activityCountResponse, len(activity.live)),
+	}
+	for platform, counts := range activity.live {
+		count := activityCountResponse{Messages: counts.messages, Chatters: int64(len(counts.chatters))}
+		frame.Platforms[platform] = count
+		frame.Messages += count.Messages
+		frame.Chatters += count.Chatters
+	}
+	payload, err := json.Marshal(frame)
+	if err != nil {
+		activity.mu.Unlock()
+		log.Printf("stats: marshal frame: %v", err)
+		return
+	}
+	if bytes.Equal(payload, activity.lastFrame) {
+		activity.mu.Unlock()
+		return
+	}
+	activity.lastFrame = payload
+	activity.mu.Unlock()
+	broadcastChatMessage(payload)
+}
+
+func flushStats() {
+	if err := flushEmoteUsage(); err != nil {
+		log.Printf("stats: flush emote usage: %v", err)
+	}
+	if err := flushActivity(); err != nil {
+		log.Printf("stats: flush activity: %v", err)
+	}
+}
+
+// StartStatsFlusher writes buffered statistics and publishes the live stats
+// frame every few seconds. Calling it again is a no-op.
+func StartStatsFlusher() {
+	statsFlusher.Do(func() {
+		go func() {
+			ticker := time.NewTicker(statsFlushInterval)
+			defer ticker.Stop()
+			for now := range ticker.C {
+				flushStats()
+				publishStatsFrame(now)
+			}
+		}()
+	})
+}
+
+// parseStatsWindow accepts Go durations plus a day suffix, e.g. "90m", "24h"
+// or "7d".
+func parseStatsWindow(raw string) (time.Duration, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultStatsWindow, nil
+	}
+	var window time.Duration
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, errors.New("invalid window")
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return 0, errors.New("invalid window")
+		}
+		window = d
+	}
+	if window <= 0 {
+		return 0, errors.New("window must be positive")
+	}
+	if window > maxStatsWindow {
+		return 0, errors.New("window must be at most 90d")
+	}
+	return window, nil
+}
+
+// SetupStatsRoutes registers the chat statistics endpoints. They are public
+// so dashboards and overlays can poll them.
+func SetupStatsRoutes(r *mux.Router) {
+	r.HandleFunc("/api/stats/emotes", handleEmoteStats).Methods(http.MethodGet)
+	r.HandleFunc("/api/stats/activity", handleActivityStats).Methods(http.MethodGet)
+	r.HandleFunc("/api/stats/trending", handleTrending).Methods(http.MethodGet)
+}
+
+func statsStore(w http.ResponseWriter) (*sqlite.Store, bool) {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		http.Error(w, "stats only supported with sqlite backend", http.StatusNotImplemented)
+		return nil, false
+	}
+	return store, true
+}
+
+// handleEmoteStats returns the most used emotes in the window, grouped by
+// platform and provider.
+func handleEmoteStats(w http.ResponseWriter, r *http.Request) {
+	store, ok := statsStore(w)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	window, err := parseStatsWindow(query.Get("window"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	limit := defaultTopEmotes
+	if raw := strings.TrimSpace(query.Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, maxTopEmotes)
+	}
+
+	flushStats()
+	since := time.Now().UTC().Add(-window)
+	rows, err := store.TopEmotes(r.Context(), sqlite.EmoteUsageQueryOpts{
+		Since:    since,
+		Platform: query.Get("platform"),
+		Provider: query.Get("provider"),
+		Limit:    limit,
+	})
+	if err != nil {
+		log.Printf("stats: top emotes: %v", err)
+		http.Error(w, "failed to load emote stats", http.StatusInternalServerError)
+		return
+	}
+
+	groups := []emoteStatsGroup{}
+	for _, row := range rows {
+		if n := len(groups); n == 0 || groups[n-1].Platform != row.Platform || groups[n-1].Provider != row.Provider {
+			groups = append(groups, emoteStatsGroup{Platform: row.Platform, Provider: row.Provider, Emotes: []emoteStatsEntry{}})
+		}
+		group := &groups[len(groups)-1]
+		group.Emotes = append(group.Emotes, emoteStatsEntry{
+			Name:     row.EmoteName,
+			ID:       row.EmoteID,
+			Uses:     row.Uses,
+			Messages: row.Messages,
+		})
+	}
+	writeJSON(w, map[string]any{
+		"window": window.String(),
+		"since":  since.Truncate(sqlite.StatsBucket).Format(time.RFC3339),
+		"groups": groups,
+	})
+}
+
+// parseStatsTime accepts unix milliseconds or RFC3339.
+func parseStatsTime(raw, param string) (time.Time, bool, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return time.Time{}, false, nil
+	}
+	if ms, err := strconv.ParseInt(raw, 10, 64); err == nil && ms >= 0 {
+		return time.UnixMilli(ms).UTC(), true, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid %s", param)
+	}
+	return t.UTC(), true, nil
+}
+
+type activityBucketResponse struct {
+	Start string `json:"start"`
+	activityCountResponse
+	Platforms map[string]activityCountResponse `json:"platforms"`
+}
+
+type activityTotalsResponse struct {
+	activityCountResponse
+	Platforms map[string]activityCountResponse `json:"platforms"`
+}
+
+type activityChannelResponse struct {
+	Platform string `json:"platform"`
+	Channel  string `json:"channel"`
+	activityCountResponse
+}
+
+type activityStatsResponse struct {
+	From     string                    `json:"from"`
+	To       string                    `json:"to"`
+	Bucket   string                    `json:"bucket"`
+	Totals   activityTotalsResponse    `json:"totals"`
+	Buckets  []activityBucketResponse  `json:"buckets"`
+	Channels []activityChannelResponse `json:"channels"`
+}
+
+// handleActivityStats returns messages and unique chatters per bucket between
+// from and to (default: the last 24 hours in 1h buckets). Empty buckets are
+// included so the result can be charted directly.
+func handleActivityStats(w http.ResponseWriter, r *http.Request) {
+	store, ok := statsStore(w)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	to, hasTo, err := parseStatsTime(query.Get("to"), "to")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !hasTo {
+		to = time.Now().UTC()
+	}
+	from, hasFrom, err := parseStatsTime(query.Get("from"), "from")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !hasFrom {
+		from = to.Add(-defaultStatsWindow)
+	}
+	if !from.Before(to) {
+		http.Error(w, "from must be before to", http.StatusBadRequest)
+		return
+	}
+	if to.Sub(from) > maxStatsWindow {
+		http.Error(w, "range must be at most 90d", http.StatusBadRequest)
+		return
+	}
+	bucket := time.Hour
+	if raw := strings.TrimSpace(query.Get("bucket")); raw != "" {
+		bucket, err = parseStatsWindow(raw)
+		if err != nil || bucket < time.Minute || bucket%time.Minute != 0 {
+			http.Error(w, "bucket must be a whole number of minutes", http.StatusBadRequest)
+			return
+		}
+	}
+	if to.Sub(from)/bucket > maxActivityBuckets {
+		http.Error(w, fmt.Sprintf("too many buckets (max %d); use a larger bucket", maxActivityBuckets), http.StatusBadRequest)
+		return
+	}
+
+	flushStats()
+	report, err := store.ActivityReport(r.Context(), sqlite.ActivityQueryOpts{
+		From:     from,
+		To:       to,
+		Bucket:   bucket,
+		Platform: query.Get("platform"),
+		Channel:  query.Get("channel"),
+	})
+	if err != nil {
+		log.Printf("stats: activity report: %v", err)
+		http.Error(w, "failed to load activity stats", http.StatusInternalServerError)
+		return
+	}
+
+	resp := activityStatsResponse{
+		From:     from.Format(time.RFC3339),
+		To:       to.Format(time.RFC3339),
+		Bucket:   bucket.String(),
+		Totals:   activityTotalsResponse{Platforms: map[string]activityCountResponse{}},
+		Buckets:  []activityBucketResponse{},
+		Channels: []activityChannelResponse{},
+	}
+	index := make(map[int64]int)
+	// Buckets are aligned to the unix epoch, matching the store.
+	first := time.UnixMilli(from.UnixMilli() / bucket.Milliseconds() * bucket.Milliseconds()).UTC()
+	for start := first; start.Before(to); start = start.Add(bucket) {
+		index[start.UnixMilli()] = len(resp.Buckets)
+		resp.Buckets = append(resp.Buckets, activityBucketResponse{
+			Start:     start.Format(time.RFC3339),
+			Platforms: map[string]activityCountResponse{},
+		})
+	}
+	for _, row := range report.Buckets {
+		i, ok := index[row.Start.UnixMilli()]
+		if !ok {
+			continue
+		}
+		b := &resp.Buckets[i]
+		b.Platforms[row.Platform] = activityCountResponse{Messages: row.Messages, Chatters: row.Chatters}
+		b.Messages += row.Messages
+		b.Chatters += row.Chatters
+	}
+	for _, row := range report.Platforms {
+		resp.Totals.Platforms[row.Platform] = activityCountResponse{Messages: row.Messages, Chatters: row.Chatters}
+		resp.Totals.Messages += row.Messages
+		resp.Totals.Chatters += row.Chatters
+	}
+	for _, row := range report.Channels {
+		resp.Channels = append(resp.Channels, activityChannelResponse{
+			Platform:              row.Platform,
+			Channel:               row.Channel,
+			activityCountResponse: activityCountResponse{Messages: row.Messages, Chatters: row.Chatters},
+		})
+	}
+	writeJSON(w, resp)
+}
@@ -0,0 +1,178 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func statsRequest(t *testing.T, path string, out any) int {
+	t.Helper()
+	r := mux.NewRouter()
+	SetupStatsRoutes(r)
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	if out != nil && rec.Code == http.StatusOK {
+		if err := json.Unmarshal(rec.Body.Bytes(), out); err != nil {
+			t.Fatalf("decode %s: %v", path, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestEmoteStatsCountsLiveMessages(t *testing.T) {
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	cfg := withFakeEmoteProviders(t, func(provider, platform string) (map[string]Emote, error) {
+		if provider != "7tv" || platform == "" {
+			return map[string]Emote{}, nil
+		}
+		return map[string]Emote{"catJAM": {ID: "7tv-cat", Name: "catJAM"}}, nil
+	})
+	if err := reloadThirdPartyEmotes(cfg); err != nil {
+		t.Fatalf("reload returned error: %v", err)
+	}
+
+	now := time.Now().UTC()
+	BroadcastFromTailer(storage.Message{ID: "e1", Timestamp: now, Username: "a", Platform: "Twitch", Text: "catJAM catJAM Kappa", EmotesJSON: `["25:14-18"]`, RawJSON: "{}"})
+	BroadcastFromTailer(storage.Message{ID: "e2", Timestamp: now, Username: "b", Platform: "Twitch", Text: "catJAM", RawJSON: "{}"})
+	BroadcastFromTailer(storage.Message{ID: "e3", Timestamp: now, Username: "c", Platform: "Twitch", Text: "no emotes here", RawJSON: "{}"})
+
+	var resp struct {
+		Window string            `json:"window"`
+		Groups []emoteStatsGroup `json:"groups"`
+	}
+	if code := statsRequest(t, "/api/stats/emotes?window=1d&platform=twitch", &resp); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	byProvider := map[string][]emoteStatsEntry{}
+	for _, g := range resp.Groups {
+		byProvider[g.Provider] = g.Emotes
+	}
+	if got := byProvider["7tv"]; len(got) != 1 || got[0].Name != "catJAM" || got[0].Uses != 3 || got[0].Messages != 2 || got[0].ID != "7tv-cat" {
+		t.Fatalf("unexpected 7tv stats: %+v", resp.Groups)
+	}
+	if got := byProvider["twitch"]; len(got) != 1 || got[0].Name != "Kappa" || got[0].Uses != 1 || got[0].ID != "25" {
+		t.Fatalf("unexpected twitch stats: %+v", resp.Groups)
+	}
+	if resp.Window != "24h0m0s" {
+		t.Fatalf("unexpected window %q", resp.Window)
+	}
+
+	if code := statsRequest(t, "/api/stats/emotes?provider=bttv", &resp); code != http.StatusOK || len(resp.Groups) != 0 {
+		t.Fatalf("expected no bttv stats, got %d %+v", code, resp.Groups)
+	}
+	for _, bad := range []string{"?window=soon", "?window=-1h", "?window=365d", "?limit=0"} {
+		if code := statsRequest(t, "/api/stats/emotes"+bad, nil); code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", bad, code)
+		}
+	}
+}
+
+func resetActivity() {
+	activity.mu.Lock()
+	activity.pending = nil
+	activity.live = nil
+	activity.lastFrame = nil
+	activity.mu.Unlock()
+}
+
+func TestActivityStatsAndLiveFrame(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	resetActivity()
+	defer resetActivity()
+
+	conn, cleanup := dialChatWS(t, "", nil)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	BroadcastFromTailer(storage.Message{ID: "a1", Timestamp: now, Username: "Ann", Platform: "Twitch", Text: "hi", RawJSON: "{}"})
+	BroadcastFromTailer(storage.Message{ID: "a2", Timestamp: now, Username: "ann", Platform: "Twitch", Text: "again", RawJSON: "{}"})
+	BroadcastFromTailer(storage.Message{ID: "a3", Timestamp: now, Username: "Bea", Platform: "YouTube", Text: "hello", RawJSON: `{"author":{"channelId":"UCbea"}}`})
+	publishStatsFrame(time.Now())
+
+	var frame wsTestFrame
+	for frame.Type != "stats" {
+		frame = readWSFrame(t, conn)
+	}
+	var live statsFrame
+	if err := json.Unmarshal(frame.Data, &live); err != nil {
+		t.Fatalf("decode stats frame: %v", err)
+	}
+	if live.Messages != 3 || live.Chatters != 2 || live.Platforms["twitch"].Messages != 2 || live.Platforms["youtube"].Chatters != 1 {
+		t.Fatalf("unexpected stats frame: %+v", live)
+	}
+
+	var resp activityStatsResponse
+	path := "/api/stats/activity?bucket=1m&from=" + strconv.FormatInt(now.Add(-5*time.Minute).UnixMilli(), 10) + "&to=" + now.Add(time.Minute).Format(time.RFC3339)
+	if code := statsRequest(t, path, &resp); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if resp.Totals.Messages != 3 || resp.Totals.Chatters != 2 || resp.Totals.Platforms["twitch"].Chatters != 1 {
+		t.Fatalf("unexpected totals: %+v", resp.Totals)
+	}
+	if len(resp.Buckets) < 6 {
+		t.Fatalf("expected empty buckets to be filled, got %d", len(resp.Buckets))
+	}
+	var busy []activityBucketResponse
+	for _, b := range resp.Buckets {
+		if b.Messages > 0 {
+			busy = append(busy, b)
+		}
+	}
+	if len(busy) != 1 || busy[0].Start != now.Truncate(time.Minute).Format(time.RFC3339) || busy[0].Platforms["youtube"].Messages != 1 {
+		t.Fatalf("unexpected buckets: %+v", busy)
+	}
+	if len(resp.Channels) != 2 {
+		t.Fatalf("expected one row per platform channel, got %+v", resp.Channels)
+	}
+
+	for _, bad := range []string{"?bucket=90s", "?bucket=1m&from=0", "?from=2026-01-02T00:00:00Z&to=2026-01-01T00:00:00Z", "?to=yesterday"} {
+		if code := statsRequest(t, "/api/stats/activity"+bad, nil); code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", bad, code)
+		}
+	}
+}
+
+func TestEmoteProviderFollowsTheServedEmote(t *testing.T) {
+	defer withSQLiteStore(t)()
+	cfg := withFakeEmoteProviders(t, func(provider, platform string) (map[string]Emote, error) {
+		if platform != "" {
+			if provider != "bttv" {
+				return map[string]Emote{}, nil
+			}
+			return map[string]Emote{"Clap": {ID: "bttv-channel-clap", Name: "Clap"}}, nil
+		}
+		emotes := map[string]Emote{
+			"Clap":                 {ID: provider + "-clap", Name: "Clap"},
+			provider + "OnlyEmote": {ID: provider + "-only", Name: provider + "OnlyEmote"},
+		}
+		return emotes, nil
+	})
+	if err := reloadThirdPartyEmotes(cfg); err != nil {
+		t.Fatalf("reload returned error: %v", err)
+	}
+
+	for name, want := range map[string]string{
+		"7tvOnlyEmote":  "7tv",
+		"bttvOnlyEmote": "bttv",
+		"ffzOnlyEmote":  "ffz",
+		"Clap":          "bttv", // the channel emote wins over every global one
+	} {
+		if got := emoteProviderFor(name, "twitch", nil); got != want {
+			t.Fatalf("%s: expected %s, got %s", name, want, got)
+		}
+	}
+	if got := emoteCacheSnapshot()["Clap"]; got.ID != "bttv-channel-clap" || got.Provider != "bttv" {
+		t.Fatalf("unexpected served Clap emote: %+v", got)
+	}
+}
@@ -0,0 +1,182 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Username colours are computed once at ingest for a dark background
+// (sanitizeUsernameColorForDarkBG), and payloads keep the unadjusted colour in
+// original_colour. Connections drawing on another backdrop pick a theme with
+// ?theme= and get the colours re-adjusted from the original on delivery, so
+// live and replayed frames agree.
+const (
+	usernameThemeDark  = "dark"
+	usernameThemeLight = "light"
+	usernameThemeVideo = "video"
+	usernameThemeHex   = "custom"
+
+	// WCAG AA: 4.5:1 for normal text, 3:1 for large text.
+	usernameContrastText  = 4.5
+	usernameContrastLarge = 3.0
+
+	usernameThemeSearchSteps = 12
+)
+
+// usernameTheme adjusts username colours until they reach minContrast against
+// every background. A video overlay has no fixed backdrop, so it checks both
+// black and white, which keeps names in a mid-tone band. The dark theme has no
+// backgrounds of its own: it is the ingest colour, sent untouched.
+type usernameTheme struct {
+	name        string
+	backgrounds []float64
+	minContrast float64
+}
+
+var (
+	defaultUsernameTheme = usernameTheme{name: usernameThemeDark}
+	lightUsernameTheme   = usernameTheme{name: usernameThemeLight, backgrounds: []float64{1}, minContrast: usernameContrastText}
+	videoUsernameTheme   = usernameTheme{name: usernameThemeVideo, backgrounds: []float64{0, 1}, minContrast: usernameContrastLarge}
+)
+
+// usernameThemeFromQuery parses ?theme=dark|light|video or a background hex
+// colour such as ?theme=%23F5F5DC (the leading # may be omitted).
+func usernameThemeFromQuery(query url.Values) (usernameTheme, error) {
+	raw := strings.TrimSpace(query.Get("theme"))
+	switch strings.ToLower(raw) {
+	case "", usernameThemeDark:
+		return defaultUsernameTheme, nil
+	case usernameThemeLight:
+		return lightUsernameTheme, nil
+	case usernameThemeVideo, "transparent":
+		return videoUsernameTheme, nil
+	}
+	hex := raw
+	if !strings.HasPrefix(hex, "#") {
+		hex = "#" + hex
+	}
+	r, g, b, ok := parseHexRGB(hex)
+	if !ok {
+		return usernameTheme{}, fmt.Errorf("unknown theme %q", raw)
+	}
+	return usernameTheme{
+		name:        usernameThemeHex,
+		backgrounds: []float64{usernameColourRelativeLuminance(r, g, b)},
+		minContrast: usernameContrastText,
+	}, nil
+}
+
+// isDefault reports whether the theme matches the colours computed at ingest,
+// so payloads can be sent untouched.
+func (t usernameTheme) isDefault() bool {
+	return t.name == "" || t.name == usernameThemeDark
+}
+
+func contrastRatio(a, b float64) float64 {
+	if a < b {
+		a, b = b, a
+	}
+	return (a + 0.05) / (b + 0.05)
+}
+
+// failing returns the first background the luminance does not reach the
+// contrast target against.
+func (t usernameTheme) failing(lum float64) (float64, bool) {
+	for _, bg := range t.backgrounds {
+		if contrastRatio(lum, bg) < t.minContrast {
+			return bg, true
+		}
+	}
+	return 0, false
+}
+
+func blendChannelToward(v, target uint8, amount float64) uint8 {
+	return uint8(float64(v) + (float64(target)-float64(v))*amount + 0.5)
+}
+
+// adjust returns hex moved toward black or white, away from the background it
+// fails against, by the smallest amount that clears that background. Hue is
+// kept; if the target is out of reach the fully blended colour is returned.
+func (t usernameTheme) adjust(hex string) string {
+	r, g, b, ok := parseHexRGB(hex)
+	if !ok {
+		return hex
+	}
+	bg, failing := t.failing(usernameColourRelativeLuminance(r, g, b))
+	if !failing {
+		return normalizeHexUsernameColour(hex)
+	}
+	target := uint8(255)
+	if contrastRatio(0, bg) > contrastRatio(1, bg) {
+		target = 0
+	}
+	blend := func(amount float64) (uint8, uint8, uint8) {
+		return blendChannelToward(r, target, amount), blendChannelToward(g, target, amount), blendChannelToward(b, target, amount)
+	}
+	lo, hi := 0.0, 1.0
+	for i := 0; i < usernameThemeSearchSteps; i++ {
+		mid := (lo + hi) / 2
+		if contrastRatio(usernameColourRelativeLuminance(blend(mid)), bg) < t.minContrast {
+			lo = mid
+		} else {
+			hi = mid
+		}
+	}
+	ar, ag, ab := blend(hi)
+	return fmt.Sprintf("#%02X%02X%02X", ar, ag, ab)
+}
+
+// apply re-adjusts the username colour of a chat payload, starting from
+// original_colour when the payload has it so dark colours are not lifted for
+// the dark theme first. Only the colour fields are rewritten; frames and
+// payloads that fail to decode are returned unchanged.
+func (t usernameTheme) apply(payload []byte) []byte {
+	if t.isDefault() || isFramePayload(payload) {
+		return payload
+	}
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(payload, &fields); err != nil {
+		return payload
+	}
+	colourField := func(name string) string {
+		var colour string
+		_ = json.Unmarshal(fields[name], &colour)
+		return colour
+	}
+	colour := colourField("original_colour")
+	if normalizeHexUsernameColour(colour) == "" {
+		colour = colourField("username_color")
+	}
+	if colour == "" {
+		colour = colourField("colour")
+	}
+	if normalizeHexUsernameColour(colour) == "" {
+		return payload
+	}
+	adjusted, err := json.Marshal(t.adjust(colour))
+	if err != nil {
+		return payload
+	}
+	if _, ok := fields["username_color"]; ok {
+		fields["username_color"] = adjusted
+	}
+	fields["colour"] = adjusted
+	out, err := json.Marshal(fields)
+	if err != nil {
+		return payload
+	}
+	return out
+}
+
+func (t usernameTheme) applyAll(payloads [][]byte) [][]byte {
+	if t.isDefault() {
+		return payloads
+	}
+	out := make([][]byte, len(payloads))
+	for i, payload := range payloads {
+		out[i] = t.apply(payload)
+	}
+	return out
+}
@@ -0,0 +1,156 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+func hexLuminance(t *testing.T, hex string) float64 {
+	t.Helper()
+	r, g, b, ok := parseHexRGB(hex)
+	if !ok {
+		t.Fatalf("invalid hex colour %q", hex)
+	}
+	return usernameColourRelativeLuminance(r, g, b)
+}
+
+func TestUsernameThemeAdjustMeetsContrast(t *testing.T) {
+	cream := url.Values{"theme": {"F5F5DC"}}
+	custom, err := usernameThemeFromQuery(cream)
+	if err != nil {
+		t.Fatalf("usernameThemeFromQuery returned error: %v", err)
+	}
+	cases := []struct {
+		theme  usernameTheme
+		bgs    []float64
+		target float64
+	}{
+		{lightUsernameTheme, []float64{1}, usernameContrastText},
+		{videoUsernameTheme, []float64{0, 1}, usernameContrastLarge},
+		{custom, []float64{hexLuminance(t, "#F5F5DC")}, usernameContrastText},
+	}
+	for _, tc := range cases {
+		for _, colour := range []string{"#FFFFFF", "#FFFF00", "#000000", "#1E90FF", "#123456"} {
+			got := tc.theme.adjust(colour)
+			lum := hexLuminance(t, got)
+			for _, bg := range tc.bgs {
+				if ratio := contrastRatio(lum, bg); ratio < tc.target {
+					t.Fatalf("%s: %s adjusted to %s has contrast %.2f, want >= %.1f", tc.theme.name, colour, got, ratio, tc.target)
+				}
+			}
+		}
+	}
+
+	if got := lightUsernameTheme.adjust("#000080"); got != "#000080" {
+		t.Fatalf("expected a readable colour to be kept, got %s", got)
+	}
+	if got := lightUsernameTheme.adjust("#FFFF00"); got[1:3] != got[3:5] || got[5:7] != "00" {
+		t.Fatalf("expected yellow to keep its hue when darkened, got %s", got)
+	}
+
+	for _, raw := range []string{"", "dark", "DARK"} {
+		theme, err := usernameThemeFromQuery(url.Values{"theme": {raw}})
+		if err != nil || !theme.isDefault() {
+			t.Fatalf("expected %q to select the default theme, got %+v err=%v", raw, theme, err)
+		}
+	}
+	if _, err := usernameThemeFromQuery(url.Values{"theme": {"sepia"}}); err == nil {
+		t.Fatal("expected an unknown theme to be rejected")
+	}
+}
+
+func chatFrameColour(t *testing.T, frame wsTestFrame) string {
+	t.Helper()
+	var raw string
+	if err := json.Unmarshal(frame.Data, &raw); err != nil {
+		t.Fatalf("decode chat data: %v", err)
+	}
+	var msg struct {
+		Colour        string `json:"colour"`
+		UsernameColor string `json:"username_color"`
+	}
+	if err := json.Unmarshal([]byte(raw), &msg); err != nil {
+		t.Fatalf("decode chat payload: %v", err)
+	}
+	if msg.UsernameColor != "" && msg.UsernameColor != msg.Colour {
+		t.Fatalf("expected colour and username_color to agree, got %q and %q", msg.Colour, msg.UsernameColor)
+	}
+	return msg.Colour
+}
+
+func TestStreamChatThemeAdjustsLiveAndReplayedColours(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+
+	dark, cleanupDark := dialChatWS(t, "", nil)
+	defer cleanupDark()
+	light, cleanupLight := dialChatWS(t, "?theme=light", nil)
+	defer cleanupLight()
+
+	row := storage.Message{ID: "c1", Username: "Sunny", Platform: "Twitch", Text: "hello", RawJSON: `{"color":"#FFFF00"}`, Timestamp: time.Now().UTC()}
+	if err := chatStore.InsertMessage(ctx, &row); err != nil {
+		t.Fatalf("InsertMessage returned error: %v", err)
+	}
+	BroadcastFromTailer(row)
+
+	if got := chatFrameColour(t, readWSFrame(t, dark)); got != "#FFFF00" {
+		t.Fatalf("expected the dark theme to keep yellow, got %s", got)
+	}
+	want := lightUsernameTheme.adjust("#FFFF00")
+	if got := chatFrameColour(t, readWSFrame(t, light)); got != want {
+		t.Fatalf("expected light theme colour %s, got %s", want, got)
+	}
+
+	replay, cleanupReplay := dialChatWS(t, "?theme=light&replay=1", nil)
+	defer cleanupReplay()
+	if got := chatFrameColour(t, readWSFrame(t, replay)); got != want {
+		t.Fatalf("expected replayed light theme colour %s, got %s", want, got)
+	}
+
+	if code := dialChatWSStatus(t, "?theme=sepia"); code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unknown theme, got %d", code)
+	}
+}
+
+func TestUsernameThemeStartsFromOriginalColour(t *testing.T) {
+	// Navy is lifted for the dark default but already readable on white.
+	row := storage.Message{ID: "navy", Username: "Deep", Platform: "Twitch", Text: "hi", RawJSON: `{"color":"#000080"}`, Timestamp: time.Now().UTC()}
+	payload, err := messagePayloadFromStorage(row)
+	if err != nil {
+		t.Fatalf("messagePayloadFromStorage returned error: %v", err)
+	}
+	var stored struct {
+		Colour         string `json:"colour"`
+		OriginalColour string `json:"original_colour"`
+	}
+	if err := json.Unmarshal(payload, &stored); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if stored.OriginalColour != "#000080" || stored.Colour == "#000080" {
+		t.Fatalf("expected a lifted colour with the original kept, got %+v", stored)
+	}
+
+	var themed map[string]any
+	if err := json.Unmarshal(lightUsernameTheme.apply(payload), &themed); err != nil {
+		t.Fatalf("decode themed payload: %v", err)
+	}
+	if themed["colour"] != "#000080" || themed["username_color"] != "#000080" || themed["message"] != "hi" {
+		t.Fatalf("expected the light theme to use the original colour, got %v", themed)
+	}
+
+	// Fields the payload struct does not declare survive the rewrite.
+	var kept map[string]any
+	extra := []byte(`{"author":"x","colour":"#FFFF00","pinned":true}`)
+	if err := json.Unmarshal(lightUsernameTheme.apply(extra), &kept); err != nil {
+		t.Fatalf("decode themed payload: %v", err)
+	}
+	if kept["pinned"] != true || kept["colour"] != lightUsernameTheme.adjust("#FFFF00") {
+		t.Fatalf("expected unknown fields to be kept, got %v", kept)
+	}
+}
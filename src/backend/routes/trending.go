@@ -0,0 +1,121 @@
+package routes
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/trends"
+)
+
+const (
+	defaultTrendingLimit = 10
+	maxTrendingLimit     = 50
+)
+
+var (
+	trendAnalyzerOnce sync.Once
+	trendAnalyzer     *trends.Analyzer
+)
+
+// trendsConfig reads ELORA_TRENDS_WINDOW, ELORA_TRENDS_MIN_COUNT and
+// ELORA_TRENDS_MIN_CHATTERS. Invalid values fall back to the defaults.
+func trendsConfig() trends.Config {
+	cfg := trends.DefaultConfig()
+	if raw := strings.TrimSpace(os.Getenv("ELORA_TRENDS_WINDOW")); raw != "" {
+		window, err := time.ParseDuration(raw)
+		if err != nil || window <= 0 {
+			log.Printf("trends: invalid ELORA_TRENDS_WINDOW %q, using %s", raw, cfg.Window)
+		} else {
+			cfg.Window = window
+			cfg.Cooldown = window
+		}
+	}
+	for env, dst := range map[string]*int{
+		"ELORA_TRENDS_MIN_COUNT":    &cfg.MinCount,
+		"ELORA_TRENDS_MIN_CHATTERS": &cfg.MinChatters,
+	} {
+		raw := strings.TrimSpace(os.Getenv(env))
+		if raw == "" {
+			continue
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			log.Printf("trends: invalid %s %q, using %d", env, raw, *dst)
+			continue
+		}
+		*dst = n
+	}
+	return cfg
+}
+
+func currentTrendAnalyzer() *trends.Analyzer {
+	trendAnalyzerOnce.Do(func() {
+		trendAnalyzer = trends.New(trendsConfig())
+	})
+	return trendAnalyzer
+}
+
+// trendFrame is the live "trend" frame sent when chat starts repeating
+// something.
+type trendFrame struct {
+	Frame string `json:"frame"`
+	trends.Trend
+}
+
+// recordTrends feeds a released message's normalized text to the trend analyzer
+// and publishes a frame for every trend it starts.
+func recordTrends(msg Message, m storage.Message) {
+	platform := strings.ToLower(normalizeSource(msg.Source))
+	if platform == "" {
+		return
+	}
+	_, chatter := viewerAccountOf(platform, m)
+	if chatter == "" {
+		chatter = strings.ToLower(strings.TrimSpace(m.Username))
+	}
+	// A linked viewer posting on both platforms is still one chatter.
+	if msg.Viewer != nil {
+		chatter = "viewer:" + msg.Viewer.ID
+	} else {
+		chatter = platform + ":" + chatter
+	}
+	for _, trend := range currentTrendAnalyzer().Observe(time.Now(), platform, chatter, msg.Message) {
+		payload, err := json.Marshal(trendFrame{Frame: "trend", Trend: trend})
+		if err != nil {
+			log.Printf("trends: marshal frame: %v", err)
+			continue
+		}
+		broadcastChatMessage(payload)
+	}
+}
+
+type trendingResponse struct {
+	Window string         `json:"window"`
+	Trends []trends.Trend `json:"trends"`
+}
+
+// handleTrending returns what chat is repeating right now, most repeated
+// first.
+func handleTrending(w http.ResponseWriter, r *http.Request) {
+	limit := defaultTrendingLimit
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 || n > maxTrendingLimit {
+			http.Error(w, "limit must be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	analyzer := currentTrendAnalyzer()
+	writeJSON(w, trendingResponse{
+		Window: analyzer.Config().Window.String(),
+		Trends: analyzer.Trending(time.Now(), limit),
+	})
+}
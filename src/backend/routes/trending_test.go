@@ -0,0 +1,108 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/trends"
+)
+
+// resetTrendAnalyzer forgets what earlier tests sent, so their messages do
+// not trend in the next test.
+func resetTrendAnalyzer() {
+	currentTrendAnalyzer()
+	trendAnalyzer = trends.New(trendsConfig())
+}
+
+// withTrendAnalyzer swaps in a fresh analyzer using cfg for the test.
+func withTrendAnalyzer(t *testing.T, cfg trends.Config) {
+	t.Helper()
+	currentTrendAnalyzer()
+	prev := trendAnalyzer
+	trendAnalyzer = trends.New(cfg)
+	t.Cleanup(func() { trendAnalyzer = prev })
+}
+
+func TestTrendFramesAndTrendingEndpoint(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	withTrendAnalyzer(t, trends.Config{MinCount: 3, MinChatters: 3})
+
+	conn, cleanup := dialChatWS(t, "", nil)
+	defer cleanup()
+
+	now := time.Now().UTC()
+	for i, m := range []storage.Message{
+		{Username: "Ann", Platform: "Twitch", Text: "W"},
+		{Username: "ann", Platform: "Twitch", Text: "W"},
+		{Username: "Bea", Platform: "YouTube", Text: "w!!", RawJSON: `{"author":{"channelId":"UCbea"}}`},
+		{Username: "Cat", Platform: "Twitch", Text: "massive W"},
+	} {
+		m.ID = "trend-" + string(rune('a'+i))
+		m.Timestamp = now
+		if m.RawJSON == "" {
+			m.RawJSON = "{}"
+		}
+		BroadcastFromTailer(m)
+	}
+
+	var frame wsTestFrame
+	for frame.Type != "trend" {
+		frame = readWSFrame(t, conn)
+	}
+	var trend trends.Trend
+	if err := json.Unmarshal(frame.Data, &trend); err != nil {
+		t.Fatalf("decode trend frame: %v", err)
+	}
+	if trend.Key != "w" || trend.Kind != trends.KindPhrase || trend.Count != 4 || trend.Chatters != 3 || len(trend.Platforms) != 2 {
+		t.Fatalf("unexpected trend frame: %+v", trend)
+	}
+
+	var resp trendingResponse
+	if code := statsRequest(t, "/api/stats/trending?limit=5", &resp); code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", code)
+	}
+	if len(resp.Trends) != 1 || resp.Trends[0].Key != "w" || resp.Window != "1m0s" {
+		t.Fatalf("unexpected trending response: %+v", resp)
+	}
+	for _, bad := range []string{"?limit=0", "?limit=51", "?limit=lots"} {
+		if code := statsRequest(t, "/api/stats/trending"+bad, nil); code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", bad, code)
+		}
+	}
+}
+
+func TestHeldMessagesTrendOnlyOnceApproved(t *testing.T) {
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+	defer approvals.reset()
+	withTrendAnalyzer(t, trends.Config{MinCount: 2, MinChatters: 2})
+
+	setApprovalMode(true)
+	now := time.Now().UTC()
+	for _, user := range []string{"Ann", "Bea", "Cat"} {
+		BroadcastFromTailer(storage.Message{ID: "held-trend-" + user, Username: user, Platform: "Twitch", Text: "secret plan", RawJSON: "{}", Timestamp: now})
+	}
+	if got := currentTrendAnalyzer().Trending(time.Now(), 10); len(got) != 0 {
+		t.Fatalf("expected held messages not to trend, got %+v", got)
+	}
+
+	held := approvals.list()
+	if len(held) != 3 {
+		t.Fatalf("expected 3 held messages, got %d", len(held))
+	}
+	rejectHeld(held[0].ID)
+	approveHeld(held[1].ID)
+	if got := currentTrendAnalyzer().Trending(time.Now(), 10); len(got) != 0 {
+		t.Fatalf("expected one approved message not to trend yet, got %+v", got)
+	}
+	approveHeld(held[2].ID)
+	got := currentTrendAnalyzer().Trending(time.Now(), 10)
+	if len(got) != 1 || got[0].Key != "secret plan" || got[0].Count != 2 {
+		t.Fatalf("expected approved messages to trend, got %+v", got)
+	}
+}
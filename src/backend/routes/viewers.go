@@ -0,0 +1,796 @@
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+	"github.com/hpwn/EloraChat/src/backend/internal/ws"
+)
+
+const (
+	viewerLinkCodeTTL        = 10 * time.Minute
+	viewerLinkCodeLength     = 6
+	viewerLinkCodesPerIP     = 5
+	viewerProfileDefaultMsgs = 20
+	viewerProfileMaxMsgs     = 100
+	maxViewerNameLength      = 100
+)
+
+// viewerLinkCodeAlphabet leaves out characters that are easy to misread in chat.
+const viewerLinkCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+// linkCommandPattern matches the chat command a viewer types to claim a code.
+var linkCommandPattern = regexp.MustCompile(`(?i)^!link\s+(\S+)$`)
+
+type viewerAccountRequest struct {
+	Platform  string `json:"platform"`
+	AccountID string `json:"account_id"`
+	Username  string `json:"username"`
+}
+
+type viewerAccountResponse struct {
+	Platform  string `json:"platform"`
+	AccountID string `json:"account_id"`
+	Username  string `json:"username"`
+	Colour    string `json:"colour,omitempty"`
+	LinkedAt  string `json:"linked_at"`
+}
+
+type viewerResponse struct {
+	ID          string                  `json:"id"`
+	DisplayName string                  `json:"display_name"`
+	Colour      string                  `json:"colour,omitempty"`
+	Accounts    []viewerAccountResponse `json:"accounts"`
+	CreatedAt   string                  `json:"created_at"`
+	UpdatedAt   string                  `json:"updated_at"`
+}
+
+type viewerProfileResponse struct {
+	viewerResponse
+	MessageCount   int               `json:"message_count"`
+	RecentMessages []json.RawMessage `json:"recent_messages"`
+}
+
+// linkedViewer is the cached view of one viewer used to enrich chat payloads.
+type linkedViewer struct {
+	ref ws.Viewer
+	// colour is the moderator override; learned is the last Twitch colour
+	// seen for any of the viewer's accounts.
+	colour         string
+	learned        string
+	accountColours map[string]string
+}
+
+// viewerDirectory caches linked accounts so payload enrichment does not hit
+// the database per message. It reloads whenever the store changes or a link
+// is modified.
+type viewerDirectory struct {
+	mu        sync.RWMutex
+	store     *sqlite.Store
+	byAccount map[string]*linkedViewer
+	byID      map[string]*linkedViewer
+}
+
+var viewerDir = &viewerDirectory{}
+
+func viewerAccountKey(platform, accountID string) string {
+	return platform + "\x00" + accountID
+}
+
+func (d *viewerDirectory) invalidate() {
+	d.mu.Lock()
+	d.store = nil
+	d.mu.Unlock()
+}
+
+// ensure loads the directory for the current store and reports whether one
+// is available.
+func (d *viewerDirectory) ensure() bool {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		return false
+	}
+	d.mu.RLock()
+	loaded := d.store == store
+	d.mu.RUnlock()
+	if loaded {
+		return true
+	}
+
+	all, err := store.ListViewers(ctx)
+	if err != nil {
+		log.Printf("viewers: load directory: %v", err)
+	}
+	byAccount := make(map[string]*linkedViewer)
+	byID := make(map[string]*linkedViewer, len(all))
+	for _, v := range all {
+		lv := &linkedViewer{
+			ref:            ws.Viewer{ID: v.ID, Name: v.DisplayName, Platforms: []string{}},
+			colour:         v.Colour,
+			accountColours: make(map[string]string),
+		}
+		for _, a := range v.Accounts {
+			key := viewerAccountKey(a.Platform, a.AccountID)
+			byAccount[key] = lv
+			if a.Colour != "" {
+				lv.accountColours[key] = a.Colour
+				if lv.learned == "" {
+					lv.learned = a.Colour
+				}
+			}
+			if !containsString(lv.ref.Platforms, a.Platform) {
+				lv.ref.Platforms = append(lv.ref.Platforms, a.Platform)
+			}
+		}
+		sort.Strings(lv.ref.Platforms)
+		byID[v.ID] = lv
+	}
+
+	d.mu.Lock()
+	d.store, d.byAccount, d.byID = store, byAccount, byID
+	d.mu.Unlock()
+	return true
+}
+
+func (d *viewerDirectory) account(platform, accountID string) *linkedViewer {
+	if platform == "" || accountID == "" || !d.ensure() {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.byAccount[viewerAccountKey(platform, accountID)]
+}
+
+func (d *viewerDirectory) viewer(id string) *linkedViewer {
+	if id == "" || !d.ensure() {
+		return nil
+	}
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.byID[id]
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
+// viewerAccountOf returns the platform and account ID a row was written by:
+// the Twitch login, or the YouTube channel ID.
+func viewerAccountOf(source string, row storage.Message) (string, string) {
+	if strings.TrimSpace(source) == "" {
+		source = row.Platform
+	}
+	switch strings.ToLower(strings.TrimSpace(source)) {
+	case "twitch":
+		obj := parseRawJSONObject(row.RawJSON)
+		login := getRawString(obj, "login")
+		if login == "" {
+			if tags, ok := parseRawJSONObjectValue(obj["tags"]); ok {
+				login = getRawString(tags, "login")
+			}
+		}
+		if login == "" {
+			login = row.Username
+		}
+		return "twitch", normalizeViewerAccountID("twitch", login)
+	case "youtube":
+		return "youtube", extractYouTubeAuthorChannelID(row.RawJSON)
+	}
+	return "", ""
+}
+
+// extractYouTubeAuthorChannelID finds the author's channel ID in a YouTube
+// payload: top level, under author/authorDetails, or inside a renderer.
+func extractYouTubeAuthorChannelID(rawJSON string) string {
+	obj := parseRawJSONObject(rawJSON)
+	if obj == nil {
+		return ""
+	}
+	keys := []string{"authorExternalChannelId", "authorChannelId", "author_channel_id"}
+	for _, key := range keys {
+		if value := getRawString(obj, key); value != "" {
+			return value
+		}
+	}
+	for _, nested := range []string{"author", "authorDetails"} {
+		if nestedObj, ok := parseRawJSONObjectValue(obj[nested]); ok {
+			for _, key := range []string{"channelId", "channel_id", "id"} {
+				if value := getRawString(nestedObj, key); value != "" {
+					return value
+				}
+			}
+		}
+	}
+	for _, value := range obj {
+		if renderer, ok := parseRawJSONObjectValue(value); ok {
+			if id := getRawString(renderer, "authorExternalChannelId"); id != "" {
+				return id
+			}
+		}
+	}
+	return ""
+}
+
+func normalizeViewerAccountID(platform, accountID string) string {
+	accountID = strings.TrimSpace(accountID)
+	if platform == "twitch" {
+		return strings.ToLower(strings.TrimPrefix(accountID, "@"))
+	}
+	return accountID
+}
+
+// linkedViewerFor returns the linked viewer behind a row, or nil.
+func linkedViewerFor(source string, row storage.Message) *ws.Viewer {
+	lv := viewerDir.account(viewerAccountOf(source, row))
+	if lv == nil {
+		return nil
+	}
+	ref := lv.ref
+	return &ref
+}
+
+// linkedViewerColour is the colour shared by all of a linked viewer's
+// messages: the moderator override, else the viewer's Twitch colour, else one
+// derived from the viewer ID.
+func linkedViewerColour(v *ws.Viewer) string {
+	if v == nil {
+		return ""
+	}
+	lv := viewerDir.viewer(v.ID)
+	if lv == nil {
+		return ""
+	}
+	viewerDir.mu.RLock()
+	defer viewerDir.mu.RUnlock()
+	if lv.colour != "" {
+		return lv.colour
+	}
+	if lv.learned != "" {
+		return lv.learned
+	}
+	return colorFromName("viewer:" + v.ID)
+}
+
+// learnViewerColour remembers the chat colour of a linked Twitch account so the
+// viewer's YouTube messages can use it too.
+func learnViewerColour(msg Message, row storage.Message) {
+	platform, accountID := viewerAccountOf(msg.Source, row)
+	if platform != "twitch" {
+		return
+	}
+	lv := viewerDir.account(platform, accountID)
+	if lv == nil {
+		return
+	}
+	colour := normalizeHexUsernameColour(msg.UsernameColor)
+	if colour == "" {
+		colour = normalizeHexUsernameColour(msg.Colour)
+	}
+	if colour == "" {
+		colour = extractTwitchRawUsernameColour(row.RawJSON)
+	}
+	if colour == "" {
+		return
+	}
+
+	key := viewerAccountKey(platform, accountID)
+	viewerDir.mu.Lock()
+	changed := lv.accountColours[key] != colour
+	lv.accountColours[key] = colour
+	lv.learned = colour
+	store := viewerDir.store
+	viewerDir.mu.Unlock()
+	if changed && store != nil {
+		if err := store.SetViewerAccountColour(ctx, platform, accountID, colour); err != nil {
+			log.Printf("viewers: save colour for %s: %v", accountID, err)
+		}
+	}
+}
+
+// isViewerLinkCommand reports whether a row is a "!link CODE" command. These
+// rows stay in storage but are never shown, live or replayed. Platform chat
+// still shows them, so a code is spent by the first account to type it (see
+// claimViewerLinkCode).
+func isViewerLinkCommand(m storage.Message) bool {
+	return linkCommandPattern.MatchString(strings.TrimSpace(m.Text))
+}
+
+// claimViewerLinkCode consumes "!link CODE" chat commands. The first account
+// to type a code is bound to it and gets a confirm code, shown only to whoever
+// holds the code's secret; typing that from the other platform completes the
+// link. It reports whether the row was a link command, which is never
+// broadcast.
+func claimViewerLinkCode(m storage.Message) bool {
+	match := linkCommandPattern.FindStringSubmatch(strings.TrimSpace(m.Text))
+	if match == nil {
+		return false
+	}
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		return true
+	}
+	platform, accountID := viewerAccountOf("", m)
+	if accountID == "" {
+		log.Printf("viewers: link command from %s without an account id", m.Platform)
+		return true
+	}
+	confirmCode, err := newViewerLinkCode()
+	if err != nil {
+		log.Printf("viewers: generate confirm code: %v", err)
+		return true
+	}
+	v, err := store.ClaimViewerLinkCode(ctx, strings.ToUpper(match[1]), confirmCode, sqlite.ViewerAccount{
+		Platform:  platform,
+		AccountID: accountID,
+		Username:  m.Username,
+	})
+	if err != nil {
+		log.Printf("viewers: claim link code: %v", err)
+		return true
+	}
+	if v != nil {
+		log.Printf("viewers: linked %d accounts to %s via code", len(v.Accounts), v.ID)
+		viewerDir.invalidate()
+	}
+	return true
+}
+
+func newViewerLinkCode() (string, error) {
+	buf := make([]byte, viewerLinkCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = viewerLinkCodeAlphabet[int(b)%len(viewerLinkCodeAlphabet)]
+	}
+	return "ELORA-" + string(buf), nil
+}
+
+func newViewerLinkSecret() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// SetupViewerRoutes registers the viewer identity endpoints. Link codes are
+// public so viewers can link themselves; everything else requires a logged-in
+// session.
+func SetupViewerRoutes(r *mux.Router) {
+	r.HandleFunc("/api/viewers/link-codes", handleCreateViewerLinkCode).Methods(http.MethodPost)
+	r.HandleFunc("/api/viewers/link-codes/{code}", handleGetViewerLinkCode).Methods(http.MethodGet)
+
+	protected := r.PathPrefix("/api/viewers").Subrouter()
+	protected.Use(SessionMiddleware)
+	protected.HandleFunc("", handleListViewers).Methods(http.MethodGet)
+	protected.HandleFunc("", handleLinkViewer).Methods(http.MethodPost)
+	protected.HandleFunc("/{id}", handleGetViewer).Methods(http.MethodGet)
+	protected.HandleFunc("/{id}", handleUpdateViewer).Methods(http.MethodPatch)
+	protected.HandleFunc("/{id}", handleDeleteViewer).Methods(http.MethodDelete)
+	protected.HandleFunc("/{id}/accounts", handleAddViewerAccount).Methods(http.MethodPost)
+	protected.HandleFunc("/{id}/accounts/{platform}/{account}", handleUnlinkViewerAccount).Methods(http.MethodDelete)
+}
+
+func viewerStore(w http.ResponseWriter) (*sqlite.Store, bool) {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		http.Error(w, "viewer identities only supported with sqlite backend", http.StatusNotImplemented)
+		return nil, false
+	}
+	return store, true
+}
+
+func viewerResponseFrom(v sqlite.Viewer) viewerResponse {
+	resp := viewerResponse{
+		ID:          v.ID,
+		DisplayName: v.DisplayName,
+		Colour:      v.Colour,
+		Accounts:    make([]viewerAccountResponse, 0, len(v.Accounts)),
+		CreatedAt:   v.CreatedAt.Format(time.RFC3339Nano),
+		UpdatedAt:   v.UpdatedAt.Format(time.RFC3339Nano),
+	}
+	for _, a := range v.Accounts {
+		resp.Accounts = append(resp.Accounts, viewerAccountResponse{
+			Platform:  a.Platform,
+			AccountID: a.AccountID,
+			Username:  a.Username,
+			Colour:    a.Colour,
+			LinkedAt:  a.LinkedAt.Format(time.RFC3339Nano),
+		})
+	}
+	return resp
+}
+
+func parseViewerAccounts(in []viewerAccountRequest) ([]sqlite.ViewerAccount, error) {
+	out := make([]sqlite.ViewerAccount, 0, len(in))
+	for _, a := range in {
+		platform := strings.ToLower(strings.TrimSpace(a.Platform))
+		if platform != "twitch" && platform != "youtube" {
+			return nil, fmt.Errorf("unsupported platform %q", a.Platform)
+		}
+		accountID := normalizeViewerAccountID(platform, a.AccountID)
+		if accountID == "" {
+			return nil, errors.New("account_id is required")
+		}
+		out = append(out, sqlite.ViewerAccount{Platform: platform, AccountID: accountID, Username: strings.TrimSpace(a.Username)})
+	}
+	return out, nil
+}
+
+// linkCodeLimiter counts link codes handed out per client IP in fixed windows
+// of viewerLinkCodeTTL, so one client cannot fill the table with codes.
+type linkCodeLimiter struct {
+	mu      sync.Mutex
+	windows map[string]linkCodeWindow
+}
+
+type linkCodeWindow struct {
+	start time.Time
+	count int
+}
+
+var viewerLinkCodeLimits = &linkCodeLimiter{windows: map[string]linkCodeWindow{}}
+
+// allow records a code for ip and reports whether it is within the limit. When
+// it is not, it also returns how long until the window resets.
+func (l *linkCodeLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for key, win := range l.windows {
+		if now.Sub(win.start) >= viewerLinkCodeTTL {
+			delete(l.windows, key)
+		}
+	}
+	win, ok := l.windows[ip]
+	if !ok {
+		win = linkCodeWindow{start: now}
+	}
+	if win.count >= viewerLinkCodesPerIP {
+		return false, win.start.Add(viewerLinkCodeTTL).Sub(now)
+	}
+	win.count++
+	l.windows[ip] = win
+	return true, 0
+}
+
+// requestIP returns the client address of r without its port.
+func requestIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func handleCreateViewerLinkCode(w http.ResponseWriter, r *http.Request) {
+	store, ok := viewerStore(w)
+	if !ok {
+		return
+	}
+	if ok, wait := viewerLinkCodeLimits.allow(requestIP(r), time.Now()); !ok {
+		w.Header().Set("Retry-After", strconv.Itoa(int(wait.Seconds())+1))
+		http.Error(w, "too many link codes, try again later", http.StatusTooManyRequests)
+		return
+	}
+	code, err := newViewerLinkCode()
+	if err != nil {
+		http.Error(w, "failed to generate code", http.StatusInternalServerError)
+		return
+	}
+	secret, err := newViewerLinkSecret()
+	if err != nil {
+		http.Error(w, "failed to generate code", http.StatusInternalServerError)
+		return
+	}
+	expiresAt := time.Now().UTC().Add(viewerLinkCodeTTL)
+	if err := store.CreateViewerLinkCode(r.Context(), code, secret, expiresAt); err != nil {
+		log.Printf("viewers: create link code: %v", err)
+		http.Error(w, "failed to create code", http.StatusInternalServerError)
+		return
+	}
+	writeJSONStatus(w, http.StatusCreated, map[string]any{
+		"code":       code,
+		"command":    "!link " + code,
+		"secret":     secret,
+		"expires_at": expiresAt.Format(time.RFC3339),
+	})
+}
+
+// handleGetViewerLinkCode shows the progress of a link code to whoever holds
+// its secret, sent as X-Link-Secret. Once an account has typed the code, the
+// response names it and carries the confirm command to type on the other
+// platform. Unknown, spent and expired codes, and wrong secrets, are 404.
+func handleGetViewerLinkCode(w http.ResponseWriter, r *http.Request) {
+	store, ok := viewerStore(w)
+	if !ok {
+		return
+	}
+	lc, err := store.GetViewerLinkCode(r.Context(), strings.ToUpper(mux.Vars(r)["code"]))
+	if err != nil {
+		log.Printf("viewers: get link code: %v", err)
+		http.Error(w, "failed to get code", http.StatusInternalServerError)
+		return
+	}
+	secret := strings.TrimSpace(r.Header.Get("X-Link-Secret"))
+	if lc == nil || secret == "" || subtle.ConstantTimeCompare([]byte(secret), []byte(lc.Secret)) != 1 {
+		http.Error(w, "link code not found", http.StatusNotFound)
+		return
+	}
+	resp := map[string]any{
+		"code":       lc.Code,
+		"status":     "pending",
+		"expires_at": lc.ExpiresAt.Format(time.RFC3339),
+	}
+	if lc.Account.Platform != "" {
+		resp["status"] = "claimed"
+		resp["platform"] = lc.Account.Platform
+		resp["username"] = lc.Account.Username
+		resp["confirm_command"] = "!link " + lc.ConfirmCode
+	}
+	writeJSON(w, resp)
+}
+
+func handleListViewers(w http.ResponseWriter, r *http.Request) {
+	store, ok := viewerStore(w)
+	if !ok {
+		return
+	}
+	query := r.URL.Query()
+	if account := strings.TrimSpace(query.Get("account")); account != "" {
+		platform := strings.ToLower(strings.TrimSpace(query.Get("platform")))
+		v, err := store.FindViewerByAccount(r.Context(), platform, normalizeViewerAccountID(platform, account))
+		if err != nil {
+			log.Printf("viewers: find by account: %v", err)
+			http.Error(w, "failed to find viewer", http.StatusInternalServerError)
+			return
+		}
+		items := []viewerResponse{}
+		if v != nil {
+			items = append(items, viewerResponseFrom(*v))
+		}
+		writeJSON(w, map[string]any{"items": items})
+		return
+	}
+
+	all, err := store.ListViewers(r.Context())
+	if err != nil {
+		log.Printf("viewers: list: %v", err)
+		http.Error(w, "failed to list viewers", http.StatusInternalServerError)
+		return
+	}
+	items := make([]viewerResponse, 0, len(all))
+	for _, v := range all {
+		items = append(items, viewerResponseFrom(v))
+	}
+	writeJSON(w, map[string]any{"items": items})
+}
+
+// handleLinkViewer links accounts into one viewer, merging viewers they
+// already belong to.
+func handleLinkViewer(w http.ResponseWriter, r *http.Request) {
+	store, ok := viewerStore(w)
+	if !ok {
+		return
+	}
+	var req struct {
+		DisplayName string                 `json:"display_name"`
+		Accounts    []viewerAccountRequest `json:"accounts"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if len(req.Accounts) == 0 {
+		http.Error(w, "at least one account is required", http.StatusBadRequest)
+		return
+	}
+	if len(req.DisplayName) > maxViewerNameLength {
+		http.Error(w, "display_name too long", http.StatusBadRequest)
+		return
+	}
+	accounts, err := parseViewerAccounts(req.Accounts)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	v, err := store.LinkViewerAccounts(r.Context(), "", accounts, req.DisplayName)
+	if err != nil {
+		log.Printf("viewers: link: %v", err)
+		http.Error(w, "failed to link accounts", http.StatusInternalServerError)
+		return
+	}
+	viewerDir.invalidate()
+	writeJSONStatus(w, http.StatusCreated, viewerResponseFrom(*v))
+}
+
+func handleGetViewer(w http.ResponseWriter, r *http.Request) {
+	store, ok := viewerStore(w)
+	if !ok {
+		return
+	}
+	limit := viewerProfileDefaultMsgs
+	if raw := strings.TrimSpace(r.URL.Query().Get("limit")); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = min(n, viewerProfileMaxMsgs)
+	}
+
+	v, err := store.GetViewer(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		log.Printf("viewers: get: %v", err)
+		http.Error(w, "failed to load viewer", http.StatusInternalServerError)
+		return
+	}
+	if v == nil {
+		http.Error(w, "viewer not found", http.StatusNotFound)
+		return
+	}
+	rows, total, err := store.ViewerMessages(r.Context(), v, limit)
+	if err != nil {
+		log.Printf("viewers: messages for %s: %v", v.ID, err)
+		http.Error(w, "failed to load viewer messages", http.StatusInternalServerError)
+		return
+	}
+	resp := viewerProfileResponse{
+		viewerResponse: viewerResponseFrom(*v),
+		MessageCount:   total,
+		RecentMessages: make([]json.RawMessage, 0, len(rows)),
+	}
+	for _, row := range rows {
+		if payload, ok := storedPayload(row, ""); ok {
+			resp.RecentMessages = append(resp.RecentMessages, payload)
+		}
+	}
+	writeJSON(w, resp)
+}
+
+func handleUpdateViewer(w http.ResponseWriter, r *http.Request) {
+	store, ok := viewerStore(w)
+	if !ok {
+		return
+	}
+	var req struct {
+		DisplayName *string `json:"display_name"`
+		Colour      *string `json:"colour"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	if req.DisplayName != nil {
+		name := strings.TrimSpace(*req.DisplayName)
+		if len(name) > maxViewerNameLength {
+			http.Error(w, "display_name too long", http.StatusBadRequest)
+			return
+		}
+		req.DisplayName = &name
+	}
+	if req.Colour != nil && strings.TrimSpace(*req.Colour) != "" {
+		colour := normalizeHexUsernameColour(*req.Colour)
+		if colour == "" {
+			http.Error(w, "colour must be #RRGGBB", http.StatusBadRequest)
+			return
+		}
+		req.Colour = &colour
+	}
+
+	id := mux.Vars(r)["id"]
+	found, err := store.UpdateViewer(r.Context(), id, req.DisplayName, req.Colour)
+	if err != nil {
+		log.Printf("viewers: update: %v", err)
+		http.Error(w, "failed to update viewer", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "viewer not found", http.StatusNotFound)
+		return
+	}
+	viewerDir.invalidate()
+	v, err := store.GetViewer(r.Context(), id)
+	if err != nil || v == nil {
+		http.Error(w, "failed to load viewer", http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, viewerResponseFrom(*v))
+}
+
+func handleDeleteViewer(w http.ResponseWriter, r *http.Request) {
+	store, ok := viewerStore(w)
+	if !ok {
+		return
+	}
+	found, err := store.DeleteViewer(r.Context(), mux.Vars(r)["id"])
+	if err != nil {
+		log.Printf("viewers: delete: %v", err)
+		http.Error(w, "failed to delete viewer", http.StatusInternalServerError)
+		return
+	}
+	if !found {
+		http.Error(w, "viewer not found", http.StatusNotFound)
+		return
+	}
+	viewerDir.invalidate()
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func handleAddViewerAccount(w http.ResponseWriter, r *http.Request) {
+	store, ok := viewerStore(w)
+	if !ok {
+		return
+	}
+	var req viewerAccountRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "invalid JSON body", http.StatusBadRequest)
+		return
+	}
+	accounts, err := parseViewerAccounts([]viewerAccountRequest{req})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	v, err := store.LinkViewerAccounts(r.Context(), mux.Vars(r)["id"], accounts, "")
+	if err != nil {
+		log.Printf("viewers: add account: %v", err)
+		http.Error(w, "failed to link account", http.StatusInternalServerError)
+		return
+	}
+	if v == nil {
+		http.Error(w, "viewer not found", http.StatusNotFound)
+		return
+	}
+	viewerDir.invalidate()
+	writeJSON(w, viewerResponseFrom(*v))
+}
+
+func handleUnlinkViewerAccount(w http.ResponseWriter, r *http.Request) {
+	store, ok := viewerStore(w)
+	if !ok {
+		return
+	}
+	vars := mux.Vars(r)
+	platform := strings.ToLower(vars["platform"])
+	accountID := normalizeViewerAccountID(platform, vars["account"])
+	v, err := store.FindViewerByAccount(r.Context(), platform, accountID)
+	if err != nil {
+		log.Printf("viewers: find by account: %v", err)
+		http.Error(w, "failed to unlink account", http.StatusInternalServerError)
+		return
+	}
+	if v == nil || v.ID != vars["id"] {
+		http.Error(w, "account not linked to viewer", http.StatusNotFound)
+		return
+	}
+	if _, err := store.UnlinkViewerAccount(r.Context(), platform, accountID); err != nil {
+		log.Printf("viewers: unlink: %v", err)
+		http.Error(w, "failed to unlink account", http.StatusInternalServerError)
+		return
+	}
+	viewerDir.invalidate()
+	w.WriteHeader(http.StatusNoContent)
+}
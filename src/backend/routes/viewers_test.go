@@ -0,0 +1,284 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+)
+
+func viewerRequest(t *testing.T, r *mux.Router, cookie *http.Cookie, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if cookie != nil {
+		req.AddCookie(cookie)
+	}
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+	return rec
+}
+
+type viewerChatPayload struct {
+	Message string `json:"message"`
+	Colour  string `json:"colour"`
+	Viewer  *struct {
+		ID        string   `json:"id"`
+		Platforms []string `json:"platforms"`
+	} `json:"viewer"`
+}
+
+func decodeViewerChatFrame(t *testing.T, frame wsTestFrame) viewerChatPayload {
+	t.Helper()
+	var raw string
+	if err := json.Unmarshal(frame.Data, &raw); err != nil {
+		t.Fatalf("decode chat data: %v", err)
+	}
+	var payload viewerChatPayload
+	if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+		t.Fatalf("decode chat payload: %v", err)
+	}
+	return payload
+}
+
+func TestViewerLinkCodeLinksAccountsAcrossPlatforms(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+
+	router := mux.NewRouter()
+	SetupViewerRoutes(router)
+
+	rec := viewerRequest(t, router, nil, http.MethodPost, "/api/viewers/link-codes", "")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var code struct {
+		Code    string `json:"code"`
+		Command string `json:"command"`
+		Secret  string `json:"secret"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &code)
+	if !strings.HasPrefix(code.Code, "ELORA-") || code.Command != "!link "+code.Code || code.Secret == "" {
+		t.Fatalf("unexpected link code: %+v", code)
+	}
+	status := func(secret string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/viewers/link-codes/"+code.Code, nil)
+		req.Header.Set("X-Link-Secret", secret)
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+	if rec := status("wrong"); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a wrong secret, got %d", rec.Code)
+	}
+
+	conn, cleanup := dialChatWS(t, "", nil)
+	defer cleanup()
+
+	youtubeRaw := `{"author":{"channelId":"UCerin"}}`
+	BroadcastFromTailer(storage.Message{ID: "link-t", Timestamp: time.Now().UTC(), Username: "Erin", Platform: "Twitch", Text: "!link " + code.Code, RawJSON: `{"color":"#33AAFF"}`})
+	// Someone copying the code from chat only re-types a code already spent.
+	BroadcastFromTailer(storage.Message{ID: "link-x", Timestamp: time.Now().UTC(), Username: "Mallory", Platform: "YouTube", Text: "!link " + code.Code, RawJSON: `{"author":{"channelId":"UCmallory"}}`})
+
+	rec = status(code.Secret)
+	var claimed struct {
+		Status         string `json:"status"`
+		Platform       string `json:"platform"`
+		Username       string `json:"username"`
+		ConfirmCommand string `json:"confirm_command"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &claimed)
+	if rec.Code != http.StatusOK || claimed.Status != "claimed" || claimed.Platform != "twitch" || claimed.Username != "Erin" {
+		t.Fatalf("expected the code to be claimed by Erin on Twitch, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.HasPrefix(claimed.ConfirmCommand, "!link ELORA-") || claimed.ConfirmCommand == code.Command {
+		t.Fatalf("expected a separate confirm command, got %q", claimed.ConfirmCommand)
+	}
+	BroadcastFromTailer(storage.Message{ID: "link-y", Timestamp: time.Now().UTC(), Username: "Erin YT", Platform: "YouTube", Text: strings.ToLower(claimed.ConfirmCommand), RawJSON: youtubeRaw})
+
+	// Link commands are swallowed, so the first frame is the next real message.
+	BroadcastFromTailer(storage.Message{ID: "chat-t", Timestamp: time.Now().UTC(), Username: "Erin", Platform: "Twitch", Text: "hello", RawJSON: `{"color":"#33AAFF"}`})
+	BroadcastFromTailer(storage.Message{ID: "chat-y", Timestamp: time.Now().UTC(), Username: "Erin YT", Platform: "YouTube", Text: "hi again", RawJSON: youtubeRaw})
+
+	twitch := decodeViewerChatFrame(t, readWSFrame(t, conn))
+	youtube := decodeViewerChatFrame(t, readWSFrame(t, conn))
+	if twitch.Message != "hello" || youtube.Message != "hi again" {
+		t.Fatalf("expected link commands to be dropped, got %q then %q", twitch.Message, youtube.Message)
+	}
+	if twitch.Viewer == nil || youtube.Viewer == nil || twitch.Viewer.ID != youtube.Viewer.ID {
+		t.Fatalf("expected both messages to carry the same viewer, got %+v and %+v", twitch.Viewer, youtube.Viewer)
+	}
+	if len(twitch.Viewer.Platforms) != 2 {
+		t.Fatalf("expected both platforms on the viewer, got %v", twitch.Viewer.Platforms)
+	}
+	if twitch.Colour == "" || twitch.Colour != youtube.Colour {
+		t.Fatalf("expected a shared colour, got %q and %q", twitch.Colour, youtube.Colour)
+	}
+
+	store := chatStore.(*sqlite.Store)
+	if v, err := store.FindViewerByAccount(ctx, "youtube", "UCmallory"); err != nil || v != nil {
+		t.Fatalf("expected the copied code not to link, got %+v (err %v)", v, err)
+	}
+	if rec := status(code.Secret); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected the completed code to be gone, got %d", rec.Code)
+	}
+}
+
+func TestViewerLinkCodesAreThrottledPerIP(t *testing.T) {
+	defer withSQLiteStore(t)()
+	prev := viewerLinkCodeLimits
+	viewerLinkCodeLimits = &linkCodeLimiter{windows: map[string]linkCodeWindow{}}
+	t.Cleanup(func() { viewerLinkCodeLimits = prev })
+
+	router := mux.NewRouter()
+	SetupViewerRoutes(router)
+	create := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/api/viewers/link-codes", nil)
+		req.RemoteAddr = remoteAddr
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec
+	}
+
+	for i := 0; i < viewerLinkCodesPerIP; i++ {
+		if rec := create("203.0.113.7:4000"); rec.Code != http.StatusCreated {
+			t.Fatalf("code %d: expected 201, got %d", i, rec.Code)
+		}
+	}
+	rec := create("203.0.113.7:4001")
+	if rec.Code != http.StatusTooManyRequests || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("expected 429 with Retry-After once over the limit, got %d", rec.Code)
+	}
+	if rec := create("203.0.113.8:4000"); rec.Code != http.StatusCreated {
+		t.Fatalf("expected another IP to get a code, got %d", rec.Code)
+	}
+
+	if ok, _ := viewerLinkCodeLimits.allow("203.0.113.7", time.Now().Add(viewerLinkCodeTTL)); !ok {
+		t.Fatalf("expected the limit to reset after the window")
+	}
+}
+
+func TestViewerRoutes(t *testing.T) {
+	defer withSQLiteStore(t)()
+
+	router := mux.NewRouter()
+	SetupViewerRoutes(router)
+	cookie := seedTwitchSession(t)
+
+	if rec := viewerRequest(t, router, nil, http.MethodGet, "/api/viewers", ""); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without a session, got %d", rec.Code)
+	}
+	if rec := viewerRequest(t, router, cookie, http.MethodPost, "/api/viewers", `{"accounts":[{"platform":"kick","account_id":"x"}]}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for an unsupported platform, got %d", rec.Code)
+	}
+
+	rec := viewerRequest(t, router, cookie, http.MethodPost, "/api/viewers", `{"display_name":"Frank","accounts":[{"platform":"twitch","account_id":"Frank"}]}`)
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var created viewerResponse
+	_ = json.Unmarshal(rec.Body.Bytes(), &created)
+	if created.ID == "" || len(created.Accounts) != 1 || created.Accounts[0].AccountID != "frank" {
+		t.Fatalf("unexpected viewer: %+v", created)
+	}
+	base := "/api/viewers/" + created.ID
+
+	if rec := viewerRequest(t, router, cookie, http.MethodPost, base+"/accounts", `{"platform":"youtube","account_id":"UCfrank","username":"Frank YT"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected account to link, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := viewerRequest(t, router, cookie, http.MethodPost, "/api/viewers/missing/accounts", `{"platform":"youtube","account_id":"UCx"}`); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for an unknown viewer, got %d", rec.Code)
+	}
+	if rec := viewerRequest(t, router, cookie, http.MethodPatch, base, `{"colour":"blue"}`); rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400 for a bad colour, got %d", rec.Code)
+	}
+	if rec := viewerRequest(t, router, cookie, http.MethodPatch, base, `{"colour":"#aa00ff"}`); rec.Code != http.StatusOK {
+		t.Fatalf("expected colour to save, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for i, m := range []storage.Message{
+		{ID: "f1", Username: "Frank", Platform: "Twitch", Text: "one"},
+		{ID: "f2", Username: "Frank YT", Platform: "YouTube", Text: "two", RawJSON: `{"author":{"channelId":"UCfrank"}}`},
+	} {
+		m.Timestamp = time.Now().UTC().Add(time.Duration(i) * time.Millisecond)
+		m.EmotesJSON = "[]"
+		if err := chatStore.InsertMessage(ctx, &m); err != nil {
+			t.Fatalf("InsertMessage returned error: %v", err)
+		}
+	}
+
+	rec = viewerRequest(t, router, cookie, http.MethodGet, base, "")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected profile, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var profile struct {
+		Colour         string            `json:"colour"`
+		Accounts       []json.RawMessage `json:"accounts"`
+		MessageCount   int               `json:"message_count"`
+		RecentMessages []json.RawMessage `json:"recent_messages"`
+	}
+	_ = json.Unmarshal(rec.Body.Bytes(), &profile)
+	if profile.Colour != "#AA00FF" || len(profile.Accounts) != 2 || profile.MessageCount != 2 || len(profile.RecentMessages) != 2 {
+		t.Fatalf("unexpected profile: %s", rec.Body.String())
+	}
+
+	rec = viewerRequest(t, router, cookie, http.MethodGet, "/api/viewers?platform=youtube&account=UCfrank", "")
+	if !strings.Contains(rec.Body.String(), created.ID) {
+		t.Fatalf("expected account lookup to find the viewer, got %s", rec.Body.String())
+	}
+	if rec := viewerRequest(t, router, cookie, http.MethodDelete, base+"/accounts/youtube/UCfrank", ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected unlink, got %d", rec.Code)
+	}
+	if rec := viewerRequest(t, router, cookie, http.MethodDelete, base, ""); rec.Code != http.StatusNoContent {
+		t.Fatalf("expected delete, got %d", rec.Code)
+	}
+	if rec := viewerRequest(t, router, cookie, http.MethodGet, base, ""); rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 after delete, got %d", rec.Code)
+	}
+}
+
+func TestViewerLinkCommandsStayOutOfReplay(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+	defer withSQLiteStore(t)()
+
+	now := time.Now().UTC()
+	rows := []storage.Message{
+		{ID: "link-r", Timestamp: now, Username: "Erin", Platform: "Twitch", Text: "!link ELORA-ABCD", RawJSON: "{}"},
+		{ID: "chat-r", Timestamp: now.Add(time.Millisecond), Username: "Erin", Platform: "Twitch", Text: "hello", RawJSON: "{}"},
+	}
+	for i := range rows {
+		if err := chatStore.InsertMessage(ctx, &rows[i]); err != nil {
+			t.Fatalf("InsertMessage returned error: %v", err)
+		}
+	}
+
+	conn, cleanup := dialChatWS(t, "?replay=1", nil)
+	defer cleanup()
+	if got := decodeViewerChatFrame(t, readWSFrame(t, conn)); got.Message != "hello" {
+		t.Fatalf("expected replay to skip the link command, got %q", got.Message)
+	}
+
+	if err := conn.WriteJSON(map[string]any{"type": "history", "id": "h", "before": rows[1].RowID + 1}); err != nil {
+		t.Fatalf("write history command: %v", err)
+	}
+	reply := readWSFrame(t, conn)
+	if reply.Type != "history" || strings.Contains(string(reply.Data), "ELORA-ABCD") {
+		t.Fatalf("expected history without the link code, got %s %s", reply.Type, reply.Data)
+	}
+
+	router := mux.NewRouter()
+	SetupMessageRoutes(router)
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/messages", nil))
+	if rec.Code != http.StatusOK || strings.Contains(rec.Body.String(), "ELORA-ABCD") || !strings.Contains(rec.Body.String(), "hello") {
+		t.Fatalf("expected /api/messages without the link code, got %d %s", rec.Code, rec.Body.String())
+	}
+}
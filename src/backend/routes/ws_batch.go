@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	wsBatchDefaultWindow = 50 * time.Millisecond
+	wsBatchMaxWindow     = time.Second
+	wsBatchDefaultItems  = 100
+	wsBatchMaxItems      = 500
+)
+
+// wsBatchConfig is the per-connection frame coalescing setting, negotiated
+// with ?batch_ms= and/or ?batch_max= on /ws/chat. When enabled, chat payloads
+// are collected for up to window or maxItems and sent as one array frame.
+type wsBatchConfig struct {
+	window   time.Duration
+	maxItems int
+}
+
+func (b wsBatchConfig) enabled() bool {
+	return b.window > 0 && b.maxItems > 1
+}
+
+func wsBatchConfigFromQuery(q url.Values) wsBatchConfig {
+	rawMS := strings.TrimSpace(q.Get("batch_ms"))
+	rawMax := strings.TrimSpace(q.Get("batch_max"))
+	if rawMS == "" && rawMax == "" {
+		return wsBatchConfig{}
+	}
+
+	cfg := wsBatchConfig{window: wsBatchDefaultWindow, maxItems: wsBatchDefaultItems}
+	if ms, err := strconv.Atoi(rawMS); err == nil {
+		cfg.window = time.Duration(ms) * time.Millisecond
+	}
+	if n, err := strconv.Atoi(rawMax); err == nil {
+		cfg.maxItems = n
+	}
+	if cfg.window > wsBatchMaxWindow {
+		cfg.window = wsBatchMaxWindow
+	}
+	if cfg.maxItems > wsBatchMaxItems {
+		cfg.maxItems = wsBatchMaxItems
+	}
+	return cfg
+}
+
+// joinJSONArray wraps already-encoded JSON values in a JSON array.
+func joinJSONArray(items [][]byte) []byte {
+	size := 2
+	for _, item := range items {
+		size += len(item) + 1
+	}
+	out := make([]byte, 0, size)
+	out = append(out, '[')
+	for i, item := range items {
+		if i > 0 {
+			out = append(out, ',')
+		}
+		out = append(out, item...)
+	}
+	return append(out, ']')
+}
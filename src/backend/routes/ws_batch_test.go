@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func TestWSBatchConfigFromQuery(t *testing.T) {
+	cases := []struct {
+		query string
+		want  wsBatchConfig
+	}{
+		{"", wsBatchConfig{}},
+		{"batch_ms=20", wsBatchConfig{window: 20 * time.Millisecond, maxItems: wsBatchDefaultItems}},
+		{"batch_max=10", wsBatchConfig{window: wsBatchDefaultWindow, maxItems: 10}},
+		{"batch_ms=5000&batch_max=9999", wsBatchConfig{window: wsBatchMaxWindow, maxItems: wsBatchMaxItems}},
+	}
+	for _, tc := range cases {
+		q, _ := url.ParseQuery(tc.query)
+		if got := wsBatchConfigFromQuery(q); got != tc.want {
+			t.Fatalf("%q: expected %+v, got %+v", tc.query, tc.want, got)
+		}
+	}
+	if (wsBatchConfig{window: time.Second, maxItems: 1}).enabled() {
+		t.Fatalf("expected batch_max=1 to disable batching")
+	}
+}
+
+func readBatchFrame(t *testing.T, frame []byte) []map[string]any {
+	t.Helper()
+	var env struct {
+		Type string           `json:"type"`
+		Data []map[string]any `json:"data"`
+	}
+	if err := json.Unmarshal(frame, &env); err != nil {
+		t.Fatalf("expected a batch envelope with array data, got %s: %v", frame, err)
+	}
+	if env.Type != "batch" {
+		t.Fatalf("expected a batch frame, got %s", frame)
+	}
+	return env.Data
+}
+
+func TestStreamChatCoalescesFrames(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "false")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	conn, cleanup := dialChatWS(t, "?batch_ms=150&batch_max=3", nil)
+	defer cleanup()
+
+	for i := 0; i < 4; i++ {
+		broadcastChatMessage([]byte(fmt.Sprintf(`{"author":"tester","message":"m%d","source":"Twitch"}`, i)))
+	}
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	_, frame, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read full batch: %v", err)
+	}
+	if items := readBatchFrame(t, frame); len(items) != 3 || items[2]["message"] != "m2" {
+		t.Fatalf("expected first batch of 3 ending in m2, got %+v", items)
+	}
+
+	start := time.Now()
+	_, frame, err = conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read partial batch: %v", err)
+	}
+	if items := readBatchFrame(t, frame); len(items) != 1 || items[0]["message"] != "m3" {
+		t.Fatalf("expected trailing batch with m3, got %+v", items)
+	}
+	if waited := time.Since(start); waited < 50*time.Millisecond {
+		t.Fatalf("expected partial batch to wait for the window, flushed after %s", waited)
+	}
+}
@@ -0,0 +1,292 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/broadcast"
+	"github.com/hpwn/EloraChat/src/backend/internal/overlaytoken"
+	"github.com/hpwn/EloraChat/src/backend/internal/storage/sqlite"
+	"github.com/hpwn/EloraChat/src/backend/internal/ws"
+)
+
+const (
+	wsHistoryDefaultLimit = 50
+	wsHistoryMaxLimit     = 100
+)
+
+// wsCommand is a client→server control message on /ws/chat. Commands are JSON
+// objects sent as text (or binary) frames; id is echoed on the reply so
+// clients can correlate requests.
+type wsCommand struct {
+	Type   string  `json:"type"`
+	ID     string  `json:"id,omitempty"`
+	Source *string `json:"source,omitempty"`
+	Before int64   `json:"before,omitempty"`
+	Limit  int     `json:"limit,omitempty"`
+	Cursor int64   `json:"cursor,omitempty"`
+	TS     int64   `json:"ts,omitempty"`
+}
+
+// wsReply is a typed server→client envelope frame answering a wsCommand.
+type wsReply struct {
+	Type string `json:"type"`
+	ID   string `json:"id,omitempty"`
+	Data any    `json:"data,omitempty"`
+}
+
+// wsSession is the per-connection view state owned by the writer goroutine.
+type wsSession struct {
+	sourceFilter string
+	paused       bool
+	lastSent     int64
+	acked        int64
+	// grant is the verified overlay token, if any; it caps which sources the
+	// connection may see regardless of set_filter.
+	grant *overlaytoken.Claims
+	// hideBots drops messages from bots and service accounts (?bots=exclude).
+	hideBots bool
+	// theme re-adjusts username colours for the overlay background (?theme=).
+	theme usernameTheme
+	// feed is feedRaw or feedCurated. Approvals reach the curated feed out of
+	// cursor order, so it dedupes replay overlap by message ID and resumes
+	// from the curated sequence instead.
+	feed      string
+	replayed  map[string]struct{}
+	pausedSeq int64
+}
+
+func newWSSession(feed, sourceFilter string, grant *overlaytoken.Claims) *wsSession {
+	s := &wsSession{feed: feed, sourceFilter: sourceFilter, grant: grant}
+	if feed == feedCurated {
+		s.replayed = make(map[string]struct{})
+	}
+	return s
+}
+
+// chatMeta is the subset of a chat payload the writer needs for filtering
+// and cursor tracking, decoded in a single pass.
+type chatMeta struct {
+	Frame  string `json:"frame"`
+	Cursor int64  `json:"cursor"`
+	Source string `json:"source"`
+	Bot    bool   `json:"bot"`
+}
+
+// aggregate reports whether the payload is a frame that is not tied to one
+// source (donation totals), which source filters and grants let through.
+func (m chatMeta) aggregate() bool {
+	return m.Frame != "" && m.Source == ""
+}
+
+func chatMetaOf(payload []byte) chatMeta {
+	var meta chatMeta
+	_ = json.Unmarshal(payload, &meta)
+	meta.Source = strings.ToLower(strings.TrimSpace(meta.Source))
+	return meta
+}
+
+// admit reports whether a payload should be delivered and advances lastSent.
+// Payloads at or below lastSent were already delivered by a backfill.
+func (s *wsSession) admit(payload []byte) bool {
+	meta := chatMetaOf(payload)
+	if meta.aggregate() {
+		return true
+	}
+	if s.sourceFilter != "" && meta.Source != s.sourceFilter {
+		return false
+	}
+	if s.grant != nil && !s.grant.Permits(meta.Source) {
+		return false
+	}
+	if s.hideBots && meta.Bot {
+		return false
+	}
+	if s.feed == feedCurated {
+		id := broadcast.MessageID(payload)
+		if _, dup := s.replayed[id]; dup {
+			delete(s.replayed, id)
+			return false
+		}
+		s.lastSent = max(s.lastSent, meta.Cursor)
+		return true
+	}
+	if meta.Cursor > 0 {
+		if meta.Cursor <= s.lastSent {
+			return false
+		}
+		s.lastSent = meta.Cursor
+	}
+	return true
+}
+
+// replayPayloads returns the connect-time replay for the session's feed.
+func (s *wsSession) replayPayloads() [][]byte {
+	if s.feed == feedCurated {
+		return s.theme.applyAll(filterBotPayloads(s.hideBots, approvals.curatedAfter(0, s.sourceFilter)))
+	}
+	return s.theme.applyAll(filterBotPayloads(s.hideBots, replayHistoryPayloads(s.sourceFilter)))
+}
+
+// noteReplayed records a payload sent by the connect-time replay so the live
+// stream does not repeat it.
+func (s *wsSession) noteReplayed(payload []byte) {
+	if s.feed == feedCurated {
+		s.replayed[broadcast.MessageID(payload)] = struct{}{}
+		return
+	}
+	s.admit(payload)
+}
+
+// permits reports whether the connection's overlay token grants the payload's source.
+func (s *wsSession) permits(payload []byte) bool {
+	return grantPermits(s.grant, payload)
+}
+
+func grantPermits(grant *overlaytoken.Claims, payload []byte) bool {
+	if grant == nil {
+		return true
+	}
+	meta := chatMetaOf(payload)
+	return meta.aggregate() || grant.Permits(meta.Source)
+}
+
+func parseWSCommand(data []byte) (wsCommand, error) {
+	var cmd wsCommand
+	if err := json.Unmarshal(data, &cmd); err != nil {
+		return wsCommand{}, errors.New("invalid command json")
+	}
+	cmd.Type = strings.ToLower(strings.TrimSpace(cmd.Type))
+	if cmd.Type == "" {
+		return wsCommand{}, errors.New("command type required")
+	}
+	return cmd, nil
+}
+
+// handle applies a command to the session. It returns the reply and any chat
+// payloads that must be delivered before live traffic (a resume backfill).
+func (s *wsSession) handle(cmd wsCommand) (wsReply, [][]byte) {
+	switch cmd.Type {
+	case "set_filter":
+		source := ""
+		if cmd.Source != nil {
+			source = strings.ToLower(strings.TrimSpace(*cmd.Source))
+		}
+		if source != "" && s.grant != nil && !s.grant.Permits(source) {
+			return wsErrorReply(cmd, "source not permitted by overlay token"), nil
+		}
+		s.sourceFilter = source
+		return wsReply{Type: "filter", ID: cmd.ID, Data: map[string]any{"source": source}}, nil
+	case "pause":
+		if !s.paused {
+			s.paused = true
+			s.pausedSeq = approvals.currentSeq()
+			if s.lastSent == 0 {
+				s.lastSent = currentTailCursor()
+			}
+		}
+		return wsReply{Type: "paused", ID: cmd.ID, Data: map[string]any{"cursor": s.lastSent}}, nil
+	case "resume":
+		var backlog [][]byte
+		if s.paused {
+			s.paused = false
+			switch {
+			case s.feed == feedCurated:
+				backlog = approvals.curatedAfter(s.pausedSeq, s.sourceFilter)
+			case s.lastSent > 0:
+				backlog = resumePayloadsAfter(s.lastSent, s.sourceFilter)
+			}
+		}
+		return wsReply{Type: "resumed", ID: cmd.ID, Data: map[string]any{"cursor": s.lastSent, "backfill": len(backlog)}}, backlog
+	case "history":
+		if s.feed == feedCurated {
+			return wsErrorReply(cmd, "history unavailable on the curated feed"), nil
+		}
+		data, err := s.history(cmd.Before, cmd.Limit)
+		if err != nil {
+			return wsErrorReply(cmd, err.Error()), nil
+		}
+		return wsReply{Type: "history", ID: cmd.ID, Data: data}, nil
+	case "ack":
+		if cmd.Cursor <= 0 {
+			return wsErrorReply(cmd, "cursor required"), nil
+		}
+		if cmd.Cursor > s.acked {
+			s.acked = cmd.Cursor
+		}
+		return wsReply{Type: "ack", ID: cmd.ID, Data: map[string]any{"cursor": s.acked}}, nil
+	case "ping":
+		return wsReply{Type: "pong", ID: cmd.ID, Data: map[string]any{"ts": cmd.TS, "server_ts": time.Now().UnixMilli()}}, nil
+	default:
+		return wsErrorReply(cmd, fmt.Sprintf("unknown command %q", cmd.Type)), nil
+	}
+}
+
+// history returns stored payloads older than before (oldest first) using the
+// session's current source filter.
+func (s *wsSession) history(before int64, limit int) (map[string]any, error) {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		return nil, errors.New("history unavailable")
+	}
+	if limit <= 0 {
+		limit = wsHistoryDefaultLimit
+	}
+	limit = min(limit, wsHistoryMaxLimit)
+
+	rows, err := store.MessagesBeforeRowID(ctx, before, limit)
+	if err != nil {
+		log.Printf("ws: history before=%d failed: %v", before, err)
+		return nil, errors.New("history query failed")
+	}
+	items := make([]json.RawMessage, 0, len(rows))
+	for i := len(rows) - 1; i >= 0; i-- {
+		if sanitized, ok := storedPayload(rows[i], s.sourceFilter); ok && s.permits(sanitized) && !(s.hideBots && isBotPayload(sanitized)) {
+			items = append(items, s.theme.apply(sanitized))
+		}
+	}
+	data := map[string]any{"items": items}
+	if len(rows) == limit {
+		data["next_before"] = rows[len(rows)-1].RowID
+	}
+	return data, nil
+}
+
+func wsErrorReply(cmd wsCommand, message string) wsReply {
+	return wsReply{Type: "error", ID: cmd.ID, Data: map[string]any{"command": cmd.Type, "message": message}}
+}
+
+func currentTailCursor() int64 {
+	store, ok := chatStore.(*sqlite.Store)
+	if !ok || store == nil {
+		return 0
+	}
+	head, err := store.TailHead(ctx)
+	if err != nil {
+		return 0
+	}
+	return head.RowID
+}
+
+// replyFrame encodes a control reply. Replies are always enveloped, regardless
+// of ELORA_WS_ENVELOPE, because clients dispatch on type.
+func (e wsEncoding) replyFrame(reply wsReply) (int, []byte, error) {
+	body, err := json.Marshal(reply)
+	if err != nil {
+		return 0, nil, err
+	}
+	if e != wsProtocolMsgpack {
+		return websocket.TextMessage, body, nil
+	}
+	out, err := ws.MsgpackFromJSON(body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return websocket.BinaryMessage, out, nil
+}
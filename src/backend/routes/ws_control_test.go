@@ -0,0 +1,157 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/storage"
+)
+
+type wsTestFrame struct {
+	Type string          `json:"type"`
+	ID   string          `json:"id"`
+	Data json.RawMessage `json:"data"`
+}
+
+func readWSFrame(t *testing.T, conn *websocket.Conn) wsTestFrame {
+	t.Helper()
+	for {
+		_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			t.Fatalf("read: %v", err)
+		}
+		if string(data) == "__keepalive__" {
+			continue
+		}
+		var frame wsTestFrame
+		if err := json.Unmarshal(data, &frame); err != nil {
+			t.Fatalf("decode frame %s: %v", data, err)
+		}
+		return frame
+	}
+}
+
+func sendWSCommand(t *testing.T, conn *websocket.Conn, cmd string) {
+	t.Helper()
+	if err := conn.WriteMessage(websocket.TextMessage, []byte(cmd)); err != nil {
+		t.Fatalf("write command: %v", err)
+	}
+}
+
+func insertControlTestMessage(t *testing.T, i int) {
+	t.Helper()
+	msg := storage.Message{
+		ID:         fmt.Sprintf("ctl-%d", i),
+		Timestamp:  time.Now().UTC().Add(time.Duration(i) * time.Millisecond),
+		Username:   "tester",
+		Platform:   "Twitch",
+		Text:       fmt.Sprintf("hello %d", i),
+		EmotesJSON: "[]",
+		RawJSON:    "{}",
+	}
+	if err := chatStore.InsertMessage(context.Background(), &msg); err != nil {
+		t.Fatalf("insert message %d: %v", i, err)
+	}
+}
+
+func TestStreamChatControlProtocol(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	for i := 1; i <= 3; i++ {
+		insertControlTestMessage(t, i)
+	}
+
+	conn, closeConn := dialChatWS(t, "", nil)
+	defer closeConn()
+
+	sendWSCommand(t, conn, `{"type":"ping","id":"p1","ts":1234}`)
+	pong := readWSFrame(t, conn)
+	if pong.Type != "pong" || pong.ID != "p1" || !jsonHasNumber(pong.Data, "ts", 1234) {
+		t.Fatalf("unexpected pong: %+v %s", pong, pong.Data)
+	}
+
+	sendWSCommand(t, conn, `{"type":"history","id":"h1","limit":2}`)
+	hist := readWSFrame(t, conn)
+	var histData struct {
+		Items      []controlTestPayload `json:"items"`
+		NextBefore int64                `json:"next_before"`
+	}
+	if err := json.Unmarshal(hist.Data, &histData); err != nil {
+		t.Fatalf("decode history: %v", err)
+	}
+	if hist.Type != "history" || len(histData.Items) != 2 || histData.Items[0].Message != "hello 2" || histData.Items[1].Message != "hello 3" {
+		t.Fatalf("unexpected history: %+v", histData)
+	}
+	if histData.NextBefore != histData.Items[0].Cursor {
+		t.Fatalf("expected next_before=%d, got %d", histData.Items[0].Cursor, histData.NextBefore)
+	}
+
+	sendWSCommand(t, conn, `{"type":"set_filter","source":"YouTube"}`)
+	if f := readWSFrame(t, conn); f.Type != "filter" || !jsonHasString(f.Data, "source", "youtube") {
+		t.Fatalf("unexpected filter reply: %+v %s", f, f.Data)
+	}
+	broadcastChatMessage([]byte(`{"author":"tester","message":"filtered","source":"Twitch"}`))
+	sendWSCommand(t, conn, `{"type":"set_filter","source":""}`)
+	if f := readWSFrame(t, conn); f.Type != "filter" {
+		t.Fatalf("expected filter reply (twitch message should be filtered), got %+v", f)
+	}
+
+	sendWSCommand(t, conn, `{"type":"pause"}`)
+	if f := readWSFrame(t, conn); f.Type != "paused" {
+		t.Fatalf("expected paused reply, got %+v", f)
+	}
+	insertControlTestMessage(t, 4)
+	broadcastChatMessage([]byte(`{"author":"tester","message":"while paused","source":"Twitch"}`))
+	sendWSCommand(t, conn, `{"type":"resume"}`)
+	if f := readWSFrame(t, conn); f.Type != "resumed" || !jsonHasNumber(f.Data, "backfill", 1) {
+		t.Fatalf("unexpected resume reply: %+v %s", f, f.Data)
+	}
+	chat := readWSFrame(t, conn)
+	var chatData string
+	_ = json.Unmarshal(chat.Data, &chatData)
+	if chat.Type != "chat" || !jsonHasString(json.RawMessage(chatData), "message", "hello 4") {
+		t.Fatalf("expected backfilled hello 4, got %+v", chat)
+	}
+
+	sendWSCommand(t, conn, `{"type":"ack","cursor":4}`)
+	if f := readWSFrame(t, conn); f.Type != "ack" || !jsonHasNumber(f.Data, "cursor", 4) {
+		t.Fatalf("unexpected ack reply: %+v %s", f, f.Data)
+	}
+
+	sendWSCommand(t, conn, `{"type":"bogus","id":"x"}`)
+	if f := readWSFrame(t, conn); f.Type != "error" || f.ID != "x" {
+		t.Fatalf("expected error reply, got %+v", f)
+	}
+}
+
+type controlTestPayload struct {
+	Cursor  int64  `json:"cursor"`
+	Message string `json:"message"`
+}
+
+func jsonHasNumber(data json.RawMessage, key string, want float64) bool {
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return false
+	}
+	got, ok := obj[key].(float64)
+	return ok && got == want
+}
+
+func jsonHasString(data json.RawMessage, key, want string) bool {
+	var obj map[string]any
+	if err := json.Unmarshal(data, &obj); err != nil {
+		return false
+	}
+	got, ok := obj[key].(string)
+	return ok && got == want
+}
@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/ws"
+)
+
+// WebSocket subprotocols offered on /ws/chat, in server preference order.
+// Clients that send no Sec-WebSocket-Protocol header get the JSON text frames
+// they always have.
+const (
+	wsProtocolJSON    = "elora.json.v1"
+	wsProtocolMsgpack = "elora.msgpack.v1"
+)
+
+var wsSubprotocols = []string{wsProtocolMsgpack, wsProtocolJSON}
+
+// wsEncoding turns sanitized JSON chat payloads into frames for one connection.
+type wsEncoding string
+
+func wsEncodingFor(subprotocol string) wsEncoding {
+	if subprotocol == wsProtocolMsgpack {
+		return wsProtocolMsgpack
+	}
+	return wsProtocolJSON
+}
+
+// chatFrame returns the frame type and body for a chat payload. The binary
+// form carries the same envelope, but with data as a nested map instead of
+// a JSON string.
+func (e wsEncoding) chatFrame(payload []byte) (int, []byte, error) {
+	if e != wsProtocolMsgpack {
+		return websocket.TextMessage, maybeEnvelope(payload), nil
+	}
+	body := payload
+	if wsEnvelopeEnabled() {
+		body = make([]byte, 0, len(payload)+24)
+		body = append(body, `{"type":"chat","data":`...)
+		body = append(body, payload...)
+		body = append(body, '}')
+	}
+	out, err := ws.MsgpackFromJSON(body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return websocket.BinaryMessage, out, nil
+}
+
+// batchFrame encodes several chat payloads as a single "batch" frame whose
+// data is the array itself. Like frame, it is enveloped even when
+// ELORA_WS_ENVELOPE is off, so clients never have to guess whether a frame
+// holds one message or many.
+func (e wsEncoding) batchFrame(payloads [][]byte) (int, []byte, error) {
+	return e.envelope([]byte(`"batch"`), joinJSONArray(payloads))
+}
+
+// framePayloadPrefix marks bus payloads that are not chat messages (platform
+// events, donation totals). Their frame name becomes the envelope type.
+// json.Marshal emits struct fields in order, so such payloads declare Frame
+// first.
+var framePayloadPrefix = []byte(`{"frame":"`)
+
+func isFramePayload(payload []byte) bool {
+	return bytes.HasPrefix(payload, framePayloadPrefix)
+}
+
+func framePayloadName(payload []byte) string {
+	var meta struct {
+		Frame string `json:"frame"`
+	}
+	_ = json.Unmarshal(payload, &meta)
+	return meta.Frame
+}
+
+// sanitizeStreamPayload prepares a bus payload for delivery. Frame payloads
+// are built by this process (or a peer running the same code) and pass
+// through untouched; everything else is a chat message.
+func sanitizeStreamPayload(payload []byte) ([]byte, error) {
+	if isFramePayload(payload) {
+		return payload, nil
+	}
+	return sanitizeMessagePayload(payload)
+}
+
+// frame encodes a non-chat frame payload. Like control replies, these are
+// always enveloped so clients can tell them from chat.
+func (e wsEncoding) frame(payload []byte) (int, []byte, error) {
+	name, err := json.Marshal(framePayloadName(payload))
+	if err != nil {
+		return 0, nil, err
+	}
+	return e.envelope(name, payload)
+}
+
+// envelope wraps data, already JSON, as {"type":<name>,"data":<data>}. name is
+// a JSON string.
+func (e wsEncoding) envelope(name, data []byte) (int, []byte, error) {
+	body := make([]byte, 0, len(data)+len(name)+18)
+	body = append(body, `{"type":`...)
+	body = append(body, name...)
+	body = append(body, `,"data":`...)
+	body = append(body, data...)
+	body = append(body, '}')
+	if e != wsProtocolMsgpack {
+		return websocket.TextMessage, body, nil
+	}
+	out, err := ws.MsgpackFromJSON(body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return websocket.BinaryMessage, out, nil
+}
+
+// nextStreamFrame encodes the head of a replay backlog and returns what is
+// left. With batching enabled, consecutive chat payloads share one frame;
+// frame payloads always get a frame of their own.
+func (e wsEncoding) nextStreamFrame(batch wsBatchConfig, payloads [][]byte) (int, []byte, [][]byte, error) {
+	if isFramePayload(payloads[0]) {
+		frameType, frame, err := e.frame(payloads[0])
+		return frameType, frame, payloads[1:], err
+	}
+	if !batch.enabled() {
+		frameType, frame, err := e.chatFrame(payloads[0])
+		return frameType, frame, payloads[1:], err
+	}
+	n := 1
+	for n < len(payloads) && n < batch.maxItems && !isFramePayload(payloads[n]) {
+		n++
+	}
+	frameType, frame, err := e.batchFrame(payloads[:n])
+	return frameType, frame, payloads[n:], err
+}
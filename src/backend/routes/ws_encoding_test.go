@@ -0,0 +1,106 @@
+package routes
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+
+	"github.com/hpwn/EloraChat/src/backend/internal/ws"
+)
+
+func subscriberCount() int {
+	return broadcaster.Subscribers()
+}
+
+// dialChatWS connects to StreamChat and waits until the connection has
+// registered its subscriber; cleanup waits for it to be removed again.
+func dialChatWS(t *testing.T, query string, protocols []string) (*websocket.Conn, func()) {
+	t.Helper()
+	before := subscriberCount()
+	server := httptest.NewServer(http.HandlerFunc(StreamChat))
+	dialer := websocket.Dialer{Subprotocols: protocols}
+	conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+query, nil)
+	if err != nil {
+		server.Close()
+		t.Fatalf("dial: %v", err)
+	}
+	waitForSubscriberCount(t, before+1)
+	return conn, func() {
+		_ = conn.Close()
+		server.Close()
+		waitForSubscriberCount(t, before)
+	}
+}
+
+func waitForSubscriberCount(t *testing.T, n int) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for subscriberCount() != n {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected %d subscribers, got %d", n, subscriberCount())
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestStreamChatNegotiatesMsgpack(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	conn, cleanup := dialChatWS(t, "", []string{wsProtocolMsgpack, wsProtocolJSON})
+	defer cleanup()
+	if conn.Subprotocol() != wsProtocolMsgpack {
+		t.Fatalf("expected %q subprotocol, got %q", wsProtocolMsgpack, conn.Subprotocol())
+	}
+
+	payload := []byte(`{"author":"tester","message":"hello","fragments":[],"emotes":[],"badges":[],"source":"Twitch","colour":"#fff"}`)
+	broadcastChatMessage(payload)
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	frameType, frame, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if frameType != websocket.BinaryMessage {
+		t.Fatalf("expected binary frame, got type %d", frameType)
+	}
+
+	sanitized, err := sanitizeMessagePayload(payload)
+	if err != nil {
+		t.Fatalf("sanitize: %v", err)
+	}
+	want, err := ws.MsgpackFromJSON([]byte(`{"type":"chat","data":` + string(sanitized) + `}`))
+	if err != nil {
+		t.Fatalf("encode expected frame: %v", err)
+	}
+	if !bytes.Equal(frame, want) {
+		t.Fatalf("unexpected msgpack frame:\n got % x\nwant % x", frame, want)
+	}
+}
+
+func TestStreamChatDefaultsToJSONText(t *testing.T) {
+	t.Setenv("ELORA_WS_ENVELOPE", "true")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	conn, cleanup := dialChatWS(t, "", nil)
+	defer cleanup()
+	if conn.Subprotocol() != "" {
+		t.Fatalf("expected no subprotocol, got %q", conn.Subprotocol())
+	}
+
+	broadcastChatMessage([]byte(`{"author":"tester","message":"hello","fragments":[],"emotes":[],"badges":[],"source":"Twitch","colour":"#fff"}`))
+
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	frameType, frame, err := conn.ReadMessage()
+	if err != nil {
+		t.Fatalf("read: %v", err)
+	}
+	if frameType != websocket.TextMessage || !strings.HasPrefix(string(frame), `{"type":"chat","data":"`) {
+		t.Fatalf("expected enveloped JSON text frame, got type %d: %s", frameType, frame)
+	}
+}
@@ -0,0 +1,316 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+// wsCloseKicked is the close code sent when an admin disconnects a client.
+// It is in the application range so overlays can choose not to reconnect.
+const wsCloseKicked = 4000
+
+const defaultKickReason = "disconnected by admin"
+
+// wsConnInfo tracks one live /ws/chat connection for the admin endpoints.
+// Identity fields are fixed at connect; counters are updated by the writer.
+type wsConnInfo struct {
+	id           string
+	remoteAddr   string
+	forwardedFor string
+	origin       string
+	userAgent    string
+	protocol     string
+	tokenID      string
+	connectedAt  time.Time
+	messages     chan []byte
+
+	mu     sync.Mutex
+	filter string
+	paused bool
+
+	framesSent          atomic.Int64
+	framesDropped       atomic.Int64
+	framesSkippedPaused atomic.Int64
+	lastWriteMicros     atomic.Int64
+	lastWriteAtMilli    atomic.Int64
+
+	kick chan string
+}
+
+type wsConnSnapshot struct {
+	ID                  string  `json:"id"`
+	RemoteAddr          string  `json:"remote_addr"`
+	ForwardedFor        string  `json:"forwarded_for,omitempty"`
+	Origin              string  `json:"origin"`
+	UserAgent           string  `json:"user_agent"`
+	Protocol            string  `json:"protocol"`
+	TokenID             string  `json:"token_id,omitempty"`
+	Filter              string  `json:"filter"`
+	Paused              bool    `json:"paused"`
+	ConnectedAt         string  `json:"connected_at"`
+	FramesSent          int64   `json:"frames_sent"`
+	FramesDropped       int64   `json:"frames_dropped"`
+	FramesSkippedPaused int64   `json:"frames_skipped_paused"`
+	LastWriteMS         float64 `json:"last_write_ms"`
+	LastWriteAt         *string `json:"last_write_at,omitempty"`
+}
+
+type wsConnRegistry struct {
+	mu    sync.Mutex
+	conns map[string]*wsConnInfo
+}
+
+var wsConnections = &wsConnRegistry{conns: make(map[string]*wsConnInfo)}
+
+func newWSConnInfo(r *http.Request, protocol, filter, tokenID string, messages chan []byte) *wsConnInfo {
+	return &wsConnInfo{
+		id:           uuid.NewString(),
+		remoteAddr:   r.RemoteAddr,
+		forwardedFor: strings.TrimSpace(r.Header.Get("X-Forwarded-For")),
+		origin:       r.Header.Get("Origin"),
+		userAgent:    r.UserAgent(),
+		protocol:     protocol,
+		tokenID:      tokenID,
+		connectedAt:  time.Now().UTC(),
+		messages:     messages,
+		filter:       filter,
+		kick:         make(chan string, 1),
+	}
+}
+
+// write sends one frame and records the count and latency.
+func (c *wsConnInfo) write(conn *websocket.Conn, messageType int, payload []byte, deadline time.Duration) error {
+	start := time.Now()
+	err := writeWSMessage(conn, messageType, payload, deadline)
+	if err != nil {
+		return err
+	}
+	c.framesSent.Add(1)
+	c.lastWriteMicros.Store(time.Since(start).Microseconds())
+	c.lastWriteAtMilli.Store(time.Now().UnixMilli())
+	return nil
+}
+
+func (c *wsConnInfo) dropped() {
+	c.framesDropped.Add(1)
+}
+
+// skippedPaused counts a message the client chose not to receive by pausing.
+// These are kept apart from dropped frames, which signal a problem.
+func (c *wsConnInfo) skippedPaused() {
+	c.framesSkippedPaused.Add(1)
+}
+
+// syncSession mirrors the writer's view state for listing.
+func (c *wsConnInfo) syncSession(s *wsSession) {
+	c.mu.Lock()
+	c.filter = s.sourceFilter
+	c.paused = s.paused
+	c.mu.Unlock()
+}
+
+func (c *wsConnInfo) snapshot() wsConnSnapshot {
+	c.mu.Lock()
+	filter, paused := c.filter, c.paused
+	c.mu.Unlock()
+	snap := wsConnSnapshot{
+		ID:                  c.id,
+		RemoteAddr:          c.remoteAddr,
+		ForwardedFor:        c.forwardedFor,
+		Origin:              c.origin,
+		UserAgent:           c.userAgent,
+		Protocol:            c.protocol,
+		TokenID:             c.tokenID,
+		Filter:              filter,
+		Paused:              paused,
+		ConnectedAt:         c.connectedAt.Format(time.RFC3339),
+		FramesSent:          c.framesSent.Load(),
+		FramesDropped:       c.framesDropped.Load(),
+		FramesSkippedPaused: c.framesSkippedPaused.Load(),
+		LastWriteMS:         float64(c.lastWriteMicros.Load()) / 1000,
+	}
+	if at := c.lastWriteAtMilli.Load(); at > 0 {
+		ts := time.UnixMilli(at).UTC().Format(time.RFC3339Nano)
+		snap.LastWriteAt = &ts
+	}
+	return snap
+}
+
+// requestKick asks the writer to close the connection. Only the first
+// reason is kept if several kicks race.
+func (c *wsConnInfo) requestKick(reason string) {
+	select {
+	case c.kick <- reason:
+	default:
+	}
+}
+
+func (reg *wsConnRegistry) add(c *wsConnInfo) {
+	reg.mu.Lock()
+	reg.conns[c.id] = c
+	reg.mu.Unlock()
+}
+
+func (reg *wsConnRegistry) remove(id string) {
+	reg.mu.Lock()
+	delete(reg.conns, id)
+	reg.mu.Unlock()
+}
+
+func (reg *wsConnRegistry) list() []*wsConnInfo {
+	reg.mu.Lock()
+	out := make([]*wsConnInfo, 0, len(reg.conns))
+	for _, c := range reg.conns {
+		out = append(out, c)
+	}
+	reg.mu.Unlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].connectedAt.Equal(out[j].connectedAt) {
+			return out[i].id < out[j].id
+		}
+		return out[i].connectedAt.Before(out[j].connectedAt)
+	})
+	return out
+}
+
+func (reg *wsConnRegistry) disconnect(id, reason string) bool {
+	reg.mu.Lock()
+	c, ok := reg.conns[id]
+	reg.mu.Unlock()
+	if ok {
+		c.requestKick(reason)
+	}
+	return ok
+}
+
+func (reg *wsConnRegistry) disconnectAll(reason string) int {
+	conns := reg.list()
+	for _, c := range conns {
+		c.requestKick(reason)
+	}
+	return len(conns)
+}
+
+// disconnectToken kicks every connection that authenticated with the overlay
+// token and returns how many there were.
+func (reg *wsConnRegistry) disconnectToken(tokenID, reason string) int {
+	n := 0
+	for _, c := range reg.list() {
+		if c.tokenID == tokenID {
+			c.requestKick(reason)
+			n++
+		}
+	}
+	return n
+}
+
+// noteStalled counts the message lost when broadcast gives up on a subscriber.
+func (reg *wsConnRegistry) noteStalled(ch chan []byte) {
+	reg.mu.Lock()
+	defer reg.mu.Unlock()
+	for _, c := range reg.conns {
+		if c.messages == ch {
+			c.dropped()
+			return
+		}
+	}
+}
+
+// kickWS sends a close frame with the admin's reason. The read loop normally
+// exits when the client echoes the close; the timer covers clients that don't.
+func kickWS(conn *websocket.Conn, reason string, deadline time.Duration) {
+	if deadline <= 0 {
+		deadline = 5 * time.Second
+	}
+	msg := websocket.FormatCloseMessage(wsCloseKicked, truncateCloseReason(reason))
+	if err := conn.WriteControl(websocket.CloseMessage, msg, time.Now().Add(deadline)); err != nil {
+		log.Println("ws: failed to send close frame:", err)
+	}
+	time.AfterFunc(time.Second, func() { _ = conn.Close() })
+}
+
+// SetupWSAdminRoutes registers endpoints for inspecting and disconnecting live
+// /ws/chat clients. They require a logged-in session.
+func SetupWSAdminRoutes(r *mux.Router) {
+	protected := r.PathPrefix("/api/ws/connections").Subrouter()
+	protected.Use(SessionMiddleware)
+	protected.HandleFunc("", handleListWSConnections).Methods(http.MethodGet)
+	protected.HandleFunc("/disconnect", handleDisconnectAllWS).Methods(http.MethodPost)
+	protected.HandleFunc("/{id}/disconnect", handleDisconnectWS).Methods(http.MethodPost)
+}
+
+// truncateCloseReason cuts reason to the protocol's 123-byte limit without
+// splitting a UTF-8 rune, which would make the close frame invalid.
+func truncateCloseReason(reason string) string {
+	const maxCloseReason = 123
+	if len(reason) <= maxCloseReason {
+		return reason
+	}
+	cut := maxCloseReason
+	for cut > 0 && !utf8.RuneStart(reason[cut]) {
+		cut--
+	}
+	return reason[:cut]
+}
+
+func handleListWSConnections(w http.ResponseWriter, r *http.Request) {
+	conns := wsConnections.list()
+	items := make([]wsConnSnapshot, 0, len(conns))
+	for _, c := range conns {
+		items = append(items, c.snapshot())
+	}
+	writeJSON(w, map[string]any{"count": len(items), "items": items})
+}
+
+func handleDisconnectWS(w http.ResponseWriter, r *http.Request) {
+	reason, err := kickReason(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	id := mux.Vars(r)["id"]
+	if !wsConnections.disconnect(id, reason) {
+		http.Error(w, "connection not found", http.StatusNotFound)
+		return
+	}
+	log.Printf("ws: admin disconnected %s: %s", id, reason)
+	writeJSON(w, map[string]any{"disconnected": 1})
+}
+
+func handleDisconnectAllWS(w http.ResponseWriter, r *http.Request) {
+	reason, err := kickReason(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	n := wsConnections.disconnectAll(reason)
+	log.Printf("ws: admin disconnected %d connections: %s", n, reason)
+	writeJSON(w, map[string]any{"disconnected": n})
+}
+
+// kickReason reads an optional {"reason":"..."} body.
+func kickReason(r *http.Request) (string, error) {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil && !errors.Is(err, io.EOF) {
+		return "", errors.New("invalid JSON body")
+	}
+	if reason := strings.TrimSpace(body.Reason); reason != "" {
+		return reason, nil
+	}
+	return defaultKickReason, nil
+}
@@ -0,0 +1,189 @@
+package routes
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+)
+
+func listWSConnections(t *testing.T, router *mux.Router, cookie *http.Cookie) []wsConnSnapshot {
+	t.Helper()
+	req := httptest.NewRequest(http.MethodGet, "/api/ws/connections", nil)
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("list: expected 200, got %d", rr.Code)
+	}
+	var body struct {
+		Items []wsConnSnapshot `json:"items"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	return body.Items
+}
+
+func expectKicked(t *testing.T, conn *websocket.Conn, reason string) {
+	t.Helper()
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for {
+		_, _, err := conn.ReadMessage()
+		if err == nil {
+			continue
+		}
+		var closeErr *websocket.CloseError
+		if !errors.As(err, &closeErr) || closeErr.Code != wsCloseKicked || closeErr.Text != reason {
+			t.Fatalf("expected close %d %q, got %v", wsCloseKicked, reason, err)
+		}
+		return
+	}
+}
+
+func TestWSAdminRoutesRequireSession(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+
+	router := mux.NewRouter()
+	SetupWSAdminRoutes(router)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/api/ws/connections", nil))
+	if rr.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401 without session, got %d", rr.Code)
+	}
+}
+
+func TestWSAdminListsAndDisconnects(t *testing.T) {
+	cleanup := withSQLiteStore(t)
+	defer cleanup()
+	t.Setenv("ELORA_WS_ENVELOPE", "false")
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	router := mux.NewRouter()
+	SetupWSAdminRoutes(router)
+	cookie := seedTwitchSession(t)
+
+	server := httptest.NewServer(http.HandlerFunc(StreamChat))
+	defer server.Close()
+	wsURL := "ws" + strings.TrimPrefix(server.URL, "http")
+	header := http.Header{"User-Agent": []string{"OBS-Browser/1.0"}}
+
+	before := subscriberCount()
+	first, _, err := websocket.DefaultDialer.Dial(wsURL+"?source=twitch", header)
+	if err != nil {
+		t.Fatalf("dial first: %v", err)
+	}
+	defer first.Close()
+	second, _, err := websocket.DefaultDialer.Dial(wsURL, nil)
+	if err != nil {
+		t.Fatalf("dial second: %v", err)
+	}
+	defer second.Close()
+	waitForSubscriberCount(t, before+2)
+
+	broadcastChatMessage([]byte(`{"author":"a","message":"hi","source":"Twitch"}`))
+	_ = first.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := first.ReadMessage(); err != nil {
+		t.Fatalf("read: %v", err)
+	}
+
+	var target *wsConnSnapshot
+	items := listWSConnections(t, router, cookie)
+	for i := range items {
+		if items[i].UserAgent == "OBS-Browser/1.0" {
+			target = &items[i]
+		}
+	}
+	if len(items) < 2 || target == nil {
+		t.Fatalf("expected both connections listed, got %+v", items)
+	}
+	if target.Filter != "twitch" || target.FramesSent < 1 || target.Protocol != wsProtocolJSON || target.LastWriteAt == nil {
+		t.Fatalf("unexpected connection snapshot: %+v", target)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/api/ws/connections/"+target.ID+"/disconnect", strings.NewReader(`{"reason":"misbehaving overlay"}`))
+	req.AddCookie(cookie)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("disconnect: expected 200, got %d", rr.Code)
+	}
+	expectKicked(t, first, "misbehaving overlay")
+	waitForSubscriberCount(t, before+1)
+
+	req = httptest.NewRequest(http.MethodPost, "/api/ws/connections/"+target.ID+"/disconnect", nil)
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 for a closed connection, got %d", rr.Code)
+	}
+
+	req = httptest.NewRequest(http.MethodPost, "/api/ws/connections/disconnect", nil)
+	req.AddCookie(cookie)
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"disconnected":1`) {
+		t.Fatalf("disconnect all: unexpected response %d %s", rr.Code, rr.Body.String())
+	}
+	expectKicked(t, second, defaultKickReason)
+	waitForSubscriberCount(t, before)
+}
+
+func TestWSSessionPauseCountsSkippedFrames(t *testing.T) {
+	t.Setenv("ELORA_WS_DROP_EMPTY", "false")
+
+	conn, cleanup := dialChatWS(t, "", nil)
+	defer cleanup()
+	if err := conn.WriteJSON(map[string]any{"type": "pause"}); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	_ = conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, _, err := conn.ReadMessage(); err != nil {
+		t.Fatalf("read pause reply: %v", err)
+	}
+	broadcastChatMessage([]byte(`{"author":"a","message":"held","source":"Twitch"}`))
+
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		var skipped, dropped int64
+		for _, c := range wsConnections.list() {
+			snap := c.snapshot()
+			skipped += snap.FramesSkippedPaused
+			dropped += snap.FramesDropped
+		}
+		if skipped > 0 {
+			if dropped != 0 {
+				t.Fatalf("expected paused messages not to count as dropped, got %d", dropped)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected paused message to be counted as skipped")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestTruncateCloseReasonKeepsRunesWhole(t *testing.T) {
+	if got := truncateCloseReason("bye"); got != "bye" {
+		t.Fatalf("expected short reason unchanged, got %q", got)
+	}
+	// 122 ASCII bytes followed by a 3-byte rune straddles the 123-byte limit.
+	reason := strings.Repeat("a", 122) + "€€"
+	got := truncateCloseReason(reason)
+	if got != strings.Repeat("a", 122) || !utf8.ValidString(got) {
+		t.Fatalf("expected the split rune to be dropped, got %q (%d bytes)", got, len(got))
+	}
+	if got := truncateCloseReason(strings.Repeat("é", 100)); len(got) != 122 || !utf8.ValidString(got) {
+		t.Fatalf("expected 61 whole runes, got %d bytes valid=%v", len(got), utf8.ValidString(got))
+	}
+}
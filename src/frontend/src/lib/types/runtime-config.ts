@@ -14,7 +14,10 @@ export type RuntimeConfig = {
   tailer: {
     enabled: boolean;
     pollIntervalMs: number;
+    minPollIntervalMs: number;
+    maxPollIntervalMs: number;
     maxBatch: number;
+    batchCeiling: number;
     maxLagMs: number;
     persistOffsets: boolean;
     offsetPath: string;
@@ -54,6 +57,22 @@ export type RuntimeConfig = {
       debug: boolean;
     };
   };
+  filter: {
+    rules: RuntimeFilterRule[];
+  };
+  bots: {
+    accounts: string[];
+    patterns: string[];
+    allow: string[];
+  };
+};
+
+export type RuntimeFilterRule = {
+  name: string;
+  words: string[];
+  patterns: string[];
+  platforms: ('twitch' | 'youtube')[];
+  action: 'mask' | 'drop' | 'flag';
 };
 
 export type RuntimeConfigResponse = {